@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/tunnel"
+)
+
+const (
+	minBackoff = 2 * time.Second
+	maxBackoff = time.Minute
+)
+
+// ko-agent 部署在无法直接访问的集群中，主动连接 KubeOperator 建立反向隧道，
+// 由 KubeOperator 通过隧道访问集群 apiserver 及集群网络内的工具入口
+func main() {
+	server := os.Getenv("KO_AGENT_SERVER")
+	cluster := os.Getenv("KO_AGENT_CLUSTER")
+	token := os.Getenv("KO_AGENT_TOKEN")
+	if server == "" || cluster == "" || token == "" {
+		log.Fatal("KO_AGENT_SERVER, KO_AGENT_CLUSTER and KO_AGENT_TOKEN are required")
+	}
+	url := strings.TrimSuffix(server, "/") + tunnel.ConnectPath
+	url = strings.Replace(url, "https://", "wss://", 1)
+	url = strings.Replace(url, "http://", "ws://", 1)
+	insecure := os.Getenv("KO_AGENT_INSECURE") == "true"
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	backoff := minBackoff
+	for {
+		header := http.Header{}
+		header.Set(tunnel.HeaderCluster, cluster)
+		header.Set(tunnel.HeaderToken, token)
+		// token 文件可能被轮换，每次连接重新读取
+		if file := os.Getenv("KO_AGENT_KUBE_TOKEN_FILE"); file != "" {
+			buf, err := ioutil.ReadFile(file)
+			if err != nil {
+				log.Printf("read kube token %s failed: %s", file, err.Error())
+			}
+			header.Set(tunnel.HeaderKubeToken, strings.TrimSpace(string(buf)))
+		}
+		start := time.Now()
+		log.Printf("connecting to %s", url)
+		err := tunnel.Connect(url, header, insecure, dialer.Dial)
+		log.Printf("tunnel closed: %v", err)
+		if time.Since(start) > maxBackoff {
+			backoff = minBackoff
+		}
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
@@ -0,0 +1,6 @@
+ALTER TABLE
+    `ko`.`ko_task_retry_log`
+ADD
+    COLUMN `action` VARCHAR(255) NULL
+AFTER
+    `cluster_id`;
@@ -0,0 +1,13 @@
+ALTER TABLE
+    `ko`.`ko_cluster_spec_conf`
+ADD
+    COLUMN `upgrade_batch_size` INT(11) NOT NULL DEFAULT 1
+AFTER
+    `authentication_mode`;
+
+ALTER TABLE
+    `ko`.`ko_cluster_spec_conf`
+ADD
+    COLUMN `upgrade_auto_rollback` TINYINT(1) NOT NULL DEFAULT 0
+AFTER
+    `upgrade_batch_size`;
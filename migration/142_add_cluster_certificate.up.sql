@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `ko_cluster_certificate` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `cluster_id` varchar(64) DEFAULT NULL,
+  `node_name` varchar(255) DEFAULT NULL,
+  `name` varchar(255) DEFAULT NULL,
+  `path` varchar(255) DEFAULT NULL,
+  `expire_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+);
+
+INSERT INTO `ko_system_setting` (`created_at`, `updated_at`, `id`, `key`, `value`, `tab`)
+VALUES
+	(date_add(now(), interval 8 HOUR), date_add(now(), interval 8 HOUR), UUID(), 'cert_expire_notice_days', '30', 'CERTIFICATE');
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `ko_cluster_node_pool` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `name` varchar(64) DEFAULT NULL,
+  `cluster_id` varchar(64) DEFAULT NULL,
+  `vm_config` varchar(64) DEFAULT NULL,
+  `zones` text,
+  `host_selector` text,
+  `labels` text,
+  `taints` text,
+  `kubelet_reserved` text,
+  PRIMARY KEY (`id`)
+);
+
+ALTER TABLE
+    `ko`.`ko_cluster_node`
+ADD
+    COLUMN `node_pool` VARCHAR(64) NULL
+AFTER
+    `role`;
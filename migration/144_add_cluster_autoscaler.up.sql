@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `ko_cluster_autoscaler` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `cluster_id` varchar(64) DEFAULT NULL,
+  `enabled` tinyint(1) NOT NULL DEFAULT 0,
+  `node_pool` varchar(64) DEFAULT NULL,
+  `min_size` int(11) NOT NULL DEFAULT 0,
+  `max_size` int(11) NOT NULL DEFAULT 0,
+  `scale_up_cooldown` int(11) NOT NULL DEFAULT 5,
+  `scale_down_cooldown` int(11) NOT NULL DEFAULT 30,
+  `scale_down_utilization` int(11) NOT NULL DEFAULT 50,
+  `last_scale_up_at` datetime DEFAULT NULL,
+  `last_scale_down_at` datetime DEFAULT NULL,
+  `last_event` text,
+  `last_task_id` varchar(64) DEFAULT NULL,
+  PRIMARY KEY (`id`)
+);
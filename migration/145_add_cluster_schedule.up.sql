@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `ko_cluster_schedule` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `cluster_id` varchar(64) DEFAULT NULL,
+  `name` varchar(64) DEFAULT NULL,
+  `cron` varchar(64) DEFAULT NULL,
+  `action` varchar(64) DEFAULT NULL,
+  `node_pool` varchar(64) DEFAULT NULL,
+  `worker_count` int(11) NOT NULL DEFAULT 0,
+  `enabled` tinyint(1) NOT NULL DEFAULT 1,
+  `last_run_at` datetime DEFAULT NULL,
+  `last_message` text,
+  PRIMARY KEY (`id`)
+);
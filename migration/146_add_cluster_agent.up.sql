@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS `ko_cluster_agent` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `name` varchar(64) DEFAULT NULL,
+  `token` varchar(255) DEFAULT NULL,
+  `kube_token` text,
+  `server_url` varchar(255) DEFAULT NULL,
+  `connected` tinyint(1) NOT NULL DEFAULT 0,
+  `remote_addr` varchar(255) DEFAULT NULL,
+  `last_connected_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+);
+
+ALTER TABLE
+    `ko`.`ko_cluster_spec_conf`
+ADD
+    COLUMN `connect_mode` VARCHAR(64) NULL
+AFTER
+    `authentication_mode`;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `ko_task_log_failure` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `task_log_detail_id` varchar(64) DEFAULT NULL,
+  `host` varchar(256) DEFAULT NULL,
+  `play` varchar(256) DEFAULT NULL,
+  `task` varchar(256) DEFAULT NULL,
+  `unreachable` tinyint(1) NOT NULL DEFAULT 0,
+  `rc` int(11) NOT NULL DEFAULT 0,
+  `msg` text,
+  `stdout` text,
+  `stderr` text,
+  `log_offset` bigint(20) NOT NULL DEFAULT 0,
+  `log_line` int(11) NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  KEY `idx_task_log_detail_id` (`task_log_detail_id`)
+);
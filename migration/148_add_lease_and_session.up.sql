@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `ko_lease` (
+  `name` varchar(255) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `holder` varchar(255) DEFAULT NULL,
+  `expire_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `ko_session_value` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `sid` varchar(255) NOT NULL,
+  `key` varchar(255) NOT NULL,
+  `value` blob,
+  `expire_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_session_value_sid_key` (`sid`, `key`),
+  KEY `idx_session_value_expire_at` (`expire_at`)
+);
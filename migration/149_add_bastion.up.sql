@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS `ko_bastion` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `name` varchar(256) NOT NULL,
+  `ip` varchar(128) NOT NULL,
+  `port` int(11) NOT NULL DEFAULT 22,
+  `credential_id` varchar(64) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `name` (`name`)
+);
+
+ALTER TABLE
+    `ko`.`ko_host`
+ADD
+    COLUMN `bastion_id` VARCHAR(64) NULL
+AFTER
+    `zone_id`;
+
+ALTER TABLE
+    `ko`.`ko_zone`
+ADD
+    COLUMN `bastion_id` VARCHAR(64) NULL
+AFTER
+    `credential_id`;
+
+ALTER TABLE
+    `ko`.`ko_credential`
+ADD
+    COLUMN `bastion_id` VARCHAR(64) NULL
+AFTER
+    `type`;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS `ko_host_discovery` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `cidr` varchar(64) NOT NULL,
+  `port` int(11) NOT NULL DEFAULT 22,
+  `credential_id` varchar(64) NOT NULL,
+  `status` varchar(64) DEFAULT NULL,
+  `message` text,
+  `total` int(11) NOT NULL DEFAULT 0,
+  `scanned` int(11) NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `ko_discovered_host` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `discovery_id` varchar(64) NOT NULL,
+  `ip` varchar(128) NOT NULL,
+  `hostname` varchar(256) DEFAULT NULL,
+  `os` varchar(64) DEFAULT NULL,
+  `os_version` varchar(64) DEFAULT NULL,
+  `architecture` varchar(64) DEFAULT NULL,
+  `cpu_core` int(11) DEFAULT 0,
+  `memory` int(11) DEFAULT 0,
+  `gpu_num` int(11) DEFAULT 0,
+  `gpu_info` varchar(128) DEFAULT NULL,
+  `has_gpu` tinyint(1) DEFAULT 0,
+  `status` varchar(64) DEFAULT NULL,
+  `message` text,
+  PRIMARY KEY (`id`),
+  KEY `idx_discovered_host_discovery_id` (`discovery_id`)
+);
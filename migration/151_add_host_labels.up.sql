@@ -0,0 +1,6 @@
+ALTER TABLE
+    `ko`.`ko_host`
+ADD
+    COLUMN `labels` TEXT NULL
+AFTER
+    `architecture`;
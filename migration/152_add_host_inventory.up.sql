@@ -0,0 +1,58 @@
+ALTER TABLE
+    `ko`.`ko_host`
+ADD
+    COLUMN `kernel_version` VARCHAR(128) NULL
+AFTER
+    `labels`,
+ADD
+    COLUMN `cgroup_version` VARCHAR(16) NULL
+AFTER
+    `kernel_version`,
+ADD
+    COLUMN `cpu_model` VARCHAR(256) NULL
+AFTER
+    `cgroup_version`,
+ADD
+    COLUMN `cpu_flags` TEXT NULL
+AFTER
+    `cpu_model`;
+
+CREATE TABLE IF NOT EXISTS `ko_host_disk` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `host_id` varchar(64) NOT NULL,
+  `name` varchar(256) NOT NULL,
+  `model` varchar(256) DEFAULT NULL,
+  `size` bigint(20) NOT NULL DEFAULT 0,
+  `rotational` tinyint(1) NOT NULL DEFAULT 0,
+  `mount` varchar(256) DEFAULT NULL,
+  `fs_type` varchar(64) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_disk_host_id` (`host_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `ko_host_nic` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `host_id` varchar(64) NOT NULL,
+  `name` varchar(64) NOT NULL,
+  `mac` varchar(64) DEFAULT NULL,
+  `mtu` int(11) NOT NULL DEFAULT 0,
+  `speed` int(11) NOT NULL DEFAULT 0,
+  `addresses` text,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_nic_host_id` (`host_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `ko_host_inventory_history` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `host_id` varchar(64) NOT NULL,
+  `inventory` mediumtext,
+  `changes` text,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_inventory_history_host_id` (`host_id`)
+);
@@ -0,0 +1,13 @@
+ALTER TABLE
+    `ko`.`ko_task_log`
+ADD
+    COLUMN `finished` tinyint(1) NOT NULL DEFAULT 0
+AFTER
+    `end_time`;
+
+UPDATE
+    `ko`.`ko_task_log`
+SET
+    `finished` = 1
+WHERE
+    `phase` IN ('SUCCESS', 'FAILED');
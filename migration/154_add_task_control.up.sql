@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS `ko_task_control` (
+  `id` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `task_log_id` varchar(64) NOT NULL,
+  `paused` tinyint(1) NOT NULL DEFAULT 0,
+  `canceled` tinyint(1) NOT NULL DEFAULT 0,
+  `skips` text,
+  `detached_playbook` varchar(64) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_task_control_task_log_id` (`task_log_id`)
+);
+
+INSERT INTO
+    `ko`.`ko_task_log_detail` (
+        `id`,
+        `created_at`,
+        `updated_at`,
+        `name`,
+        `task`,
+        `task_log_id`,
+        `cluster_id`,
+        `start_time`,
+        `end_time`,
+        `message`,
+        `status`
+    )
+SELECT
+    `id`,
+    `created_at`,
+    `updated_at`,
+    'action',
+    UPPER(`action`),
+    `task_log_id`,
+    `cluster_id`,
+    `restart_time`,
+    `restart_time`,
+    `message`,
+    'SUCCESS'
+FROM
+    `ko`.`ko_task_retry_log`
+WHERE
+    `action` IS NOT NULL
+    AND `action` != '';
@@ -0,0 +1,6 @@
+ALTER TABLE
+    `ko`.`ko_task_log`
+ADD
+    COLUMN `priority` int(11) NOT NULL DEFAULT 10
+AFTER
+    `finished`;
@@ -0,0 +1,7 @@
+UPDATE
+    `ko`.`ko_cluster_agent`
+SET
+    `token` = SHA2(`token`, 256)
+WHERE
+    `token` IS NOT NULL
+    AND `token` != '';
@@ -389,3 +389,79 @@ func (v *fusionComputeClient) ListFolders() ([]string, error) {
 	folders := []string{}
 	return folders, nil
 }
+
+func (f *fusionComputeClient) PowerOn(names []string) error {
+	return f.setPowerState(names, "running", "start")
+}
+
+func (f *fusionComputeClient) PowerOff(names []string) error {
+	return f.setPowerState(names, "stopped", "stop")
+}
+
+// setPowerState sdk 未提供开关机接口，直接调用 {vmUri}/action/{start|stop}
+func (f *fusionComputeClient) setPowerState(names []string, status, action string) error {
+	siteName := f.Vars["datacenter"].(string)
+	c := f.newFusionComputeClient()
+	if err := c.Connect(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := c.DisConnect(); err != nil {
+			logger.Log.Errorf("fusionComputeClient DisConnect failed, error: %s", err.Error())
+		}
+	}()
+	sm := site.NewManager(c)
+	ss, err := sm.ListSite()
+	if err != nil {
+		return err
+	}
+	siteUri := ""
+	for _, s := range ss {
+		if s.Name == siteName {
+			siteUri = s.Uri
+		}
+	}
+	if siteUri == "" {
+		return fmt.Errorf("site %s not found", siteName)
+	}
+	vmm := vm.NewManager(c, siteUri)
+	vms, err := vmm.ListVm(false)
+	if err != nil {
+		return err
+	}
+	api, err := c.GetApiClient()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		var target *vm.Vm
+		for i := range vms {
+			if vms[i].Name == name {
+				target = &vms[i]
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("vm %s not found", name)
+		}
+		if target.Status == status {
+			continue
+		}
+		resp, err := api.R().SetBody(map[string]string{"mode": "safe"}).Post(target.Uri + "/action/" + action)
+		if err != nil {
+			return err
+		}
+		if !resp.IsSuccess() {
+			return fmt.Errorf("%s vm %s failed: %s", action, name, string(resp.Body()))
+		}
+		reached := false
+		for i := 0; i < 60 && !reached; i++ {
+			time.Sleep(5 * time.Second)
+			item, err := vmm.GetVM(target.Uri)
+			reached = err == nil && item.Status == status
+		}
+		if !reached {
+			return fmt.Errorf("wait vm %s %s timeout", name, status)
+		}
+	}
+	return nil
+}
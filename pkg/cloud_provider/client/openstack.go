@@ -3,13 +3,16 @@ package client
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack"
 	"github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumetypes"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/availabilityzones"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/secgroups"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/startstop"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/imagedata"
 	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/imageimport"
 	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
@@ -420,3 +423,52 @@ func (v *openStackClient) ListFolders() ([]string, error) {
 	folders := []string{}
 	return folders, nil
 }
+
+func (v *openStackClient) PowerOn(names []string) error {
+	return v.setPowerState(names, "ACTIVE")
+}
+
+func (v *openStackClient) PowerOff(names []string) error {
+	return v.setPowerState(names, "SHUTOFF")
+}
+
+func (v *openStackClient) setPowerState(names []string, status string) error {
+	provider, err := v.GetAuth()
+	if err != nil {
+		return err
+	}
+	client, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{
+		Region: v.Vars["datacenter"].(string),
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		pages, err := servers.List(client, servers.ListOpts{Name: "^" + name + "$"}).AllPages()
+		if err != nil {
+			return err
+		}
+		ss, err := servers.ExtractServers(pages)
+		if err != nil {
+			return err
+		}
+		if len(ss) != 1 {
+			return fmt.Errorf("found %d servers named %s", len(ss), name)
+		}
+		if ss[0].Status == status {
+			continue
+		}
+		if status == "ACTIVE" {
+			err = startstop.Start(client, ss[0].ID).ExtractErr()
+		} else {
+			err = startstop.Stop(client, ss[0].ID).ExtractErr()
+		}
+		if err != nil {
+			return err
+		}
+		if err := servers.WaitForStatus(client, ss[0].ID, status, 300); err != nil {
+			return fmt.Errorf("wait server %s %s failed: %v", name, status, err)
+		}
+	}
+	return nil
+}
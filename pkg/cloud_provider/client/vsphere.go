@@ -3,12 +3,14 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/vmware/govmomi/nfc"
 	"io"
 	"io/ioutil"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
@@ -23,6 +25,9 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// guestShutdownTimeout 等待虚拟机操作系统正常关机的时间，超时后强制关机
+const guestShutdownTimeout = 5 * time.Minute
+
 type vSphereClient struct {
 	Vars   map[string]interface{}
 	Client *govmomi.Client
@@ -462,7 +467,7 @@ func CancelUpload(ctx context.Context, lease *nfc.Lease, data types.DynamicData)
 	return lease.Abort(ctx, &types.LocalizedMethodFault{
 		DynamicData: data,
 		Fault: &types.OvfImportFailed{
-			types.OvfImport{},
+			OvfImport: types.OvfImport{},
 		},
 	})
 }
@@ -596,3 +601,72 @@ func (v *vSphereClient) ListFolders() ([]string, error) {
 	}
 	return result, nil
 }
+
+func (v *vSphereClient) PowerOn(names []string) error {
+	return v.setPowerState(names, types.VirtualMachinePowerStatePoweredOn)
+}
+
+func (v *vSphereClient) PowerOff(names []string) error {
+	return v.setPowerState(names, types.VirtualMachinePowerStatePoweredOff)
+}
+
+// setPowerState 虚拟机默认创建在 kubeoperator 目录下，找不到时再按名称查找
+func (v *vSphereClient) setPowerState(names []string, state types.VirtualMachinePowerState) error {
+	if err := v.GetConnect(); err != nil {
+		return err
+	}
+	ctx := context.TODO()
+	f := find.NewFinder(v.Client.Client, true)
+	datacenter, err := f.Datacenter(ctx, v.Vars["datacenter"].(string))
+	if err != nil {
+		return err
+	}
+	f.SetDatacenter(datacenter)
+
+	for _, name := range names {
+		vm, err := f.VirtualMachine(ctx, constant.VSphereFolder+"/"+name)
+		if err != nil {
+			if vm, err = f.VirtualMachine(ctx, name); err != nil {
+				return err
+			}
+		}
+		current, err := vm.PowerState(ctx)
+		if err != nil {
+			return err
+		}
+		if current == state {
+			continue
+		}
+		if state == types.VirtualMachinePowerStatePoweredOff && shutdownGuest(ctx, vm) {
+			continue
+		}
+		var task *object.Task
+		if state == types.VirtualMachinePowerStatePoweredOn {
+			task, err = vm.PowerOn(ctx)
+		} else {
+			task, err = vm.PowerOff(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		if err := task.Wait(ctx); err != nil {
+			return fmt.Errorf("change power state of vm %s failed: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// shutdownGuest 通过 VMware Tools 正常关闭操作系统，未安装 Tools 或超时未关机时返回 false，由调用方强制关机
+func shutdownGuest(ctx context.Context, vm *object.VirtualMachine) bool {
+	if err := vm.ShutdownGuest(ctx); err != nil {
+		logger.Log.Infof("shutdown guest of vm %s failed, power off instead: %v", vm.Name(), err)
+		return false
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, guestShutdownTimeout)
+	defer cancel()
+	if err := vm.WaitForPowerState(waitCtx, types.VirtualMachinePowerStatePoweredOff); err != nil {
+		logger.Log.Infof("vm %s is not powered off after guest shutdown, power off instead: %v", vm.Name(), err)
+		return false
+	}
+	return true
+}
@@ -16,6 +16,8 @@ type CloudClient interface {
 	CreateDefaultFolder() error
 	ListDatastores() ([]client.DatastoreResult, error)
 	ListFolders() ([]string, error)
+	PowerOn(names []string) error
+	PowerOff(names []string) error
 }
 
 func NewCloudClient(vars map[string]interface{}) CloudClient {
@@ -17,14 +17,27 @@ const (
 	NodeRoleNameMaster = "master"
 	NodeRoleNameWorker = "worker"
 	LbModeInternal     = "internal"
+	LbModeExternal     = "external"
 
 	ClusterProviderBareMetal = "bareMetal"
 	ClusterProviderPlan      = "plan"
 
+	UpgradeStrategyRolling = "rolling"
+
+	NodePoolLabelKey = "kubeoperator.io/node-pool"
+
+	ScheduleActionScale     = "scale"
+	ScheduleActionHibernate = "hibernate"
+	ScheduleActionWake      = "wake"
+
 	AuthenticationModeBearer      = "bearer"
 	AuthenticationModeCertificate = "certificate"
 	AuthenticationModeConfigFile  = "configFile"
 
+	ConnectModeAgent       = "agent"
+	AgentKubeApiserverIp   = "kubernetes.default.svc"
+	AgentKubeApiserverPort = 443
+
 	DefaultNamespace     = "kube-operator"
 	F5Namespace          = "kube-system"
 	DefaultApiServerPort = 8443
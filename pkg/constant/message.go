@@ -26,6 +26,8 @@ const (
 	ClusterScale              = "CLUSTER_SCALE"
 	ClusterAddWorker          = "CLUSTER_ADD_WORKER"
 	ClusterRemoveWorker       = "CLUSTER_REMOVE_WORKER"
+	ClusterAddMaster          = "CLUSTER_ADD_MASTER"
+	ClusterRemoveMaster       = "CLUSTER_REMOVE_MASTER"
 	ClusterRestore            = "CLUSTER_RESTORE"
 	ClusterBackup             = "CLUSTER_BACKUP"
 	ClusterEnableProvisioner  = "CLUSTER_ENABLE_PROVISIONER"
@@ -35,6 +37,13 @@ const (
 	ClusterEventWarning       = "CLUSTER_EVENT_WARNING"
 	MsgTest                   = "MSG_TEST"
 	LicenseExpires            = "LICENSE_EXPIRE"
+	ClusterCertExpire         = "CLUSTER_CERT_EXPIRE"
+	ClusterCertRotate         = "CLUSTER_CERT_ROTATE"
+	ClusterRuntimeMigrate     = "CLUSTER_RUNTIME_MIGRATE"
+	ClusterAutoscale          = "CLUSTER_AUTOSCALE"
+	ClusterSchedule           = "CLUSTER_SCHEDULE"
+	ClusterHibernate          = "CLUSTER_HIBERNATE"
+	ClusterWake               = "CLUSTER_WAKE"
 	ClusterOperator           = "CLUSTER_OPERATOR"
 )
 
@@ -61,6 +70,8 @@ var MsgTitle = map[string]string{
 	ClusterScale:              "集群伸缩",
 	ClusterAddWorker:          "集群扩容",
 	ClusterRemoveWorker:       "集群缩容",
+	ClusterAddMaster:          "增加控制节点",
+	ClusterRemoveMaster:       "移除控制节点",
 	ClusterRestore:            "集群恢复",
 	ClusterBackup:             "集群备份",
 	ClusterEnableProvisioner:  "启用存储提供商",
@@ -70,6 +81,13 @@ var MsgTitle = map[string]string{
 	ClusterEventWarning:       "集群事件告警",
 	MsgTest:                   "KubeOperator测试",
 	LicenseExpires:            "License到期提醒",
+	ClusterCertExpire:         "集群证书到期提醒",
+	ClusterCertRotate:         "集群证书轮换",
+	ClusterRuntimeMigrate:     "容器运行时迁移",
+	ClusterAutoscale:          "集群自动伸缩",
+	ClusterSchedule:           "集群定时任务",
+	ClusterHibernate:          "集群休眠",
+	ClusterWake:               "集群唤醒",
 }
 
 var Templates = map[string]map[string]string{
@@ -108,6 +126,16 @@ var Templates = map[string]map[string]string{
 		DingTalk:   "pkg/templates/cluster_op.md",
 		WorkWeiXin: "pkg/templates/cluster_op.md",
 	},
+	ClusterAddMaster: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
+	ClusterRemoveMaster: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
 	ClusterRestore: {
 		Email:      "pkg/templates/cluster_op.html",
 		DingTalk:   "pkg/templates/cluster_op.md",
@@ -143,4 +171,39 @@ var Templates = map[string]map[string]string{
 		DingTalk:   "pkg/templates/cluster_op.md",
 		WorkWeiXin: "pkg/templates/cluster_op.md",
 	},
+	ClusterCertExpire: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
+	ClusterCertRotate: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
+	ClusterRuntimeMigrate: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
+	ClusterAutoscale: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
+	ClusterSchedule: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
+	ClusterHibernate: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
+	ClusterWake: {
+		Email:      "pkg/templates/cluster_op.html",
+		DingTalk:   "pkg/templates/cluster_op.md",
+		WorkWeiXin: "pkg/templates/cluster_op.md",
+	},
 }
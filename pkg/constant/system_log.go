@@ -30,13 +30,34 @@ const (
 	UNBIND_PROJECT_RESOURCE_HOST   = "解绑项目资源(主机)|Unbind project resources(host)"
 
 	// 集群
-	CREATE_CLUSTER  = "添加集群|Create cluster"
-	IMPORT_CLUSTER  = "导入集群|Import cluster"
-	INIT_CLUSTER    = "初始化集群|Init cluster"
-	DELETE_CLUSTER  = "删除集群|Delete cluster"
-	UPGRADE_CLUSTER = "集群升级|Upgrade cluster"
-	HEALTH_CHECK    = "集群健康检查|Health check"
-	HEALTH_RECOVER  = "集群健康恢复|Health recover"
+	CREATE_CLUSTER            = "添加集群|Create cluster"
+	IMPORT_CLUSTER            = "导入集群|Import cluster"
+	INIT_CLUSTER              = "初始化集群|Init cluster"
+	DELETE_CLUSTER            = "删除集群|Delete cluster"
+	UPGRADE_CLUSTER           = "集群升级|Upgrade cluster"
+	ROLLBACK_UPGRADE_CLUSTER  = "回滚集群升级|Rollback cluster upgrade"
+	ROTATE_CLUSTER_CERT       = "轮换集群证书|Rotate cluster certificates"
+	UPDATE_CLUSTER_SPEC       = "修改集群配置|Update cluster spec"
+	APPLY_CLUSTER             = "声明式应用集群|Apply cluster declaration"
+	MIGRATE_CLUSTER_RUNTIME   = "迁移容器运行时|Migrate container runtime"
+	CREATE_CLUSTER_NODE_POOL  = "创建节点池|Create node pool"
+	UPDATE_CLUSTER_NODE_POOL  = "更新节点池|Update node pool"
+	DELETE_CLUSTER_NODE_POOL  = "删除节点池|Delete node pool"
+	UPDATE_CLUSTER_AUTOSCALER = "修改自动伸缩配置|Update cluster autoscaler"
+	CREATE_CLUSTER_SCHEDULE   = "创建集群定时任务|Create cluster schedule"
+	UPDATE_CLUSTER_SCHEDULE   = "更新集群定时任务|Update cluster schedule"
+	DELETE_CLUSTER_SCHEDULE   = "删除集群定时任务|Delete cluster schedule"
+	HIBERNATE_CLUSTER         = "休眠集群|Hibernate cluster"
+	WAKE_CLUSTER              = "唤醒集群|Wake cluster"
+	CREATE_CLUSTER_AGENT      = "生成集群 agent|Generate cluster agent"
+	ADOPT_CLUSTER             = "接管集群|Adopt cluster"
+	HEALTH_CHECK              = "集群健康检查|Health check"
+	HEALTH_RECOVER            = "集群健康恢复|Health recover"
+
+	PAUSE_CLUSTER_TASK  = "暂停集群任务|Pause cluster task"
+	RESUME_CLUSTER_TASK = "继续集群任务|Resume cluster task"
+	SKIP_CLUSTER_TASK   = "跳过集群任务步骤|Skip cluster task handler"
+	CANCEL_CLUSTER_TASK = "取消集群任务|Cancel cluster task"
 
 	CREATE_COMPONENT = "添加集群组件|Create cluster component"
 	DELETE_COMPONENT = "删除集群组件|Delete cluster component"
@@ -75,10 +96,14 @@ const (
 	DELETE_CLUSTER_CIS_SCAN_RESULT = "删除集群CIS扫描结果|Delete cluster CIS scan results"
 
 	// 主机
-	CREATE_HOST    = "添加主机|Create host"
-	EDIT_HOST      = "编辑主机|Edit host"
-	SYNC_HOST_LIST = "主机同步|Sync host"
-	DELETE_HOST    = "删除主机|Delete host"
+	CREATE_HOST           = "添加主机|Create host"
+	EDIT_HOST             = "编辑主机|Edit host"
+	SYNC_HOST_LIST        = "主机同步|Sync host"
+	DELETE_HOST           = "删除主机|Delete host"
+	START_HOST_DISCOVERY  = "开始主机发现|Start host discovery"
+	DELETE_HOST_DISCOVERY = "删除主机发现|Delete host discovery"
+	ACCEPT_HOST_DISCOVERY = "导入发现的主机|Accept discovered hosts"
+	IMPORT_HOST           = "导入主机|Import hosts"
 
 	// 自动模式
 	CREATE_REGION        = "添加区域|Create region"
@@ -129,4 +154,8 @@ const (
 	CREATE_NTP = "添加 NTP|Create NTP server"
 	UPDATE_NTP = "修改 NTP|Update NTP server"
 	DELETE_NTP = "删除 NTP|DElete NTP server"
+
+	CREATE_BASTION = "添加跳板机|Create bastion"
+	UPDATE_BASTION = "修改跳板机|Update bastion"
+	DELETE_BASTION = "删除跳板机|Delete bastion"
 )
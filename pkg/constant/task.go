@@ -1,23 +1,54 @@
 package constant
 
 const (
-	TaskLogTypeClusterCreate     = "CLUSTER_CREATE"
-	TaskLogTypeClusterImport     = "CLUSTER_IMPORT"
-	TaskLogTypeClusterUpgrade    = "CLUSTER_UPGRADE"
-	TaskLogTypeClusterDelete     = "CLUSTER_DELEDE"
-	TaskLogTypeClusterNodeExtend = "CLUSTER_NODE_EXTEND"
-	TaskLogTypeClusterNodeShrink = "CLUSTER_NODE_SHRINK"
-	TaskLogTypeBackup            = "CLUSTER_BACKUP"
-	TaskLogTypeRestore           = "CLUSTER_RESTORE"
-	TaskLogTypeVeleroBackup      = "CLUSTER_VELERO_BACKUP"
-	TaskLogTypeVeleroRestore     = "CLUSTER_VELERO_RESTORE"
-	TaskLogTypeUpgrade           = "CLUSTER_UPGRADE"
+	TaskLogTypeClusterCreate         = "CLUSTER_CREATE"
+	TaskLogTypeClusterImport         = "CLUSTER_IMPORT"
+	TaskLogTypeClusterUpgrade        = "CLUSTER_UPGRADE"
+	TaskLogTypeClusterRollingUpgrade = "CLUSTER_ROLLING_UPGRADE"
+	TaskLogTypeClusterDelete         = "CLUSTER_DELEDE"
+	TaskLogTypeClusterNodeExtend     = "CLUSTER_NODE_EXTEND"
+	TaskLogTypeClusterNodeShrink     = "CLUSTER_NODE_SHRINK"
+	TaskLogTypeClusterMasterExtend   = "CLUSTER_MASTER_EXTEND"
+	TaskLogTypeClusterMasterShrink   = "CLUSTER_MASTER_SHRINK"
+	TaskLogTypeClusterCertRotate     = "CLUSTER_CERT_ROTATE"
+	TaskLogTypeClusterSpecUpdate     = "CLUSTER_SPEC_UPDATE"
+	TaskLogTypeClusterRuntimeMigrate = "CLUSTER_RUNTIME_MIGRATE"
+	TaskLogTypeClusterHibernate      = "CLUSTER_HIBERNATE"
+	TaskLogTypeClusterWake           = "CLUSTER_WAKE"
+	TaskLogTypeClusterAdopt          = "CLUSTER_ADOPT"
+	TaskLogTypeComponent             = "CLUSTER_COMPONENT"
+	TaskLogTypeProvisioner           = "CLUSTER_PROVISIONER"
+	TaskLogTypeBackup                = "CLUSTER_BACKUP"
+	TaskLogTypeRestore               = "CLUSTER_RESTORE"
+	TaskLogTypeVeleroBackup          = "CLUSTER_VELERO_BACKUP"
+	TaskLogTypeVeleroRestore         = "CLUSTER_VELERO_RESTORE"
+	TaskLogTypeUpgrade               = "CLUSTER_UPGRADE"
 
 	TaskLogStatusSuccess = "SUCCESS"
 	TaskLogStatusFailed  = "FAILED"
 	TaskLogStatusRunning = "RUNNING"
 	TaskLogStatusWaiting = "WAITING"
 	TaskLogStatusRedo    = "REDO"
+	TaskLogStatusPaused  = "PAUSED"
+	TaskLogStatusSkip    = "SKIP"
+
+	TaskStreamEventLog    = "log"
+	TaskStreamEventPhase  = "phase"
+	TaskStreamEventDetail = "detail"
+	TaskStreamEventEnd    = "end"
+
+	// 任务调度优先级，数值越大越先执行
+	TaskPriorityCron = 0
+	TaskPriorityUser = 10
+
+	TaskActionPause  = "pause"
+	TaskActionResume = "resume"
+	TaskActionSkip   = "skip"
+	TaskActionCancel = "cancel"
+	// TaskLogDetailNameAction 人工干预记录的步骤名称，不属于任务的执行流程
+	TaskLogDetailNameAction = "action"
+	// TaskLogDetailNameUpgradeHop 多跳升级中每一跳的进度记录，Task 为该跳的目标版本
+	TaskLogDetailNameUpgradeHop = "upgrade"
 
 	StatusPending       = "Pending"
 	StatusRunning       = "Running"
@@ -31,6 +62,9 @@ const (
 	StatusTerminating   = "Terminating"
 	StatusSynchronizing = "Synchronizing"
 	StatusWaiting       = "Waiting"
+	StatusHibernating   = "Hibernating"
+	StatusHibernated    = "Hibernated"
+	StatusWaking        = "Waking"
 	StatusDisabled      = "disable"
 	StatusEnabled       = "enable"
 )
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/condition"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/page"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type BastionController struct {
+	Ctx            context.Context
+	BastionService service.BastionService
+}
+
+func NewBastionController() *BastionController {
+	return &BastionController{
+		BastionService: service.NewBastionService(),
+	}
+}
+
+// List Bastion
+// @Tags bastions
+// @Summary Show all bastions
+// @Description 获取跳板机列表
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} page.Page
+// @Security ApiKeyAuth
+// @Router /bastions/ [get]
+func (b BastionController) Get() (*page.Page, error) {
+	p, _ := b.Ctx.Values().GetBool("page")
+	if p {
+		num, _ := b.Ctx.Values().GetInt(constant.PageNumQueryKey)
+		size, _ := b.Ctx.Values().GetInt(constant.PageSizeQueryKey)
+		return b.BastionService.Page(num, size, condition.TODO())
+	} else {
+		var p page.Page
+		items, err := b.BastionService.List(condition.TODO())
+		if err != nil {
+			return &p, err
+		}
+		p.Items = items
+		p.Total = len(items)
+		return &p, nil
+	}
+}
+
+// Search Bastion
+// @Tags bastions
+// @Summary Search bastion
+// @Description 过滤跳板机
+// @Accept  json
+// @Produce  json
+// @Param conditions body condition.Conditions true "conditions"
+// @Success 200 {object} page.Page
+// @Security ApiKeyAuth
+// @Router /bastions/search [post]
+func (b BastionController) PostSearch() (*page.Page, error) {
+	var conditions condition.Conditions
+	if b.Ctx.GetContentLength() > 0 {
+		if err := b.Ctx.ReadJSON(&conditions); err != nil {
+			return nil, err
+		}
+	}
+	p, _ := b.Ctx.Values().GetBool("page")
+	if p {
+		num, _ := b.Ctx.Values().GetInt(constant.PageNumQueryKey)
+		size, _ := b.Ctx.Values().GetInt(constant.PageSizeQueryKey)
+		return b.BastionService.Page(num, size, conditions)
+	} else {
+		var p page.Page
+		items, err := b.BastionService.List(conditions)
+		if err != nil {
+			return &p, err
+		}
+		p.Items = items
+		p.Total = len(items)
+		return &p, nil
+	}
+}
+
+// Get Bastion
+// @Tags bastions
+// @Summary Show a bastion
+// @Description 获取单个跳板机
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.Bastion
+// @Security ApiKeyAuth
+// @Router /bastions/{name}/ [get]
+func (b BastionController) GetBy(name string) (*dto.Bastion, error) {
+	return b.BastionService.Get(name)
+}
+
+// Create Bastion
+// @Tags bastions
+// @Summary Create a bastion
+// @Description 创建跳板机
+// @Accept  json
+// @Produce  json
+// @Param request body dto.BastionCreate true "request"
+// @Success 200 {object} dto.Bastion
+// @Security ApiKeyAuth
+// @Router /bastions/ [post]
+func (b BastionController) Post() (*dto.Bastion, error) {
+	var req dto.BastionCreate
+	if err := b.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	operator := b.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_BASTION, req.Name)
+
+	return b.BastionService.Create(req)
+}
+
+// Update Bastion
+// @Tags bastions
+// @Summary Update a bastion
+// @Description 更新跳板机
+// @Accept  json
+// @Produce  json
+// @Param request body dto.BastionUpdate true "request"
+// @Success 200 {object} dto.Bastion
+// @Security ApiKeyAuth
+// @Router /bastions/{name}/ [patch]
+func (b BastionController) PatchBy(name string) (*dto.Bastion, error) {
+	var req dto.BastionUpdate
+	if err := b.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	operator := b.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPDATE_BASTION, name)
+
+	return b.BastionService.Update(name, req)
+}
+
+// Delete Bastion
+// @Tags bastions
+// @Summary Delete a bastion
+// @Description 删除跳板机，仍被主机、区域或凭据使用时不能删除
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /bastions/{name}/ [delete]
+func (b BastionController) DeleteBy(name string) error {
+	operator := b.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_BASTION, name)
+	return b.BastionService.Delete(name)
+}
+
+// Test Bastion
+// @Tags bastions
+// @Summary Test bastion connection
+// @Description 测试跳板机 ssh 连接
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /bastions/{name}/test [post]
+func (b BastionController) PostByTest(name string) error {
+	return b.BastionService.Test(name)
+}
@@ -26,8 +26,13 @@ type ClusterController struct {
 	ClusterImportService  service.ClusterImportService
 	CisService            service.CisService
 	ClusterUpgradeService service.ClusterUpgradeService
+	ClusterPlanService    service.ClusterPlanService
 	ClusterHealthService  service.ClusterHealthService
 	BackupAccountService  service.BackupAccountService
+
+	ClusterDeclarationService service.ClusterDeclarationService
+	ClusterHibernateService   service.ClusterHibernateService
+	ClusterAdoptService       service.ClusterAdoptService
 }
 
 func NewClusterController() *ClusterController {
@@ -38,8 +43,13 @@ func NewClusterController() *ClusterController {
 		ClusterImportService:  service.NewClusterImportService(),
 		CisService:            service.NewCisService(),
 		ClusterUpgradeService: service.NewClusterUpgradeService(),
+		ClusterPlanService:    service.NewClusterPlanService(),
 		ClusterHealthService:  service.NewClusterHealthService(),
 		BackupAccountService:  service.NewBackupAccountService(),
+
+		ClusterDeclarationService: service.NewClusterDeclarationService(),
+		ClusterHibernateService:   service.NewClusterHibernateService(),
+		ClusterAdoptService:       service.NewClusterAdoptService(),
 	}
 }
 
@@ -189,6 +199,44 @@ func (c ClusterController) Post() (*dto.Cluster, error) {
 	return item, nil
 }
 
+// Plan Cluster Creation
+// @Tags clusters
+// @Summary Preview the creation of a cluster
+// @Description 预览集群创建时的 inventory、变量和执行步骤，不会保存任何数据
+// @Param request body dto.ClusterCreate true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterPlan
+// @Security ApiKeyAuth
+// @Router /clusters/plan [post]
+func (c ClusterController) PostPlan() (*dto.ClusterPlan, error) {
+	var req dto.ClusterCreate
+	err := c.Ctx.ReadJSON(&req)
+	if err != nil {
+		return nil, err
+	}
+	return c.ClusterPlanService.PlanCreate(req)
+}
+
+// Plan Cluster Upgrade
+// @Tags clusters
+// @Summary Preview the upgrade of a cluster
+// @Description 预览集群升级时的 inventory、变量和执行步骤，不会保存任何数据
+// @Param request body dto.ClusterUpgrade true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterPlan
+// @Security ApiKeyAuth
+// @Router /clusters/plan/upgrade [post]
+func (c ClusterController) PostPlanUpgrade() (*dto.ClusterPlan, error) {
+	var req dto.ClusterUpgrade
+	err := c.Ctx.ReadJSON(&req)
+	if err != nil {
+		return nil, err
+	}
+	return c.ClusterPlanService.PlanUpgrade(req)
+}
+
 func (c ClusterController) PostInitBy(name string) error {
 	operator := c.Ctx.Values().GetString("operator")
 	go kolog.Save(operator, constant.INIT_CLUSTER, name)
@@ -245,6 +293,124 @@ func (c ClusterController) PostUpgrade() error {
 	return c.ClusterUpgradeService.Upgrade(req)
 }
 
+// Rollback Cluster Upgrade
+// @Tags clusters
+// @Summary Rollback a failed cluster upgrade
+// @Description Restore the cluster with the etcd snapshot taken before upgrade
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/upgrade/rollback/{name} [post]
+func (c ClusterController) PostUpgradeRollbackBy(name string) error {
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ROLLBACK_UPGRADE_CLUSTER, name)
+
+	return c.ClusterUpgradeService.Rollback(name)
+}
+
+// Hibernate Cluster
+// @Tags clusters
+// @Summary Hibernate a cluster
+// @Description 驱逐负载后关闭集群虚拟机，只支持部署计划创建的集群
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/hibernate/{name} [post]
+func (c ClusterController) PostHibernateBy(name string) error {
+	if err := c.ClusterHibernateService.Hibernate(name); err != nil {
+		return err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.HIBERNATE_CLUSTER, name)
+	return nil
+}
+
+// Wake Cluster
+// @Tags clusters
+// @Summary Wake a hibernated cluster
+// @Description 开启集群虚拟机并等待集群健康检查通过
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/wake/{name} [post]
+func (c ClusterController) PostWakeBy(name string) error {
+	if err := c.ClusterHibernateService.Wake(name); err != nil {
+		return err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.WAKE_CLUSTER, name)
+	return nil
+}
+
+// Adopt Cluster
+// @Tags clusters
+// @Summary Adopt an imported kubeadm cluster
+// @Description 通过 SSH 采集导入集群的 kubeadm 部署信息，转为可扩缩容、升级、备份的受管集群
+// @Param request body dto.ClusterAdopt true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/adopt/{name} [post]
+func (c ClusterController) PostAdoptBy(name string) error {
+	var req dto.ClusterAdopt
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return err
+	}
+	if err := c.ClusterAdoptService.Adopt(name, req); err != nil {
+		return err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ADOPT_CLUSTER, name)
+	return nil
+}
+
+// Export Cluster
+// @Tags clusters
+// @Summary Export a cluster as YAML
+// @Description 导出集群的声明式定义
+// @Produce  application/x-yaml
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/export/{name} [get]
+func (c ClusterController) GetExportBy(name string) error {
+	buf, err := c.ClusterDeclarationService.Export(name)
+	if err != nil {
+		return err
+	}
+	c.Ctx.Header("Content-Type", "application/octet-stream")
+	c.Ctx.Header("Content-Disposition", "attachment; filename=\""+name+".yaml\"")
+	_, _ = c.Ctx.Write(buf)
+	return nil
+}
+
+// Apply Cluster
+// @Tags clusters
+// @Summary Apply a cluster declaration
+// @Description 集群不存在时创建，存在时按差异执行扩缩容、组件、工具和升级操作，dryRun=true 时只返回操作计划
+// @Param dryRun query string false "是否只返回操作计划"
+// @Accept  application/x-yaml
+// @Produce  json
+// @Success 200 {object} dto.ClusterApplyResult
+// @Security ApiKeyAuth
+// @Router /clusters/apply [post]
+func (c ClusterController) PostApply() (*dto.ClusterApplyResult, error) {
+	body, err := c.Ctx.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	dryRun := c.Ctx.URLParam("dryRun") == "true"
+	result, err := c.ClusterDeclarationService.Apply(body, dryRun)
+	if err == nil && !dryRun {
+		operator := c.Ctx.Values().GetString("operator")
+		go kolog.Save(operator, constant.APPLY_CLUSTER, result.Cluster)
+	}
+	return result, err
+}
+
 // Delete Cluster
 // @Tags clusters
 // @Summary Delete a cluster
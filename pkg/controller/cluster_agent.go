@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/tunnel"
+	"github.com/gorilla/websocket"
+	"github.com/kataras/iris/v12/context"
+)
+
+var agentUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type ClusterAgentController struct {
+	Ctx                 context.Context
+	ClusterAgentService service.ClusterAgentService
+}
+
+func NewClusterAgentController() *ClusterAgentController {
+	return &ClusterAgentController{
+		ClusterAgentService: service.NewClusterAgentService(),
+	}
+}
+
+// Get Agent
+// @Tags clusters
+// @Summary Show cluster agent
+// @Description 获取集群反向隧道 agent 的连接状态，token 和部署清单只在生成时返回
+// @Param cluster path string true "集群名称"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterAgent
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/agent [get]
+func (c ClusterAgentController) Get() (*dto.ClusterAgent, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	return c.ClusterAgentService.Get(clusterName)
+}
+
+// Create Agent
+// @Tags clusters
+// @Summary Generate cluster agent manifest
+// @Description 生成反向隧道 agent 的 token 和部署清单，集群可以在导入前生成，serverUrl 为集群访问 KubeOperator 的地址
+// @Param cluster path string true "集群名称"
+// @Param request body dto.ClusterAgentCreate true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterAgent
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/agent [post]
+func (c ClusterAgentController) Post() (*dto.ClusterAgent, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	var req dto.ClusterAgentCreate
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	item, err := c.ClusterAgentService.Create(clusterName, req)
+	if err != nil {
+		return nil, err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_CLUSTER_AGENT, clusterName)
+	return item, nil
+}
+
+// AgentConnectController agent 通过 websocket 建立反向隧道，使用 agent token 认证
+func AgentConnectController(ctx context.Context) {
+	clusterName := ctx.GetHeader(tunnel.HeaderCluster)
+	agentService := service.NewClusterAgentService()
+	if err := agentService.Authenticate(clusterName, ctx.GetHeader(tunnel.HeaderToken)); err != nil {
+		logger.Log.Warnf("agent of cluster %s from %s rejected: %s", clusterName, ctx.RemoteAddr(), err.Error())
+		ctx.StatusCode(http.StatusUnauthorized)
+		return
+	}
+	ws, err := agentUpgrader.Upgrade(ctx.ResponseWriter(), ctx.Request(), nil)
+	if err != nil {
+		logger.Log.Errorf("upgrade agent connection of cluster %s failed: %s", clusterName, err.Error())
+		return
+	}
+	agentService.Serve(clusterName, ctx.GetHeader(tunnel.HeaderKubeToken), ctx.RemoteAddr(), ws)
+}
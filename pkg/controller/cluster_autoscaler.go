@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterAutoscalerController struct {
+	Ctx                      context.Context
+	ClusterAutoscalerService service.ClusterAutoscalerService
+}
+
+func NewClusterAutoscalerController() *ClusterAutoscalerController {
+	return &ClusterAutoscalerController{
+		ClusterAutoscalerService: service.NewClusterAutoscalerService(),
+	}
+}
+
+// Get Autoscaler
+// @Tags clusters
+// @Summary Show cluster autoscaler
+// @Description 获取集群自动伸缩配置和最近一次伸缩记录
+// @Param cluster path string true "集群名称"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterAutoscaler
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/autoscaler [get]
+func (c ClusterAutoscalerController) Get() (*dto.ClusterAutoscaler, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	return c.ClusterAutoscalerService.Get(clusterName)
+}
+
+// Update Autoscaler
+// @Tags clusters
+// @Summary Update cluster autoscaler
+// @Description 修改集群自动伸缩配置，只支持部署计划创建的集群
+// @Param cluster path string true "集群名称"
+// @Param request body dto.ClusterAutoscalerUpdate true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterAutoscaler
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/autoscaler [post]
+func (c ClusterAutoscalerController) Post() (*dto.ClusterAutoscaler, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	var req dto.ClusterAutoscalerUpdate
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	item, err := c.ClusterAutoscalerService.Save(clusterName, req)
+	if err != nil {
+		return nil, err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPDATE_CLUSTER_AUTOSCALER, clusterName)
+	return item, nil
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterCertificateController struct {
+	Ctx                       context.Context
+	ClusterCertificateService service.ClusterCertificateService
+}
+
+func NewClusterCertificateController() *ClusterCertificateController {
+	return &ClusterCertificateController{
+		ClusterCertificateService: service.NewClusterCertificateService(),
+	}
+}
+
+// List Certificates
+// @Tags clusters
+// @Summary List cluster certificates
+// @Description 获取集群各节点证书的过期时间
+// @Param cluster path string true "集群名称"
+// @Accept  json
+// @Produce  json
+// @Success 200 {Array} dto.ClusterCertificate
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/certificates [get]
+func (c ClusterCertificateController) Get() ([]dto.ClusterCertificate, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	return c.ClusterCertificateService.List(clusterName)
+}
+
+// Refresh Certificates
+// @Tags clusters
+// @Summary Refresh cluster certificates
+// @Description 重新读取集群各节点证书的过期时间
+// @Param cluster path string true "集群名称"
+// @Accept  json
+// @Produce  json
+// @Success 200 {Array} dto.ClusterCertificate
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/certificates/refresh [post]
+func (c ClusterCertificateController) PostRefresh() ([]dto.ClusterCertificate, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	return c.ClusterCertificateService.Refresh(clusterName)
+}
+
+// Rotate Certificates
+// @Tags clusters
+// @Summary Rotate cluster certificates
+// @Description 重新签发集群证书
+// @Param cluster path string true "集群名称"
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/certificates/rotate [post]
+func (c ClusterCertificateController) PostRotate() error {
+	clusterName := c.Ctx.Params().GetString("cluster")
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ROTATE_CLUSTER_CERT, clusterName)
+
+	return c.ClusterCertificateService.Rotate(clusterName)
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterNodePoolController struct {
+	Ctx                    context.Context
+	ClusterNodePoolService service.ClusterNodePoolService
+}
+
+func NewClusterNodePoolController() *ClusterNodePoolController {
+	return &ClusterNodePoolController{
+		ClusterNodePoolService: service.NewClusterNodePoolService(),
+	}
+}
+
+// List NodePools
+// @Tags clusters
+// @Summary List cluster node pools
+// @Description 获取集群节点池列表
+// @Param cluster path string true "集群名称"
+// @Accept  json
+// @Produce  json
+// @Success 200 {Array} dto.ClusterNodePool
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/nodepools [get]
+func (c ClusterNodePoolController) Get() ([]dto.ClusterNodePool, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	return c.ClusterNodePoolService.List(clusterName)
+}
+
+// Get NodePool
+// @Tags clusters
+// @Summary Show a cluster node pool
+// @Description 获取单个节点池
+// @Param cluster path string true "集群名称"
+// @Param name path string true "节点池名称"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterNodePool
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/nodepools/{name} [get]
+func (c ClusterNodePoolController) GetBy(name string) (*dto.ClusterNodePool, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	return c.ClusterNodePoolService.Get(clusterName, name)
+}
+
+// Create NodePool
+// @Tags clusters
+// @Summary Create a cluster node pool
+// @Description 创建节点池，扩缩容时通过 pool 参数指定节点池
+// @Param cluster path string true "集群名称"
+// @Param request body dto.ClusterNodePool true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterNodePool
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/nodepools [post]
+func (c ClusterNodePoolController) Post() (*dto.ClusterNodePool, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	var req dto.ClusterNodePool
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	item, err := c.ClusterNodePoolService.Create(clusterName, req)
+	if err != nil {
+		return nil, err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_CLUSTER_NODE_POOL, clusterName+"("+req.Name+")")
+	return item, nil
+}
+
+// Update NodePool
+// @Tags clusters
+// @Summary Update a cluster node pool
+// @Description 更新节点池，labels 和 taints 会同步到节点池中的节点
+// @Param cluster path string true "集群名称"
+// @Param name path string true "节点池名称"
+// @Param request body dto.ClusterNodePool true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterNodePool
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/nodepools/{name} [patch]
+func (c ClusterNodePoolController) PatchBy(name string) (*dto.ClusterNodePool, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	var req dto.ClusterNodePool
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	item, err := c.ClusterNodePoolService.Update(clusterName, name, req)
+	if err != nil {
+		return nil, err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPDATE_CLUSTER_NODE_POOL, clusterName+"("+name+")")
+	return item, nil
+}
+
+// Delete NodePool
+// @Tags clusters
+// @Summary Delete a cluster node pool
+// @Description 删除节点池，节点池中不能有节点
+// @Param cluster path string true "集群名称"
+// @Param name path string true "节点池名称"
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/nodepools/{name} [delete]
+func (c ClusterNodePoolController) DeleteBy(name string) error {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	if err := c.ClusterNodePoolService.Delete(clusterName, name); err != nil {
+		return err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_CLUSTER_NODE_POOL, clusterName+"("+name+")")
+	return nil
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterRuntimeController struct {
+	Ctx                   context.Context
+	ClusterRuntimeService service.ClusterRuntimeService
+}
+
+func NewClusterRuntimeController() *ClusterRuntimeController {
+	return &ClusterRuntimeController{
+		ClusterRuntimeService: service.NewClusterRuntimeService(),
+	}
+}
+
+// Migrate Runtime
+// @Tags clusters
+// @Summary Migrate container runtime to containerd
+// @Description 逐个节点将容器运行时从 docker 迁移到 containerd
+// @Param cluster path string true "集群名称"
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/runtime/migrate [post]
+func (c ClusterRuntimeController) PostMigrate() error {
+	clusterName := c.Ctx.Params().GetString("cluster")
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.MIGRATE_CLUSTER_RUNTIME, clusterName)
+
+	return c.ClusterRuntimeService.Migrate(clusterName)
+}
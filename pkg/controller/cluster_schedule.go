@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterScheduleController struct {
+	Ctx                    context.Context
+	ClusterScheduleService service.ClusterScheduleService
+}
+
+func NewClusterScheduleController() *ClusterScheduleController {
+	return &ClusterScheduleController{
+		ClusterScheduleService: service.NewClusterScheduleService(),
+	}
+}
+
+// List Schedules
+// @Tags clusters
+// @Summary List cluster schedules
+// @Description 获取集群定时任务列表
+// @Param cluster path string true "集群名称"
+// @Accept  json
+// @Produce  json
+// @Success 200 {Array} model.ClusterSchedule
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/schedules [get]
+func (c ClusterScheduleController) Get() ([]model.ClusterSchedule, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	return c.ClusterScheduleService.List(clusterName)
+}
+
+// Create Schedule
+// @Tags clusters
+// @Summary Create a cluster schedule
+// @Description 创建集群定时任务，action 支持 scale、hibernate 和 wake
+// @Param cluster path string true "集群名称"
+// @Param request body dto.ClusterSchedule true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.ClusterSchedule
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/schedules [post]
+func (c ClusterScheduleController) Post() (*model.ClusterSchedule, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	var req dto.ClusterSchedule
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	item, err := c.ClusterScheduleService.Create(clusterName, req)
+	if err != nil {
+		return nil, err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CREATE_CLUSTER_SCHEDULE, clusterName+"("+req.Name+")")
+	return item, nil
+}
+
+// Update Schedule
+// @Tags clusters
+// @Summary Update a cluster schedule
+// @Description 更新集群定时任务
+// @Param cluster path string true "集群名称"
+// @Param name path string true "定时任务名称"
+// @Param request body dto.ClusterSchedule true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.ClusterSchedule
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/schedules/{name} [patch]
+func (c ClusterScheduleController) PatchBy(name string) (*model.ClusterSchedule, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	var req dto.ClusterSchedule
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	item, err := c.ClusterScheduleService.Update(clusterName, name, req)
+	if err != nil {
+		return nil, err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.UPDATE_CLUSTER_SCHEDULE, clusterName+"("+name+")")
+	return item, nil
+}
+
+// Delete Schedule
+// @Tags clusters
+// @Summary Delete a cluster schedule
+// @Description 删除集群定时任务
+// @Param cluster path string true "集群名称"
+// @Param name path string true "定时任务名称"
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/schedules/{name} [delete]
+func (c ClusterScheduleController) DeleteBy(name string) error {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	if err := c.ClusterScheduleService.Delete(clusterName, name); err != nil {
+		return err
+	}
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_CLUSTER_SCHEDULE, clusterName+"("+name+")")
+	return nil
+}
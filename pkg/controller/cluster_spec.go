@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterSpecController struct {
+	Ctx                context.Context
+	ClusterSpecService service.ClusterSpecService
+}
+
+func NewClusterSpecController() *ClusterSpecController {
+	return &ClusterSpecController{
+		ClusterSpecService: service.NewClusterSpecService(),
+	}
+}
+
+// Update Cluster Spec
+// @Tags clusters
+// @Summary Update spec of a running cluster
+// @Description 修改运行中集群的配置，只重新执行受影响的步骤，dryRun 时只返回变更内容
+// @Param cluster path string true "集群名称"
+// @Param request body dto.ClusterSpecUpdate true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterSpecUpdateResult
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/spec [patch]
+func (c ClusterSpecController) Patch() (*dto.ClusterSpecUpdateResult, error) {
+	var req dto.ClusterSpecUpdate
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	clusterName := c.Ctx.Params().GetString("cluster")
+
+	if !req.DryRun {
+		operator := c.Ctx.Values().GetString("operator")
+		go kolog.Save(operator, constant.UPDATE_CLUSTER_SPEC, clusterName)
+	}
+
+	return c.ClusterSpecService.Update(clusterName, req)
+}
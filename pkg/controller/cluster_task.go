@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterTaskController struct {
+	Ctx                context.Context
+	ClusterTaskService service.ClusterTaskService
+}
+
+func NewClusterTaskController() *ClusterTaskController {
+	return &ClusterTaskController{
+		ClusterTaskService: service.NewClusterTaskService(),
+	}
+}
+
+// Pause Task
+// @Tags clusters
+// @Summary Pause a cluster task
+// @Description 在执行下一个步骤前暂停集群任务
+// @Param cluster path string true "集群名称"
+// @Param id path string true "任务ID"
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/tasks/{id}/pause [post]
+func (c ClusterTaskController) PostPause() error {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	taskID := c.Ctx.Params().GetString("id")
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.PAUSE_CLUSTER_TASK, clusterName)
+
+	return c.ClusterTaskService.Pause(clusterName, taskID)
+}
+
+// Resume Task
+// @Tags clusters
+// @Summary Resume a paused cluster task
+// @Description 继续执行已暂停的集群任务
+// @Param cluster path string true "集群名称"
+// @Param id path string true "任务ID"
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/tasks/{id}/resume [post]
+func (c ClusterTaskController) PostResume() error {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	taskID := c.Ctx.Params().GetString("id")
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.RESUME_CLUSTER_TASK, clusterName)
+
+	return c.ClusterTaskService.Resume(clusterName, taskID)
+}
+
+// Skip Task Handler
+// @Tags clusters
+// @Summary Skip a handler of cluster task
+// @Description 跳过集群任务的指定步骤
+// @Param cluster path string true "集群名称"
+// @Param id path string true "任务ID"
+// @Param request body dto.TaskSkip true "request"
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/tasks/{id}/skip [post]
+func (c ClusterTaskController) PostSkip() error {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	taskID := c.Ctx.Params().GetString("id")
+	var req dto.TaskSkip
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.SKIP_CLUSTER_TASK, clusterName+"("+req.Handler+")")
+
+	return c.ClusterTaskService.Skip(clusterName, taskID, req.Handler)
+}
+
+// Cancel Task
+// @Tags clusters
+// @Summary Cancel a cluster task
+// @Description 取消集群任务
+// @Param cluster path string true "集群名称"
+// @Param id path string true "任务ID"
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/tasks/{id}/cancel [post]
+func (c ClusterTaskController) PostCancel() error {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	taskID := c.Ctx.Params().GetString("id")
+
+	operator := c.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.CANCEL_CLUSTER_TASK, clusterName)
+
+	return c.ClusterTaskService.Cancel(clusterName, taskID)
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/kataras/iris/v12/context"
+)
+
+type ClusterUpgradeController struct {
+	Ctx                   context.Context
+	ClusterUpgradeService service.ClusterUpgradeService
+}
+
+func NewClusterUpgradeController() *ClusterUpgradeController {
+	return &ClusterUpgradeController{
+		ClusterUpgradeService: service.NewClusterUpgradeService(),
+	}
+}
+
+// Get Upgrade Path
+// @Tags clusters
+// @Summary Get cluster upgrade path
+// @Description 计算集群从当前版本升级到目标版本需要依次经过的版本
+// @Param cluster path string true "集群名称"
+// @Param to query string true "目标版本"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.ClusterUpgradePath
+// @Security ApiKeyAuth
+// @Router /clusters/{cluster}/upgrade/path [get]
+func (c ClusterUpgradeController) GetPath() (*dto.ClusterUpgradePath, error) {
+	clusterName := c.Ctx.Params().GetString("cluster")
+	target := c.Ctx.URLParam("to")
+	return c.ClusterUpgradeService.Path(clusterName, target)
+}
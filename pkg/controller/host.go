@@ -10,6 +10,7 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/controller/page"
 	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
 	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/hostfile"
 	sessionUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/session"
 	"github.com/go-playground/validator/v10"
 	"github.com/kataras/iris/v12/context"
@@ -19,12 +20,14 @@ type HostController struct {
 	Ctx                  context.Context
 	HostService          service.HostService
 	SystemSettingService service.SystemSettingService
+	HostPreflightService service.HostPreflightService
 }
 
 func NewHostController() *HostController {
 	return &HostController{
 		HostService:          service.NewHostService(),
 		SystemSettingService: service.NewSystemSettingService(),
+		HostPreflightService: service.NewHostPreflightService(),
 	}
 }
 
@@ -216,6 +219,28 @@ func (h *HostController) PostSync() error {
 	return h.HostService.SyncList(req)
 }
 
+// Preflight Host
+// @Tags hosts
+// @Summary Pre-flight check hosts
+// @Description 检查主机是否满足部署集群的要求
+// @Accept  json
+// @Produce  json
+// @Param request body dto.HostPreflight true "request"
+// @Success 200 {object} dto.HostPreflightReport
+// @Security ApiKeyAuth
+// @Router /hosts/preflight [post]
+func (h *HostController) PostPreflight() (*dto.HostPreflightReport, error) {
+	var req dto.HostPreflight
+	if err := h.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	return h.HostPreflightService.Check(req)
+}
+
 func (h *HostController) PostBatch() error {
 	var req dto.HostOp
 	err := h.Ctx.ReadJSON(&req)
@@ -282,3 +307,71 @@ func (h *HostController) PostUpload() error {
 	defer f.Close()
 	return h.HostService.ImportHosts(bs)
 }
+
+// Import Hosts
+// @Tags hosts
+// @Summary Import hosts from csv, json or yaml file
+// @Description 从 csv、json、yaml 文件导入主机，format 为空时按文件后缀判断，dryRun 为 true 时只返回变更预览
+// @Accept  mpfd
+// @Produce  json
+// @Param format query string false "csv, json or yaml"
+// @Param dryRun query bool false "preview only"
+// @Success 200 {object} dto.HostImportResult
+// @Security ApiKeyAuth
+// @Router /hosts/import/ [post]
+func (h *HostController) PostImport() (*dto.HostImportResult, error) {
+	f, header, err := h.Ctx.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	bs, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	format := h.Ctx.URLParamDefault("format", hostfile.FormatOf(header.Filename))
+	dryRun, _ := h.Ctx.URLParamBool("dryRun")
+	if !dryRun {
+		operator := h.Ctx.Values().GetString("operator")
+		go kolog.Save(operator, constant.IMPORT_HOST, header.Filename)
+	}
+	return h.HostService.ImportHostFile(format, bs, dryRun)
+}
+
+// Export Hosts
+// @Tags hosts
+// @Summary Export hosts as csv, json or yaml file
+// @Description 按导入文件的格式导出主机清单
+// @Produce  application/octet-stream
+// @Param format query string false "csv, json or yaml"
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /hosts/export/ [get]
+func (h *HostController) GetExport() error {
+	projectName, err := sessionUtil.GetProjectName(h.Ctx)
+	if err != nil {
+		return err
+	}
+	format := h.Ctx.URLParamDefault("format", hostfile.FormatCSV)
+	buf, err := h.HostService.ExportHosts(format, projectName)
+	if err != nil {
+		return err
+	}
+	h.Ctx.Header("Content-Type", "application/octet-stream")
+	h.Ctx.Header("Content-Disposition", "attachment; filename=\"hosts."+format+"\"")
+	_, _ = h.Ctx.Write(buf)
+	return nil
+}
+
+// List Host Inventory History
+// @Tags hosts
+// @Summary Show inventory history of a host
+// @Description 获取主机硬件信息的变化记录
+// @Accept  json
+// @Produce  json
+// @Success 200 {Array} []dto.HostInventoryHistory
+// @Security ApiKeyAuth
+// @Router /hosts/{name}/inventory/history [get]
+func (h *HostController) GetByInventoryHistory(name string) ([]dto.HostInventoryHistory, error) {
+	return h.HostService.ListInventoryHistory(name)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/kolog"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/page"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/kataras/iris/v12/context"
+)
+
+type HostDiscoveryController struct {
+	Ctx                  context.Context
+	HostDiscoveryService service.HostDiscoveryService
+}
+
+func NewHostDiscoveryController() *HostDiscoveryController {
+	return &HostDiscoveryController{
+		HostDiscoveryService: service.NewHostDiscoveryService(),
+	}
+}
+
+// List HostDiscovery
+// @Tags hosts
+// @Summary Show all host discoveries
+// @Description 获取主机发现列表
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} page.Page
+// @Security ApiKeyAuth
+// @Router /hosts/discoveries/ [get]
+func (h HostDiscoveryController) Get() (*page.Page, error) {
+	p, _ := h.Ctx.Values().GetBool("page")
+	if p {
+		num, _ := h.Ctx.Values().GetInt(constant.PageNumQueryKey)
+		size, _ := h.Ctx.Values().GetInt(constant.PageSizeQueryKey)
+		return h.HostDiscoveryService.Page(num, size)
+	} else {
+		var p page.Page
+		items, err := h.HostDiscoveryService.List()
+		if err != nil {
+			return &p, err
+		}
+		p.Items = items
+		p.Total = len(items)
+		return &p, nil
+	}
+}
+
+// Get HostDiscovery
+// @Tags hosts
+// @Summary Show a host discovery
+// @Description 获取主机发现进度及发现的主机
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.HostDiscovery
+// @Security ApiKeyAuth
+// @Router /hosts/discoveries/{id}/ [get]
+func (h HostDiscoveryController) GetBy(id string) (*dto.HostDiscovery, error) {
+	return h.HostDiscoveryService.Get(id)
+}
+
+// Create HostDiscovery
+// @Tags hosts
+// @Summary Start a host discovery
+// @Description 扫描网段并发现可以登录的主机
+// @Accept  json
+// @Produce  json
+// @Param request body dto.HostDiscoveryCreate true "request"
+// @Success 200 {object} dto.HostDiscovery
+// @Security ApiKeyAuth
+// @Router /hosts/discoveries/ [post]
+func (h HostDiscoveryController) Post() (*dto.HostDiscovery, error) {
+	var req dto.HostDiscoveryCreate
+	if err := h.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	operator := h.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.START_HOST_DISCOVERY, req.Cidr)
+
+	return h.HostDiscoveryService.Create(req)
+}
+
+// Delete HostDiscovery
+// @Tags hosts
+// @Summary Delete a host discovery
+// @Description 删除主机发现记录
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Router /hosts/discoveries/{id}/ [delete]
+func (h HostDiscoveryController) DeleteBy(id string) error {
+	operator := h.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.DELETE_HOST_DISCOVERY, id)
+	return h.HostDiscoveryService.Delete(id)
+}
+
+// Accept Discovered Hosts
+// @Tags hosts
+// @Summary Accept discovered hosts
+// @Description 将发现的主机批量导入到项目和区域
+// @Accept  json
+// @Produce  json
+// @Param request body dto.HostDiscoveryAccept true "request"
+// @Success 200 {Array} []dto.Host
+// @Security ApiKeyAuth
+// @Router /hosts/discoveries/{id}/accept [post]
+func (h HostDiscoveryController) PostByAccept(id string) ([]dto.Host, error) {
+	var req dto.HostDiscoveryAccept
+	if err := h.Ctx.ReadJSON(&req); err != nil {
+		return nil, err
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	operator := h.Ctx.Values().GetString("operator")
+	go kolog.Save(operator, constant.ACCEPT_HOST_DISCOVERY, req.Project)
+
+	return h.HostDiscoveryService.Accept(id, req)
+}
@@ -1,21 +1,31 @@
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/controller/page"
 	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
 	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/gorilla/websocket"
 	"github.com/kataras/iris/v12/context"
 )
 
+var taskStreamUpgrader = websocket.Upgrader{}
+
 type TaskLogController struct {
-	Ctx            context.Context
-	TaskLogService service.TaskLogService
+	Ctx                  context.Context
+	TaskLogService       service.TaskLogService
+	TaskSchedulerService service.TaskSchedulerService
 }
 
 func NewTaskLogController() *TaskLogController {
 	return &TaskLogController{
-		TaskLogService: service.NewTaskLogService(),
+		TaskLogService:       service.NewTaskLogService(),
+		TaskSchedulerService: service.NewTaskSchedulerService(),
 	}
 }
 
@@ -54,6 +64,83 @@ func (c TaskLogController) GetLog2By(clusterName, logId string) (*dto.Logs, erro
 	return c.TaskLogService.GetTaskLogByName(clusterName, logId)
 }
 
+// Task Queue
+// @Tags task_logs
+// @Summary Show task queue
+// @Description 获取任务调度队列，包括并发限制、执行中和等待中的任务
+// @Produce  json
+// @Success 200 {object} dto.TaskQueue
+// @Security ApiKeyAuth
+// @Router /tasks/queue [get]
+func (c TaskLogController) GetQueue() (*dto.TaskQueue, error) {
+	return c.TaskSchedulerService.Queue()
+}
+
+// Stream TaskLog
+// @Tags task_logs
+// @Summary Stream task log
+// @Description 推送任务日志和阶段变化，WebSocket 握手时使用 WebSocket，否则使用 SSE；通过 offset 参数或 Last-Event-ID 从指定字节位置续传
+// @Param offset query integer false "日志字节偏移"
+// @Produce  text/event-stream
+// @Success 200 {object} dto.TaskStreamEvent
+// @Security ApiKeyAuth
+// @Router /tasks/{id}/stream [get]
+func (c TaskLogController) GetByStream(id string) error {
+	if _, err := c.TaskLogService.GetByID(id); err != nil {
+		return err
+	}
+	offsetStr := c.Ctx.URLParamDefault("offset", "0")
+	if lastID := c.Ctx.GetHeader("Last-Event-ID"); lastID != "" {
+		offsetStr = lastID
+	}
+	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	if err != nil || offset < 0 {
+		return fmt.Errorf("invalid offset %s", offsetStr)
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Ctx.Request()) {
+		ws, err := taskStreamUpgrader.Upgrade(c.Ctx.ResponseWriter(), c.Ctx.Request(), nil)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+		// 读取到客户端关闭连接后停止推送
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, _, err := ws.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		if err := c.TaskLogService.Stream(id, offset, done, func(event dto.TaskStreamEvent) error {
+			return ws.WriteJSON(event)
+		}); err != nil {
+			logger.Log.Errorf("stream task %s failed: %s", id, err.Error())
+		}
+		return nil
+	}
+
+	c.Ctx.ContentType("text/event-stream")
+	c.Ctx.Header("Cache-Control", "no-cache")
+	c.Ctx.Header("X-Accel-Buffering", "no")
+	if err := c.TaskLogService.Stream(id, offset, c.Ctx.Request().Context().Done(), func(event dto.TaskStreamEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Ctx.ResponseWriter(), "id: %d\nevent: %s\ndata: %s\n\n", event.Offset, event.Type, data); err != nil {
+			return err
+		}
+		c.Ctx.ResponseWriter().Flush()
+		return nil
+	}); err != nil {
+		logger.Log.Errorf("stream task %s failed: %s", id, err.Error())
+	}
+	return nil
+}
+
 func (c TaskLogController) GetBackupLogsBy(clusterName string) (*page.Page, error) {
 	p, _ := c.Ctx.Values().GetBool("page")
 	if p {
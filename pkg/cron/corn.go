@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/cron/job"
+	"github.com/ClusterOperator/ClusterOperator/pkg/ha"
 	"github.com/robfig/cron/v3"
 )
 
@@ -19,7 +20,7 @@ type InitCronPhase struct {
 func (c *InitCronPhase) Init() error {
 	//Cron = cron.New()
 	nyc, _ := time.LoadLocation("Asia/Shanghai")
-	Cron = cron.New(cron.WithLocation(nyc))
+	Cron = cron.New(cron.WithLocation(nyc), cron.WithChain(leaderOnly))
 	if c.Enable {
 		_, err := Cron.AddJob("0 3 * * *", job.NewRefreshHostInfo())
 		if err != nil {
@@ -33,6 +34,22 @@ func (c *InitCronPhase) Init() error {
 		if err != nil {
 			return fmt.Errorf("can not add license corn job: %s", err.Error())
 		}
+		_, err = Cron.AddJob("@daily", job.NewCertificateExpire())
+		if err != nil {
+			return fmt.Errorf("can not add certificate corn job: %s", err.Error())
+		}
+		_, err = Cron.AddJob("@every 1m", job.NewClusterAutoscale())
+		if err != nil {
+			return fmt.Errorf("can not add autoscale corn job: %s", err.Error())
+		}
+		_, err = Cron.AddJob("@every 1m", job.NewClusterSchedule())
+		if err != nil {
+			return fmt.Errorf("can not add schedule corn job: %s", err.Error())
+		}
+		_, err = Cron.AddJob("@every 10m", job.NewSessionClean())
+		if err != nil {
+			return fmt.Errorf("can not add session clean corn job: %s", err.Error())
+		}
 		Cron.Start()
 	}
 	return nil
@@ -41,3 +58,12 @@ func (c *InitCronPhase) Init() error {
 func (c *InitCronPhase) PhaseName() string {
 	return phaseName
 }
+
+// leaderOnly 多副本部署时定时任务只在 leader 上执行
+func leaderOnly(j cron.Job) cron.Job {
+	return cron.FuncJob(func() {
+		if ha.IsLeader() {
+			j.Run()
+		}
+	})
+}
@@ -0,0 +1,19 @@
+package job
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+)
+
+type ClusterAutoscale struct {
+	clusterAutoscalerService service.ClusterAutoscalerService
+}
+
+func NewClusterAutoscale() *ClusterAutoscale {
+	return &ClusterAutoscale{
+		clusterAutoscalerService: service.NewClusterAutoscalerService(),
+	}
+}
+
+func (c *ClusterAutoscale) Run() {
+	c.clusterAutoscalerService.Reconcile()
+}
@@ -50,7 +50,7 @@ func (c *ClusterBackup) Run() {
 				defer wg.Done()
 				logger.Log.Infof("backup cluster [%s]", cluster.Name)
 				if cluster.ID != "" {
-					err := c.cLusterBackupFileService.Backup(dto.ClusterBackupFileCreate{ClusterName: cluster.Name})
+					err := c.cLusterBackupFileService.Backup(dto.ClusterBackupFileCreate{ClusterName: cluster.Name, Cron: true})
 					if err != nil {
 						logger.Log.Errorf("backup cluster error: %s", err.Error())
 					} else {
@@ -0,0 +1,19 @@
+package job
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+)
+
+type CertificateExpire struct {
+	clusterCertificateService service.ClusterCertificateService
+}
+
+func NewCertificateExpire() *CertificateExpire {
+	return &CertificateExpire{
+		clusterCertificateService: service.NewClusterCertificateService(),
+	}
+}
+
+func (c *CertificateExpire) Run() {
+	c.clusterCertificateService.CheckExpire()
+}
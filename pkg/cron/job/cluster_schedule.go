@@ -0,0 +1,19 @@
+package job
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+)
+
+type ClusterSchedule struct {
+	clusterScheduleService service.ClusterScheduleService
+}
+
+func NewClusterSchedule() *ClusterSchedule {
+	return &ClusterSchedule{
+		clusterScheduleService: service.NewClusterScheduleService(),
+	}
+}
+
+func (c *ClusterSchedule) Run() {
+	c.clusterScheduleService.RunDue()
+}
@@ -0,0 +1,15 @@
+package job
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/ha"
+)
+
+type SessionClean struct{}
+
+func NewSessionClean() *SessionClean {
+	return &SessionClean{}
+}
+
+func (s *SessionClean) Run() {
+	ha.CleanExpiredSessions()
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/ClusterOperator/ClusterOperator/pkg/model"
+
+type Bastion struct {
+	model.Bastion
+	CredentialName string `json:"credentialName"`
+}
+
+type BastionCreate struct {
+	Name         string `json:"name" validate:"required"`
+	Ip           string `json:"ip" validate:"required"`
+	Port         int    `json:"port" validate:"required"`
+	CredentialID string `json:"credentialId" validate:"required"`
+}
+
+type BastionUpdate struct {
+	Ip           string `json:"ip" validate:"required"`
+	Port         int    `json:"port" validate:"required"`
+	CredentialID string `json:"credentialId" validate:"required"`
+}
@@ -29,6 +29,7 @@ type ClusterNode struct {
 type NodeCreate struct {
 	HostName string `json:"hostName"`
 	Role     string `json:"role"`
+	NodePool string `json:"nodePool,omitempty"`
 }
 
 type ClusterCreate struct {
@@ -85,6 +86,7 @@ type ClusterCreate struct {
 	MasterScheduleType string       `json:"masterScheduleType"`
 	WorkerAmount       int          `json:"workerAmount"`
 	Nodes              []NodeCreate `json:"nodes"`
+	SkipPreflight      bool         `json:"skipPreflight"`
 }
 
 type ClusterBatch struct {
@@ -93,8 +95,9 @@ type ClusterBatch struct {
 }
 
 type Endpoint struct {
-	Address string
-	Port    int
+	Address     string
+	Port        int
+	ConnectMode string
 }
 
 type ClusterWithEndpoint struct {
@@ -111,8 +114,17 @@ type IsClusterNameExist struct {
 }
 
 type ClusterUpgrade struct {
-	ClusterName string `json:"clusterName"`
-	Version     string `json:"version"`
+	ClusterName  string `json:"clusterName"`
+	Version      string `json:"version"`
+	Strategy     string `json:"strategy"`
+	BatchSize    int    `json:"batchSize"`
+	AutoRollback bool   `json:"autoRollback"`
+}
+
+type ClusterUpgradePath struct {
+	Current string   `json:"current"`
+	Target  string   `json:"target"`
+	Hops    []string `json:"hops"`
 }
 
 type ClusterHealth struct {
@@ -149,6 +161,7 @@ type ClusterLoad struct {
 	CertDataStr        string `json:"certDataStr"`
 	KeyDataStr         string `json:"keyDataStr"`
 	ConfigContent      string `json:"configContent"`
+	ConnectMode        string `json:"connectMode"`
 }
 
 type ClusterLoadInfo struct {
@@ -266,3 +279,22 @@ func (c ClusterCreate) ClusterCreateDto2Mo() *model.Cluster {
 	cluster.SpecConf.KubeNetworkNodePrefix = nodeMask
 	return &cluster
 }
+
+type ClusterSpecUpdate struct {
+	Conf    map[string]interface{} `json:"conf"`
+	Runtime map[string]interface{} `json:"runtime"`
+	DryRun  bool                   `json:"dryRun"`
+}
+
+type ClusterSpecChange struct {
+	Scope string      `json:"scope"`
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type ClusterSpecUpdateResult struct {
+	Changes []ClusterSpecChange `json:"changes"`
+	Phases  []string            `json:"phases"`
+	TaskID  string              `json:"taskId"`
+}
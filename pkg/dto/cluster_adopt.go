@@ -0,0 +1,16 @@
+package dto
+
+type ClusterAdopt struct {
+	CredentialID string             `json:"credentialId"`
+	ZoneID       string             `json:"zoneId"`
+	Nodes        []ClusterAdoptNode `json:"nodes" validate:"required"`
+}
+
+type ClusterAdoptNode struct {
+	Name         string `json:"name" validate:"required"`
+	Ip           string `json:"ip" validate:"required"`
+	Port         int    `json:"port"`
+	CredentialID string `json:"credentialId"`
+	ZoneID       string `json:"zoneId"`
+	BastionID    string `json:"bastionId"`
+}
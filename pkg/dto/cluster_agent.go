@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/ClusterOperator/ClusterOperator/pkg/model"
+
+// ClusterAgent Token 和 Manifest 只在生成 token 时返回
+type ClusterAgent struct {
+	model.ClusterAgent
+	Token    string `json:"token,omitempty"`
+	Manifest string `json:"manifest,omitempty"`
+}
+
+type ClusterAgentCreate struct {
+	ServerUrl string `json:"serverUrl"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "github.com/ClusterOperator/ClusterOperator/pkg/model"
+
+type ClusterAutoscaler struct {
+	model.ClusterAutoscaler
+	CurrentSize int `json:"currentSize"`
+}
+
+type ClusterAutoscalerUpdate struct {
+	Enabled              bool   `json:"enabled"`
+	NodePool             string `json:"nodePool"`
+	MinSize              int    `json:"minSize"`
+	MaxSize              int    `json:"maxSize"`
+	ScaleUpCooldown      int    `json:"scaleUpCooldown"`
+	ScaleDownCooldown    int    `json:"scaleDownCooldown"`
+	ScaleDownUtilization int    `json:"scaleDownUtilization"`
+}
@@ -11,6 +11,8 @@ type ClusterBackupFileCreate struct {
 	Name                    string `json:"name"`
 	ClusterBackupStrategyID string `json:"clusterBackupStrategyId" validate:"required"`
 	Folder                  string `json:"folder"`
+	// Cron 由定时任务发起，调度优先级低于用户操作
+	Cron bool `json:"-"`
 }
 
 type ClusterBackupFileOp struct {
@@ -0,0 +1,8 @@
+package dto
+
+import "github.com/ClusterOperator/ClusterOperator/pkg/model"
+
+type ClusterCertificate struct {
+	model.ClusterCertificate
+	RemainingDays int `json:"remainingDays"`
+}
@@ -0,0 +1,59 @@
+package dto
+
+const (
+	ClusterDeclarationVersion = "kubeoperator.io/v1"
+	ClusterDeclarationKind    = "Cluster"
+
+	ApplyOperationCreate           = "create"
+	ApplyOperationUpgrade          = "upgrade"
+	ApplyOperationAddNodes         = "add-nodes"
+	ApplyOperationRemoveNodes      = "remove-nodes"
+	ApplyOperationEnableComponent  = "enable-component"
+	ApplyOperationDisableComponent = "disable-component"
+	ApplyOperationEnableTool       = "enable-tool"
+	ApplyOperationDisableTool      = "disable-tool"
+	ApplyOperationSyncNodePool     = "sync-node-pool"
+	ApplyOperationUpdateSpec       = "update-spec"
+
+	ApplyStatusPlanned = "planned"
+	ApplyStatusApplied = "applied"
+	ApplyStatusPending = "pending"
+)
+
+// ClusterDeclaration 集群的声明式定义，以单个 YAML 文档导出和应用
+type ClusterDeclaration struct {
+	APIVersion string                        `json:"apiVersion"`
+	Kind       string                        `json:"kind"`
+	Spec       ClusterCreate                 `json:"spec"`
+	Components []ClusterDeclarationComponent `json:"components"`
+	Tools      []ClusterDeclarationTool      `json:"tools"`
+	NodePools  []ClusterNodePool             `json:"nodePools,omitempty"`
+}
+
+type ClusterDeclarationComponent struct {
+	Name    string                 `json:"name"`
+	Type    string                 `json:"type"`
+	Version string                 `json:"version"`
+	Vars    map[string]interface{} `json:"vars,omitempty"`
+}
+
+type ClusterDeclarationTool struct {
+	Name    string                 `json:"name"`
+	Version string                 `json:"version"`
+	Vars    map[string]interface{} `json:"vars,omitempty"`
+}
+
+type ClusterApplyOperation struct {
+	Type    string   `json:"type"`
+	Role    string   `json:"role,omitempty"`
+	Pool    string   `json:"pool,omitempty"`
+	Targets []string `json:"targets,omitempty"`
+	Count   int      `json:"count,omitempty"`
+	Version string   `json:"version,omitempty"`
+	Status  string   `json:"status"`
+}
+
+type ClusterApplyResult struct {
+	Cluster    string                  `json:"cluster"`
+	Operations []ClusterApplyOperation `json:"operations"`
+}
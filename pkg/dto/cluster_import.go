@@ -25,6 +25,7 @@ type ClusterImport struct {
 	CertDataStr        string `json:"certDataStr"`
 	KeyDataStr         string `json:"keyDataStr"`
 	ConfigContent      string `json:"configContent"`
+	ConnectMode        string `json:"connectMode"`
 }
 
 type clusterInfo struct {
@@ -124,6 +125,7 @@ func (c ClusterImport) ClusterImportDto2Mo() (*model.Cluster, error) {
 		KubeApiServerPort:  port,
 		KubeRouter:         c.Router,
 		AuthenticationMode: c.AuthenticationMode,
+		ConnectMode:        c.ConnectMode,
 		Status:             constant.StatusRunning,
 	}
 	cluster.Secret = model.ClusterSecret{KubeadmToken: "",
@@ -193,9 +195,15 @@ func (c ClusterImport) ClusterImportDto2Mo() (*model.Cluster, error) {
 		LbKubeApiserverIp:       c.KoClusterInfo.LbKubeApiserverIp,
 		KubeApiServerPort:       c.KoClusterInfo.KubeApiServerPort,
 		AuthenticationMode:      c.AuthenticationMode,
+		ConnectMode:             c.ConnectMode,
 
 		Status: constant.StatusRunning,
 	}
+	// 通过 agent 连接时集群内的 apiserver 地址对 KubeOperator 不可达，统一使用 agent 所在集群的 service 地址
+	if c.ConnectMode == constant.ConnectModeAgent {
+		cluster.SpecConf.LbKubeApiserverIp = address
+		cluster.SpecConf.KubeApiServerPort = port
+	}
 	return &cluster, nil
 }
 
@@ -12,12 +12,15 @@ type Node struct {
 }
 
 type NodeBatch struct {
-	Hosts     []string `json:"hosts"`
-	Nodes     []string `json:"nodes"`
-	Increase  int      `json:"increase"`
-	Operation string   `json:"operation"`
-	IsForce   bool     `json:"isForce"`
-	StatusID  string   `json:"statusID"`
+	Hosts         []string `json:"hosts"`
+	Nodes         []string `json:"nodes"`
+	Increase      int      `json:"increase"`
+	Operation     string   `json:"operation"`
+	IsForce       bool     `json:"isForce"`
+	StatusID      string   `json:"statusID"`
+	Role          string   `json:"role"`
+	Pool          string   `json:"pool"`
+	SkipPreflight bool     `json:"skipPreflight"`
 }
 
 type NodePage struct {
@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/ClusterOperator/ClusterOperator/pkg/model"
+
+// ClusterNodePool 节点池定义，size 为节点池当前节点数，导出和 apply 时作为期望节点数
+type ClusterNodePool struct {
+	Name            string                         `json:"name"`
+	VmConfig        string                         `json:"vmConfig,omitempty"`
+	Zones           []string                       `json:"zones,omitempty"`
+	HostSelector    *model.NodePoolHostSelector    `json:"hostSelector,omitempty"`
+	Labels          map[string]string              `json:"labels,omitempty"`
+	Taints          []model.NodePoolTaint          `json:"taints,omitempty"`
+	KubeletReserved *model.NodePoolKubeletReserved `json:"kubeletReserved,omitempty"`
+	Size            int                            `json:"size"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "github.com/ClusterOperator/kobe/api"
+
+type ClusterPlan struct {
+	Inventory *api.Inventory    `json:"inventory"`
+	Vars      map[string]string `json:"vars"`
+	Handlers  []PlanHandler     `json:"handlers"`
+}
+
+// PlanHandler 计划执行的步骤，Hop 为多跳升级中该步骤所属的目标版本，Skip 表示执行时会跳过
+type PlanHandler struct {
+	Name      string   `json:"name"`
+	Hop       string   `json:"hop,omitempty"`
+	Playbooks []string `json:"playbooks"`
+	Skip      bool     `json:"skip"`
+	Message   string   `json:"message"`
+}
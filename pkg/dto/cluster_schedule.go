@@ -0,0 +1,11 @@
+package dto
+
+type ClusterSchedule struct {
+	Name        string `json:"name"`
+	Cron        string `json:"cron"`
+	Action      string `json:"action"`
+	NodePool    string `json:"nodePool"`
+	WorkerCount int    `json:"workerCount"`
+	// Enabled 创建时为空默认开启，修改时为空保持原值
+	Enabled *bool `json:"enabled"`
+}
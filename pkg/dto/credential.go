@@ -17,6 +17,7 @@ type CredentialCreate struct {
 	Password   string
 	PrivateKey string
 	Type       string `json:"type" validate:"required"`
+	BastionID  string `json:"bastionId"`
 }
 
 type CredentialUpdate struct {
@@ -24,6 +25,7 @@ type CredentialUpdate struct {
 	Password   string `json:"password"`
 	PrivateKey string `json:"privateKey"`
 	Type       string `json:"type" validate:"required"`
+	BastionID  string `json:"bastionId"`
 }
 
 type CredentialBatchOp struct {
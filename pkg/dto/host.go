@@ -3,6 +3,8 @@ package dto
 import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/errorf"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/inventory"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/preflight"
 )
 
 type Host struct {
@@ -21,6 +23,7 @@ type HostCreate struct {
 	Project      string                 `json:"project" validate:"required"`
 	Cluster      string                 `json:"cluster"`
 	CredentialID string                 `json:"credentialId"`
+	BastionID    string                 `json:"bastionId"`
 	Credential   CredentialOfHostCreate `json:"credential"`
 }
 
@@ -30,6 +33,7 @@ type HostUptate struct {
 	FlexIp       string                 `json:"flexIp"`
 	Port         int                    `json:"port" validate:"required"`
 	CredentialID string                 `json:"credentialId"`
+	BastionID    string                 `json:"bastionId"`
 	Credential   CredentialOfHostCreate `json:"credential"`
 }
 
@@ -60,3 +64,44 @@ type ImportHostResponse struct {
 	Errs    errorf.CErrFs `json:"errs"`
 	Success bool          `json:"success"`
 }
+
+type HostPreflight struct {
+	Hosts         []string `json:"hosts" validate:"required"`
+	Role          string   `json:"role"`
+	StorageDir    string   `json:"storageDir"`
+	KubeProxyMode string   `json:"kubeProxyMode"`
+}
+
+type HostPreflightReport struct {
+	Level string                `json:"level"`
+	Hosts []HostPreflightResult `json:"hosts"`
+}
+
+type HostPreflightResult struct {
+	Name   string            `json:"name"`
+	Ip     string            `json:"ip"`
+	Role   string            `json:"role"`
+	Level  string            `json:"level"`
+	Checks []preflight.Check `json:"checks"`
+}
+
+type HostImportResult struct {
+	DryRun bool             `json:"dryRun"`
+	Items  []HostImportItem `json:"items"`
+}
+
+// HostImportItem 导入文件中单条记录的变更，Action 为 create、update 或 unchanged，Changes 为更新的字段
+type HostImportItem struct {
+	Row     int      `json:"row"`
+	Name    string   `json:"name"`
+	Ip      string   `json:"ip"`
+	Project string   `json:"project"`
+	Action  string   `json:"action"`
+	Changes []string `json:"changes"`
+}
+
+type HostInventoryHistory struct {
+	model.HostInventoryHistory
+	Inventory inventory.Inventory `json:"inventory"`
+	Changes   []string            `json:"changes"`
+}
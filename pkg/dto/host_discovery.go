@@ -0,0 +1,25 @@
+package dto
+
+import "github.com/ClusterOperator/ClusterOperator/pkg/model"
+
+type HostDiscovery struct {
+	model.HostDiscovery
+	CredentialName string `json:"credentialName"`
+}
+
+type HostDiscoveryCreate struct {
+	Cidr         string `json:"cidr" validate:"required"`
+	Port         int    `json:"port" validate:"required"`
+	CredentialID string `json:"credentialId" validate:"required"`
+}
+
+type HostDiscoveryAccept struct {
+	Project string                    `json:"project" validate:"required"`
+	Zone    string                    `json:"zone"`
+	Items   []HostDiscoveryAcceptItem `json:"items" validate:"required"`
+}
+
+type HostDiscoveryAcceptItem struct {
+	Ip   string `json:"ip" validate:"required"`
+	Name string `json:"name"`
+}
@@ -12,3 +12,33 @@ type TaskLog struct {
 type Logs struct {
 	Msg string `json:"msg"`
 }
+
+type TaskStreamEvent struct {
+	Type    string               `json:"type"`
+	Offset  int64                `json:"offset"`
+	Data    string               `json:"data,omitempty"`
+	Phase   string               `json:"phase,omitempty"`
+	Message string               `json:"message,omitempty"`
+	Detail  *model.TaskLogDetail `json:"detail,omitempty"`
+}
+
+type TaskSkip struct {
+	Handler string `json:"handler" validate:"required"`
+}
+
+type TaskQueue struct {
+	MaxConcurrent int             `json:"maxConcurrent"`
+	TypeLimits    map[string]int  `json:"typeLimits"`
+	Running       []TaskQueueItem `json:"running"`
+	Waiting       []TaskQueueItem `json:"waiting"`
+}
+
+type TaskQueueItem struct {
+	TaskLogID   string `json:"taskLogId"`
+	ClusterName string `json:"clusterName"`
+	Type        string `json:"type"`
+	Priority    int    `json:"priority"`
+	Position    int    `json:"position"`
+	SubmitTime  int64  `json:"submitTime"`
+	StartTime   int64  `json:"startTime"`
+}
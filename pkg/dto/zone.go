@@ -18,6 +18,7 @@ type ZoneCreate struct {
 	RegionName     string      `json:"regionName" validate:"required"`
 	IpPoolName     string      `json:"ipPoolName"`
 	CredentialName string      `json:"credentialName"`
+	BastionID      string      `json:"bastionId"`
 }
 
 type ZoneOp struct {
@@ -42,6 +43,7 @@ type ZoneUpdate struct {
 	RegionID       string      `json:"regionID" validate:"required"`
 	IpPoolName     string      `json:"ipPoolName" validate:"required"`
 	CredentialName string      `json:"credentialName"`
+	BastionID      string      `json:"bastionId"`
 }
 
 type CloudDatastore struct {
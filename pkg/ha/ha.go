@@ -0,0 +1,131 @@
+package ha
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+)
+
+const (
+	phaseName       = "ha"
+	leaderLease     = "leader"
+	replicaPrefix   = "replica/"
+	ClusterPrefix   = "cluster/"
+	TaskPrefix      = "task/"
+	DiscoveryPrefix = "discovery/"
+	recoveryPrefix  = "recovery"
+)
+
+var (
+	leaderLock   sync.Mutex
+	leader       bool
+	replicas     []string
+	recoverFuncs []func() error
+)
+
+// OnRecover 注册故障恢复操作，副本成为 leader 或有副本失联时执行，用于处理失联副本上被中断的任务
+func OnRecover(f func() error) {
+	recoverFuncs = append(recoverFuncs, f)
+}
+
+// IsLeader 当前副本是否为 leader，定时任务等后台工作只在 leader 上执行
+func IsLeader() bool {
+	leaderLock.Lock()
+	defer leaderLock.Unlock()
+	return leader
+}
+
+// InitHAPhase 启动时注册共享会话存储，并开始续约和选主
+type InitHAPhase struct{}
+
+func (i *InitHAPhase) Init() error {
+	constant.Sess.UseDatabase(&sessionStore{})
+	if _, err := acquire(replicaPrefix + Identity); err != nil {
+		return err
+	}
+	logger.Log.Infof("replica %s started", Identity)
+	elect()
+	go func() {
+		ticker := time.NewTicker(renewInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			renewHeld()
+			if _, err := acquire(replicaPrefix + Identity); err != nil {
+				logger.Log.Errorf("renew replica lease failed: %s", err.Error())
+			}
+			elect()
+		}
+	}()
+	return nil
+}
+
+func (i *InitHAPhase) PhaseName() string {
+	return phaseName
+}
+
+// elect 获取或续约 leader 租约，续约顺序为操作租约、副本租约、leader 租约，保证副本失联被发现时它持有的租约都已过期
+func elect() {
+	ok, err := acquire(leaderLease)
+	if err != nil {
+		logger.Log.Errorf("renew leader lease failed: %s", err.Error())
+		return
+	}
+	leaderLock.Lock()
+	changed := leader != ok
+	leader = ok
+	leaderLock.Unlock()
+	if changed {
+		if ok {
+			logger.Log.Infof("replica %s became leader", Identity)
+		} else {
+			logger.Log.Infof("replica %s lost leadership", Identity)
+		}
+	}
+	if !ok {
+		return
+	}
+
+	alive, err := Alive(replicaPrefix)
+	if err != nil {
+		logger.Log.Errorf("list replicas failed: %s", err.Error())
+		return
+	}
+	sort.Strings(alive)
+	lost := lostReplicas(replicas, alive)
+	for _, r := range lost {
+		logger.Log.Infof("replica %s lost", r)
+	}
+	replicas = alive
+	if changed || len(lost) > 0 {
+		go recoverInterrupted()
+	}
+}
+
+func lostReplicas(before, after []string) []string {
+	current := map[string]bool{}
+	for _, r := range after {
+		current[r] = true
+	}
+	var lost []string
+	for _, r := range before {
+		if !current[r] {
+			lost = append(lost, r)
+		}
+	}
+	return lost
+}
+
+func recoverInterrupted() {
+	if ok, err := TryLock(recoveryPrefix); err != nil || !ok {
+		return
+	}
+	defer Unlock(recoveryPrefix)
+	for _, f := range recoverFuncs {
+		if err := f(); err != nil {
+			logger.Log.Errorf("recover interrupted tasks failed: %s", err.Error())
+		}
+	}
+}
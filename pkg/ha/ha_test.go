@@ -0,0 +1,50 @@
+package ha
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+)
+
+func TestLostReplicas(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []string
+		after  []string
+		want   []string
+	}{
+		{name: "unchanged", before: []string{"a", "b"}, after: []string{"a", "b"}},
+		{name: "new replica", before: []string{"a"}, after: []string{"a", "b"}},
+		{name: "replica lost", before: []string{"a", "b"}, after: []string{"b", "c"}, want: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lostReplicas(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lostReplicas() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "profile", value: &dto.Profile{User: dto.SessionUser{Name: "admin", IsAdmin: true, Roles: []string{"ADMIN"}}}},
+		{name: "string", value: "zh-CN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encodeSessionValue(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decodeSessionValue(model.SessionValue{Value: data}); !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("decodeSessionValue() = %#v, want %#v", got, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package ha
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	leaseTTL      = 15 * time.Second
+	renewInterval = 5 * time.Second
+)
+
+// Identity 当前副本的标识，每次启动都不同，重启前持有的租约只能等待过期
+var Identity = newIdentity()
+
+var (
+	heldLock sync.Mutex
+	held     = map[string]bool{}
+)
+
+func newIdentity() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%s", hostname, strings.Split(uuid.NewV4().String(), "-")[0])
+}
+
+// TryLock 获取租约，持有期间自动续约，租约已被其他副本或本副本的其他操作持有时返回 false
+func TryLock(name string) (bool, error) {
+	heldLock.Lock()
+	if held[name] {
+		heldLock.Unlock()
+		return false, nil
+	}
+	held[name] = true
+	heldLock.Unlock()
+
+	ok, err := acquire(name)
+	if err != nil || !ok {
+		heldLock.Lock()
+		delete(held, name)
+		heldLock.Unlock()
+		return false, err
+	}
+	return true, nil
+}
+
+// Unlock 释放本副本持有的租约
+func Unlock(name string) {
+	heldLock.Lock()
+	delete(held, name)
+	heldLock.Unlock()
+	if err := db.DB.Where("name = ? AND holder = ?", name, Identity).Delete(&model.Lease{}).Error; err != nil {
+		logger.Log.Errorf("release lease %s failed: %s", name, err.Error())
+	}
+}
+
+// Alive 查询名称以 prefix 开头且未过期的租约，返回去掉前缀后的名称
+func Alive(prefix string) ([]string, error) {
+	var leases []model.Lease
+	if err := db.DB.Where("name LIKE ? AND expire_at > NOW()", prefix+"%").Find(&leases).Error; err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, lease := range leases {
+		names = append(names, strings.TrimPrefix(lease.Name, prefix))
+	}
+	return names, nil
+}
+
+// acquire 获取或续约租约，租约不存在、已过期或由本副本持有时成功，过期时间使用数据库时间避免副本间时钟不一致
+func acquire(name string) (bool, error) {
+	if err := db.DB.Exec("INSERT INTO ko_lease (name, holder, expire_at, created_at, updated_at) "+
+		"VALUES (?, ?, DATE_ADD(NOW(), INTERVAL ? SECOND), NOW(), NOW()) "+
+		"ON DUPLICATE KEY UPDATE holder = IF(expire_at < NOW() OR holder = VALUES(holder), VALUES(holder), holder), "+
+		"expire_at = IF(holder = VALUES(holder), VALUES(expire_at), expire_at), updated_at = NOW()",
+		name, Identity, int(leaseTTL/time.Second)).Error; err != nil {
+		return false, err
+	}
+	var lease model.Lease
+	if err := db.DB.Where("name = ?", name).First(&lease).Error; err != nil {
+		return false, err
+	}
+	return lease.Holder == Identity, nil
+}
+
+// renewHeld 续约本副本持有的租约，续约失败说明租约已过期并被其他副本获取
+func renewHeld() {
+	heldLock.Lock()
+	var names []string
+	for name := range held {
+		names = append(names, name)
+	}
+	heldLock.Unlock()
+	for _, name := range names {
+		ok, err := acquire(name)
+		if err != nil {
+			logger.Log.Errorf("renew lease %s failed: %s", name, err.Error())
+			continue
+		}
+		if !ok {
+			logger.Log.Errorf("lease %s is taken by other replica", name)
+			heldLock.Lock()
+			delete(held, name)
+			heldLock.Unlock()
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package ha
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/kataras/iris/v12/sessions"
+	uuid "github.com/satori/go.uuid"
+)
+
+func init() {
+	// 会话中保存的类型需要注册，读取时才能还原为原类型
+	gob.Register(&dto.Profile{})
+}
+
+// sessionStore 将会话保存到数据库，各副本共享登录状态
+type sessionStore struct{}
+
+var _ sessions.Database = (*sessionStore)(nil)
+
+// Acquire 过期的会话视为不存在，过期数据由 leader 上的定时任务 CleanExpiredSessions 清理
+func (s *sessionStore) Acquire(sid string, expires time.Duration) sessions.LifeTime {
+	var value model.SessionValue
+	if err := db.DB.Where("sid = ?", sid).First(&value).Error; err != nil || value.ExpireAt == nil || value.ExpireAt.Before(time.Now()) {
+		return sessions.LifeTime{}
+	}
+	return sessions.LifeTime{Time: *value.ExpireAt}
+}
+
+// CleanExpiredSessions 删除已过期的会话
+func CleanExpiredSessions() {
+	if err := db.DB.Where("expire_at < ?", time.Now()).Delete(&model.SessionValue{}).Error; err != nil {
+		logger.Log.Errorf("clean expired session failed: %s", err.Error())
+	}
+}
+
+func (s *sessionStore) OnUpdateExpiration(sid string, newExpires time.Duration) error {
+	return db.DB.Model(&model.SessionValue{}).Where("sid = ?", sid).Update("expire_at", time.Now().Add(newExpires)).Error
+}
+
+func (s *sessionStore) Set(sid string, lifetime sessions.LifeTime, key string, value interface{}, immutable bool) {
+	data, err := encodeSessionValue(value)
+	if err != nil {
+		logger.Log.Errorf("encode session value %s failed: %s", key, err.Error())
+		return
+	}
+	var expireAt *time.Time
+	if !lifetime.IsZero() {
+		expireAt = &lifetime.Time
+	}
+	if err := db.DB.Exec("INSERT INTO ko_session_value (id, sid, `key`, value, expire_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, NOW(), NOW()) "+
+		"ON DUPLICATE KEY UPDATE value = VALUES(value), expire_at = VALUES(expire_at), updated_at = NOW()",
+		uuid.NewV4().String(), sid, key, data, expireAt).Error; err != nil {
+		logger.Log.Errorf("save session value %s failed: %s", key, err.Error())
+	}
+}
+
+func (s *sessionStore) Get(sid string, key string) interface{} {
+	var value model.SessionValue
+	if err := db.DB.Where("sid = ? AND `key` = ?", sid, key).First(&value).Error; err != nil {
+		return nil
+	}
+	return decodeSessionValue(value)
+}
+
+func (s *sessionStore) Visit(sid string, cb func(key string, value interface{})) {
+	var values []model.SessionValue
+	if err := db.DB.Where("sid = ?", sid).Find(&values).Error; err != nil {
+		return
+	}
+	for _, value := range values {
+		cb(value.Key, decodeSessionValue(value))
+	}
+}
+
+func (s *sessionStore) Len(sid string) int {
+	var count int
+	_ = db.DB.Model(&model.SessionValue{}).Where("sid = ?", sid).Count(&count).Error
+	return count
+}
+
+func (s *sessionStore) Delete(sid string, key string) bool {
+	d := db.DB.Where("sid = ? AND `key` = ?", sid, key).Delete(&model.SessionValue{})
+	return d.Error == nil && d.RowsAffected > 0
+}
+
+func (s *sessionStore) Clear(sid string) {
+	_ = db.DB.Where("sid = ?", sid).Delete(&model.SessionValue{}).Error
+}
+
+func (s *sessionStore) Release(sid string) {
+	s.Clear(sid)
+}
+
+func encodeSessionValue(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeSessionValue(value model.SessionValue) interface{} {
+	var v interface{}
+	if err := gob.NewDecoder(bytes.NewReader(value.Value)).Decode(&v); err != nil {
+		logger.Log.Errorf("decode session value %s failed: %s", value.Key, err.Error())
+		return nil
+	}
+	return v
+}
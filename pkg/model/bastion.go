@@ -0,0 +1,66 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/encrypt"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	DeleteBastionError = "DELETE_BASTION_FAILED_RESOURCE"
+)
+
+// Bastion 跳板机，可关联到主机、区域或凭据，优先级依次降低
+type Bastion struct {
+	common.BaseModel
+	ID           string     `json:"id" gorm:"type:varchar(64)"`
+	Name         string     `json:"name" gorm:"type:varchar(256);not null;unique"`
+	Ip           string     `json:"ip" gorm:"type:varchar(128);not null"`
+	Port         int        `json:"port"`
+	CredentialID string     `json:"credentialId" gorm:"type:varchar(64)"`
+	Credential   Credential `json:"-" gorm:"save_associations:false"`
+}
+
+func (b *Bastion) BeforeCreate() (err error) {
+	b.ID = uuid.NewV4().String()
+	return err
+}
+
+func (b *Bastion) BeforeDelete() (err error) {
+	for _, item := range []interface{}{&Host{}, &Zone{}, &Credential{}} {
+		var count int
+		if err := db.DB.Model(item).Where("bastion_id = ?", b.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New(DeleteBastionError)
+		}
+	}
+	return err
+}
+
+// ToSSHConfig 跳板机的 ssh 连接配置，Credential 需要预加载
+func (b Bastion) ToSSHConfig() (*ssh.Config, error) {
+	cfg := &ssh.Config{
+		User:        b.Credential.Username,
+		Host:        b.Ip,
+		Port:        b.Port,
+		DialTimeOut: 5 * time.Second,
+	}
+	switch b.Credential.Type {
+	case "password":
+		password, err := encrypt.StringDecrypt(b.Credential.Password)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Password = password
+	case "privateKey":
+		cfg.PrivateKey = []byte(b.Credential.PrivateKey)
+	}
+	return cfg, nil
+}
@@ -82,6 +82,26 @@ func (c Cluster) BeforeDelete() error {
 		tx.Rollback()
 		return err
 	}
+	if err := tx.Where("cluster_id = ?", cluster.ID).Delete(&ClusterCertificate{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("cluster_id = ?", cluster.ID).Delete(&ClusterNodePool{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("cluster_id = ?", cluster.ID).Delete(&ClusterAutoscaler{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("cluster_id = ?", cluster.ID).Delete(&ClusterSchedule{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("name = ?", cluster.Name).Delete(&ClusterAgent{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
 	var (
 		hostIDList []string
 		hostIPList []string
@@ -141,6 +161,9 @@ func (c Cluster) DeleteClusterAbout() {
 	if err := db.DB.Where("cluster_id = ?", c.ID).Delete(&ClusterTool{}).Error; err != nil {
 		logger.Log.Infof("delete tools failed, err: %v", err)
 	}
+	if err := db.DB.Where("task_log_id in (?)", db.DB.Model(&TaskLog{}).Select("id").Where("cluster_id = ?", c.ID).SubQuery()).Delete(&TaskControl{}).Error; err != nil {
+		logger.Log.Infof("delete task controls failed, err: %v", err)
+	}
 	if err := db.DB.Where("cluster_id = ?", c.ID).Delete(&TaskLog{}).Error; err != nil {
 		logger.Log.Infof("delete kubepi bind failed, err: %v", err)
 	}
@@ -386,6 +409,15 @@ func (c Cluster) GetKobeVars() map[string]string {
 }
 
 func (c Cluster) ParseInventory() *api.Inventory {
+	return c.parseInventory(ClusterNode.ToKobeHost)
+}
+
+// PreviewInventory 生成 inventory 但不采集主机信息，用于预览部署计划
+func (c Cluster) PreviewInventory() *api.Inventory {
+	return c.parseInventory(ClusterNode.toKobeHost)
+}
+
+func (c Cluster) parseInventory(toKobeHost func(n ClusterNode, nodeNameRule string, role string) *api.Host) *api.Inventory {
 	var masters []string
 	var workers []string
 	var chrony []string
@@ -409,13 +441,20 @@ func (c Cluster) ParseInventory() *api.Inventory {
 				lbhosts = append(lbhosts, node.Name)
 			}
 			if i == 0 {
-				hosts = append(hosts, node.ToKobeHost(c.NodeNameRule, "master"))
+				hosts = append(hosts, toKobeHost(node, c.NodeNameRule, "master"))
 				i = 1
 			} else {
-				hosts = append(hosts, node.ToKobeHost(c.NodeNameRule, "backup"))
+				hosts = append(hosts, toKobeHost(node, c.NodeNameRule, "backup"))
 			}
 		} else {
-			hosts = append(hosts, node.ToKobeHost(c.NodeNameRule, "internal"))
+			hosts = append(hosts, toKobeHost(node, c.NodeNameRule, "internal"))
+		}
+	}
+	// 每个节点对应一个 host，节点池的 kubelet 预留资源作为主机变量
+	poolVars := c.nodePoolHostVars()
+	for i, node := range c.Nodes {
+		for k, v := range poolVars[node.NodePool] {
+			hosts[i].Vars[k] = v
 		}
 	}
 	if len(masters) > 0 {
@@ -595,3 +634,19 @@ func (c Cluster) loadComponentVars(result map[string]string) {
 		}
 	}
 }
+
+func (c Cluster) nodePoolHostVars() map[string]map[string]string {
+	result := map[string]map[string]string{}
+	if c.ID == "" {
+		return result
+	}
+	var pools []ClusterNodePool
+	if err := db.DB.Where("cluster_id = ?", c.ID).Find(&pools).Error; err != nil {
+		logger.Log.Errorf("get cluster %s node pools err, err: %s", c.Name, err.Error())
+		return result
+	}
+	for _, p := range pools {
+		result[p.Name] = p.HostVars()
+	}
+	return result
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterAgent 反向隧道 agent 的注册信息，name 为集群名称，导入集群前即可创建；
+// token 只保存 sha256 摘要，明文只在生成时返回一次；kubeToken 为 agent 连接时上报并校验通过的 ServiceAccount token
+type ClusterAgent struct {
+	common.BaseModel
+	ID              string     `json:"-"`
+	Name            string     `json:"name"`
+	Token           string     `json:"-"`
+	KubeToken       string     `json:"-" gorm:"type:text(65535)"`
+	ServerUrl       string     `json:"serverUrl"`
+	Connected       bool       `json:"connected"`
+	RemoteAddr      string     `json:"remoteAddr"`
+	LastConnectedAt *time.Time `json:"lastConnectedAt"`
+}
+
+func (c *ClusterAgent) BeforeCreate() error {
+	c.ID = uuid.NewV4().String()
+	return nil
+}
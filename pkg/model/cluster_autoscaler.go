@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterAutoscaler plan 集群 worker 的自动伸缩配置，nodePool 为空时伸缩不属于任何节点池的 worker，冷却时间单位为分钟
+type ClusterAutoscaler struct {
+	common.BaseModel
+	ID                   string     `json:"-"`
+	ClusterID            string     `json:"clusterId"`
+	Enabled              bool       `json:"enabled"`
+	NodePool             string     `json:"nodePool"`
+	MinSize              int        `json:"minSize"`
+	MaxSize              int        `json:"maxSize"`
+	ScaleUpCooldown      int        `json:"scaleUpCooldown"`
+	ScaleDownCooldown    int        `json:"scaleDownCooldown"`
+	ScaleDownUtilization int        `json:"scaleDownUtilization"`
+	LastScaleUpAt        *time.Time `json:"lastScaleUpAt"`
+	LastScaleDownAt      *time.Time `json:"lastScaleDownAt"`
+	LastEvent            string     `json:"lastEvent" gorm:"type:text(65535)"`
+	LastTaskID           string     `json:"lastTaskId"`
+}
+
+func (c *ClusterAutoscaler) BeforeCreate() error {
+	c.ID = uuid.NewV4().String()
+	return nil
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+type ClusterCertificate struct {
+	common.BaseModel
+	ID        string    `json:"id"`
+	ClusterID string    `json:"clusterId"`
+	NodeName  string    `json:"nodeName"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	ExpireAt  time.Time `json:"expireAt"`
+}
+
+func (c *ClusterCertificate) BeforeCreate() error {
+	c.ID = uuid.NewV4().String()
+	return nil
+}
@@ -2,7 +2,6 @@ package model
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/db"
@@ -22,6 +21,7 @@ type ClusterNode struct {
 	Host          Host   `json:"-" gorm:"save_associations:false"`
 	ClusterID     string `json:"clusterId"`
 	Role          string `json:"role"`
+	NodePool      string `json:"nodePool"`
 	Status        string `json:"status"`
 	CurrentTaskID string `json:"currentTaskID"`
 	Dirty         bool   `json:"dirty"`
@@ -73,23 +73,31 @@ func (n ClusterNode) ToKobeHost(nodeNameRule string, role string) *api.Host {
 		"os_version":   n.Host.OsVersion}).Error; err != nil {
 		logger.Log.Errorf("get host config err, err: %s", err.Error())
 	}
+	return n.toKobeHost(nodeNameRule, role)
+}
 
+func (n ClusterNode) toKobeHost(nodeNameRule string, role string) *api.Host {
 	r, _ := n.GetRegistry(n.Host.Architecture)
+	vars, err := n.Host.InventoryVars()
+	if err != nil {
+		// 无法确定跳板机时不能直接连接，使连接失败
+		logger.Log.Errorf("get inventory vars of host %s failed: %s", n.Host.Name, err.Error())
+		vars = map[string]string{"ansible_ssh_common_args": "-o ProxyCommand=false"}
+	}
 	apiHost := api.Host{
 		Port:       int32(n.Host.Port),
 		User:       n.Host.Credential.Username,
 		Password:   n.Host.Credential.Password,
 		PrivateKey: n.Host.Credential.PrivateKey,
-		Vars: map[string]string{
-			"has_gpu":              fmt.Sprintf("%v", n.Host.HasGpu),
-			"architectures":        r.Architecture,
-			"registry_protocol":    r.Protocol,
-			"registry_hostname":    r.Hostname,
-			"repo_port":            fmt.Sprintf("%v", r.RepoPort),
-			"registry_port":        fmt.Sprintf("%v", r.RegistryPort),
-			"registry_hosted_port": fmt.Sprintf("%v", r.RegistryHostedPort),
-		},
+		Vars:       vars,
 	}
+	apiHost.Vars["has_gpu"] = fmt.Sprintf("%v", n.Host.HasGpu)
+	apiHost.Vars["architectures"] = r.Architecture
+	apiHost.Vars["registry_protocol"] = r.Protocol
+	apiHost.Vars["registry_hostname"] = r.Hostname
+	apiHost.Vars["repo_port"] = fmt.Sprintf("%v", r.RepoPort)
+	apiHost.Vars["registry_port"] = fmt.Sprintf("%v", r.RegistryPort)
+	apiHost.Vars["registry_hosted_port"] = fmt.Sprintf("%v", r.RegistryHostedPort)
 	switch nodeNameRule {
 	case constant.NodeNameRuleDefault, constant.NodeNameRuleHostName:
 		apiHost.Ip = n.Host.Ip
@@ -109,15 +117,6 @@ func (n ClusterNode) ToKobeHost(nodeNameRule string, role string) *api.Host {
 	return &apiHost
 }
 
-func (n ClusterNode) ToSSHConfig() ssh.Config {
-	password, privateKey, _ := n.Host.GetHostPasswordAndPrivateKey()
-	return ssh.Config{
-		User:        n.Host.Credential.Username,
-		Host:        n.Host.Ip,
-		Port:        n.Host.Port,
-		PrivateKey:  privateKey,
-		Password:    password,
-		DialTimeOut: 5 * time.Second,
-		Retry:       3,
-	}
+func (n ClusterNode) ToSSHConfig() (ssh.Config, error) {
+	return n.Host.ToSSHConfig()
 }
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/facts"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterNodePool 集群 worker 节点池，zones、hostSelector、labels、taints、kubeletReserved 以 json 保存
+type ClusterNodePool struct {
+	common.BaseModel
+	ID              string `json:"-"`
+	Name            string `json:"name" gorm:"type:varchar(64)"`
+	ClusterID       string `json:"clusterId" gorm:"type:varchar(64)"`
+	VmConfig        string `json:"vmConfig" gorm:"type:varchar(64)"`
+	Zones           string `json:"-" gorm:"type:text(65535)"`
+	HostSelector    string `json:"-" gorm:"type:text(65535)"`
+	Labels          string `json:"-" gorm:"type:text(65535)"`
+	Taints          string `json:"-" gorm:"type:text(65535)"`
+	KubeletReserved string `json:"-" gorm:"type:text(65535)"`
+}
+
+type NodePoolHostSelector struct {
+	NamePattern  string `json:"namePattern,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+	MinCpu       int    `json:"minCpu,omitempty"`
+	MinMemory    int    `json:"minMemory,omitempty"`
+	HasGpu       bool   `json:"hasGpu,omitempty"`
+}
+
+type NodePoolTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+type NodePoolKubeletReserved struct {
+	Cpu              string `json:"cpu,omitempty"`
+	Memory           string `json:"memory,omitempty"`
+	EphemeralStorage string `json:"ephemeralStorage,omitempty"`
+}
+
+func (p *ClusterNodePool) BeforeCreate() error {
+	p.ID = uuid.NewV4().String()
+	return nil
+}
+
+// Match 判断主机是否满足节点池的主机选择条件，未设置条件时全部满足
+func (s NodePoolHostSelector) Match(host Host) bool {
+	if s.NamePattern != "" {
+		if ok, _ := filepath.Match(s.NamePattern, host.Name); !ok {
+			return false
+		}
+	}
+	if s.Architecture != "" && s.Architecture != host.Architecture {
+		return false
+	}
+	if host.CpuCore < s.MinCpu || host.Memory < s.MinMemory {
+		return false
+	}
+	return !s.HasGpu || host.HasGpu
+}
+
+func (p ClusterNodePool) GetHostSelector() NodePoolHostSelector {
+	var selector NodePoolHostSelector
+	_ = json.Unmarshal([]byte(p.HostSelector), &selector)
+	return selector
+}
+
+// HostVars 节点池的 kubelet 预留资源，作为主机变量覆盖集群默认值
+func (p ClusterNodePool) HostVars() map[string]string {
+	var reserved NodePoolKubeletReserved
+	_ = json.Unmarshal([]byte(p.KubeletReserved), &reserved)
+	vars := map[string]string{}
+	if reserved.Cpu != "" {
+		vars[facts.KubeCpuReservedFactName] = reserved.Cpu
+	}
+	if reserved.Memory != "" {
+		vars[facts.KubeMemoryReservedFactName] = reserved.Memory
+	}
+	if reserved.EphemeralStorage != "" {
+		vars[facts.KubeEphemeralStorageReservedFactName] = reserved.EphemeralStorage
+	}
+	return vars
+}
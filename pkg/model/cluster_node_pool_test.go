@@ -0,0 +1,40 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodePoolHostVars(t *testing.T) {
+	tests := []struct {
+		name string
+		pool ClusterNodePool
+		want map[string]string
+	}{
+		{
+			name: "empty",
+			pool: ClusterNodePool{Name: "default"},
+			want: map[string]string{},
+		},
+		{
+			name: "kubelet reserved",
+			pool: ClusterNodePool{
+				Name:            "gpu",
+				Labels:          `{"accelerator":"nvidia","node-role.kubernetes.io/gpu":""}`,
+				Taints:          `[{"key":"gpu","value":"true","effect":"NoSchedule"},{"key":"dedicated","effect":"NoExecute"}]`,
+				KubeletReserved: `{"cpu":"500m","ephemeralStorage":"2G"}`,
+			},
+			want: map[string]string{
+				"kube_cpu_reserved":               "500m",
+				"kube_ephemeral_storage_reserved": "2G",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pool.HostVars(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HostVars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
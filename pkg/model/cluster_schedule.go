@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClusterSchedule 集群定时任务，cron 为标准五段式表达式；action 为 scale 时将 nodePool 中的 worker 数量调整为 workerCount
+type ClusterSchedule struct {
+	common.BaseModel
+	ID          string     `json:"-"`
+	ClusterID   string     `json:"-"`
+	Name        string     `json:"name"`
+	Cron        string     `json:"cron"`
+	Action      string     `json:"action"`
+	NodePool    string     `json:"nodePool"`
+	WorkerCount int        `json:"workerCount"`
+	Enabled     bool       `json:"enabled"`
+	LastRunAt   *time.Time `json:"lastRunAt"`
+	LastMessage string     `json:"lastMessage" gorm:"type:text(65535)"`
+}
+
+func (c *ClusterSchedule) BeforeCreate() error {
+	c.ID = uuid.NewV4().String()
+	return nil
+}
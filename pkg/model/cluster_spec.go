@@ -73,6 +73,10 @@ type ClusterSpecConf struct {
 	KubeApiServerPort  int    `json:"kubeApiServerPort"`
 	KubeRouter         string `json:"kubeRouter"`
 	AuthenticationMode string `json:"authenticationMode"`
+	ConnectMode        string `json:"connectMode"`
+
+	UpgradeBatchSize    int  `json:"upgradeBatchSize"`
+	UpgradeAutoRollback bool `json:"upgradeAutoRollback"`
 
 	Status  string `json:"status"`
 	Message string `json:"message" gorm:"type:text(65535)"`
@@ -20,6 +20,7 @@ type Credential struct {
 	Password   string `json:"password" gorm:"type:varchar(256)"`
 	PrivateKey string `json:"privateKey" gorm:"type: text(0)"`
 	Type       string `json:"type" gorm:"type:varchar(64)"`
+	BastionID  string `json:"bastionId" gorm:"type:varchar(64)"`
 }
 
 func (c *Credential) BeforeCreate() (err error) {
@@ -4,15 +4,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
 	"time"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/encrypt"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/kobe"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
 	"github.com/ClusterOperator/kobe/api"
 	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/viper"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -23,29 +27,56 @@ const (
 
 type Host struct {
 	common.BaseModel
-	ID           string     `json:"-"`
-	Name         string     `json:"name" gorm:"type:varchar(256);not null;unique"`
-	Memory       int        `json:"memory" gorm:"type:int(64)"`
-	CpuCore      int        `json:"cpuCore" gorm:"type:int(64)"`
-	Os           string     `json:"os" gorm:"type:varchar(64)"`
-	OsVersion    string     `json:"osVersion" gorm:"type:varchar(64)"`
-	GpuNum       int        `json:"gpuNum" gorm:"type:int(64)"`
-	GpuInfo      string     `json:"gpuInfo" gorm:"type:varchar(128)"`
-	Ip           string     `json:"ip" gorm:"type:varchar(128);not null;unique"`
-	FlexIp       string     `json:"flexIp" gorm:"type:varchar(128);unique"`
-	HasGpu       bool       `json:"hasGpu" gorm:"type:boolean;default:false"`
-	Port         int        `json:"port" gorm:"type:varchar(64)"`
-	CredentialID string     `json:"credentialId" gorm:"type:varchar(64)"`
-	ClusterID    string     `json:"clusterId" gorm:"type:varchar(64)"`
-	ZoneID       string     `json:"zoneId" gorm:"type:varchar(64)"`
-	Zone         Zone       `json:"-"  gorm:"save_associations:false" `
-	Volumes      []Volume   `json:"volumes" gorm:"save_associations:false"`
-	Credential   Credential `json:"-" gorm:"save_associations:false" `
-	Cluster      Cluster    `json:"-" gorm:"save_associations:false" `
-	Status       string     `json:"status" gorm:"type:varchar(64)"`
-	Message      string     `json:"message" gorm:"type:text(65535)"`
-	Datastore    string     `json:"datastore" gorm:"type:varchar(64)"`
-	Architecture string     `json:"architecture" gorm:"type:varchar(64)"`
+	ID            string     `json:"-"`
+	Name          string     `json:"name" gorm:"type:varchar(256);not null;unique"`
+	Memory        int        `json:"memory" gorm:"type:int(64)"`
+	CpuCore       int        `json:"cpuCore" gorm:"type:int(64)"`
+	Os            string     `json:"os" gorm:"type:varchar(64)"`
+	OsVersion     string     `json:"osVersion" gorm:"type:varchar(64)"`
+	GpuNum        int        `json:"gpuNum" gorm:"type:int(64)"`
+	GpuInfo       string     `json:"gpuInfo" gorm:"type:varchar(128)"`
+	Ip            string     `json:"ip" gorm:"type:varchar(128);not null;unique"`
+	FlexIp        string     `json:"flexIp" gorm:"type:varchar(128);unique"`
+	HasGpu        bool       `json:"hasGpu" gorm:"type:boolean;default:false"`
+	Port          int        `json:"port" gorm:"type:varchar(64)"`
+	CredentialID  string     `json:"credentialId" gorm:"type:varchar(64)"`
+	ClusterID     string     `json:"clusterId" gorm:"type:varchar(64)"`
+	ZoneID        string     `json:"zoneId" gorm:"type:varchar(64)"`
+	BastionID     string     `json:"bastionId" gorm:"type:varchar(64)"`
+	Zone          Zone       `json:"-"  gorm:"save_associations:false" `
+	Volumes       []Volume   `json:"volumes" gorm:"save_associations:false"`
+	Credential    Credential `json:"-" gorm:"save_associations:false" `
+	Cluster       Cluster    `json:"-" gorm:"save_associations:false" `
+	Status        string     `json:"status" gorm:"type:varchar(64)"`
+	Message       string     `json:"message" gorm:"type:text(65535)"`
+	Datastore     string     `json:"datastore" gorm:"type:varchar(64)"`
+	Architecture  string     `json:"architecture" gorm:"type:varchar(64)"`
+	Labels        string     `json:"-" gorm:"type:text(65535)"`
+	KernelVersion string     `json:"kernelVersion" gorm:"type:varchar(128)"`
+	CgroupVersion string     `json:"cgroupVersion" gorm:"type:varchar(16)"`
+	CpuModel      string     `json:"cpuModel" gorm:"type:varchar(256)"`
+	CpuFlags      string     `json:"cpuFlags" gorm:"type:text(65535)"`
+	Disks         []HostDisk `json:"disks" gorm:"save_associations:false"`
+	Nics          []HostNic  `json:"nics" gorm:"save_associations:false"`
+}
+
+// GetLabels 解析以 json 保存的主机标签
+func (h Host) GetLabels() map[string]string {
+	labels := map[string]string{}
+	if h.Labels != "" {
+		_ = json.Unmarshal([]byte(h.Labels), &labels)
+	}
+	return labels
+}
+
+// SetLabels 以 json 保存主机标签，标签为空时清空
+func (h *Host) SetLabels(labels map[string]string) {
+	if len(labels) == 0 {
+		h.Labels = ""
+		return
+	}
+	buf, _ := json.Marshal(labels)
+	h.Labels = string(buf)
 }
 
 func (h Host) GetHostPasswordAndPrivateKey() (string, []byte, error) {
@@ -64,6 +95,85 @@ func (h Host) GetHostPasswordAndPrivateKey() (string, []byte, error) {
 	return password, privateKey, nil
 }
 
+// GetBastion 查找连接主机使用的跳板机，依次使用主机、区域、凭据关联的跳板机，未关联时返回 nil
+func (h Host) GetBastion() (*Bastion, error) {
+	bastionID := h.BastionID
+	if bastionID == "" && h.ZoneID != "" {
+		var zone Zone
+		if err := db.DB.Where("id = ?", h.ZoneID).First(&zone).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+			return nil, err
+		}
+		bastionID = zone.BastionID
+	}
+	if bastionID == "" && h.CredentialID != "" {
+		var credential Credential
+		if err := db.DB.Where("id = ?", h.CredentialID).First(&credential).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+			return nil, err
+		}
+		bastionID = credential.BastionID
+	}
+	if bastionID == "" {
+		return nil, nil
+	}
+	var bastion Bastion
+	if err := db.DB.Preload("Credential").Where("id = ?", bastionID).First(&bastion).Error; err != nil {
+		return nil, err
+	}
+	return &bastion, nil
+}
+
+// BastionSSHConfig 连接主机使用的跳板机 ssh 配置，未使用跳板机时返回 nil；查询失败时返回错误，避免绕过跳板机直接连接
+func (h Host) BastionSSHConfig() (*ssh.Config, error) {
+	bastion, err := h.GetBastion()
+	if err != nil {
+		return nil, fmt.Errorf("get bastion of host %s failed: %v", h.Name, err)
+	}
+	if bastion == nil {
+		return nil, nil
+	}
+	cfg, err := bastion.ToSSHConfig()
+	if err != nil {
+		return nil, fmt.Errorf("get ssh config of bastion %s failed: %v", bastion.Name, err)
+	}
+	return cfg, nil
+}
+
+// ToSSHConfig 主机的 ssh 连接配置，关联了跳板机时经跳板机连接
+func (h Host) ToSSHConfig() (ssh.Config, error) {
+	password, privateKey, _ := h.GetHostPasswordAndPrivateKey()
+	proxy, err := h.BastionSSHConfig()
+	if err != nil {
+		return ssh.Config{}, err
+	}
+	return ssh.Config{
+		User:        h.Credential.Username,
+		Host:        h.Ip,
+		Port:        h.Port,
+		PrivateKey:  privateKey,
+		Password:    password,
+		DialTimeOut: 5 * time.Second,
+		Retry:       3,
+		Proxy:       proxy,
+	}, nil
+}
+
+// BastionSecretDir 保存跳板机密码和私钥文件的目录
+func BastionSecretDir() string {
+	if dir := viper.GetString("kobe.secret_dir"); dir != "" {
+		return dir
+	}
+	return path.Join(constant.DefaultDataDir, "bastion")
+}
+
+// InventoryVars 主机在 ansible inventory 中的连接变量，使用跳板机时通过 ProxyCommand 连接
+func (h Host) InventoryVars() (map[string]string, error) {
+	proxy, err := h.BastionSSHConfig()
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ProxyVars(proxy, BastionSecretDir())
+}
+
 func (h *Host) BeforeCreate() error {
 	h.ID = uuid.NewV4().String()
 	return nil
@@ -87,6 +197,11 @@ func (h *Host) BeforeDelete(tx *gorm.DB) error {
 		if len(projectResources) > 0 {
 			return errors.New("DELETE_HOST_FAILED_BY_PROJECT")
 		}
+		for _, m := range []interface{}{&HostDisk{}, &HostNic{}, &HostInventoryHistory{}} {
+			if err := tx.Where("host_id = ?", h.ID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
 		var ip Ip
 		tx.Where(Ip{Address: h.Ip}).First(&ip)
 		if ip.ID != "" {
@@ -113,6 +228,10 @@ func (h *Host) GetHostConfig() error {
 	if err != nil {
 		return err
 	}
+	vars, err := h.InventoryVars()
+	if err != nil {
+		return err
+	}
 	ansible := kobe.NewAnsible(&kobe.Config{
 		Inventory: &api.Inventory{
 			Hosts: []*api.Host{
@@ -123,7 +242,7 @@ func (h *Host) GetHostConfig() error {
 					User:       h.Credential.Username,
 					Password:   password,
 					PrivateKey: string(privateKey),
-					Vars:       map[string]string{},
+					Vars:       vars,
 				},
 			},
 			Groups: []*api.Group{
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// HostDiscovery 按网段扫描可以通过 ssh 连接的主机
+type HostDiscovery struct {
+	common.BaseModel
+	ID           string           `json:"id"`
+	Cidr         string           `json:"cidr"`
+	Port         int              `json:"port"`
+	CredentialID string           `json:"credentialId"`
+	Credential   Credential       `json:"-" gorm:"save_associations:false"`
+	Status       string           `json:"status"`
+	Message      string           `json:"message" gorm:"type:text(65535)"`
+	Total        int              `json:"total"`
+	Scanned      int              `json:"scanned"`
+	Hosts        []DiscoveredHost `json:"hosts" gorm:"foreignkey:DiscoveryID;save_associations:false"`
+}
+
+func (d *HostDiscovery) BeforeCreate() (err error) {
+	d.ID = uuid.NewV4().String()
+	return nil
+}
+
+// DiscoveredHost 扫描到的主机，接受后作为主机导入
+type DiscoveredHost struct {
+	common.BaseModel
+	ID           string `json:"id"`
+	DiscoveryID  string `json:"discoveryId"`
+	Ip           string `json:"ip"`
+	Hostname     string `json:"hostname"`
+	Os           string `json:"os"`
+	OsVersion    string `json:"osVersion"`
+	Architecture string `json:"architecture"`
+	CpuCore      int    `json:"cpuCore"`
+	Memory       int    `json:"memory"`
+	GpuNum       int    `json:"gpuNum"`
+	GpuInfo      string `json:"gpuInfo"`
+	HasGpu       bool   `json:"hasGpu"`
+	Status       string `json:"status"`
+	Message      string `json:"message" gorm:"type:text(65535)"`
+}
+
+func (d *DiscoveredHost) BeforeCreate() (err error) {
+	d.ID = uuid.NewV4().String()
+	return nil
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/inventory"
+	uuid "github.com/satori/go.uuid"
+)
+
+// HostDisk 主机块设备及分区，Size 单位为字节
+type HostDisk struct {
+	common.BaseModel
+	ID         string `json:"-"`
+	HostID     string `json:"-"`
+	Name       string `json:"name"`
+	Model      string `json:"model"`
+	Size       int64  `json:"size"`
+	Rotational bool   `json:"rotational"`
+	Mount      string `json:"mount"`
+	FsType     string `json:"fsType"`
+}
+
+func (d *HostDisk) BeforeCreate() (err error) {
+	d.ID = uuid.NewV4().String()
+	return nil
+}
+
+// HostNic 主机网卡，Addresses 以逗号分隔
+type HostNic struct {
+	common.BaseModel
+	ID        string `json:"-"`
+	HostID    string `json:"-"`
+	Name      string `json:"name"`
+	Mac       string `json:"mac"`
+	Mtu       int    `json:"mtu"`
+	Speed     int    `json:"speed"`
+	Addresses string `json:"addresses" gorm:"type:text(65535)"`
+}
+
+func (n *HostNic) BeforeCreate() (err error) {
+	n.ID = uuid.NewV4().String()
+	return nil
+}
+
+// HostInventoryHistory 主机硬件信息的历史，只在采集结果变化时记录，Inventory 以 json 保存
+type HostInventoryHistory struct {
+	common.BaseModel
+	ID        string `json:"id"`
+	HostID    string `json:"-"`
+	Inventory string `json:"-" gorm:"type:mediumtext"`
+	Changes   string `json:"-" gorm:"type:text(65535)"`
+}
+
+func (h *HostInventoryHistory) BeforeCreate() (err error) {
+	h.ID = uuid.NewV4().String()
+	return nil
+}
+
+// SetInventory 用采集结果填充主机的硬件信息
+func (h *Host) SetInventory(inv inventory.Inventory) {
+	h.KernelVersion = inv.KernelVersion
+	h.CgroupVersion = inv.CgroupVersion
+	h.CpuModel = inv.CpuModel
+	h.CpuFlags = strings.Join(inv.CpuFlags, " ")
+	h.Disks = []HostDisk{}
+	for _, d := range inv.Disks {
+		h.Disks = append(h.Disks, HostDisk{
+			HostID:     h.ID,
+			Name:       d.Name,
+			Model:      d.Model,
+			Size:       d.Size,
+			Rotational: d.Rotational,
+			Mount:      d.Mount,
+			FsType:     d.FsType,
+		})
+	}
+	h.Nics = []HostNic{}
+	for _, n := range inv.Nics {
+		h.Nics = append(h.Nics, HostNic{
+			HostID:    h.ID,
+			Name:      n.Name,
+			Mac:       n.Mac,
+			Mtu:       n.Mtu,
+			Speed:     n.Speed,
+			Addresses: strings.Join(n.Addresses, ","),
+		})
+	}
+}
+
+// GetInventory 将主机的硬件信息转换为采集结果，用于比较变化
+func (h Host) GetInventory() inventory.Inventory {
+	inv := inventory.Inventory{
+		KernelVersion: h.KernelVersion,
+		CgroupVersion: h.CgroupVersion,
+		CpuModel:      h.CpuModel,
+		CpuFlags:      strings.Fields(h.CpuFlags),
+	}
+	for _, d := range h.Disks {
+		inv.Disks = append(inv.Disks, inventory.Disk{
+			Name:       d.Name,
+			Model:      d.Model,
+			Size:       d.Size,
+			Rotational: d.Rotational,
+			Mount:      d.Mount,
+			FsType:     d.FsType,
+		})
+	}
+	for _, n := range h.Nics {
+		nic := inventory.Nic{Name: n.Name, Mac: n.Mac, Mtu: n.Mtu, Speed: n.Speed}
+		if n.Addresses != "" {
+			nic.Addresses = strings.Split(n.Addresses, ",")
+		}
+		inv.Nics = append(inv.Nics, nic)
+	}
+	return inv
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Lease 多副本部署时的租约，用于选主和集群操作互斥，持有者需在过期前续约，过期后可被其他副本获取
+type Lease struct {
+	common.BaseModel
+	Name     string    `json:"name" gorm:"primary_key"`
+	Holder   string    `json:"holder"`
+	ExpireAt time.Time `json:"expireAt"`
+}
+
+// SessionValue 保存在数据库中的会话数据，多个副本共享登录状态
+type SessionValue struct {
+	common.BaseModel
+	ID       string     `json:"id"`
+	Sid      string     `json:"sid"`
+	Key      string     `json:"key"`
+	Value    []byte     `json:"-"`
+	ExpireAt *time.Time `json:"expireAt"`
+}
+
+func (n *SessionValue) BeforeCreate() (err error) {
+	n.ID = uuid.NewV4().String()
+	return nil
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"errors"
 
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
 	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
@@ -23,6 +24,14 @@ type Plan struct {
 	Region         Region `json:"-"`
 }
 
+// MasterAmount 部署模板对应的 master 数量，单节点模板为 1 个，其他为 3 个
+func (p Plan) MasterAmount() int {
+	if p.DeployTemplate == constant.SINGLE {
+		return 1
+	}
+	return 3
+}
+
 func (p *Plan) BeforeCreate() (err error) {
 	p.ID = uuid.NewV4().String()
 	return err
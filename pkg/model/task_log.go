@@ -16,6 +16,10 @@ type TaskLog struct {
 	Message   string `json:"message" gorm:"type:text(65535)"`
 	StartTime int64  `json:"startTime"`
 	EndTime   int64  `json:"endTime"`
+	// Finished 任务已执行完毕，不再需要在服务重启后继续执行；多阶段任务的 Phase 在中间阶段也可能为 SUCCESS
+	Finished bool `json:"finished"`
+	// Priority 提交到调度器时的优先级，服务重启后按原优先级继续执行
+	Priority int `json:"priority"`
 
 	Details []TaskLogDetail `json:"details"`
 }
@@ -33,6 +37,25 @@ type TaskLogDetail struct {
 	EndTime       int64  `json:"endTime"`
 	Message       string `json:"message" gorm:"type:text(65535)"`
 	Status        string `json:"status"`
+
+	Failures []TaskLogFailure `json:"failures" gorm:"foreignkey:TaskLogDetailID"`
+}
+
+// TaskLogFailure playbook 失败时每个主机、每个 ansible 任务的失败记录，LogOffset、LogLine 指向完整日志中的失败位置
+type TaskLogFailure struct {
+	common.BaseModel
+	ID              string `json:"id"`
+	TaskLogDetailID string `json:"taskLogDetailID"`
+	Host            string `json:"host"`
+	Play            string `json:"play"`
+	Task            string `json:"task"`
+	Unreachable     bool   `json:"unreachable"`
+	Rc              int    `json:"rc"`
+	Msg             string `json:"msg" gorm:"type:text(65535)"`
+	Stdout          string `json:"stdout" gorm:"type:text(65535)"`
+	Stderr          string `json:"stderr" gorm:"type:text(65535)"`
+	LogOffset       int64  `json:"logOffset"`
+	LogLine         int    `json:"logLine"`
 }
 
 type TaskRetryLog struct {
@@ -40,11 +63,25 @@ type TaskRetryLog struct {
 	ID             string `json:"id"`
 	TaskLogID      string `json:"taskLogID"`
 	ClusterID      string `json:"clusterID"`
+	Action         string `json:"action"`
 	LastFailedTime int64  `json:"lastFailedTime"`
 	RestartTime    int64  `json:"restartTime"`
 	Message        string `json:"message" gorm:"type:text(65535)"`
 }
 
+// TaskControl 用户对任务的干预操作，执行任务的副本在每个步骤前及 playbook 执行期间读取
+type TaskControl struct {
+	common.BaseModel
+	ID        string `json:"id"`
+	TaskLogID string `json:"taskLogID"`
+	Paused    bool   `json:"paused"`
+	Canceled  bool   `json:"canceled"`
+	// Skips 需要跳过的步骤，逗号分隔
+	Skips string `json:"skips" gorm:"type:text(65535)"`
+	// DetachedPlaybook 任务取消时仍在 kobe 中执行的 playbook，执行结束前集群不可发起其他任务
+	DetachedPlaybook string `json:"detachedPlaybook"`
+}
+
 func (n *TaskLog) BeforeCreate() (err error) {
 	n.ID = uuid.NewV4().String()
 	return nil
@@ -63,3 +100,15 @@ func (n *TaskRetryLog) BeforeCreate() (err error) {
 	}
 	return nil
 }
+
+func (n *TaskControl) BeforeCreate() (err error) {
+	if len(n.ID) == 0 {
+		n.ID = uuid.NewV4().String()
+	}
+	return nil
+}
+
+func (n *TaskLogFailure) BeforeCreate() (err error) {
+	n.ID = uuid.NewV4().String()
+	return nil
+}
@@ -20,6 +20,7 @@ type Zone struct {
 	Status       string     `json:"status" gorm:"type:varchar(64)"`
 	RegionID     string     `json:"regionID" gorm:"type:varchar(64)"`
 	CredentialID string     `json:"credentialId" gorm:"type:varchar(64)"`
+	BastionID    string     `json:"bastionId" gorm:"type:varchar(64)"`
 	IpPoolID     string     `json:"ipPoolId"`
 	Message      string     `json:"message"`
 	Region       Region     `json:"-"`
@@ -1,13 +1,17 @@
 package xpack
 
 import (
+	"fmt"
+	"plugin"
+
 	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
-	"github.com/ClusterOperator/ClusterOperator/pkg/plugin"
+	koplugin "github.com/ClusterOperator/ClusterOperator/pkg/plugin"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
 	"github.com/pkg/errors"
 )
 
 func LoadXpackPlugin() error {
-	p := plugin.GetPlugin("xPack")
+	p := koplugin.GetPlugin("xPack")
 	if p != nil {
 		f, err := p.Lookup("XpackRegister")
 		if err != nil {
@@ -20,6 +24,22 @@ func LoadXpackPlugin() error {
 		if err := fu(); err != nil {
 			return errors.Wrap(err, "register xpack err")
 		}
+		if err := loadXpackPhases(p); err != nil {
+			return errors.Wrap(err, "register xpack phase err")
+		}
 	}
 	return nil
 }
+
+// loadXpackPhases xpack 可选导出 PhaseRegister，用于注册自定义的集群操作步骤
+func loadXpackPhases(p *plugin.Plugin) error {
+	f, err := p.Lookup("PhaseRegister")
+	if err != nil {
+		return nil
+	}
+	fu, ok := f.(func(func(flow string, phase adm.Phase) error) error)
+	if !ok {
+		return fmt.Errorf("PhaseRegister has wrong signature %T", f)
+	}
+	return fu(adm.RegisterPhase)
+}
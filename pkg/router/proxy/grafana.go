@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
@@ -29,9 +28,7 @@ func GrafanaProxy(ctx context.Context) {
 		return
 	}
 	proxy := httputil.NewSingleHostReverseProxy(u)
-	proxy.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
+	proxy.Transport = toolTransport(clusterName, endpoint)
 	req := ctx.Request()
 	req.Host = fmt.Sprintf(constant.DefaultGrafanaIngress)
 	if proxyPath == "root" {
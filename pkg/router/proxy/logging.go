@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"crypto/tls"
 	"fmt"
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/kataras/iris/v12/context"
@@ -28,9 +27,7 @@ func LoggingProxy(ctx context.Context) {
 		return
 	}
 	proxy := httputil.NewSingleHostReverseProxy(u)
-	proxy.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
+	proxy.Transport = toolTransport(clusterName, endpoint)
 	req := ctx.Request()
 	req.Host = fmt.Sprintf(constant.DefaultLoggingIngress)
 	req.URL.Path = proxyPath
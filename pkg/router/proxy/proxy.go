@@ -1,7 +1,13 @@
 package proxy
 
 import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
 	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/tunnel"
 	"github.com/kataras/iris/v12"
 )
 
@@ -24,3 +30,14 @@ func RegisterProxy(parent iris.Party) {
 	proxy.Any("/registry/{cluster_name}/{p:path}", RegistryProxy)
 	proxy.Any("/kubeapps/{cluster_name}/{p:path}", KubeappsProxy)
 }
+
+// toolTransport 通过 agent 连接的集群由 agent 在集群内访问工具入口
+func toolTransport(clusterName string, endpoint dto.Endpoint) *http.Transport {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	if endpoint.ConnectMode == constant.ConnectModeAgent {
+		transport.DialContext = tunnel.Dialer(clusterName)
+	}
+	return transport
+}
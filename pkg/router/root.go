@@ -6,6 +6,7 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/i18n"
 	"github.com/ClusterOperator/ClusterOperator/pkg/router/proxy"
 	v1 "github.com/ClusterOperator/ClusterOperator/pkg/router/v1"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/tunnel"
 	"github.com/iris-contrib/swagger/v12"
 	"github.com/iris-contrib/swagger/v12/swaggerFiles"
 	"github.com/kataras/iris/v12"
@@ -26,6 +27,7 @@ func Server() *iris.Application {
 	}
 	app.Get("/swagger/{any:path}", swagger.CustomWrapHandler(c, swaggerFiles.Handler))
 	app.Get("/api/v1/health", controller.HealthController)
+	app.Get(tunnel.ConnectPath, controller.AgentConnectController)
 	proxy.RegisterProxy(app)
 	api := app.Party("/api")
 	v1.V1(api)
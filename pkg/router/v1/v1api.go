@@ -30,6 +30,8 @@ func V1(parent iris.Party) {
 	AuthScope.Use(middleware.ForceMiddleware)
 	mvc.New(AuthScope.Party("/clusters")).HandleError(ErrorHandler).Handle(controller.NewClusterController())
 	mvc.New(AuthScope.Party("/credentials")).HandleError(ErrorHandler).Handle(controller.NewCredentialController())
+	mvc.New(AuthScope.Party("/bastions")).HandleError(ErrorHandler).Handle(controller.NewBastionController())
+	mvc.New(AuthScope.Party("/hosts/discoveries")).HandleError(ErrorHandler).Handle(controller.NewHostDiscoveryController())
 	mvc.New(AuthScope.Party("/hosts")).HandleError(ErrorHandler).Handle(controller.NewHostController())
 	mvc.New(AuthScope.Party("/users")).HandleError(ErrorHandler).Handle(controller.NewUserController())
 	mvc.New(AuthScope.Party("/dashboard")).HandleError(ErrorHandler).Handle(controller.NewKubePiController())
@@ -51,6 +53,15 @@ func V1(parent iris.Party) {
 	mvc.New(AuthScope.Party("/license")).Handle(ErrorHandler).Handle(controller.NewLicenseController())
 	mvc.New(AuthScope.Party("/clusters/backup/files")).HandleError(ErrorHandler).Handle(controller.NewClusterBackupFileController())
 	mvc.New(AuthScope.Party("/clusters/velero/{cluster}/{operate}")).HandleError(ErrorHandler).Handle(controller.NewClusterVeleroBackupController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/tasks/{id}")).HandleError(ErrorHandler).Handle(controller.NewClusterTaskController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/upgrade")).HandleError(ErrorHandler).Handle(controller.NewClusterUpgradeController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/certificates")).HandleError(ErrorHandler).Handle(controller.NewClusterCertificateController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/spec")).HandleError(ErrorHandler).Handle(controller.NewClusterSpecController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/runtime")).HandleError(ErrorHandler).Handle(controller.NewClusterRuntimeController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/nodepools")).HandleError(ErrorHandler).Handle(controller.NewClusterNodePoolController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/autoscaler")).HandleError(ErrorHandler).Handle(controller.NewClusterAutoscalerController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/schedules")).HandleError(ErrorHandler).Handle(controller.NewClusterScheduleController())
+	mvc.New(AuthScope.Party("/clusters/{cluster}/agent")).HandleError(ErrorHandler).Handle(controller.NewClusterAgentController())
 	mvc.New(AuthScope.Party("/manifests")).HandleError(ErrorHandler).Handle(controller.NewManifestController())
 	mvc.New(AuthScope.Party("/vmconfigs")).HandleError(ErrorHandler).Handle(controller.NewVmConfigController())
 	mvc.New(AuthScope.Party("/ippools")).HandleError(ErrorHandler).Handle(controller.NewIpPoolController())
@@ -1,26 +1,47 @@
 package hook
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/ha"
 	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/jinzhu/gorm"
 )
 
 func init() {
-	BeforeApplicationStart.AddFunc(recoverClusterTask)
+	ha.OnRecover(recoverClusterTask)
+	ha.OnRecover(recoverHostDiscovery)
 }
 
-var stableStatus = []string{constant.StatusRunning, constant.StatusFailed, constant.StatusNotReady, constant.StatusLost}
+var stableStatus = []string{constant.StatusRunning, constant.StatusFailed, constant.StatusNotReady, constant.StatusLost, constant.StatusHibernated}
 var statleTaskStatus = []string{constant.TaskLogStatusSuccess, constant.TaskLogStatusFailed}
 
 // cluster
 func recoverClusterTask() error {
+	queue := service.NewTaskQueueService()
+	interrupted, err := queue.ListInterrupted()
+	if err != nil {
+		return err
+	}
+	taskIDs, clusterIDs, err := aliveTasks()
+	if err != nil {
+		return err
+	}
+	// 其他副本上仍在执行的任务所属集群的资源不做处理
+	aliveClusterIDs := clusterIDs
+	for _, task := range interrupted {
+		taskIDs = append(taskIDs, task.ID)
+		clusterIDs = append(clusterIDs, task.ClusterID)
+	}
+
 	logger.Log.Info("Update status to failed caused by task cancel")
 	tx := db.DB.Begin()
-	if err := db.DB.Model(&model.Cluster{}).Where("status not in (?)", stableStatus).Updates(map[string]interface{}{
+	if err := excludeIDs(db.DB.Model(&model.Cluster{}).Where("status not in (?)", stableStatus), "id", clusterIDs).Updates(map[string]interface{}{
 		"status":  constant.StatusFailed,
 		"message": constant.TaskCancel,
 	}).Error; err != nil {
@@ -28,16 +49,17 @@ func recoverClusterTask() error {
 		return err
 	}
 
-	if err := db.DB.Model(&model.TaskLog{}).Where("phase not in (?)", statleTaskStatus).Updates(map[string]interface{}{
+	if err := excludeIDs(db.DB.Model(&model.TaskLog{}).Where("phase not in (?)", statleTaskStatus), "id", taskIDs).Updates(map[string]interface{}{
 		"phase":    constant.TaskLogStatusFailed,
 		"message":  constant.TaskCancel,
 		"end_time": time.Now().Unix(),
+		"finished": true,
 	}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	if err := tx.Model(&model.TaskLogDetail{}).Where("status = ?", constant.TaskLogStatusRunning).Updates(map[string]interface{}{
+	if err := excludeIDs(tx.Model(&model.TaskLogDetail{}).Where("status = ?", constant.TaskLogStatusRunning), "task_log_id", taskIDs).Updates(map[string]interface{}{
 		"status":   constant.TaskLogStatusFailed,
 		"message":  constant.TaskCancel,
 		"end_time": time.Now().Unix(),
@@ -46,7 +68,7 @@ func recoverClusterTask() error {
 		return err
 	}
 
-	if err := tx.Model(&model.ClusterSpecComponent{}).Where("status not in (?)", []string{constant.StatusDisabled, constant.StatusEnabled, constant.StatusFailed}).Updates(map[string]interface{}{
+	if err := excludeIDs(tx.Model(&model.ClusterSpecComponent{}).Where("status not in (?)", []string{constant.StatusDisabled, constant.StatusEnabled, constant.StatusFailed}), "cluster_id", aliveClusterIDs).Updates(map[string]interface{}{
 		"status":  constant.StatusFailed,
 		"message": constant.TaskCancel,
 	}).Error; err != nil {
@@ -54,7 +76,7 @@ func recoverClusterTask() error {
 		return err
 	}
 
-	if err := tx.Model(&model.Host{}).Where("status != ? AND status != ?", constant.StatusRunning, constant.StatusFailed).Updates(map[string]interface{}{
+	if err := excludeIDs(tx.Model(&model.Host{}).Where("status != ? AND status != ?", constant.StatusRunning, constant.StatusFailed), "cluster_id", aliveClusterIDs).Updates(map[string]interface{}{
 		"status":  constant.StatusFailed,
 		"message": constant.TaskCancel,
 	}).Error; err != nil {
@@ -62,7 +84,7 @@ func recoverClusterTask() error {
 		return err
 	}
 
-	if err := tx.Model(&model.ClusterStorageProvisioner{}).Where("status not in (?)", stableStatus).Updates(map[string]interface{}{
+	if err := excludeIDs(tx.Model(&model.ClusterStorageProvisioner{}).Where("status not in (?)", stableStatus), "cluster_id", aliveClusterIDs).Updates(map[string]interface{}{
 		"status":  constant.StatusFailed,
 		"message": constant.TaskCancel,
 	}).Error; err != nil {
@@ -71,7 +93,7 @@ func recoverClusterTask() error {
 	}
 
 	var nodes []model.ClusterNode
-	if err := db.DB.Where("status not in (?) AND status != ''", stableStatus).Find(&nodes).Error; err != nil {
+	if err := excludeIDs(db.DB.Where("status not in (?) AND status != ''", stableStatus), "current_task_id", taskIDs).Find(&nodes).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -87,5 +109,45 @@ func recoverClusterTask() error {
 	tx.Commit()
 
 	logger.Log.Info("update status successful !")
-	return nil
+
+	return queue.Resume(interrupted)
+}
+
+// recoverHostDiscovery 扫描由所在副本的后台协程执行，副本退出后无法继续，标记为失败
+func recoverHostDiscovery() error {
+	alive, err := ha.Alive(ha.DiscoveryPrefix)
+	if err != nil {
+		return err
+	}
+	return excludeIDs(db.DB.Model(&model.HostDiscovery{}).Where("status = ?", constant.StatusRunning), "id", alive).Updates(map[string]interface{}{
+		"status":  constant.StatusFailed,
+		"message": constant.TaskCancel,
+	}).Error
+}
+
+// aliveTasks 查询仍有副本在执行或等待执行的任务
+func aliveTasks() ([]string, []string, error) {
+	taskIDs, err := ha.Alive(ha.TaskPrefix)
+	if err != nil || len(taskIDs) == 0 {
+		return nil, nil, err
+	}
+	var (
+		tasks      []model.TaskLog
+		clusterIDs []string
+	)
+	if err := db.DB.Where("id in (?)", taskIDs).Find(&tasks).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, task := range tasks {
+		clusterIDs = append(clusterIDs, task.ClusterID)
+	}
+	return taskIDs, clusterIDs, nil
+}
+
+// 被中断但可以继续执行的任务不做取消处理
+func excludeIDs(query *gorm.DB, column string, ids []string) *gorm.DB {
+	if len(ids) == 0 {
+		return query
+	}
+	return query.Where(fmt.Sprintf("%s not in (?)", column), ids)
 }
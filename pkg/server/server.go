@@ -7,12 +7,15 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/cron"
 	"github.com/ClusterOperator/ClusterOperator/pkg/data"
 	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/ha"
 	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
 	"github.com/ClusterOperator/ClusterOperator/pkg/migrate"
 	"github.com/ClusterOperator/ClusterOperator/pkg/plugin"
 	"github.com/ClusterOperator/ClusterOperator/pkg/plugin/xpack"
 	"github.com/ClusterOperator/ClusterOperator/pkg/router"
 	"github.com/ClusterOperator/ClusterOperator/pkg/server/hook"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
 	"github.com/kataras/iris/v12"
 	"github.com/spf13/viper"
 )
@@ -42,6 +45,12 @@ func Phases() []Phase {
 		},
 		&data.InitDataPhase{},
 		&plugin.InitPluginDBPhase{},
+		&adm.InitPhaseRegistry{},
+		&service.InitTaskSchedulerPhase{
+			MaxConcurrent: viper.GetInt("task.max_concurrent"),
+			TypeLimits:    viper.GetStringMapString("task.type_limits"),
+		},
+		&ha.InitHAPhase{},
 		&cron.InitCronPhase{
 			Enable: viper.GetBool("cron.enable"),
 		},
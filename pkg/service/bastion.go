@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/condition"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/page"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	dbUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+)
+
+var BastionNameExist = "BASTION_NAME_EXISTS"
+
+type BastionService interface {
+	Get(name string) (*dto.Bastion, error)
+	List(conditions condition.Conditions) ([]dto.Bastion, error)
+	Page(num, size int, conditions condition.Conditions) (*page.Page, error)
+	Create(creation dto.BastionCreate) (*dto.Bastion, error)
+	Update(name string, update dto.BastionUpdate) (*dto.Bastion, error)
+	Delete(name string) error
+	Test(name string) error
+}
+
+type bastionService struct{}
+
+func NewBastionService() BastionService {
+	return &bastionService{}
+}
+
+func (b bastionService) Get(name string) (*dto.Bastion, error) {
+	var bastion model.Bastion
+	if err := db.DB.Preload("Credential").Where("name = ?", name).First(&bastion).Error; err != nil {
+		return nil, err
+	}
+	return toBastionDTO(bastion), nil
+}
+
+func (b bastionService) List(conditions condition.Conditions) ([]dto.Bastion, error) {
+	var (
+		items    []dto.Bastion
+		bastions []model.Bastion
+	)
+	d := db.DB.Model(model.Bastion{})
+	if err := dbUtil.WithConditions(&d, model.Bastion{}, conditions); err != nil {
+		return nil, err
+	}
+	if err := d.Preload("Credential").Order("name").Find(&bastions).Error; err != nil {
+		return nil, err
+	}
+	for _, bastion := range bastions {
+		items = append(items, *toBastionDTO(bastion))
+	}
+	return items, nil
+}
+
+func (b bastionService) Page(num, size int, conditions condition.Conditions) (*page.Page, error) {
+	var (
+		p        page.Page
+		items    []dto.Bastion
+		bastions []model.Bastion
+	)
+	d := db.DB.Model(model.Bastion{})
+	if err := dbUtil.WithConditions(&d, model.Bastion{}, conditions); err != nil {
+		return nil, err
+	}
+	if err := d.
+		Count(&p.Total).
+		Preload("Credential").
+		Order("name").
+		Offset((num - 1) * size).
+		Limit(size).
+		Find(&bastions).Error; err != nil {
+		return nil, err
+	}
+	for _, bastion := range bastions {
+		items = append(items, *toBastionDTO(bastion))
+	}
+	p.Items = items
+	return &p, nil
+}
+
+func (b bastionService) Create(creation dto.BastionCreate) (*dto.Bastion, error) {
+	var count int
+	if err := db.DB.Model(&model.Bastion{}).Where("name = ?", creation.Name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New(BastionNameExist)
+	}
+	var credential model.Credential
+	if err := db.DB.Where("id = ?", creation.CredentialID).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	bastion := model.Bastion{
+		Name:         creation.Name,
+		Ip:           creation.Ip,
+		Port:         creation.Port,
+		CredentialID: credential.ID,
+		Credential:   credential,
+	}
+	if err := db.DB.Create(&bastion).Error; err != nil {
+		return nil, err
+	}
+	return toBastionDTO(bastion), nil
+}
+
+func (b bastionService) Update(name string, update dto.BastionUpdate) (*dto.Bastion, error) {
+	var bastion model.Bastion
+	if err := db.DB.Where("name = ?", name).First(&bastion).Error; err != nil {
+		return nil, err
+	}
+	var credential model.Credential
+	if err := db.DB.Where("id = ?", update.CredentialID).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	bastion.Ip = update.Ip
+	bastion.Port = update.Port
+	bastion.CredentialID = credential.ID
+	bastion.Credential = credential
+	if err := db.DB.Save(&bastion).Error; err != nil {
+		return nil, err
+	}
+	return toBastionDTO(bastion), nil
+}
+
+func (b bastionService) Delete(name string) error {
+	var bastion model.Bastion
+	if err := db.DB.Where("name = ?", name).First(&bastion).Error; err != nil {
+		return err
+	}
+	return db.DB.Delete(&bastion).Error
+}
+
+// Test 检查跳板机是否可以通过 ssh 连接
+func (b bastionService) Test(name string) error {
+	var bastion model.Bastion
+	if err := db.DB.Preload("Credential").Where("name = ?", name).First(&bastion).Error; err != nil {
+		return err
+	}
+	cfg, err := bastion.ToSSHConfig()
+	if err != nil {
+		return err
+	}
+	client, err := ssh.New(cfg)
+	if err != nil {
+		return err
+	}
+	return client.Ping()
+}
+
+func toBastionDTO(bastion model.Bastion) *dto.Bastion {
+	return &dto.Bastion{Bastion: bastion, CredentialName: bastion.Credential.Name}
+}
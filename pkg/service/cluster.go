@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	dbUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/db"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/kubepi"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/db"
@@ -24,6 +26,7 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/kotf"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/kubeconfig"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/tunnel"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/webkubectl"
 )
 
@@ -57,6 +60,8 @@ func NewClusterService() ClusterService {
 		systemSettingService: NewSystemSettingService(),
 		clusterIaasService:   NewClusterIaasService(),
 		tasklogService:       NewTaskLogService(),
+		taskSchedulerService: NewTaskSchedulerService(),
+		hostPreflightService: NewHostPreflightService(),
 	}
 }
 
@@ -71,6 +76,8 @@ type clusterService struct {
 	systemSettingService SystemSettingService
 	clusterIaasService   ClusterIaasService
 	tasklogService       TaskLogService
+	taskSchedulerService TaskSchedulerService
+	hostPreflightService HostPreflightService
 }
 
 func (c clusterService) Get(name string) (dto.Cluster, error) {
@@ -287,6 +294,11 @@ func (c *clusterService) ReCreate(name string) error {
 	if err != nil {
 		return err
 	}
+	unlock, err := lockCluster(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	tasklog, err := c.tasklogService.GetByID(cluster.CurrentTaskID)
 	if err != nil {
 		return err
@@ -306,7 +318,11 @@ func (c *clusterService) ReCreate(name string) error {
 		"log_id": cluster.TaskLog.ID,
 	}).Debugf("get ansible writer log of cluster %s successful, now start to init the cluster", cluster.Name)
 
-	go c.clusterInitService.Init(cluster, writer)
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.clusterInitService.Init(cluster, writer)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -317,6 +333,15 @@ func (c *clusterService) Delete(name string, force bool, uninstall bool) error {
 	if err != nil {
 		return fmt.Errorf("can not get cluster %s reason %s", name, err)
 	}
+	unlock, err := lockCluster(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// 强制删除时不等待正在执行的任务
+	if !force && c.tasklogService.IsTaskOn(name) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
 
 	// ko 导入集群执行删除时，是否卸载，卸载则走正常手动卸载模式，否则走导入集群删除逻辑直接删除数据库数据，但是需要删除主机和资源绑定信息
 	if cluster.Source == constant.ClusterSourceKoExternal {
@@ -333,7 +358,7 @@ func (c *clusterService) Delete(name string, force bool, uninstall bool) error {
 	switch cluster.Source {
 	case constant.ClusterSourceLocal:
 		switch cluster.Status {
-		case constant.StatusRunning, constant.StatusLost, constant.StatusFailed, constant.StatusNotReady:
+		case constant.StatusRunning, constant.StatusLost, constant.StatusFailed, constant.StatusNotReady, constant.StatusHibernated:
 			tasklog, err := c.tasklogService.NewTerminalTask(cluster.ID, constant.TaskLogTypeClusterDelete)
 			if err != nil {
 				return fmt.Errorf("can not update cluster %s status", cluster.Name)
@@ -344,9 +369,17 @@ func (c *clusterService) Delete(name string, force bool, uninstall bool) error {
 			_ = c.clusterRepo.Save(&cluster)
 			switch cluster.Provider {
 			case constant.ClusterProviderBareMetal:
-				go c.uninstallCluster(&cluster, force)
+				if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+					c.uninstallCluster(&cluster, force)
+				}); err != nil {
+					return err
+				}
 			case constant.ClusterProviderPlan:
-				go c.destroyCluster(&cluster, force)
+				if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+					c.destroyCluster(&cluster, force)
+				}); err != nil {
+					return err
+				}
 			}
 		case constant.StatusCreating, constant.StatusInitializing:
 			return fmt.Errorf("can not delete cluster %s in this  status %s", cluster.Name, cluster.Status)
@@ -495,20 +528,16 @@ func (c clusterService) GetRouterEndpoint(name string) (dto.Endpoint, error) {
 		return endpoint, err
 	}
 	endpoint.Address = cluster.SpecConf.KubeRouter
+	endpoint.ConnectMode = cluster.SpecConf.ConnectMode
 	return endpoint, nil
 }
 
 func (c clusterService) GetWebkubectlToken(name string) (dto.WebkubectlToken, error) {
 	var token dto.WebkubectlToken
-	endpoints, err := c.GetApiServerEndpoints(name)
+	addr, err := c.webkubectlApiServer(name)
 	if err != nil {
 		return token, err
 	}
-	aliveHost, err := clusterUtil.SelectAliveHost(endpoints)
-	if err != nil {
-		return token, err
-	}
-	addr := fmt.Sprintf("https://%s", aliveHost)
 	secret, err := c.GetSecrets(name)
 	if err != nil {
 		return token, nil
@@ -522,6 +551,37 @@ func (c clusterService) GetWebkubectlToken(name string) (dto.WebkubectlToken, er
 	return token, nil
 }
 
+// webkubectlApiServer 通过 agent 或跳板机连接的集群由 webkubectl 经 KubeOperator 的 kubernetes 代理访问
+func (c clusterService) webkubectlApiServer(name string) (string, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(name, []string{"SpecConf", "Nodes", "Nodes.Host", "Nodes.Host.Credential"})
+	if err != nil {
+		return "", err
+	}
+	proxyAddr := fmt.Sprintf("%s/proxy/kubernetes/%s", strings.TrimSuffix(viper.GetString("agent.endpoint"), "/"), name)
+	if cluster.SpecConf.ConnectMode == constant.ConnectModeAgent {
+		if !tunnel.Connected(name) {
+			return "", tunnel.ErrNotConnected
+		}
+		return proxyAddr, nil
+	}
+	proxy, err := clusterUtil.BastionProxy(&cluster)
+	if err != nil {
+		return "", err
+	}
+	if proxy != nil {
+		return proxyAddr, nil
+	}
+	endpoints, err := c.GetApiServerEndpoints(name)
+	if err != nil {
+		return "", err
+	}
+	aliveHost, err := clusterUtil.SelectAliveHost(endpoints)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s", aliveHost), nil
+}
+
 func (c clusterService) GetKubeconfig(name string) (string, error) {
 	cluster, err := c.clusterRepo.GetWithPreload(name, []string{"SpecConf"})
 	if err != nil {
@@ -531,7 +591,10 @@ func (c clusterService) GetKubeconfig(name string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	cfg := m.ToSSHConfig()
+	cfg, err := m.ToSSHConfig()
+	if err != nil {
+		return "", err
+	}
 	s, err := ssh.New(&cfg)
 	if err != nil {
 		return "", err
@@ -2,9 +2,6 @@ package adm
 
 import (
 	"encoding/json"
-	"reflect"
-	"runtime"
-	"strings"
 	"time"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
@@ -18,8 +15,14 @@ import (
 func (ca *ClusterAdm) AddWorker(aHelper *AnsibleHelper) error {
 	task := ca.getAddWorkerCurrentTask(aHelper)
 	if task != nil {
+		if aHelper.intercept(task, ca.getNextAddWorkerConditionName) {
+			return nil
+		}
 		f := ca.getAddWorkerHandler(task.Task)
-		err := f(aHelper)
+		if f == nil {
+			f = missingHandler(task.Task)
+		}
+		err := aHelper.run(f)
 		if err != nil {
 			aHelper.setCondition(model.TaskLogDetail{
 				Task:          task.Task,
@@ -28,6 +31,7 @@ func (ca *ClusterAdm) AddWorker(aHelper *AnsibleHelper) error {
 				StartTime:     task.StartTime,
 				EndTime:       time.Now().Unix(),
 				Message:       err.Error(),
+				Failures:      playbookFailures(err),
 			})
 			aHelper.Status = constant.TaskLogStatusFailed
 			aHelper.Message = err.Error()
@@ -41,17 +45,7 @@ func (ca *ClusterAdm) AddWorker(aHelper *AnsibleHelper) error {
 			EndTime:       time.Now().Unix(),
 		})
 
-		nextConditionType := ca.getNextAddWorkerConditionName(task.Task)
-		if nextConditionType == ConditionTypeDone {
-			aHelper.Status = constant.TaskLogStatusSuccess
-		} else {
-			aHelper.setCondition(model.TaskLogDetail{
-				Task:          nextConditionType,
-				Status:        constant.TaskLogStatusRunning,
-				LastProbeTime: time.Now().Unix(),
-				StartTime:     time.Now().Unix(),
-			})
-		}
+		aHelper.advance(task, ca.getNextAddWorkerConditionName)
 	}
 	return nil
 }
@@ -59,7 +53,7 @@ func (ca *ClusterAdm) AddWorker(aHelper *AnsibleHelper) error {
 func (ca *ClusterAdm) getAddWorkerCurrentTask(aHelper *AnsibleHelper) *model.TaskLogDetail {
 	if len(aHelper.LogDetail) == 0 {
 		return &model.TaskLogDetail{
-			Task:          ca.addWorkerHandlers[0].name(),
+			Task:          ca.addWorkerHandlers[0].name,
 			Status:        constant.TaskLogStatusRunning,
 			LastProbeTime: time.Now().Unix(),
 			StartTime:     time.Now().Unix(),
@@ -76,30 +70,11 @@ func (ca *ClusterAdm) getAddWorkerCurrentTask(aHelper *AnsibleHelper) *model.Tas
 }
 
 func (ca *ClusterAdm) getAddWorkerHandler(detailName string) Handler {
-	for _, f := range ca.addWorkerHandlers {
-		if detailName == f.name() {
-			return f
-		}
-	}
-	return nil
+	return findHandler(ca.addWorkerHandlers, detailName)
 }
 
-func (ca *ClusterAdm) getNextAddWorkerConditionName(detailName string) string {
-	var (
-		i int
-		f Handler
-	)
-	for i, f = range ca.addWorkerHandlers {
-		name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
-		if strings.Contains(name, detailName) {
-			break
-		}
-	}
-	if i == len(ca.addWorkerHandlers)-1 {
-		return ConditionTypeDone
-	}
-	next := ca.addWorkerHandlers[i+1]
-	return next.name()
+func (ca *ClusterAdm) getNextAddWorkerConditionName(detailName string) (string, error) {
+	return nextHandlerName(ca.addWorkerHandlers, detailName)
 }
 
 func (ca *ClusterAdm) EnsureAddWorkerTaskStart(aHelper *AnsibleHelper) error {
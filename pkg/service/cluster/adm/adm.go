@@ -2,6 +2,7 @@ package adm
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
@@ -12,11 +13,13 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/db"
 	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/errorf"
 	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model"
 	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
 	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/facts"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/kobe"
+	"github.com/ClusterOperator/kobe/api"
 )
 
 const (
@@ -34,41 +37,105 @@ func (h Handler) name() string {
 	return strings.TrimSuffix(name[i:], "-fm")
 }
 
+// advance 当前步骤完成或跳过后进入下一步骤，最后一步完成时任务成功，步骤未注册时任务失败
+func (c *AnsibleHelper) advance(task *model.TaskLogDetail, nextConditionName func(string) (string, error)) {
+	next, err := nextConditionName(task.Task)
+	switch {
+	case err != nil:
+		c.Status = constant.TaskLogStatusFailed
+		c.Message = err.Error()
+	case next == ConditionTypeDone:
+		c.Status = constant.TaskLogStatusSuccess
+	default:
+		c.setCondition(model.TaskLogDetail{
+			Task:          next,
+			Status:        constant.TaskLogStatusRunning,
+			LastProbeTime: time.Now().Unix(),
+			StartTime:     time.Now().Unix(),
+		})
+	}
+}
+
 func (c *AnsibleHelper) setCondition(newDetail model.TaskLogDetail) {
 	if newDetail.Status == constant.TaskLogStatusRunning {
+		newDetail.Name = c.DetailName
 		c.LogDetail = append(c.LogDetail, newDetail)
 		return
 	}
 	for i := 0; i < len(c.LogDetail); i++ {
-		if c.LogDetail[i].Task == newDetail.Task {
+		if c.LogDetail[i].Task == newDetail.Task && c.LogDetail[i].Name == c.DetailName {
 			c.LogDetail[i].Status = newDetail.Status
 			c.LogDetail[i].Message = newDetail.Message
 			c.LogDetail[i].LastProbeTime = newDetail.LastProbeTime
 			c.LogDetail[i].EndTime = time.Now().Unix()
+			if newDetail.Status == constant.TaskLogStatusFailed {
+				// 重试后再次失败时只保留最近一次的失败记录
+				if c.LogDetail[i].ID != "" {
+					if err := db.DB.Where("task_log_detail_id = ?", c.LogDetail[i].ID).Delete(&model.TaskLogFailure{}).Error; err != nil {
+						logger.Log.Errorf("delete failures of task %s failed: %s", c.LogDetail[i].Task, err.Error())
+					}
+				}
+				c.LogDetail[i].Failures = newDetail.Failures
+			}
 		}
 	}
 }
 
+// playbookFailures 将 playbook 错误中各主机的失败信息转换为失败记录
+func playbookFailures(err error) []model.TaskLogFailure {
+	var playbookErr *kobe.PlaybookError
+	if !errors.As(err, &playbookErr) {
+		return nil
+	}
+	var failures []model.TaskLogFailure
+	for _, f := range playbookErr.Failures {
+		failures = append(failures, model.TaskLogFailure{
+			Host:        f.Host,
+			Play:        f.Play,
+			Task:        f.Task,
+			Unreachable: f.Unreachable,
+			Rc:          f.Rc,
+			Msg:         f.Msg,
+			Stdout:      f.Stdout,
+			Stderr:      f.Stderr,
+		})
+	}
+	return failures
+}
+
 type AnsibleHelper struct {
-	Status    string
-	Message   string
-	LogDetail []model.TaskLogDetail
+	TaskID      string
+	ClusterName string
+	Status      string
+	Message     string
+	LogDetail   []model.TaskLogDetail
+	// DetailName 同一任务多次执行同一流程时（如多跳升级）区分各轮的步骤记录
+	DetailName string
 
 	ClusterVersion        string
 	ClusterUpgradeVersion string
 	ClusterRuntime        string
 
+	// MasterNode 正在增加或移除的控制节点，ControlNode 用于执行 etcdctl 的现有控制节点
+	MasterNode  model.ClusterNode
+	ControlNode model.ClusterNode
+
+	Rolling   *RollingUpgrade
+	Migration *RuntimeMigration
+
 	Writer io.Writer
 	Kobe   kobe.Interface
 }
 
 func NewAnsibleHelper(cluster model.Cluster, writer ...io.Writer) *AnsibleHelper {
 	c := &AnsibleHelper{
+		TaskID:                cluster.TaskLog.ID,
+		ClusterName:           cluster.Name,
 		Status:                constant.TaskLogStatusRunning,
 		ClusterVersion:        cluster.Version,
 		ClusterUpgradeVersion: cluster.UpgradeVersion,
 		ClusterRuntime:        cluster.SpecRuntime.RuntimeType,
-		LogDetail:             cluster.TaskLog.Details,
+		LogDetail:             FlowDetails(cluster.TaskLog.Details),
 	}
 	if writer != nil {
 		c.Writer = writer[0]
@@ -76,33 +143,20 @@ func NewAnsibleHelper(cluster model.Cluster, writer ...io.Writer) *AnsibleHelper
 	c.Kobe = kobe.NewAnsible(&kobe.Config{
 		Inventory: cluster.ParseInventory(),
 	})
-	for i := range facts.DefaultFacts {
-		c.Kobe.SetVar(i, facts.DefaultFacts[i])
-	}
-	clusterVars := cluster.GetKobeVars()
-	for k, v := range clusterVars {
+	for k, v := range loadClusterVars(cluster) {
 		c.Kobe.SetVar(k, v)
 	}
-	c.Kobe.SetVar(facts.ClusterNameFactName, cluster.Name)
-	ntpServerRepo := repository.NewNtpServerRepository()
-	ntps, _ := ntpServerRepo.GetAddressStr()
-	c.Kobe.SetVar(facts.NtpServerFactName, ntps)
-	maniFest, _ := GetManiFestBy(cluster.Version)
-	if maniFest.Name != "" {
-		vars := maniFest.GetVars()
-		for k, v := range vars {
-			c.Kobe.SetVar(k, v)
-		}
-	}
 	return c
 }
 
 func NewAnsibleHelperWithNewWorker(cluster model.Cluster, workers []string, writer ...io.Writer) *AnsibleHelper {
 	c := &AnsibleHelper{
+		TaskID:                cluster.TaskLog.ID,
+		ClusterName:           cluster.Name,
 		Status:                constant.TaskLogStatusRunning,
 		ClusterVersion:        cluster.Version,
 		ClusterUpgradeVersion: cluster.UpgradeVersion,
-		LogDetail:             cluster.TaskLog.Details,
+		LogDetail:             FlowDetails(cluster.TaskLog.Details),
 	}
 	if writer != nil {
 		c.Writer = writer[0]
@@ -116,74 +170,246 @@ func NewAnsibleHelperWithNewWorker(cluster model.Cluster, workers []string, writ
 	c.Kobe = kobe.NewAnsible(&kobe.Config{
 		Inventory: inventory,
 	})
-	for i := range facts.DefaultFacts {
-		c.Kobe.SetVar(i, facts.DefaultFacts[i])
+	for k, v := range loadClusterVars(cluster) {
+		c.Kobe.SetVar(k, v)
 	}
-	clusterVars := cluster.GetKobeVars()
-	for k, v := range clusterVars {
+	return c
+}
+
+// NewAnsibleHelperWithMaster 增加或移除控制节点，group 为 new-master 或 del-master
+func NewAnsibleHelperWithMaster(cluster model.Cluster, group string, master, control model.ClusterNode, writer ...io.Writer) *AnsibleHelper {
+	c := &AnsibleHelper{
+		TaskID:                cluster.TaskLog.ID,
+		ClusterName:           cluster.Name,
+		Status:                constant.TaskLogStatusRunning,
+		ClusterVersion:        cluster.Version,
+		ClusterUpgradeVersion: cluster.UpgradeVersion,
+		ClusterRuntime:        cluster.SpecRuntime.RuntimeType,
+		LogDetail:             FlowDetails(cluster.TaskLog.Details),
+		MasterNode:            master,
+		ControlNode:           control,
+	}
+	if writer != nil {
+		c.Writer = writer[0]
+	}
+	inventory := cluster.ParseInventory()
+	inventory.Groups = append(inventory.Groups, &api.Group{
+		Name:     group,
+		Hosts:    []string{master.Name},
+		Children: []string{},
+		Vars:     map[string]string{},
+	})
+	c.Kobe = kobe.NewAnsible(&kobe.Config{
+		Inventory: inventory,
+	})
+	for k, v := range loadClusterVars(cluster) {
 		c.Kobe.SetVar(k, v)
 	}
-	c.Kobe.SetVar(facts.ClusterNameFactName, cluster.Name)
+	return c
+}
+
+// details 返回当前流程的步骤记录
+func (c *AnsibleHelper) details() []model.TaskLogDetail {
+	var details []model.TaskLogDetail
+	for _, d := range c.LogDetail {
+		if d.Name == c.DetailName {
+			details = append(details, d)
+		}
+	}
+	return details
+}
+
+// loadClusterVars 合并默认变量、集群变量和版本清单变量，后者覆盖前者
+func loadClusterVars(cluster model.Cluster) map[string]string {
+	result := map[string]string{}
+	for k, v := range facts.DefaultFacts {
+		result[k] = v
+	}
+	for k, v := range cluster.GetKobeVars() {
+		result[k] = v
+	}
+	result[facts.ClusterNameFactName] = cluster.Name
 	ntpServerRepo := repository.NewNtpServerRepository()
 	ntps, _ := ntpServerRepo.GetAddressStr()
-	c.Kobe.SetVar(facts.NtpServerFactName, ntps)
+	result[facts.NtpServerFactName] = ntps
 	maniFest, _ := GetManiFestBy(cluster.Version)
 	if maniFest.Name != "" {
-		vars := maniFest.GetVars()
-		for k, v := range vars {
-			c.Kobe.SetVar(k, v)
+		for k, v := range maniFest.GetVars() {
+			result[k] = v
 		}
 	}
-	return c
+	return result
+}
+
+// FlowDetails 去除人工干预和升级进度记录，返回任务执行流程的步骤记录
+func FlowDetails(details []model.TaskLogDetail) []model.TaskLogDetail {
+	var result []model.TaskLogDetail
+	for _, d := range details {
+		if d.Name != constant.TaskLogDetailNameAction && d.Name != constant.TaskLogDetailNameUpgradeHop {
+			result = append(result, d)
+		}
+	}
+	return result
 }
 
 type ClusterAdm struct {
-	createHandlers    []Handler
-	upgradeHandlers   []Handler
-	addWorkerHandlers []Handler
+	createHandlers    []namedHandler
+	upgradeHandlers   []namedHandler
+	addWorkerHandlers []namedHandler
+
+	addMasterHandlers      []namedHandler
+	removeMasterHandlers   []namedHandler
+	rollingUpgradeHandlers []namedHandler
 }
 
 func NewClusterAdm() *ClusterAdm {
 	ca := new(ClusterAdm)
-	ca.createHandlers = []Handler{
-		ca.EnsureInitTaskStart,
-		ca.EnsurePrepareBaseSystemConfig,
-		ca.EnsurePrepareContainerRuntime,
-		ca.EnsurePrepareKubernetesComponent,
-		ca.EnsurePrepareLoadBalancer,
-		ca.EnsurePrepareCertificates,
-		ca.EnsureInitEtcd,
-		ca.EnsureInitMaster,
-		ca.EnsureInitWorker,
-		ca.EnsureInitNetwork,
-		ca.EnsureInitHelm,
-		ca.EnsureInitMetricsServer,
-		ca.EnsureInitIngressController,
-		ca.EnsurePostInit,
-	}
-	ca.upgradeHandlers = []Handler{
-		ca.EnsureUpgradeTaskStart,
-		ca.EnsureBackupETCD,
-		ca.EnsureUpgradeRuntime,
-		ca.EnsureUpgradeETCD,
-		ca.EnsureUpgradeKubernetes,
-		ca.EnsureUpdateCertificates,
-	}
-	ca.addWorkerHandlers = []Handler{
-		ca.EnsureAddWorkerTaskStart,
-		ca.EnsureAddWorkerBaseSystemConfig,
-		ca.EnsureAddWorkerContainerRuntime,
-		ca.EnsureAddWorkerKubernetesComponent,
-		ca.EnsureAddWorkerLoadBalancer,
-		ca.EnsureAddWorkerCertificates,
-		ca.EnsureAddWorkerWorker,
-		ca.EnsureAddWorkerNetwork,
-		ca.EnsureAddWorkerPost,
-		ca.EnsureAddWorkerStorage,
-	}
+	ca.createHandlers = withPhases(FlowCreate, ca.defaultHandlers(FlowCreate)...)
+	ca.upgradeHandlers = withPhases(FlowUpgrade, ca.defaultHandlers(FlowUpgrade)...)
+	ca.addWorkerHandlers = withPhases(FlowAddWorker, ca.defaultHandlers(FlowAddWorker)...)
+	ca.addMasterHandlers = withPhases(FlowAddMaster, ca.defaultHandlers(FlowAddMaster)...)
+	ca.removeMasterHandlers = withPhases(FlowRemoveMaster, ca.defaultHandlers(FlowRemoveMaster)...)
+	ca.rollingUpgradeHandlers = withPhases(FlowRollingUpgrade, ca.defaultHandlers(FlowRollingUpgrade)...)
 	return ca
 }
 
+// defaultHandlers 流程的默认步骤，未知流程返回 nil
+func (ca *ClusterAdm) defaultHandlers(flow string) []Handler {
+	switch flow {
+	case FlowCreate:
+		return []Handler{
+			ca.EnsureInitTaskStart,
+			ca.EnsurePrepareBaseSystemConfig,
+			ca.EnsurePrepareContainerRuntime,
+			ca.EnsurePrepareKubernetesComponent,
+			ca.EnsurePrepareLoadBalancer,
+			ca.EnsurePrepareCertificates,
+			ca.EnsureInitEtcd,
+			ca.EnsureInitMaster,
+			ca.EnsureInitWorker,
+			ca.EnsureInitNetwork,
+			ca.EnsureInitHelm,
+			ca.EnsureInitMetricsServer,
+			ca.EnsureInitIngressController,
+			ca.EnsurePostInit,
+		}
+	case FlowUpgrade:
+		return []Handler{
+			ca.EnsureUpgradeTaskStart,
+			ca.EnsureBackupETCD,
+			ca.EnsureUpgradeRuntime,
+			ca.EnsureUpgradeETCD,
+			ca.EnsureUpgradeKubernetes,
+			ca.EnsureUpdateCertificates,
+		}
+	case FlowAddWorker:
+		return []Handler{
+			ca.EnsureAddWorkerTaskStart,
+			ca.EnsureAddWorkerBaseSystemConfig,
+			ca.EnsureAddWorkerContainerRuntime,
+			ca.EnsureAddWorkerKubernetesComponent,
+			ca.EnsureAddWorkerLoadBalancer,
+			ca.EnsureAddWorkerCertificates,
+			ca.EnsureAddWorkerWorker,
+			ca.EnsureAddWorkerNetwork,
+			ca.EnsureAddWorkerPost,
+			ca.EnsureAddWorkerStorage,
+		}
+	case FlowAddMaster:
+		return []Handler{
+			ca.EnsureAddMasterTaskStart,
+			ca.EnsureAddMasterBaseSystemConfig,
+			ca.EnsureAddMasterContainerRuntime,
+			ca.EnsureAddMasterKubernetesComponent,
+			ca.EnsureAddMasterCertificates,
+			ca.EnsureAddMasterEtcdMember,
+			ca.EnsureAddMasterEtcd,
+			ca.EnsureAddMasterControlPlane,
+			ca.EnsureUpdateLoadBalancer,
+		}
+	case FlowRemoveMaster:
+		return []Handler{
+			ca.EnsureRemoveMasterTaskStart,
+			ca.EnsureRemoveMasterCheckQuorum,
+			ca.EnsureRemoveMasterNode,
+			ca.EnsureRemoveMasterEtcdMember,
+			ca.EnsureRemoveMasterReset,
+			ca.EnsureUpdateLoadBalancer,
+		}
+	case FlowRollingUpgrade:
+		return []Handler{
+			ca.EnsureUpgradeTaskStart,
+			ca.EnsureBackupETCD,
+			ca.EnsureUpgradeETCD,
+			ca.EnsureRollingUpgradeMasters,
+			ca.EnsureRollingUpgradeWorkers,
+			ca.EnsureUpdateCertificates,
+		}
+	}
+	return nil
+}
+
+func (ca *ClusterAdm) handlers(taskType string) []namedHandler {
+	switch taskType {
+	case constant.TaskLogTypeClusterCreate:
+		return ca.createHandlers
+	case constant.TaskLogTypeClusterUpgrade:
+		return ca.upgradeHandlers
+	case constant.TaskLogTypeClusterNodeExtend:
+		return ca.addWorkerHandlers
+	case constant.TaskLogTypeClusterRollingUpgrade:
+		return ca.rollingUpgradeHandlers
+	case constant.TaskLogTypeClusterMasterExtend:
+		return ca.addMasterHandlers
+	case constant.TaskLogTypeClusterMasterShrink:
+		return ca.removeMasterHandlers
+	}
+	return nil
+}
+
+// HandlerNames 返回任务类型对应的步骤名称
+func (ca *ClusterAdm) HandlerNames(taskType string) []string {
+	var names []string
+	for _, h := range ca.handlers(taskType) {
+		names = append(names, h.name)
+	}
+	return names
+}
+
+// Playbooks 返回任务类型各步骤执行的 playbook，不重复
+func (ca *ClusterAdm) Playbooks(taskType string) []string {
+	var (
+		playbooks []string
+		exists    = map[string]bool{}
+	)
+	for _, h := range ca.handlers(taskType) {
+		for _, p := range h.playbooks {
+			if !exists[p] {
+				exists[p] = true
+				playbooks = append(playbooks, p)
+			}
+		}
+	}
+	return playbooks
+}
+
+// CheckPlaybooks 任务需要的 playbook 在 kobe 中不存在时返回错误，避免任务执行到中途才失败
+func (ca *ClusterAdm) CheckPlaybooks(taskType string) error {
+	return RequirePlaybooks(ca.Playbooks(taskType)...)
+}
+
+// RequirePlaybooks 检查不在固定流程中的 playbook 是否存在于 kobe 中
+func RequirePlaybooks(playbooks ...string) error {
+	available, err := kobe.ListPlaybooks(kobe.Project)
+	if err != nil {
+		return err
+	}
+	if missing := kobe.MissingPlaybooks(available, playbooks); len(missing) > 0 {
+		return errorf.CErrFs{errorf.New("PLAYBOOK_NOT_FOUND", strings.Join(missing, ", "))}
+	}
+	return nil
+}
+
 func (ca *ClusterAdm) OnInitialize(ansible *AnsibleHelper) error {
 	err := ca.Create(ansible)
 	return err
@@ -199,6 +425,18 @@ func (ca *ClusterAdm) OnAddWorker(ansible *AnsibleHelper) error {
 	return err
 }
 
+func (ca *ClusterAdm) OnAddMaster(ansible *AnsibleHelper) error {
+	return ca.runHandlers(ansible, ca.addMasterHandlers)
+}
+
+func (ca *ClusterAdm) OnRemoveMaster(ansible *AnsibleHelper) error {
+	return ca.runHandlers(ansible, ca.removeMasterHandlers)
+}
+
+func (ca *ClusterAdm) OnRollingUpgrade(ansible *AnsibleHelper) error {
+	return ca.runHandlers(ansible, ca.rollingUpgradeHandlers)
+}
+
 func GetManiFestBy(name string) (dto.ClusterManifest, error) {
 	var clusterManifest dto.ClusterManifest
 	var mo model.ClusterManifest
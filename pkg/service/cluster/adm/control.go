@@ -0,0 +1,232 @@
+package adm
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/kobe"
+	"github.com/ClusterOperator/kobe/api"
+	"github.com/jinzhu/gorm"
+)
+
+// 用户对正在执行任务的干预操作保存在数据库中，执行任务的副本在执行下一个 Ensure* 步骤前生效，
+// 取消操作在 playbook 执行期间也会生效
+
+// loadTaskControl 读取任务的干预记录，没有记录时返回空记录
+func loadTaskControl(taskID string) (model.TaskControl, error) {
+	control := model.TaskControl{TaskLogID: taskID}
+	if err := db.DB.Where("task_log_id = ?", taskID).First(&control).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		return control, err
+	}
+	return control, nil
+}
+
+// updateTaskControl 在事务中修改任务的干预记录，没有记录时新建
+func updateTaskControl(taskID string, update func(control *model.TaskControl)) error {
+	tx := db.DB.Begin()
+	control := model.TaskControl{TaskLogID: taskID}
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("task_log_id = ?", taskID).First(&control).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		tx.Rollback()
+		return err
+	}
+	update(&control)
+	if err := tx.Save(&control).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// parseSkips 解析逗号分隔的跳过步骤
+func parseSkips(skips string) map[string]bool {
+	result := map[string]bool{}
+	for _, name := range strings.Split(skips, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result[name] = true
+		}
+	}
+	return result
+}
+
+// addSkip 追加跳过的步骤，已存在时不重复记录
+func addSkip(skips, handlerName string) string {
+	if parseSkips(skips)[handlerName] {
+		return skips
+	}
+	if skips == "" {
+		return handlerName
+	}
+	return skips + "," + handlerName
+}
+
+func PauseTask(taskID string) error {
+	return updateTaskControl(taskID, func(control *model.TaskControl) {
+		control.Paused = true
+	})
+}
+
+func ResumeTask(taskID string) error {
+	return updateTaskControl(taskID, func(control *model.TaskControl) {
+		control.Paused = false
+	})
+}
+
+func SkipTask(taskID, handlerName string) error {
+	return updateTaskControl(taskID, func(control *model.TaskControl) {
+		control.Skips = addSkip(control.Skips, handlerName)
+	})
+}
+
+// CancelTask 标记任务取消，执行任务的副本在下一个步骤前或 playbook 执行期间停止等待
+func CancelTask(taskID string) error {
+	return updateTaskControl(taskID, func(control *model.TaskControl) {
+		control.Canceled = true
+	})
+}
+
+// ResetTask 任务失败或重试时清除暂停、取消状态，保留尚未生效的跳过步骤和未结束的 playbook
+func ResetTask(taskID string) error {
+	return updateTaskControl(taskID, func(control *model.TaskControl) {
+		control.Paused = false
+		control.Canceled = false
+	})
+}
+
+// ReleaseTask 任务成功后清理干预记录
+func ReleaseTask(taskID string) error {
+	return db.DB.Where("task_log_id = ?", taskID).Delete(&model.TaskControl{}).Error
+}
+
+// IsTaskPaused 任务是否处于暂停状态
+func IsTaskPaused(taskID string) bool {
+	control, err := loadTaskControl(taskID)
+	if err != nil {
+		logger.Log.Errorf("load control of task %s failed: %s", taskID, err.Error())
+		return false
+	}
+	return control.Paused
+}
+
+// IsPlaybookDetached 任务取消时未结束的 playbook 是否仍在 kobe 中执行
+// 查询失败时在 playbook 超时时间内按仍在执行处理，避免集群在 playbook 结束前发起其他任务
+func IsPlaybookDetached(taskID string) bool {
+	control, err := loadTaskControl(taskID)
+	if err != nil {
+		logger.Log.Errorf("load control of task %s failed: %s", taskID, err.Error())
+		return true
+	}
+	if control.DetachedPlaybook == "" {
+		return false
+	}
+	client := kobe.NewAnsible(&kobe.Config{Inventory: &api.Inventory{}})
+	res, err := client.GetResult(control.DetachedPlaybook)
+	if err != nil {
+		logger.Log.Errorf("get result of detached playbook %s failed: %s", control.DetachedPlaybook, err.Error())
+		if time.Since(control.UpdatedAt) < phases.PlaybookTimeout() {
+			return true
+		}
+	} else if !res.Finished {
+		return true
+	}
+	if err := updateTaskControl(taskID, func(control *model.TaskControl) {
+		control.DetachedPlaybook = ""
+	}); err != nil {
+		logger.Log.Errorf("clear detached playbook of task %s failed: %s", taskID, err.Error())
+	}
+	return false
+}
+
+// run 执行步骤，执行期间任务被取消时停止等待 playbook 的结果，并记录仍在执行的 playbook
+func (c *AnsibleHelper) run(f Handler) error {
+	if c.TaskID == "" {
+		return f(c)
+	}
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(phases.PhaseInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if control, err := loadTaskControl(c.TaskID); err == nil && control.Canceled {
+					c.Kobe.Detach()
+					return
+				}
+			}
+		}
+	}()
+	err := f(c)
+	close(stop)
+
+	var detached *phases.PlaybookDetachedError
+	if errors.As(err, &detached) {
+		writeLog("----task canceled, playbook "+detached.TaskID+" keeps running in kobe----", c.Writer)
+		if err := updateTaskControl(c.TaskID, func(control *model.TaskControl) {
+			control.DetachedPlaybook = detached.TaskID
+		}); err != nil {
+			logger.Log.Errorf("save detached playbook of task %s failed: %s", c.TaskID, err.Error())
+		}
+	}
+	return err
+}
+
+// intercept 在执行 task 对应的步骤前处理暂停、跳过、取消，返回 true 表示本轮不再执行该步骤
+func (c *AnsibleHelper) intercept(task *model.TaskLogDetail, nextConditionName func(string) (string, error)) bool {
+	if c.TaskID == "" {
+		return false
+	}
+	control, err := loadTaskControl(c.TaskID)
+	if err != nil {
+		logger.Log.Errorf("load control of task %s failed: %s", c.TaskID, err.Error())
+		return false
+	}
+	canceled, paused, skip := control.Canceled, control.Paused, parseSkips(control.Skips)[task.Task]
+
+	switch {
+	case canceled:
+		c.setCondition(model.TaskLogDetail{
+			Task:          task.Task,
+			Status:        constant.TaskLogStatusFailed,
+			LastProbeTime: time.Now().Unix(),
+			StartTime:     task.StartTime,
+			EndTime:       time.Now().Unix(),
+			Message:       constant.TaskCancel,
+		})
+		c.Status = constant.TaskLogStatusFailed
+		c.Message = constant.TaskCancel
+		return true
+	case paused:
+		if c.Status != constant.TaskLogStatusPaused {
+			writeLog("----task paused before "+task.Task+"----", c.Writer)
+		}
+		c.Status = constant.TaskLogStatusPaused
+		return true
+	}
+	if c.Status == constant.TaskLogStatusPaused {
+		writeLog("----task resumed----", c.Writer)
+		c.Status = constant.TaskLogStatusRunning
+	}
+	if !skip {
+		return false
+	}
+
+	writeLog("----skip "+task.Task+"----", c.Writer)
+	c.setCondition(model.TaskLogDetail{
+		Task:          task.Task,
+		Status:        constant.TaskLogStatusSkip,
+		LastProbeTime: time.Now().Unix(),
+		StartTime:     task.StartTime,
+		EndTime:       time.Now().Unix(),
+	})
+	c.Status = constant.TaskLogStatusRunning
+	c.advance(task, nextConditionName)
+	return true
+}
@@ -0,0 +1,52 @@
+package adm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+)
+
+func TestSkips(t *testing.T) {
+	tests := []struct {
+		name    string
+		skips   string
+		handler string
+		want    string
+	}{
+		{name: "empty", skips: "", handler: "EnsureInitHelm", want: "EnsureInitHelm"},
+		{name: "append", skips: "EnsureInitHelm", handler: "EnsurePostInit", want: "EnsureInitHelm,EnsurePostInit"},
+		{name: "duplicate", skips: "EnsureInitHelm,EnsurePostInit", handler: "EnsureInitHelm", want: "EnsureInitHelm,EnsurePostInit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := addSkip(tt.skips, tt.handler)
+			if got != tt.want {
+				t.Errorf("addSkip() = %q, want %q", got, tt.want)
+			}
+			if !parseSkips(got)[tt.handler] {
+				t.Errorf("parseSkips(%q) does not contain %s", got, tt.handler)
+			}
+		})
+	}
+	if got := parseSkips(" , "); len(got) != 0 {
+		t.Errorf("parseSkips() = %v, want empty", got)
+	}
+}
+
+func TestFlowDetails(t *testing.T) {
+	details := []model.TaskLogDetail{
+		{Task: "EnsureInitTaskStart"},
+		{Name: constant.TaskLogDetailNameAction, Task: "PAUSE"},
+		{Name: "v1.20.10", Task: "EnsureUpgradeTaskStart"},
+		{Name: constant.TaskLogDetailNameUpgradeHop, Task: "v1.20.10"},
+	}
+	want := []model.TaskLogDetail{details[0], details[2]}
+	if got := FlowDetails(details); !reflect.DeepEqual(got, want) {
+		t.Errorf("FlowDetails() = %+v, want %+v", got, want)
+	}
+	if got := FlowDetails([]model.TaskLogDetail{details[1]}); len(got) != 0 {
+		t.Errorf("FlowDetails() = %+v, want empty", got)
+	}
+}
@@ -1,9 +1,6 @@
 package adm
 
 import (
-	"reflect"
-	"runtime"
-	"strings"
 	"time"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
@@ -16,8 +13,14 @@ import (
 func (ca *ClusterAdm) Create(aHelper *AnsibleHelper) error {
 	task := ca.getCreateCurrentTask(aHelper)
 	if task != nil {
+		if aHelper.intercept(task, ca.getNextCreateConditionName) {
+			return nil
+		}
 		f := ca.getCreateHandler(task.Task)
-		if err := f(aHelper); err != nil {
+		if f == nil {
+			f = missingHandler(task.Task)
+		}
+		if err := aHelper.run(f); err != nil {
 			aHelper.setCondition(model.TaskLogDetail{
 				Task:          task.Task,
 				Status:        constant.TaskLogStatusFailed,
@@ -25,6 +28,7 @@ func (ca *ClusterAdm) Create(aHelper *AnsibleHelper) error {
 				StartTime:     task.StartTime,
 				EndTime:       time.Now().Unix(),
 				Message:       err.Error(),
+				Failures:      playbookFailures(err),
 			})
 			aHelper.Status = constant.TaskLogStatusFailed
 			aHelper.Message = err.Error()
@@ -38,17 +42,7 @@ func (ca *ClusterAdm) Create(aHelper *AnsibleHelper) error {
 			EndTime:       time.Now().Unix(),
 		})
 
-		nextConditionType := ca.getNextCreateConditionName(task.Task)
-		if nextConditionType == ConditionTypeDone {
-			aHelper.Status = constant.TaskLogStatusSuccess
-		} else {
-			aHelper.setCondition(model.TaskLogDetail{
-				Task:          nextConditionType,
-				Status:        constant.TaskLogStatusRunning,
-				LastProbeTime: time.Now().Unix(),
-				StartTime:     time.Now().Unix(),
-			})
-		}
+		aHelper.advance(task, ca.getNextCreateConditionName)
 	}
 	return nil
 }
@@ -56,7 +50,7 @@ func (ca *ClusterAdm) Create(aHelper *AnsibleHelper) error {
 func (ca *ClusterAdm) getCreateCurrentTask(aHelper *AnsibleHelper) *model.TaskLogDetail {
 	if len(aHelper.LogDetail) == 0 {
 		taskItem := &model.TaskLogDetail{
-			Task:          ca.createHandlers[0].name(),
+			Task:          ca.createHandlers[0].name,
 			Status:        constant.TaskLogStatusRunning,
 			LastProbeTime: time.Now().Unix(),
 			StartTime:     time.Now().Unix(),
@@ -75,29 +69,10 @@ func (ca *ClusterAdm) getCreateCurrentTask(aHelper *AnsibleHelper) *model.TaskLo
 }
 
 func (ca *ClusterAdm) getCreateHandler(conditionName string) Handler {
-	for _, f := range ca.createHandlers {
-		if conditionName == f.name() {
-			return f
-		}
-	}
-	return nil
+	return findHandler(ca.createHandlers, conditionName)
 }
-func (ca *ClusterAdm) getNextCreateConditionName(conditionName string) string {
-	var (
-		i int
-		f Handler
-	)
-	for i, f = range ca.createHandlers {
-		name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
-		if strings.Contains(name, conditionName) {
-			break
-		}
-	}
-	if i == len(ca.createHandlers)-1 {
-		return ConditionTypeDone
-	}
-	next := ca.createHandlers[i+1]
-	return next.name()
+func (ca *ClusterAdm) getNextCreateConditionName(conditionName string) (string, error) {
+	return nextHandlerName(ca.createHandlers, conditionName)
 }
 
 func (ca *ClusterAdm) EnsureInitTaskStart(aHelper *AnsibleHelper) error {
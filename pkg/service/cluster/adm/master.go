@@ -0,0 +1,220 @@
+package adm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+)
+
+const (
+	addMasterBase                = "93-add-master-01-base.yml"
+	addMasterContainerRuntime    = "93-add-master-02-runtime.yml"
+	addMasterKubernetesComponent = "93-add-master-03-kubernetes-component.yml"
+	addMasterCertificates        = "93-add-master-04-certificates.yml"
+	addMasterEtcd                = "93-add-master-05-etcd.yml"
+	addMasterControlPlane        = "93-add-master-06-kubernetes-master.yml"
+	updateLoadBalancer           = "93-update-load-balancer.yml"
+	removeMasterNode             = "98-remove-master.yml"
+	resetMaster                  = "98-reset-master.yml"
+)
+
+// runHandlers 执行 handlers 中当前步骤，进度记录在 aHelper.LogDetail 中
+func (ca *ClusterAdm) runHandlers(aHelper *AnsibleHelper, handlers []namedHandler) error {
+	task := currentTask(aHelper, handlers)
+	if task == nil {
+		return nil
+	}
+	next := func(name string) (string, error) {
+		return nextHandlerName(handlers, name)
+	}
+	if aHelper.intercept(task, next) {
+		return nil
+	}
+	f := findHandler(handlers, task.Task)
+	if f == nil {
+		f = missingHandler(task.Task)
+	}
+	if err := aHelper.run(f); err != nil {
+		aHelper.setCondition(model.TaskLogDetail{
+			Task:          task.Task,
+			Status:        constant.TaskLogStatusFailed,
+			LastProbeTime: time.Now().Unix(),
+			StartTime:     task.StartTime,
+			EndTime:       time.Now().Unix(),
+			Message:       err.Error(),
+			Failures:      playbookFailures(err),
+		})
+		aHelper.Status = constant.TaskLogStatusFailed
+		aHelper.Message = err.Error()
+		return nil
+	}
+	aHelper.setCondition(model.TaskLogDetail{
+		Task:          task.Task,
+		Status:        constant.TaskLogStatusSuccess,
+		LastProbeTime: time.Now().Unix(),
+		StartTime:     task.StartTime,
+		EndTime:       time.Now().Unix(),
+	})
+	aHelper.advance(task, next)
+	return nil
+}
+
+func currentTask(aHelper *AnsibleHelper, handlers []namedHandler) *model.TaskLogDetail {
+	details := aHelper.details()
+	if len(details) == 0 {
+		return &model.TaskLogDetail{
+			Task:          handlers[0].name,
+			Status:        constant.TaskLogStatusRunning,
+			LastProbeTime: time.Now().Unix(),
+			StartTime:     time.Now().Unix(),
+			EndTime:       time.Now().Unix(),
+		}
+	}
+	for _, detail := range details {
+		if detail.Status == constant.TaskLogStatusFailed || detail.Status == constant.TaskLogStatusRunning {
+			return &detail
+		}
+	}
+	return nil
+}
+
+func (ca *ClusterAdm) EnsureAddMasterTaskStart(aHelper *AnsibleHelper) error {
+	time.Sleep(5 * time.Second)
+	writeLog("----add master task start----", aHelper.Writer)
+	return nil
+}
+
+func (ca *ClusterAdm) EnsureAddMasterBaseSystemConfig(aHelper *AnsibleHelper) error {
+	return phases.RunPlaybookAndGetResult(aHelper.Kobe, addMasterBase, "", aHelper.Writer)
+}
+
+func (ca *ClusterAdm) EnsureAddMasterContainerRuntime(aHelper *AnsibleHelper) error {
+	return phases.RunPlaybookAndGetResult(aHelper.Kobe, addMasterContainerRuntime, "", aHelper.Writer)
+}
+
+func (ca *ClusterAdm) EnsureAddMasterKubernetesComponent(aHelper *AnsibleHelper) error {
+	return phases.RunPlaybookAndGetResult(aHelper.Kobe, addMasterKubernetesComponent, "", aHelper.Writer)
+}
+
+func (ca *ClusterAdm) EnsureAddMasterCertificates(aHelper *AnsibleHelper) error {
+	return phases.RunPlaybookAndGetResult(aHelper.Kobe, addMasterCertificates, "", aHelper.Writer)
+}
+
+// EnsureAddMasterEtcdMember 现有成员全部健康时才注册新成员，新成员启动前集群容错能力会下降
+func (ca *ClusterAdm) EnsureAddMasterEtcdMember(aHelper *AnsibleHelper) error {
+	client, err := controlClient(aHelper)
+	if err != nil {
+		return err
+	}
+	members, err := clusterUtil.ListEtcdMembers(client)
+	if err != nil {
+		return err
+	}
+	healthy, err := clusterUtil.ListEtcdHealthyEndpoints(client)
+	if err != nil {
+		return err
+	}
+	if len(healthy) < len(members) {
+		return fmt.Errorf("etcd has %d members but only %d healthy, refuse to add member", len(members), len(healthy))
+	}
+	writeLog(fmt.Sprintf("----add etcd member %s----", aHelper.MasterNode.Name), aHelper.Writer)
+	return clusterUtil.AddEtcdMember(client, aHelper.MasterNode.Name, aHelper.MasterNode.Host.Ip)
+}
+
+// EnsureAddMasterEtcd 新成员启动失败时移除该成员，恢复原有 quorum
+func (ca *ClusterAdm) EnsureAddMasterEtcd(aHelper *AnsibleHelper) error {
+	err := phases.RunPlaybookAndGetResult(aHelper.Kobe, addMasterEtcd, "", aHelper.Writer)
+	if err == nil {
+		return nil
+	}
+	writeLog(fmt.Sprintf("----start etcd failed, remove etcd member %s----", aHelper.MasterNode.Name), aHelper.Writer)
+	client, cErr := controlClient(aHelper)
+	if cErr != nil {
+		return fmt.Errorf("%s, rollback etcd member failed: %s", err.Error(), cErr.Error())
+	}
+	if rErr := clusterUtil.RemoveEtcdMember(client, aHelper.MasterNode.Name, aHelper.MasterNode.Host.Ip); rErr != nil {
+		return fmt.Errorf("%s, rollback etcd member failed: %s", err.Error(), rErr.Error())
+	}
+	return err
+}
+
+func (ca *ClusterAdm) EnsureAddMasterControlPlane(aHelper *AnsibleHelper) error {
+	return phases.RunPlaybookAndGetResult(aHelper.Kobe, addMasterControlPlane, "", aHelper.Writer)
+}
+
+// EnsureUpdateLoadBalancer 按照 kube-master 和 new-master 组刷新 kube-apiserver 负载均衡配置，不包含 del-master 组
+func (ca *ClusterAdm) EnsureUpdateLoadBalancer(aHelper *AnsibleHelper) error {
+	return phases.RunPlaybookAndGetResult(aHelper.Kobe, updateLoadBalancer, "", aHelper.Writer)
+}
+
+func (ca *ClusterAdm) EnsureRemoveMasterTaskStart(aHelper *AnsibleHelper) error {
+	time.Sleep(5 * time.Second)
+	writeLog("----remove master task start----", aHelper.Writer)
+	return nil
+}
+
+func (ca *ClusterAdm) EnsureRemoveMasterCheckQuorum(aHelper *AnsibleHelper) error {
+	client, err := controlClient(aHelper)
+	if err != nil {
+		return err
+	}
+	return CheckRemoveMasterQuorum(client, aHelper.MasterNode)
+}
+
+func (ca *ClusterAdm) EnsureRemoveMasterNode(aHelper *AnsibleHelper) error {
+	return phases.RunPlaybookAndGetResult(aHelper.Kobe, removeMasterNode, "", aHelper.Writer)
+}
+
+func (ca *ClusterAdm) EnsureRemoveMasterEtcdMember(aHelper *AnsibleHelper) error {
+	client, err := controlClient(aHelper)
+	if err != nil {
+		return err
+	}
+	writeLog(fmt.Sprintf("----remove etcd member %s----", aHelper.MasterNode.Name), aHelper.Writer)
+	return clusterUtil.RemoveEtcdMember(client, aHelper.MasterNode.Name, aHelper.MasterNode.Host.Ip)
+}
+
+func (ca *ClusterAdm) EnsureRemoveMasterReset(aHelper *AnsibleHelper) error {
+	return phases.RunPlaybookAndGetResult(aHelper.Kobe, resetMaster, "", aHelper.Writer)
+}
+
+// CheckRemoveMasterQuorum 移除 master 后剩余的健康 etcd 成员必须仍满足 quorum
+func CheckRemoveMasterQuorum(client ssh.Interface, master model.ClusterNode) error {
+	members, err := clusterUtil.ListEtcdMembers(client)
+	if err != nil {
+		return err
+	}
+	healthy, err := clusterUtil.ListEtcdHealthyEndpoints(client)
+	if err != nil {
+		return err
+	}
+	remain, remainHealthy := len(members), len(healthy)
+	for _, m := range members {
+		if m.Name == master.Name || (len(m.PeerURLs) > 0 && clusterUtil.IsEtcdEndpointOf(m.PeerURLs[0], master.Host.Ip)) {
+			remain--
+		}
+	}
+	for _, endpoint := range healthy {
+		if clusterUtil.IsEtcdEndpointOf(endpoint, master.Host.Ip) {
+			remainHealthy--
+		}
+	}
+	return clusterUtil.CheckEtcdQuorum(remain, remainHealthy)
+}
+
+func controlClient(aHelper *AnsibleHelper) (ssh.Interface, error) {
+	if aHelper.ControlNode.ID == "" {
+		return nil, errors.New("can not find available master to run etcdctl")
+	}
+	cfg, err := aHelper.ControlNode.ToSSHConfig()
+	if err != nil {
+		return nil, err
+	}
+	return ssh.New(&cfg)
+}
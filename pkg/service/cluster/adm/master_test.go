@@ -0,0 +1,36 @@
+package adm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+)
+
+func TestMasterPlaybooks(t *testing.T) {
+	tests := []struct {
+		taskType string
+		want     []string
+	}{
+		{
+			taskType: constant.TaskLogTypeClusterMasterExtend,
+			want: []string{addMasterBase, addMasterContainerRuntime, addMasterKubernetesComponent, addMasterCertificates,
+				addMasterEtcd, addMasterControlPlane, updateLoadBalancer},
+		},
+		{
+			taskType: constant.TaskLogTypeClusterMasterShrink,
+			want:     []string{removeMasterNode, resetMaster, updateLoadBalancer},
+		},
+	}
+	ca := NewClusterAdm()
+	for _, tt := range tests {
+		t.Run(tt.taskType, func(t *testing.T) {
+			if got := ca.Playbooks(tt.taskType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Playbooks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := ca.Playbooks("UNKNOWN"); len(got) != 0 {
+		t.Errorf("Playbooks() of unknown task = %v, want empty", got)
+	}
+}
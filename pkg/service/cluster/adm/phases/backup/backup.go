@@ -7,16 +7,16 @@ import (
 )
 
 const (
-	backupCluster = "94-backup-cluster.yml"
+	BackupCluster = "94-backup-cluster.yml"
 )
 
 type BackupClusterPhase struct {
 }
 
 func (backup BackupClusterPhase) Name() string {
-	return "backupCluster"
+	return "BackupCluster"
 }
 
 func (backup BackupClusterPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, backupCluster, "", writer)
+	return phases.RunPlaybookAndGetResult(b, BackupCluster, "", writer)
 }
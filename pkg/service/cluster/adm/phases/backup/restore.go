@@ -7,16 +7,16 @@ import (
 )
 
 const (
-	restoreCluster = "95-restore-cluster.yml"
+	RestoreCluster = "95-restore-cluster.yml"
 )
 
 type RestoreClusterPhase struct {
 }
 
 func (restore RestoreClusterPhase) Name() string {
-	return "backupCluster"
+	return "BackupCluster"
 }
 
 func (restore RestoreClusterPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, restoreCluster, "", writer)
+	return phases.RunPlaybookAndGetResult(b, RestoreCluster, "", writer)
 }
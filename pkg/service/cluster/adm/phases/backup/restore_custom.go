@@ -15,7 +15,7 @@ type RestoreClusterCustomPhase struct {
 }
 
 func (restore RestoreClusterCustomPhase) Name() string {
-	return "backupCluster"
+	return "BackupCluster"
 }
 
 func (restore RestoreClusterCustomPhase) Run(b kobe.Interface, writer io.Writer) error {
@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	initAddWorkerNetwork = "91-add-worker-07-network.yml"
+	InitAddWorkerNetwork = "91-add-worker-07-network.yml"
 )
 
 type AddWorkerNetworkPhase struct {
@@ -19,5 +19,5 @@ func (AddWorkerNetworkPhase) Name() string {
 }
 
 func (s AddWorkerNetworkPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, initAddWorkerNetwork, "", writer)
+	return phases.RunPlaybookAndGetResult(b, InitAddWorkerNetwork, "", writer)
 }
@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	initAddWorkerPost = "91-add-worker-08-post.yml"
+	InitAddWorkerPost = "91-add-worker-08-post.yml"
 )
 
 type AddWorkerPostPhase struct {
@@ -19,5 +19,5 @@ func (s AddWorkerPostPhase) Name() string {
 }
 
 func (s AddWorkerPostPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, initAddWorkerPost, "", writer)
+	return phases.RunPlaybookAndGetResult(b, InitAddWorkerPost, "", writer)
 }
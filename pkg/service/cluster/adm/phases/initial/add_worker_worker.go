@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	initAddWorkerWorker = "91-add-worker-06-kubernetes-worker.yml"
+	InitAddWorkerWorker = "91-add-worker-06-kubernetes-worker.yml"
 )
 
 type AddWorkerMasterPhase struct {
@@ -19,5 +19,5 @@ func (AddWorkerMasterPhase) Name() string {
 }
 
 func (s AddWorkerMasterPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, initAddWorkerWorker, "", writer)
+	return phases.RunPlaybookAndGetResult(b, InitAddWorkerWorker, "", writer)
 }
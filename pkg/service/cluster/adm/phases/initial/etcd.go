@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	initEtcd = "06-etcd.yml"
+	InitEtcd = "06-etcd.yml"
 )
 
 type EtcdPhase struct {
@@ -23,5 +23,5 @@ func (s EtcdPhase) Run(b kobe.Interface, writer io.Writer) error {
 	if s.Upgrade {
 		tag = "upgrade"
 	}
-	return phases.RunPlaybookAndGetResult(b, initEtcd, tag, writer)
+	return phases.RunPlaybookAndGetResult(b, InitEtcd, tag, writer)
 }
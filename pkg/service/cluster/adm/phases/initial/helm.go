@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	initHelm = "11-helm-install.yml"
+	InitHelm = "11-helm-install.yml"
 )
 
 type HelmPhase struct {
@@ -18,5 +18,5 @@ func (h HelmPhase) Name() string {
 }
 
 func (h HelmPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, initHelm, "", writer)
+	return phases.RunPlaybookAndGetResult(b, InitHelm, "", writer)
 }
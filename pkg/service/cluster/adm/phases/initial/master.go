@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	initMaster = "07-kubernetes-master.yml"
+	InitMaster = "07-kubernetes-master.yml"
 )
 
 type MasterPhase struct {
@@ -18,5 +18,5 @@ func (MasterPhase) Name() string {
 }
 
 func (s MasterPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, initMaster, "", writer)
+	return phases.RunPlaybookAndGetResult(b, InitMaster, "", writer)
 }
@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	initNetwork = "09-plugin-network.yml"
+	InitNetwork = "09-plugin-network.yml"
 )
 
 type NetworkPhase struct {
@@ -18,5 +18,5 @@ func (NetworkPhase) Name() string {
 }
 
 func (s NetworkPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, initNetwork, "", writer)
+	return phases.RunPlaybookAndGetResult(b, InitNetwork, "", writer)
 }
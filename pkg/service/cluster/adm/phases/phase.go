@@ -3,7 +3,6 @@ package phases
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"io"
 	"time"
 
@@ -20,11 +19,29 @@ const (
 	DefaultPhaseTimeoutMinute = 10
 )
 
+// PlaybookDetachedError 任务取消后不再等待 playbook 的结果，TaskID 为仍在 kobe 中执行的 playbook
+type PlaybookDetachedError struct {
+	TaskID string
+}
+
+func (e *PlaybookDetachedError) Error() string {
+	return "TASK_CANCELED"
+}
+
 type Interface interface {
 	Name() string
 	Run(p kobe.Interface, writer io.Writer) error
 }
 
+// PlaybookTimeout 等待 playbook 执行结果的最长时间，job.timeout 单位为分钟
+func PlaybookTimeout() time.Duration {
+	timeout := viper.GetInt("job.timeout")
+	if timeout < DefaultPhaseTimeoutMinute {
+		timeout = DefaultPhaseTimeoutMinute
+	}
+	return time.Duration(timeout) * time.Minute
+}
+
 func RunPlaybookAndGetResult(b kobe.Interface, playbookName, tag string, writer io.Writer) error {
 	taskId, err := b.RunPlaybook(playbookName, tag)
 	var result kobe.Result
@@ -40,11 +57,12 @@ func RunPlaybookAndGetResult(b kobe.Interface, playbookName, tag string, writer
 			}
 		}()
 	}
-	timeout := viper.GetInt("job.timeout")
-	if timeout < DefaultPhaseTimeoutMinute {
-		timeout = DefaultPhaseTimeoutMinute
-	}
-	err = wait.Poll(PhaseInterval, time.Duration(timeout)*time.Minute, func() (done bool, err error) {
+	err = wait.Poll(PhaseInterval, PlaybookTimeout(), func() (done bool, err error) {
+		select {
+		case <-b.Done():
+			return true, &PlaybookDetachedError{TaskID: taskId}
+		default:
+		}
 		res, err := b.GetResult(taskId)
 		if err != nil {
 			return true, err
@@ -64,7 +82,7 @@ func RunPlaybookAndGetResult(b kobe.Interface, playbookName, tag string, writer
 					result.GatherFailedInfo()
 					if result.HostFailedInfo != nil && len(result.HostFailedInfo) > 0 {
 						by, _ := json.Marshal(&result.HostFailedInfo)
-						return true, errors.New(string(by))
+						return true, &kobe.PlaybookError{Failures: result.HostFailures, Message: string(by)}
 					}
 				}
 			}
@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	ingressPlaybook = "14-ingress-controller.yml"
+	IngressPlaybook = "14-ingress-controller.yml"
 )
 
 type ControllerPhase struct {
@@ -23,5 +23,5 @@ func (c ControllerPhase) Run(b kobe.Interface, writer io.Writer) error {
 	if c.IngressControllerType != "" {
 		b.SetVar(facts.IngressControllerTypeFactName, c.IngressControllerType)
 	}
-	return phases.RunPlaybookAndGetResult(b, ingressPlaybook, "", writer)
+	return phases.RunPlaybookAndGetResult(b, IngressPlaybook, "", writer)
 }
@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	prepareAddWorkerBase = "91-add-worker-01-base.yml"
+	PrepareAddWorkerBase = "91-add-worker-01-base.yml"
 )
 
 type AddWorkerBaseSystemConfigPhase struct {
@@ -19,5 +19,5 @@ func (s AddWorkerBaseSystemConfigPhase) Name() string {
 }
 
 func (s AddWorkerBaseSystemConfigPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, prepareAddWorkerBase, "", writer)
+	return phases.RunPlaybookAndGetResult(b, PrepareAddWorkerBase, "", writer)
 }
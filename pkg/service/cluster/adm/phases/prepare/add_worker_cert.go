@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	prepareAddWorkerCertificates = "91-add-worker-05-certificates.yml"
+	PrepareAddWorkerCertificates = "91-add-worker-05-certificates.yml"
 )
 
 type AddWorkerCertificatesPhase struct {
@@ -19,5 +19,5 @@ func (c AddWorkerCertificatesPhase) Name() string {
 }
 
 func (c AddWorkerCertificatesPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, prepareAddWorkerCertificates, "", writer)
+	return phases.RunPlaybookAndGetResult(b, PrepareAddWorkerCertificates, "", writer)
 }
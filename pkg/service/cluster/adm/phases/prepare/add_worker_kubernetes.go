@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	prepareAddWorkerKubernetesComponents = "91-add-worker-03-kubernetes-component.yml"
+	PrepareAddWorkerKubernetesComponents = "91-add-worker-03-kubernetes-component.yml"
 )
 
 type AddWorkerKubernetesComponentPhase struct {
@@ -19,5 +19,5 @@ func (s AddWorkerKubernetesComponentPhase) Name() string {
 }
 
 func (s AddWorkerKubernetesComponentPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, prepareAddWorkerKubernetesComponents, "", writer)
+	return phases.RunPlaybookAndGetResult(b, PrepareAddWorkerKubernetesComponents, "", writer)
 }
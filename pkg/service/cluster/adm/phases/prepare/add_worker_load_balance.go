@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	prepareAddWorkerLoadBalancer = "91-add-worker-04-load-balancer.yml"
+	PrepareAddWorkerLoadBalancer = "91-add-worker-04-load-balancer.yml"
 )
 
 type AddWorkerLoadBalancerPhase struct {
@@ -19,5 +19,5 @@ func (s AddWorkerLoadBalancerPhase) Name() string {
 }
 
 func (s AddWorkerLoadBalancerPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, prepareAddWorkerLoadBalancer, "", writer)
+	return phases.RunPlaybookAndGetResult(b, PrepareAddWorkerLoadBalancer, "", writer)
 }
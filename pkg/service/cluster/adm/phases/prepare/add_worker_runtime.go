@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	prepareAddWorkerContainerRuntime = "91-add-worker-02-runtime.yml"
+	PrepareAddWorkerContainerRuntime = "91-add-worker-02-runtime.yml"
 )
 
 type AddWorkerContainerRuntimePhase struct {
@@ -19,5 +19,5 @@ func (s AddWorkerContainerRuntimePhase) Name() string {
 }
 
 func (s AddWorkerContainerRuntimePhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, prepareAddWorkerContainerRuntime, "", writer)
+	return phases.RunPlaybookAndGetResult(b, PrepareAddWorkerContainerRuntime, "", writer)
 }
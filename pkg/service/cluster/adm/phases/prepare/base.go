@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	prepareBase = "01-base.yml"
+	PrepareBase = "01-base.yml"
 )
 
 type BaseSystemConfigPhase struct {
@@ -18,5 +18,5 @@ func (s BaseSystemConfigPhase) Name() string {
 }
 
 func (s BaseSystemConfigPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, prepareBase, "", writer)
+	return phases.RunPlaybookAndGetResult(b, PrepareBase, "", writer)
 }
@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	prepareCertificates = "05-certificates.yml"
+	PrepareCertificates = "05-certificates.yml"
 )
 
 type CertificatesPhase struct {
@@ -18,5 +18,5 @@ func (c CertificatesPhase) Name() string {
 }
 
 func (c CertificatesPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, prepareCertificates, "", writer)
+	return phases.RunPlaybookAndGetResult(b, PrepareCertificates, "", writer)
 }
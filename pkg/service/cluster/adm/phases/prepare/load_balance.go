@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	prepareLoadBalancer = "04-load-balancer.yml"
+	PrepareLoadBalancer = "04-load-balancer.yml"
 )
 
 type LoadBalancerPhase struct {
@@ -18,5 +18,5 @@ func (s LoadBalancerPhase) Name() string {
 }
 
 func (s LoadBalancerPhase) Run(b kobe.Interface, writer io.Writer) error {
-	return phases.RunPlaybookAndGetResult(b, prepareLoadBalancer, "", writer)
+	return phases.RunPlaybookAndGetResult(b, PrepareLoadBalancer, "", writer)
 }
@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	playbookNameContainerRuntime = "02-runtime.yml"
+	PlaybookNameContainerRuntime = "02-runtime.yml"
 )
 
 type ContainerRuntimePhase struct {
@@ -24,5 +24,5 @@ func (s ContainerRuntimePhase) Run(b kobe.Interface, writer io.Writer) error {
 		tag = "upgrade"
 	}
 
-	return phases.RunPlaybookAndGetResult(b, playbookNameContainerRuntime, tag, writer)
+	return phases.RunPlaybookAndGetResult(b, PlaybookNameContainerRuntime, tag, writer)
 }
@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	upgradeCluster = "92-upgrade-cluster.yml"
+	UpgradeCluster = "92-upgrade-cluster.yml"
 )
 
 type UpgradeClusterPhase struct {
@@ -15,12 +15,12 @@ type UpgradeClusterPhase struct {
 }
 
 func (upgrade UpgradeClusterPhase) Name() string {
-	return "upgradeCluster"
+	return "UpgradeCluster"
 }
 
 func (upgrade UpgradeClusterPhase) Run(b kobe.Interface, writer io.Writer) error {
 	if upgrade.Version != "" {
 		b.SetVar("kube_upgrade_version", upgrade.Version)
 	}
-	return phases.RunPlaybookAndGetResult(b, upgradeCluster, "", writer)
+	return phases.RunPlaybookAndGetResult(b, UpgradeCluster, "", writer)
 }
@@ -0,0 +1,142 @@
+package adm
+
+import (
+	"strings"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/facts"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases/backup"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases/initial"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases/plugin/ingress"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases/plugin/storage"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases/prepare"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases/upgrade"
+)
+
+const maskedValue = "******"
+
+// handlerPlaybooks 各步骤执行的 playbook，引用 phases 中的定义
+var handlerPlaybooks = map[string][]string{
+	"EnsurePrepareBaseSystemConfig":      {prepare.PrepareBase},
+	"EnsurePrepareContainerRuntime":      {prepare.PlaybookNameContainerRuntime},
+	"EnsurePrepareKubernetesComponent":   {prepare.PrepareKubernetesComponents},
+	"EnsurePrepareLoadBalancer":          {prepare.PrepareLoadBalancer},
+	"EnsurePrepareCertificates":          {prepare.PrepareCertificates},
+	"EnsureInitEtcd":                     {initial.InitEtcd},
+	"EnsureInitMaster":                   {initial.InitMaster},
+	"EnsureInitWorker":                   {initial.InitWorker},
+	"EnsureInitNetwork":                  {initial.InitNetwork},
+	"EnsureInitHelm":                     {initial.InitHelm},
+	"EnsureInitMetricsServer":            {initial.InitMetricsServer},
+	"EnsureInitIngressController":        {ingress.IngressPlaybook},
+	"EnsurePostInit":                     {initial.InitPost},
+	"EnsureBackupETCD":                   {backup.BackupCluster},
+	"EnsureUpgradeRuntime":               {prepare.PlaybookNameContainerRuntime},
+	"EnsureUpgradeETCD":                  {initial.InitEtcd},
+	"EnsureUpgradeKubernetes":            {upgrade.UpgradeCluster},
+	"EnsureUpdateCertificates":           {prepare.PrepareCertificates},
+	"EnsureRollingUpgradeMasters":        {upgradeClusterNode},
+	"EnsureRollingUpgradeWorkers":        {upgradeClusterNode},
+	"EnsureAddWorkerBaseSystemConfig":    {prepare.PrepareAddWorkerBase},
+	"EnsureAddWorkerContainerRuntime":    {prepare.PrepareAddWorkerContainerRuntime},
+	"EnsureAddWorkerKubernetesComponent": {prepare.PrepareAddWorkerKubernetesComponents},
+	"EnsureAddWorkerLoadBalancer":        {prepare.PrepareAddWorkerLoadBalancer},
+	"EnsureAddWorkerCertificates":        {prepare.PrepareAddWorkerCertificates},
+	"EnsureAddWorkerWorker":              {initial.InitAddWorkerWorker},
+	"EnsureAddWorkerNetwork":             {initial.InitAddWorkerNetwork},
+	"EnsureAddWorkerPost":                {initial.InitAddWorkerPost},
+	"EnsureAddWorkerStorage":             {storage.AddWorkerStorage},
+	"EnsureAddMasterBaseSystemConfig":    {addMasterBase},
+	"EnsureAddMasterContainerRuntime":    {addMasterContainerRuntime},
+	"EnsureAddMasterKubernetesComponent": {addMasterKubernetesComponent},
+	"EnsureAddMasterCertificates":        {addMasterCertificates},
+	"EnsureAddMasterEtcd":                {addMasterEtcd},
+	"EnsureAddMasterControlPlane":        {addMasterControlPlane},
+	"EnsureUpdateLoadBalancer":           {updateLoadBalancer},
+	"EnsureRemoveMasterNode":             {removeMasterNode},
+	"EnsureRemoveMasterReset":            {resetMaster},
+}
+
+// Plan 生成任务的执行计划，只读取数据，不调用 kobe；升级任务按 upgradeHops 依次列出每一跳的步骤，
+// 未指定时只升级到 cluster.UpgradeVersion
+func (ca *ClusterAdm) Plan(cluster model.Cluster, taskType string, upgradeHops ...string) dto.ClusterPlan {
+	plan := dto.ClusterPlan{
+		Inventory: cluster.PreviewInventory(),
+		Vars:      loadClusterVars(cluster),
+	}
+	for _, h := range plan.Inventory.Hosts {
+		if h.Password != "" {
+			h.Password = maskedValue
+		}
+		if h.PrivateKey != "" {
+			h.PrivateKey = maskedValue
+		}
+	}
+
+	switch taskType {
+	case constant.TaskLogTypeClusterCreate:
+		plan.Vars[facts.ComponentOptionFactName] = "cluster"
+	case constant.TaskLogTypeClusterUpgrade, constant.TaskLogTypeClusterRollingUpgrade:
+		hops := upgradeHops
+		if len(hops) == 0 {
+			hops = []string{cluster.UpgradeVersion}
+		}
+		current := cluster.Version
+		for _, hop := range hops {
+			vars := upgradeHopVars(current, hop, cluster.SpecRuntime.RuntimeType)
+			for k, v := range vars {
+				plan.Vars[k] = v
+			}
+			plan.Handlers = append(plan.Handlers, planHandlers(ca.handlers(taskType), hop, upgradeSkips(vars, cluster.SpecRuntime.RuntimeType))...)
+			current = hop
+		}
+		return plan
+	}
+	plan.Handlers = planHandlers(ca.handlers(taskType), "", nil)
+	return plan
+}
+
+// upgradeHopVars 一跳升级的版本变量，运行时和 etcd 版本没有变化时不设置
+func upgradeHopVars(from, to, runtimeType string) map[string]string {
+	vars := map[string]string{}
+	if index := strings.Index(to, "-"); index != -1 {
+		vars["kube_upgrade_version"] = to[:index]
+	}
+	runtimeVersionKey := getRuntimeVersionKey(runtimeType)
+	if _, newVersion, newer := compareManifestVersion(from, to, runtimeVersionKey); newer {
+		vars[runtimeVersionKey] = newVersion
+	}
+	if _, newVersion, newer := compareManifestVersion(from, to, etcdVersionKey); newer {
+		vars[etcdVersionKey] = newVersion
+	}
+	return vars
+}
+
+// upgradeSkips 运行时和 etcd 版本没有变化时对应步骤会跳过
+func upgradeSkips(vars map[string]string, runtimeType string) map[string]string {
+	skips := map[string]string{}
+	if _, ok := vars[getRuntimeVersionKey(runtimeType)]; !ok {
+		skips["EnsureUpgradeRuntime"] = "runtime version is newest, skip upgrade"
+	}
+	if _, ok := vars[etcdVersionKey]; !ok {
+		skips["EnsureUpgradeETCD"] = "etcd version is newest, skip upgrade"
+	}
+	return skips
+}
+
+func planHandlers(handlers []namedHandler, hop string, skips map[string]string) []dto.PlanHandler {
+	var items []dto.PlanHandler
+	for _, h := range handlers {
+		message, skip := skips[h.name]
+		items = append(items, dto.PlanHandler{
+			Name:      h.name,
+			Hop:       hop,
+			Playbooks: h.playbooks,
+			Skip:      skip,
+			Message:   message,
+		})
+	}
+	return items
+}
@@ -0,0 +1,69 @@
+package adm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+)
+
+func TestHandlerPlaybooksMatchHandlers(t *testing.T) {
+	ca := new(ClusterAdm)
+	names := map[string]bool{}
+	for _, flow := range []string{FlowCreate, FlowUpgrade, FlowAddWorker, FlowAddMaster, FlowRemoveMaster, FlowRollingUpgrade} {
+		for _, h := range ca.defaultHandlers(flow) {
+			names[h.name()] = true
+		}
+	}
+	for name, playbooks := range handlerPlaybooks {
+		if !names[name] {
+			t.Errorf("handlerPlaybooks has unknown handler %s", name)
+		}
+		for _, p := range playbooks {
+			if p == "" {
+				t.Errorf("handler %s has empty playbook", name)
+			}
+		}
+	}
+}
+
+func TestUpgradeSkips(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		runtime string
+		want    []string
+	}{
+		{name: "both upgraded", vars: map[string]string{"docker_version": "20.10", etcdVersionKey: "3.5"}, runtime: "docker", want: nil},
+		{name: "containerd upgraded", vars: map[string]string{"containerd_version": "1.6"}, runtime: "containerd", want: []string{"EnsureUpgradeETCD"}},
+		{name: "nothing upgraded", vars: map[string]string{}, runtime: "docker", want: []string{"EnsureUpgradeETCD", "EnsureUpgradeRuntime"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, name := range []string{"EnsureUpgradeETCD", "EnsureUpgradeRuntime"} {
+				if _, ok := upgradeSkips(tt.vars, tt.runtime)[name]; ok {
+					got = append(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("upgradeSkips() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanHandlers(t *testing.T) {
+	handlers := []namedHandler{
+		{name: "EnsureUpgradeTaskStart"},
+		{name: "EnsureUpgradeETCD", playbooks: []string{"06-etcd.yml"}},
+	}
+	got := planHandlers(handlers, "v1.20.4-ko1", map[string]string{"EnsureUpgradeETCD": "skip"})
+	want := []dto.PlanHandler{
+		{Name: "EnsureUpgradeTaskStart", Hop: "v1.20.4-ko1"},
+		{Name: "EnsureUpgradeETCD", Hop: "v1.20.4-ko1", Playbooks: []string{"06-etcd.yml"}, Skip: true, Message: "skip"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planHandlers() = %+v, want %+v", got, want)
+	}
+}
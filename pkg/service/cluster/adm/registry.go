@@ -0,0 +1,198 @@
+package adm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases"
+	"github.com/spf13/viper"
+)
+
+const (
+	FlowCreate    = "create"
+	FlowUpgrade   = "upgrade"
+	FlowAddWorker = "add-worker"
+
+	FlowAddMaster      = "add-master"
+	FlowRemoveMaster   = "remove-master"
+	FlowRollingUpgrade = "rolling-upgrade"
+)
+
+// Phase 自定义步骤，通过 Before 或 After 指定插入到哪个步骤的前面或后面
+type Phase struct {
+	Name      string
+	Handler   Handler
+	Before    string
+	After     string
+	Playbooks []string
+}
+
+// PhaseConfig 配置文件中声明的自定义步骤，执行指定的 playbook
+type PhaseConfig struct {
+	Flow     string `mapstructure:"flow"`
+	Name     string `mapstructure:"name"`
+	Playbook string `mapstructure:"playbook"`
+	Tag      string `mapstructure:"tag"`
+	Before   string `mapstructure:"before"`
+	After    string `mapstructure:"after"`
+}
+
+type namedHandler struct {
+	name      string
+	handler   Handler
+	playbooks []string
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string][]Phase{}
+)
+
+// RegisterPhase 注册自定义步骤，xpack 等插件可以在加载时调用，锚点必须是默认步骤或已注册的自定义步骤
+func RegisterPhase(flow string, phase Phase) error {
+	defaults := new(ClusterAdm).defaultHandlers(flow)
+	if defaults == nil {
+		return fmt.Errorf("unknown flow %s", flow)
+	}
+	if phase.Name == "" || phase.Handler == nil {
+		return fmt.Errorf("phase name and handler are required")
+	}
+	if phase.Before != "" && phase.After != "" {
+		return fmt.Errorf("phase %s can not set both before and after", phase.Name)
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	for _, p := range registry[flow] {
+		if p.Name == phase.Name {
+			return fmt.Errorf("phase %s already registered in flow %s", phase.Name, flow)
+		}
+	}
+	phases := append(append([]Phase{}, registry[flow]...), phase)
+	if _, err := insertPhases(flow, namedHandlers(defaults), phases); err != nil {
+		return err
+	}
+	registry[flow] = phases
+	return nil
+}
+
+// PlaybookPhase 生成执行指定 playbook 的步骤
+func PlaybookPhase(c PhaseConfig) Phase {
+	return Phase{
+		Name:   c.Name,
+		Before: c.Before,
+		After:  c.After,
+		Handler: func(aHelper *AnsibleHelper) error {
+			return phases.RunPlaybookAndGetResult(aHelper.Kobe, c.Playbook, c.Tag, aHelper.Writer)
+		},
+		Playbooks: []string{c.Playbook},
+	}
+}
+
+// LoadConfigPhases 加载配置文件 phase.custom 中声明的步骤
+func LoadConfigPhases() error {
+	var configs []PhaseConfig
+	if err := viper.UnmarshalKey("phase.custom", &configs); err != nil {
+		return err
+	}
+	for _, c := range configs {
+		if c.Playbook == "" {
+			return fmt.Errorf("playbook of phase %s is required", c.Name)
+		}
+		if err := RegisterPhase(c.Flow, PlaybookPhase(c)); err != nil {
+			return err
+		}
+		logger.Log.Infof("register phase %s to flow %s", c.Name, c.Flow)
+	}
+	return nil
+}
+
+// InitPhaseRegistry 启动时加载自定义步骤
+type InitPhaseRegistry struct{}
+
+func (i *InitPhaseRegistry) Init() error {
+	return LoadConfigPhases()
+}
+
+func (i *InitPhaseRegistry) PhaseName() string {
+	return "phase registry"
+}
+
+// withPhases 按照注册顺序把自定义步骤插入到默认步骤中，注册时已校验锚点
+func withPhases(flow string, hs ...Handler) []namedHandler {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	handlers, err := insertPhases(flow, namedHandlers(hs), registry[flow])
+	if err != nil {
+		panic(err)
+	}
+	return handlers
+}
+
+func namedHandlers(hs []Handler) []namedHandler {
+	var handlers []namedHandler
+	for _, h := range hs {
+		handlers = append(handlers, namedHandler{name: h.name(), handler: h, playbooks: handlerPlaybooks[h.name()]})
+	}
+	return handlers
+}
+
+// insertPhases 依次插入自定义步骤，未指定锚点时追加到最后，锚点不存在时返回错误
+func insertPhases(flow string, handlers []namedHandler, phases []Phase) ([]namedHandler, error) {
+	for _, p := range phases {
+		item := namedHandler{name: p.Name, handler: p.Handler, playbooks: p.Playbooks}
+		if p.Before == "" && p.After == "" {
+			handlers = append(handlers, item)
+			continue
+		}
+		anchor := p.Before
+		if anchor == "" {
+			anchor = p.After
+		}
+		index := -1
+		for i := range handlers {
+			if handlers[i].name == anchor {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return nil, fmt.Errorf("can not find anchor %s of phase %s in flow %s", anchor, p.Name, flow)
+		}
+		if p.After != "" {
+			index++
+		}
+		handlers = append(handlers[:index], append([]namedHandler{item}, handlers[index:]...)...)
+	}
+	return handlers, nil
+}
+
+func findHandler(handlers []namedHandler, name string) Handler {
+	for _, h := range handlers {
+		if h.name == name {
+			return h.handler
+		}
+	}
+	return nil
+}
+
+// nextHandlerName 返回下一步骤的名称，已是最后一步时返回 ConditionTypeDone，步骤未注册时返回错误
+func nextHandlerName(handlers []namedHandler, name string) (string, error) {
+	for i := range handlers {
+		if handlers[i].name != name {
+			continue
+		}
+		if i == len(handlers)-1 {
+			return ConditionTypeDone, nil
+		}
+		return handlers[i+1].name, nil
+	}
+	return "", fmt.Errorf("handler %s is not registered", name)
+}
+
+// missingHandler 步骤在重启后不再注册时，任务以失败结束而不是 panic
+func missingHandler(name string) Handler {
+	return func(aHelper *AnsibleHelper) error {
+		return fmt.Errorf("handler %s is not registered", name)
+	}
+}
@@ -0,0 +1,95 @@
+package adm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testHandlers(names ...string) []namedHandler {
+	var handlers []namedHandler
+	for _, name := range names {
+		handlers = append(handlers, namedHandler{name: name})
+	}
+	return handlers
+}
+
+func handlerNames(handlers []namedHandler) []string {
+	var names []string
+	for _, h := range handlers {
+		names = append(names, h.name)
+	}
+	return names
+}
+
+func TestInsertPhases(t *testing.T) {
+	tests := []struct {
+		name   string
+		phases []Phase
+		want   []string
+		err    bool
+	}{
+		{name: "no phase", want: []string{"A", "B", "C"}},
+		{name: "append", phases: []Phase{{Name: "X"}}, want: []string{"A", "B", "C", "X"}},
+		{name: "before", phases: []Phase{{Name: "X", Before: "B"}}, want: []string{"A", "X", "B", "C"}},
+		{name: "after last", phases: []Phase{{Name: "X", After: "C"}}, want: []string{"A", "B", "C", "X"}},
+		{name: "anchor on custom phase", phases: []Phase{{Name: "X", After: "A"}, {Name: "Y", After: "X"}}, want: []string{"A", "X", "Y", "B", "C"}},
+		{name: "missing anchor", phases: []Phase{{Name: "X", Before: "D"}}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := insertPhases(FlowCreate, testHandlers("A", "B", "C"), tt.phases)
+			if (err != nil) != tt.err {
+				t.Fatalf("insertPhases() error = %v, want error %v", err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(handlerNames(got), tt.want) {
+				t.Errorf("insertPhases() = %v, want %v", handlerNames(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestNextHandlerName(t *testing.T) {
+	handlers := testHandlers("A", "B", "C")
+	tests := []struct {
+		name string
+		want string
+		err  bool
+	}{
+		{name: "A", want: "B"},
+		{name: "B", want: "C"},
+		{name: "C", want: ConditionTypeDone},
+		{name: "D", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextHandlerName(handlers, tt.name)
+			if (err != nil) != tt.err {
+				t.Fatalf("nextHandlerName() error = %v, want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("nextHandlerName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterPhaseRejectsMissingAnchor(t *testing.T) {
+	defer func() {
+		registryLock.Lock()
+		delete(registry, FlowUpgrade)
+		registryLock.Unlock()
+	}()
+	if err := RegisterPhase("unknown", Phase{Name: "X", Handler: func(*AnsibleHelper) error { return nil }}); err == nil {
+		t.Errorf("RegisterPhase() to unknown flow should fail")
+	}
+	if err := RegisterPhase(FlowUpgrade, Phase{Name: "X", Handler: func(*AnsibleHelper) error { return nil }, Before: "EnsureNotExist"}); err == nil {
+		t.Errorf("RegisterPhase() with missing anchor should fail")
+	}
+	if err := RegisterPhase(FlowUpgrade, Phase{Name: "X", Handler: func(*AnsibleHelper) error { return nil }, After: "EnsureBackupETCD"}); err != nil {
+		t.Fatalf("RegisterPhase() error = %v", err)
+	}
+	names := handlerNames(NewClusterAdm().upgradeHandlers)
+	if len(names) < 3 || names[1] != "EnsureBackupETCD" || names[2] != "X" {
+		t.Errorf("upgrade handlers = %v, want X after EnsureBackupETCD", names)
+	}
+}
@@ -0,0 +1,212 @@
+package adm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases/backup"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// upgradeClusterNode 只升级 upgrade-node 分组中节点的 playbook，kobe 项目中不存在时任务在创建前被拒绝
+	upgradeClusterNode = "92-upgrade-cluster-node.yml"
+	upgradeNodeGroup   = "upgrade-node"
+
+	healthCheckInterval = 10 * time.Second
+	healthCheckTimeout  = 5 * time.Minute
+)
+
+// RollingUpgrade 滚动升级参数，节点驱逐、健康检查等依赖集群 API 的操作由 service 注入
+type RollingUpgrade struct {
+	Masters      []string
+	Workers      []string
+	BatchSize    int
+	AutoRollback bool
+
+	Drain       func(node string) error
+	Uncordon    func(node string) error
+	Upgraded    func(node, version string) bool
+	HealthCheck func() error
+}
+
+// EnsureRollingUpgradeMasters master 逐个升级
+func (ca *ClusterAdm) EnsureRollingUpgradeMasters(aHelper *AnsibleHelper) error {
+	if aHelper.Rolling == nil {
+		return errors.New("rolling upgrade options are not set")
+	}
+	return ca.rollingUpgrade(aHelper, rollingBatches(aHelper.Rolling.Masters, 1, false))
+}
+
+// EnsureRollingUpgradeWorkers worker 按批次升级
+func (ca *ClusterAdm) EnsureRollingUpgradeWorkers(aHelper *AnsibleHelper) error {
+	if aHelper.Rolling == nil {
+		return errors.New("rolling upgrade options are not set")
+	}
+	return ca.rollingUpgrade(aHelper, rollingBatches(aHelper.Rolling.Workers, aHelper.Rolling.BatchSize, true))
+}
+
+// rollingBatches 按批次大小拆分节点，canary 为 true 时第一个节点单独作为金丝雀批次先行升级
+func rollingBatches(nodes []string, batchSize int, canary bool) [][]string {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	var batches [][]string
+	if canary && batchSize > 1 && len(nodes) > 1 {
+		batches = append(batches, nodes[:1])
+		nodes = nodes[1:]
+	}
+	for start := 0; start < len(nodes); start += batchSize {
+		end := start + batchSize
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+		batches = append(batches, nodes[start:end])
+	}
+	return batches
+}
+
+func (ca *ClusterAdm) rollingUpgrade(aHelper *AnsibleHelper, batches [][]string) error {
+	r := aHelper.Rolling
+	index := strings.Index(aHelper.ClusterUpgradeVersion, "-")
+	if index == -1 {
+		return fmt.Errorf("invalid upgrade version %s", aHelper.ClusterUpgradeVersion)
+	}
+	aHelper.Kobe.SetVar("kube_upgrade_version", aHelper.ClusterUpgradeVersion[:index])
+	runtimeVersionKey := getRuntimeVersionKey(aHelper.ClusterRuntime)
+	if _, newVersion, newer := compareManifestVersion(aHelper.ClusterVersion, aHelper.ClusterUpgradeVersion, runtimeVersionKey); newer {
+		aHelper.Kobe.SetVar(runtimeVersionKey, newVersion)
+	}
+
+	for _, nodes := range batches {
+		// 服务重启或重试时跳过已经升级完成的节点
+		var batch []string
+		for _, n := range nodes {
+			if !r.Upgraded(n, aHelper.ClusterUpgradeVersion) {
+				batch = append(batch, n)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		writeLog(fmt.Sprintf("----upgrade nodes %s----", strings.Join(batch, ",")), aHelper.Writer)
+		if err := ca.upgradeBatch(aHelper, batch); err != nil {
+			return ca.rollbackUpgrade(aHelper, err)
+		}
+	}
+	return nil
+}
+
+func (ca *ClusterAdm) upgradeBatch(aHelper *AnsibleHelper, batch []string) error {
+	r := aHelper.Rolling
+	for _, n := range batch {
+		writeLog("drain node "+n, aHelper.Writer)
+		if err := r.Drain(n); err != nil {
+			return fmt.Errorf("drain node %s failed: %s", n, err.Error())
+		}
+	}
+	aHelper.Kobe.SetGroupHosts(upgradeNodeGroup, batch)
+	if err := phases.RunPlaybookAndGetResult(aHelper.Kobe, upgradeClusterNode, "", aHelper.Writer); err != nil {
+		return err
+	}
+	for _, n := range batch {
+		writeLog("uncordon node "+n, aHelper.Writer)
+		if err := r.Uncordon(n); err != nil {
+			return fmt.Errorf("uncordon node %s failed: %s", n, err.Error())
+		}
+	}
+
+	var lastErr error
+	if err := wait.Poll(healthCheckInterval, healthCheckTimeout, func() (bool, error) {
+		if lastErr = r.HealthCheck(); lastErr != nil {
+			writeLog("cluster is unhealthy, retry after 10s: "+lastErr.Error(), aHelper.Writer)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("health check failed after upgrade nodes %s: %v", strings.Join(batch, ","), lastErr)
+	}
+	return nil
+}
+
+// rollbackUpgrade 开启自动回滚时降级节点并使用升级前备份的 etcd 快照恢复集群
+func (ca *ClusterAdm) rollbackUpgrade(aHelper *AnsibleHelper, cause error) error {
+	if !aHelper.Rolling.AutoRollback {
+		return cause
+	}
+	writeLog("----upgrade failed, rollback cluster----", aHelper.Writer)
+	if err := RollbackUpgrade(aHelper, aHelper.TaskID); err != nil {
+		return fmt.Errorf("%s, rollback failed: %s", cause.Error(), err.Error())
+	}
+	return fmt.Errorf("%s, cluster has been rolled back", cause.Error())
+}
+
+// RollbackPlaybooks 回滚升级需要的 playbook
+func RollbackPlaybooks() []string {
+	return []string{upgradeClusterNode, backup.RestoreCluster}
+}
+
+// UpgradeSnapshotPath 升级任务单独保存的 etcd 快照，避免定时备份覆盖默认快照后回滚到错误的数据
+func UpgradeSnapshotPath(clusterName, upgradeTaskID string) string {
+	return path.Join(constant.BackupDir, clusterName, "upgrade-"+upgradeTaskID+".db")
+}
+
+// RollbackUpgrade 将已升级的节点降级回 aHelper.ClusterVersion，再使用升级任务 upgradeTaskID 备份的 etcd 快照恢复集群
+func RollbackUpgrade(aHelper *AnsibleHelper, upgradeTaskID string) error {
+	if r := aHelper.Rolling; r != nil {
+		index := strings.Index(aHelper.ClusterVersion, "-")
+		if index == -1 {
+			return fmt.Errorf("invalid cluster version %s", aHelper.ClusterVersion)
+		}
+		// 先降级 worker，保证 kubelet 版本不高于 apiserver
+		var nodes []string
+		for _, n := range append(append([]string{}, r.Workers...), r.Masters...) {
+			if !r.Upgraded(n, aHelper.ClusterVersion) {
+				nodes = append(nodes, n)
+			}
+		}
+		if len(nodes) > 0 {
+			writeLog(fmt.Sprintf("----downgrade nodes %s to %s----", strings.Join(nodes, ","), aHelper.ClusterVersion), aHelper.Writer)
+			aHelper.Kobe.SetVar("kube_upgrade_version", aHelper.ClusterVersion[:index])
+			runtimeVersionKey := getRuntimeVersionKey(aHelper.ClusterRuntime)
+			if oldVersion, _, newer := compareManifestVersion(aHelper.ClusterVersion, aHelper.ClusterUpgradeVersion, runtimeVersionKey); newer {
+				aHelper.Kobe.SetVar(runtimeVersionKey, oldVersion)
+			}
+			aHelper.Kobe.SetGroupHosts(upgradeNodeGroup, nodes)
+			if err := phases.RunPlaybookAndGetResult(aHelper.Kobe, upgradeClusterNode, "", aHelper.Writer); err != nil {
+				return fmt.Errorf("downgrade nodes failed: %s", err.Error())
+			}
+		}
+	}
+
+	writeLog("----restore etcd with snapshot taken before upgrade----", aHelper.Writer)
+	if err := copyFile(UpgradeSnapshotPath(aHelper.ClusterName, upgradeTaskID), path.Join(constant.BackupDir, aHelper.ClusterName, constant.BackupFileDefaultName)); err != nil {
+		return fmt.Errorf("load upgrade snapshot failed: %s", err.Error())
+	}
+	phase := backup.RestoreClusterPhase{}
+	return phase.Run(aHelper.Kobe, aHelper.Writer)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
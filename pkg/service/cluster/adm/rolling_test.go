@@ -0,0 +1,83 @@
+package adm
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ClusterOperator/kobe/api"
+)
+
+type fakeKobe struct {
+	vars      map[string]string
+	groups    map[string][]string
+	playbooks []string
+	done      chan struct{}
+}
+
+func newFakeKobe() *fakeKobe {
+	return &fakeKobe{vars: map[string]string{}, groups: map[string][]string{}, done: make(chan struct{})}
+}
+
+func (f *fakeKobe) RunPlaybook(name, tag string) (string, error) {
+	f.playbooks = append(f.playbooks, name)
+	return "", errors.New("kobe is not available in tests")
+}
+func (f *fakeKobe) Watch(writer io.Writer, taskId string) error { return nil }
+func (f *fakeKobe) GetResult(taskId string) (*api.Result, error) {
+	return &api.Result{Finished: true, Success: true}, nil
+}
+func (f *fakeKobe) SetVar(key string, value string)            { f.vars[key] = value }
+func (f *fakeKobe) SetGroupHosts(group string, hosts []string) { f.groups[group] = hosts }
+func (f *fakeKobe) Detach()                                    {}
+func (f *fakeKobe) Done() <-chan struct{}                      { return f.done }
+
+func TestRollingBatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodes     []string
+		batchSize int
+		canary    bool
+		want      [][]string
+	}{
+		{name: "empty", nodes: nil, batchSize: 2, canary: true, want: nil},
+		{name: "masters one by one", nodes: []string{"m1", "m2", "m3"}, batchSize: 1, want: [][]string{{"m1"}, {"m2"}, {"m3"}}},
+		{name: "invalid batch size", nodes: []string{"w1", "w2"}, batchSize: 0, canary: true, want: [][]string{{"w1"}, {"w2"}}},
+		{name: "canary first", nodes: []string{"w1", "w2", "w3", "w4"}, batchSize: 2, canary: true, want: [][]string{{"w1"}, {"w2", "w3"}, {"w4"}}},
+		{name: "single node", nodes: []string{"w1"}, batchSize: 3, canary: true, want: [][]string{{"w1"}}},
+		{name: "no canary", nodes: []string{"w1", "w2", "w3"}, batchSize: 2, want: [][]string{{"w1", "w2"}, {"w3"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollingBatches(tt.nodes, tt.batchSize, tt.canary); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rollingBatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollbackUpgrade(t *testing.T) {
+	k := newFakeKobe()
+	aHelper := &AnsibleHelper{
+		ClusterName:           "missing-cluster",
+		ClusterVersion:        "v1.20.4-ko1",
+		ClusterUpgradeVersion: "v1.20.6-ko1",
+		Writer:                io.Discard,
+		Kobe:                  k,
+		Rolling: &RollingUpgrade{
+			Masters: []string{"m1"},
+			Workers: []string{"w1", "w2"},
+			// 节点都还是升级前的版本，不需要降级
+			Upgraded: func(node, version string) bool { return true },
+		},
+	}
+	// 升级快照不存在时不执行 etcd 恢复
+	if err := RollbackUpgrade(aHelper, "task"); err == nil || !strings.Contains(err.Error(), "load upgrade snapshot failed") {
+		t.Fatalf("RollbackUpgrade() error = %v, want snapshot error", err)
+	}
+	if len(k.playbooks) != 0 {
+		t.Errorf("playbooks = %v, want none", k.playbooks)
+	}
+}
@@ -0,0 +1,109 @@
+package adm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/facts"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// migrateRuntimeNode 迁移 migrate-node 分组中节点运行时的 playbook，kobe 项目中不存在时任务在创建前被拒绝
+	migrateRuntimeNode  = "95-migrate-runtime.yml"
+	migrateRuntimeGroup = "migrate-node"
+
+	// 每个节点对应一个步骤，步骤名称为前缀加节点名称
+	migrateRuntimeTaskPrefix = "EnsureMigrateRuntime:"
+
+	migrateRuntimeTarget = "containerd"
+)
+
+// RuntimeMigration 容器运行时迁移参数，节点驱逐等依赖集群 API 的操作由 service 注入
+type RuntimeMigration struct {
+	Nodes      []string
+	StorageDir string
+
+	Drain    func(node string) error
+	Uncordon func(node string) error
+	Migrated func(node string) bool
+}
+
+// MigrateRuntimePlaybooks 迁移容器运行时需要的 playbook
+func MigrateRuntimePlaybooks() []string {
+	return []string{migrateRuntimeNode}
+}
+
+func (ca *ClusterAdm) migrateRuntimeHandlers(m *RuntimeMigration) []namedHandler {
+	handlers := []namedHandler{{name: "EnsureMigrateRuntimeTaskStart", handler: ca.EnsureMigrateRuntimeTaskStart}}
+	for _, n := range m.Nodes {
+		node := n
+		handlers = append(handlers, namedHandler{
+			name: migrateRuntimeTaskPrefix + node,
+			handler: func(aHelper *AnsibleHelper) error {
+				return ca.migrateRuntime(aHelper, node)
+			},
+			playbooks: []string{migrateRuntimeNode},
+		})
+	}
+	return handlers
+}
+
+// OnMigrateRuntime 逐个节点将容器运行时从 docker 迁移到 containerd
+func (ca *ClusterAdm) OnMigrateRuntime(aHelper *AnsibleHelper) error {
+	if aHelper.Migration == nil {
+		return errors.New("runtime migration options are not set")
+	}
+	return ca.runHandlers(aHelper, ca.migrateRuntimeHandlers(aHelper.Migration))
+}
+
+func (ca *ClusterAdm) EnsureMigrateRuntimeTaskStart(aHelper *AnsibleHelper) error {
+	time.Sleep(5 * time.Second)
+	writeLog("----migrate container runtime task start----", aHelper.Writer)
+	return nil
+}
+
+func (ca *ClusterAdm) migrateRuntime(aHelper *AnsibleHelper, node string) (err error) {
+	m := aHelper.Migration
+	// 服务重启或重试时跳过已经迁移完成的节点，上次执行可能在恢复调度前中断，仍然恢复调度
+	if m.Migrated(node) {
+		writeLog(fmt.Sprintf("----node %s is already running %s, skip----", node, migrateRuntimeTarget), aHelper.Writer)
+		return uncordonNode(aHelper, node)
+	}
+	// 驱逐后无论迁移是否成功都恢复调度，避免节点一直不可调度
+	defer func() {
+		if uncordonErr := uncordonNode(aHelper, node); uncordonErr != nil && err == nil {
+			err = uncordonErr
+		}
+	}()
+	writeLog("drain node "+node, aHelper.Writer)
+	if err := m.Drain(node); err != nil {
+		return fmt.Errorf("drain node %s failed: %s", node, err.Error())
+	}
+
+	aHelper.Kobe.SetVar(facts.ContainerRuntimeFactName, migrateRuntimeTarget)
+	if m.StorageDir != "" {
+		aHelper.Kobe.SetVar(facts.ContainerdStorageDirFactName, m.StorageDir)
+	}
+	aHelper.Kobe.SetGroupHosts(migrateRuntimeGroup, []string{node})
+	if err := phases.RunPlaybookAndGetResult(aHelper.Kobe, migrateRuntimeNode, "", aHelper.Writer); err != nil {
+		return err
+	}
+
+	if err := wait.Poll(healthCheckInterval, healthCheckTimeout, func() (bool, error) {
+		return m.Migrated(node), nil
+	}); err != nil {
+		return fmt.Errorf("node %s is not ready with %s after migration", node, migrateRuntimeTarget)
+	}
+	return nil
+}
+
+func uncordonNode(aHelper *AnsibleHelper, node string) error {
+	writeLog("uncordon node "+node, aHelper.Writer)
+	if err := aHelper.Migration.Uncordon(node); err != nil {
+		return fmt.Errorf("uncordon node %s failed: %s", node, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package adm
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateRuntimeUncordon(t *testing.T) {
+	tests := []struct {
+		name          string
+		migrated      bool
+		drainErr      error
+		wantErr       string
+		wantCalls     []string
+		wantPlaybooks []string
+	}{
+		{
+			name:      "already migrated",
+			migrated:  true,
+			wantCalls: []string{"uncordon"},
+		},
+		{
+			name:      "drain failed",
+			drainErr:  errors.New("pdb"),
+			wantErr:   "drain node n1 failed",
+			wantCalls: []string{"drain", "uncordon"},
+		},
+		{
+			name:          "playbook failed",
+			wantErr:       "kobe is not available",
+			wantCalls:     []string{"drain", "uncordon"},
+			wantPlaybooks: []string{migrateRuntimeNode},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			k := newFakeKobe()
+			aHelper := &AnsibleHelper{
+				Writer: io.Discard,
+				Kobe:   k,
+				Migration: &RuntimeMigration{
+					Nodes:    []string{"n1"},
+					Migrated: func(node string) bool { return tt.migrated },
+					Drain: func(node string) error {
+						calls = append(calls, "drain")
+						return tt.drainErr
+					},
+					Uncordon: func(node string) error {
+						calls = append(calls, "uncordon")
+						return nil
+					},
+				},
+			}
+			err := NewClusterAdm().migrateRuntime(aHelper, "n1")
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("migrateRuntime() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(k.playbooks, tt.wantPlaybooks) {
+				t.Errorf("playbooks = %v, want %v", k.playbooks, tt.wantPlaybooks)
+			}
+			if tt.wantPlaybooks != nil && !reflect.DeepEqual(k.groups[migrateRuntimeGroup], []string{"n1"}) {
+				t.Errorf("migrate group = %v, want [n1]", k.groups[migrateRuntimeGroup])
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package adm
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm/phases"
+)
+
+const (
+	SpecScopeConf    = "conf"
+	SpecScopeRuntime = "runtime"
+)
+
+// 配置变更使用只下发配置并重启相关服务的 playbook，不重新执行创建流程，kobe 项目中不存在时任务在创建前被拒绝
+const (
+	reconfigureBase      = "89-reconfigure-base.yml"
+	reconfigureRuntime   = "89-reconfigure-runtime.yml"
+	reconfigureKubelet   = "89-reconfigure-kubelet.yml"
+	reconfigureEtcd      = "89-reconfigure-etcd.yml"
+	reconfigureMaster    = "89-reconfigure-master.yml"
+	reconfigureKubeProxy = "89-reconfigure-kube-proxy.yml"
+)
+
+// reconfigureSteps 配置变更步骤及执行顺序
+var reconfigureSteps = []struct {
+	name     string
+	playbook string
+}{
+	{"EnsureReconfigureBaseSystem", reconfigureBase},
+	{"EnsureReconfigureContainerRuntime", reconfigureRuntime},
+	{"EnsureReconfigureKubelet", reconfigureKubelet},
+	{"EnsureReconfigureEtcd", reconfigureEtcd},
+	{"EnsureReconfigureMaster", reconfigureMaster},
+	{"EnsureReconfigureKubeProxy", reconfigureKubeProxy},
+}
+
+// specFieldPhases 运行中集群允许修改的配置项，以及修改后需要重新执行的步骤
+var specFieldPhases = map[string]map[string][]string{
+	SpecScopeConf: {
+		"yumOperate":               {"EnsureReconfigureBaseSystem"},
+		"kubeMaxPods":              {"EnsureReconfigureKubelet"},
+		"kubernetesAudit":          {"EnsureReconfigureMaster"},
+		"kubeServiceNodePortRange": {"EnsureReconfigureMaster"},
+		"kubeProxyMode":            {"EnsureReconfigureKubeProxy"},
+		"nodeportAddress":          {"EnsureReconfigureKubeProxy"},
+		"etcdSnapshotCount":        {"EnsureReconfigureEtcd"},
+		"etcdCompactionRetention":  {"EnsureReconfigureEtcd"},
+		"etcdMaxRequest":           {"EnsureReconfigureEtcd"},
+		"etcdQuotaBackend":         {"EnsureReconfigureEtcd"},
+	},
+	SpecScopeRuntime: {
+		"dockerMirrorRegistry": {"EnsureReconfigureContainerRuntime"},
+		"dockerRemoteApi":      {"EnsureReconfigureContainerRuntime"},
+	},
+}
+
+// SpecFieldPhases 返回配置项修改后需要重新执行的步骤，不允许修改时返回 false
+func SpecFieldPhases(scope, field string) ([]string, bool) {
+	phases, ok := specFieldPhases[scope][field]
+	return phases, ok
+}
+
+// specFieldValues 允许修改的配置项的取值检查，与 specFieldPhases 一一对应
+var specFieldValues = map[string]map[string]func(interface{}) error{
+	SpecScopeConf: {
+		"yumOperate":               oneOf("replace", "coexist", "no"),
+		"kubeMaxPods":              positiveInt,
+		"kubernetesAudit":          oneOf("yes", "no"),
+		"kubeServiceNodePortRange": portRange,
+		"kubeProxyMode":            oneOf("iptables", "ipvs"),
+		"nodeportAddress":          cidrList,
+		"etcdSnapshotCount":        positiveInt,
+		"etcdCompactionRetention":  positiveInt,
+		"etcdMaxRequest":           positiveInt,
+		"etcdQuotaBackend":         positiveInt,
+	},
+	SpecScopeRuntime: {
+		"dockerMirrorRegistry": oneOf("enable", "disable"),
+		"dockerRemoteApi":      oneOf("enable", "disable"),
+	},
+}
+
+// ValidateSpecField 检查配置项的新值，value 为 json 解码后的值
+func ValidateSpecField(scope, field string, value interface{}) error {
+	check, ok := specFieldValues[scope][field]
+	if !ok {
+		return fmt.Errorf("%s.%s can not be changed on a running cluster", scope, field)
+	}
+	if err := check(value); err != nil {
+		return fmt.Errorf("invalid %s.%s: %v", scope, field, err)
+	}
+	return nil
+}
+
+func oneOf(values ...string) func(interface{}) error {
+	return func(value interface{}) error {
+		s, ok := value.(string)
+		if ok {
+			for _, v := range values {
+				if s == v {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("%v is not one of %s", value, strings.Join(values, ", "))
+	}
+}
+
+func positiveInt(value interface{}) error {
+	var n float64
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	default:
+		return fmt.Errorf("%v is not an integer", value)
+	}
+	if n != math.Trunc(n) || n <= 0 {
+		return fmt.Errorf("%v is not a positive integer", value)
+	}
+	return nil
+}
+
+// portRange 检查 NodePort 端口范围，格式为 start-end
+func portRange(value interface{}) error {
+	s, _ := value.(string)
+	parts := strings.Split(s, "-")
+	if len(parts) == 2 {
+		start, err1 := strconv.Atoi(parts[0])
+		end, err2 := strconv.Atoi(parts[1])
+		if err1 == nil && err2 == nil && start > 0 && start < end && end <= 65535 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v is not a port range like 30000-32767", value)
+}
+
+// cidrList 检查以逗号分隔的网段，为空时不限制
+func cidrList(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("%v is not a string", value)
+	}
+	if s == "" {
+		return nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(item)); err != nil {
+			return fmt.Errorf("%s is not a cidr", item)
+		}
+	}
+	return nil
+}
+
+// SpecPhases 按照执行顺序返回需要执行的步骤
+func (ca *ClusterAdm) SpecPhases(names []string) []string {
+	var result []string
+	for _, h := range ca.specHandlers(names) {
+		result = append(result, h.name)
+	}
+	return result
+}
+
+// SpecPlaybooks 配置变更需要的 playbook
+func (ca *ClusterAdm) SpecPlaybooks(names []string) []string {
+	var result []string
+	for _, h := range ca.specHandlers(names) {
+		result = append(result, h.playbooks...)
+	}
+	return result
+}
+
+func (ca *ClusterAdm) specHandlers(names []string) []namedHandler {
+	selected := map[string]bool{}
+	for _, n := range names {
+		selected[n] = true
+	}
+	handlers := []namedHandler{{name: "EnsureUpdateSpecTaskStart", handler: ca.EnsureUpdateSpecTaskStart}}
+	for _, step := range reconfigureSteps {
+		if !selected[step.name] {
+			continue
+		}
+		playbook := step.playbook
+		handlers = append(handlers, namedHandler{
+			name: step.name,
+			handler: func(aHelper *AnsibleHelper) error {
+				return phases.RunPlaybookAndGetResult(aHelper.Kobe, playbook, "", aHelper.Writer)
+			},
+			playbooks: []string{playbook},
+		})
+	}
+	return handlers
+}
+
+// OnUpdateSpec 重新执行配置变更影响到的步骤
+func (ca *ClusterAdm) OnUpdateSpec(ansible *AnsibleHelper, names []string) error {
+	return ca.runHandlers(ansible, ca.specHandlers(names))
+}
+
+func (ca *ClusterAdm) EnsureUpdateSpecTaskStart(aHelper *AnsibleHelper) error {
+	time.Sleep(5 * time.Second)
+	writeLog("----update cluster spec task start----", aHelper.Writer)
+	return nil
+}
@@ -0,0 +1,101 @@
+package adm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpecPhases(t *testing.T) {
+	tests := []struct {
+		name          string
+		names         []string
+		wantPhases    []string
+		wantPlaybooks []string
+	}{
+		{
+			name:       "nothing changed",
+			wantPhases: []string{"EnsureUpdateSpecTaskStart"},
+		},
+		{
+			name:          "execution order",
+			names:         []string{"EnsureReconfigureKubeProxy", "EnsureReconfigureEtcd", "EnsureReconfigureBaseSystem", "EnsureReconfigureEtcd"},
+			wantPhases:    []string{"EnsureUpdateSpecTaskStart", "EnsureReconfigureBaseSystem", "EnsureReconfigureEtcd", "EnsureReconfigureKubeProxy"},
+			wantPlaybooks: []string{reconfigureBase, reconfigureEtcd, reconfigureKubeProxy},
+		},
+		{
+			name:          "unknown step",
+			names:         []string{"EnsureInitMaster", "EnsureReconfigureKubelet"},
+			wantPhases:    []string{"EnsureUpdateSpecTaskStart", "EnsureReconfigureKubelet"},
+			wantPlaybooks: []string{reconfigureKubelet},
+		},
+	}
+	ca := NewClusterAdm()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ca.SpecPhases(tt.names); !reflect.DeepEqual(got, tt.wantPhases) {
+				t.Errorf("SpecPhases() = %v, want %v", got, tt.wantPhases)
+			}
+			if got := ca.SpecPlaybooks(tt.names); !reflect.DeepEqual(got, tt.wantPlaybooks) {
+				t.Errorf("SpecPlaybooks() = %v, want %v", got, tt.wantPlaybooks)
+			}
+		})
+	}
+}
+
+func TestSpecFieldPhases(t *testing.T) {
+	// 所有允许修改的配置项都要有对应的变更步骤
+	steps := map[string]bool{}
+	for _, step := range reconfigureSteps {
+		steps[step.name] = true
+	}
+	for scope, fields := range specFieldPhases {
+		for field, names := range fields {
+			for _, name := range names {
+				if !steps[name] {
+					t.Errorf("%s.%s uses unknown step %s", scope, field, name)
+				}
+			}
+			if _, ok := specFieldValues[scope][field]; !ok {
+				t.Errorf("%s.%s has no value check", scope, field)
+			}
+		}
+	}
+	if _, ok := SpecFieldPhases(SpecScopeConf, "kubePodSubnet"); ok {
+		t.Errorf("kubePodSubnet should not be changeable")
+	}
+}
+
+func TestValidateSpecField(t *testing.T) {
+	tests := []struct {
+		scope   string
+		field   string
+		value   interface{}
+		wantErr bool
+	}{
+		{SpecScopeConf, "kubeProxyMode", "ipvs", false},
+		{SpecScopeConf, "kubeProxyMode", "foo", true},
+		{SpecScopeConf, "kubeProxyMode", float64(1), true},
+		{SpecScopeConf, "kubeMaxPods", float64(110), false},
+		{SpecScopeConf, "kubeMaxPods", float64(-1), true},
+		{SpecScopeConf, "kubeMaxPods", float64(1.5), true},
+		{SpecScopeConf, "kubeMaxPods", "110", true},
+		{SpecScopeConf, "kubeServiceNodePortRange", "30000-32767", false},
+		{SpecScopeConf, "kubeServiceNodePortRange", "30000", true},
+		{SpecScopeConf, "kubeServiceNodePortRange", "32767-30000", true},
+		{SpecScopeConf, "kubeServiceNodePortRange", "30000-70000", true},
+		{SpecScopeConf, "nodeportAddress", "", false},
+		{SpecScopeConf, "nodeportAddress", "10.0.0.0/8, 192.168.1.0/24", false},
+		{SpecScopeConf, "nodeportAddress", "10.0.0.1", true},
+		{SpecScopeConf, "etcdQuotaBackend", float64(8589934592), false},
+		{SpecScopeConf, "etcdSnapshotCount", float64(0), true},
+		{SpecScopeRuntime, "dockerRemoteApi", "enable", false},
+		{SpecScopeRuntime, "dockerRemoteApi", "true", true},
+		{SpecScopeConf, "kubePodSubnet", "10.244.0.0/16", true},
+	}
+	for _, tt := range tests {
+		err := ValidateSpecField(tt.scope, tt.field, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSpecField(%s, %s, %v) error = %v, wantErr %v", tt.scope, tt.field, tt.value, err, tt.wantErr)
+		}
+	}
+}
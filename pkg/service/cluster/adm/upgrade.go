@@ -2,8 +2,7 @@ package adm
 
 import (
 	"fmt"
-	"reflect"
-	"runtime"
+	"path"
 	"strings"
 	"time"
 
@@ -19,8 +18,14 @@ import (
 func (ca *ClusterAdm) Upgrade(aHelper *AnsibleHelper) error {
 	task := ca.getUpgradeCurrentTask(aHelper)
 	if task != nil {
+		if aHelper.intercept(task, ca.getNextUpgradeConditionName) {
+			return nil
+		}
 		f := ca.getUpgradeHandler(task.Task)
-		err := f(aHelper)
+		if f == nil {
+			f = missingHandler(task.Task)
+		}
+		err := aHelper.run(f)
 		if err != nil {
 			aHelper.setCondition(model.TaskLogDetail{
 				Task:          task.Task,
@@ -29,8 +34,9 @@ func (ca *ClusterAdm) Upgrade(aHelper *AnsibleHelper) error {
 				StartTime:     task.StartTime,
 				EndTime:       time.Now().Unix(),
 				Message:       err.Error(),
+				Failures:      playbookFailures(err),
 			})
-			aHelper.Status = constant.StatusFailed
+			aHelper.Status = constant.TaskLogStatusFailed
 			aHelper.Message = err.Error()
 			return nil
 		}
@@ -42,25 +48,16 @@ func (ca *ClusterAdm) Upgrade(aHelper *AnsibleHelper) error {
 			EndTime:       time.Now().Unix(),
 		})
 
-		nextConditionType := ca.getNextUpgradeConditionName(task.Task)
-		if nextConditionType == ConditionTypeDone {
-			aHelper.Status = constant.TaskLogStatusSuccess
-		} else {
-			aHelper.setCondition(model.TaskLogDetail{
-				Task:          nextConditionType,
-				Status:        constant.TaskLogStatusRunning,
-				LastProbeTime: time.Now().Unix(),
-				StartTime:     time.Now().Unix(),
-			})
-		}
+		aHelper.advance(task, ca.getNextUpgradeConditionName)
 	}
 	return nil
 }
 
 func (ca *ClusterAdm) getUpgradeCurrentTask(aHelper *AnsibleHelper) *model.TaskLogDetail {
-	if len(aHelper.LogDetail) == 0 {
+	details := aHelper.details()
+	if len(details) == 0 {
 		return &model.TaskLogDetail{
-			Task:          ca.upgradeHandlers[0].name(),
+			Task:          ca.upgradeHandlers[0].name,
 			Status:        constant.TaskLogStatusRunning,
 			LastProbeTime: time.Now().Unix(),
 			StartTime:     time.Now().Unix(),
@@ -68,7 +65,7 @@ func (ca *ClusterAdm) getUpgradeCurrentTask(aHelper *AnsibleHelper) *model.TaskL
 			Message:       "",
 		}
 	}
-	for _, task := range aHelper.LogDetail {
+	for _, task := range details {
 		if task.Status == constant.TaskLogStatusFailed || task.Status == constant.TaskLogStatusRunning {
 			return &task
 		}
@@ -77,29 +74,32 @@ func (ca *ClusterAdm) getUpgradeCurrentTask(aHelper *AnsibleHelper) *model.TaskL
 }
 
 func (ca *ClusterAdm) getUpgradeHandler(taskName string) Handler {
-	for _, f := range ca.upgradeHandlers {
-		if taskName == f.name() {
-			return f
-		}
-	}
-	return nil
+	return findHandler(ca.upgradeHandlers, taskName)
 }
-func (ca *ClusterAdm) getNextUpgradeConditionName(taskName string) string {
-	var (
-		i int
-		f Handler
-	)
-	for i, f = range ca.upgradeHandlers {
-		name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
-		if strings.Contains(name, taskName) {
-			break
-		}
-	}
-	if i == len(ca.upgradeHandlers)-1 {
-		return ConditionTypeDone
+func (ca *ClusterAdm) getNextUpgradeConditionName(taskName string) (string, error) {
+	return nextHandlerName(ca.upgradeHandlers, taskName)
+}
+
+const etcdVersionKey = "etcd_version"
+
+func getRuntimeVersionKey(runtime string) string {
+	var runtimeVersionKey = "runtime_version"
+	switch runtime {
+	case "docker":
+		runtimeVersionKey = strings.Replace(runtimeVersionKey, "runtime", "docker", -1)
+	case "containerd":
+		runtimeVersionKey = strings.Replace(runtimeVersionKey, "runtime", "containerd", -1)
 	}
-	next := ca.upgradeHandlers[i+1]
-	return next.name()
+	return runtimeVersionKey
+}
+
+// compareManifestVersion 对比升级前后版本清单中的组件版本
+func compareManifestVersion(oldManifestName, newManifestName, key string) (string, string, bool) {
+	oldManiFest, _ := GetManiFestBy(oldManifestName)
+	newManiFest, _ := GetManiFestBy(newManifestName)
+	oldVersion := oldManiFest.GetVars()[key]
+	newVersion := newManiFest.GetVars()[key]
+	return oldVersion, newVersion, version.IsNewerThan(newVersion, oldVersion)
 }
 
 func (ca *ClusterAdm) EnsureUpgradeTaskStart(aHelper *AnsibleHelper) error {
@@ -111,28 +111,20 @@ func (ca *ClusterAdm) EnsureUpgradeTaskStart(aHelper *AnsibleHelper) error {
 func (ca *ClusterAdm) EnsureBackupETCD(aHelper *AnsibleHelper) error {
 	time.Sleep(5 * time.Second)
 	phase := backup.BackupClusterPhase{}
-	return phase.Run(aHelper.Kobe, aHelper.Writer)
+	if err := phase.Run(aHelper.Kobe, aHelper.Writer); err != nil {
+		return err
+	}
+	// 复制一份升级任务专用的快照，定时备份会覆盖默认快照
+	return copyFile(path.Join(constant.BackupDir, aHelper.ClusterName, constant.BackupFileDefaultName), UpgradeSnapshotPath(aHelper.ClusterName, aHelper.TaskID))
 }
 func (ca *ClusterAdm) EnsureUpgradeRuntime(aHelper *AnsibleHelper) error {
 	time.Sleep(5 * time.Second)
 	phase := prepare.ContainerRuntimePhase{
 		Upgrade: true,
 	}
-	oldManiFest, _ := GetManiFestBy(aHelper.ClusterVersion)
-	newManiFest, _ := GetManiFestBy(aHelper.ClusterUpgradeVersion)
-	oldVars := oldManiFest.GetVars()
-	newVars := newManiFest.GetVars()
-	var runtimeVersionKey = "runtime_version"
-	switch aHelper.ClusterRuntime {
-	case "docker":
-		runtimeVersionKey = strings.Replace(runtimeVersionKey, "runtime", "docker", -1)
-	case "containerd":
-		runtimeVersionKey = strings.Replace(runtimeVersionKey, "runtime", "containerd", -1)
-	}
-	oldVersion := oldVars[runtimeVersionKey]
-	newVersion := newVars[runtimeVersionKey]
+	runtimeVersionKey := getRuntimeVersionKey(aHelper.ClusterRuntime)
+	oldVersion, newVersion, newer := compareManifestVersion(aHelper.ClusterVersion, aHelper.ClusterUpgradeVersion, runtimeVersionKey)
 	_, _ = fmt.Fprintf(aHelper.Writer, "%s -> %s", oldVersion, newVersion)
-	newer := version.IsNewerThan(newVersion, oldVersion)
 	if !newer {
 		_, _ = fmt.Fprintln(aHelper.Writer, "runtime version is newest.skip upgrade")
 		return nil
@@ -146,15 +138,8 @@ func (ca *ClusterAdm) EnsureUpgradeETCD(aHelper *AnsibleHelper) error {
 	phase := initial.EtcdPhase{
 		Upgrade: true,
 	}
-	oldManiFest, _ := GetManiFestBy(aHelper.ClusterVersion)
-	newManiFest, _ := GetManiFestBy(aHelper.ClusterUpgradeVersion)
-	oldVars := oldManiFest.GetVars()
-	newVars := newManiFest.GetVars()
-	var etcdVersionKey = "etcd_version"
-	oldVersion := oldVars[etcdVersionKey]
-	newVersion := newVars[etcdVersionKey]
+	oldVersion, newVersion, newer := compareManifestVersion(aHelper.ClusterVersion, aHelper.ClusterUpgradeVersion, etcdVersionKey)
 	_, _ = fmt.Fprintf(aHelper.Writer, "%s -> %s", oldVersion, newVersion)
-	newer := version.IsNewerThan(newVersion, oldVersion)
 	if !newer {
 		_, _ = fmt.Fprintln(aHelper.Writer, "etcd version is newest.skip upgrade")
 		return nil
@@ -177,3 +162,9 @@ func (ca *ClusterAdm) EnsureUpdateCertificates(aHelper *AnsibleHelper) error {
 	return phase.Run(aHelper.Kobe, aHelper.Writer)
 
 }
+
+// RotateCertificates 重新签发集群证书，不依赖升级流程单独执行
+func RotateCertificates(aHelper *AnsibleHelper) error {
+	phase := prepare.CertificatesPhase{}
+	return phase.Run(aHelper.Kobe, aHelper.Writer)
+}
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+	"github.com/jinzhu/gorm"
+)
+
+// adoptFactsScript 以 key=value 形式输出节点上 kubeadm 部署的关键信息
+const adoptFactsScript = `echo "hostname=$(hostname)"
+echo "arch=$(uname -m)"
+echo "kubelet=$(kubelet --version 2>/dev/null | awk '{print $2}')"
+[ -f /etc/kubernetes/manifests/kube-apiserver.yaml ] && echo "master=true"
+[ -f /etc/kubernetes/manifests/etcd.yaml ] && echo "etcd=true"
+[ -x /usr/local/bin/etcdctl ] && echo "etcdctl=true"
+[ -f /etc/kubernetes/pki/etcd/etcd.crt ] && echo "etcdCert=true"
+echo "etcdDataDir=$(grep -o -- '--data-dir=[^ "]*' /etc/kubernetes/manifests/etcd.yaml 2>/dev/null | cut -d= -f2)"
+flags=$(cat /var/lib/kubelet/kubeadm-flags.env 2>/dev/null)
+case "$flags" in
+  *containerd*) echo "runtime=containerd"; echo "runtimeDir=$(containerd config dump 2>/dev/null | awk -F'"' '/^root =/{print $2}')" ;;
+  *crio*) echo "runtime=crio" ;;
+  *) echo "runtime=docker"; echo "runtimeDir=$(docker info -f '{{.DockerRootDir}}' 2>/dev/null)" ;;
+esac
+echo "cgroupDriver=$(awk '/^cgroupDriver:/{print $2}' /var/lib/kubelet/config.yaml 2>/dev/null)"
+echo "cni=$(ls /etc/cni/net.d 2>/dev/null | tr '\n' ' ')"
+`
+
+const adoptKubectl = "kubectl --kubeconfig /etc/kubernetes/admin.conf -n kube-system get cm"
+
+type adoptFacts struct {
+	Hostname       string
+	Arch           string
+	KubeletVersion string
+	Master         bool
+	Etcd           bool
+	// EtcdCtl、EtcdCert 节点上是否有受管集群运维 etcd 使用的 etcdctl 和客户端证书
+	EtcdCtl      bool
+	EtcdCert     bool
+	EtcdDataDir  string
+	Runtime      string
+	RuntimeDir   string
+	CgroupDriver string
+	NetworkType  string
+}
+
+type ClusterAdoptService interface {
+	Adopt(clusterName string, req dto.ClusterAdopt) error
+}
+
+func NewClusterAdoptService() ClusterAdoptService {
+	return &clusterAdoptService{
+		clusterRepo:          repository.NewClusterRepository(),
+		taskLogService:       NewTaskLogService(),
+		hostService:          NewHostService(),
+		taskSchedulerService: NewTaskSchedulerService(),
+	}
+}
+
+type clusterAdoptService struct {
+	clusterRepo          repository.ClusterRepository
+	taskLogService       TaskLogService
+	hostService          HostService
+	taskSchedulerService TaskSchedulerService
+}
+
+// Adopt 通过 SSH 采集导入集群各节点的 kubeadm 部署信息，补全集群规格和主机后转为受管集群
+func (c *clusterAdoptService) Adopt(clusterName string, req dto.ClusterAdopt) error {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "SpecRuntime", "SpecNetwork", "Nodes"})
+	if err != nil {
+		return err
+	}
+	if cluster.Source != constant.ClusterSourceExternal || cluster.SpecConf.ConnectMode == constant.ConnectModeAgent {
+		return errors.New("ADOPT_NOT_SUPPORTED")
+	}
+	if cluster.Status != constant.StatusRunning {
+		return fmt.Errorf("cluster status error %s", cluster.Status)
+	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(clusterName) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
+	hosts, err := adoptHosts(cluster, req)
+	if err != nil {
+		return err
+	}
+	tasklog, err := c.taskLogService.NewTerminalTask(cluster.ID, constant.TaskLogTypeClusterAdopt)
+	if err != nil {
+		return err
+	}
+	cluster.TaskLog = *tasklog
+	cluster.CurrentTaskID = tasklog.ID
+	if err := c.clusterRepo.Save(&cluster); err != nil {
+		return err
+	}
+
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		err := c.adopt(&cluster, hosts)
+		if err != nil {
+			logger.Log.Errorf("adopt cluster %s failed: %s", cluster.Name, err.Error())
+		}
+		c.end(&cluster, err)
+		if err != nil {
+			return
+		}
+		var synchosts []dto.HostSync
+		for _, h := range hosts {
+			synchosts = append(synchosts, dto.HostSync{HostName: h.Name, HostStatus: constant.StatusRunning})
+		}
+		_ = c.hostService.SyncList(synchosts)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *clusterAdoptService) adopt(cluster *model.Cluster, hosts []model.Host) error {
+	facts, err := discoverAdoptFacts(hosts)
+	if err != nil {
+		return err
+	}
+	if err := fillAdoptSpec(cluster, hosts, facts); err != nil {
+		return err
+	}
+
+	tx := db.DB.Begin()
+	for i := range hosts {
+		hosts[i].ClusterID = cluster.ID
+		hosts[i].Status = constant.StatusRunning
+		hosts[i].Architecture = facts[i].Arch
+		if err := tx.Save(&hosts[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Create(&model.ClusterResource{
+			ResourceType: constant.ResourceHost,
+			ResourceID:   hosts[i].ID,
+			ClusterID:    cluster.ID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Create(&model.ProjectResource{
+			ResourceType: constant.ResourceHost,
+			ResourceID:   hosts[i].ID,
+			ProjectID:    cluster.ProjectID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Model(&model.ClusterNode{}).Where("id = ?", cluster.Nodes[i].ID).Updates(map[string]interface{}{
+			"host_id": hosts[i].ID,
+			"role":    cluster.Nodes[i].Role,
+			"status":  constant.StatusRunning,
+		}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Save(&cluster.SpecConf).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Save(&cluster.SpecRuntime).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Save(&cluster.SpecNetwork).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&model.Cluster{}).Where("id = ?", cluster.ID).Updates(map[string]interface{}{
+		"source":         constant.ClusterSourceLocal,
+		"provider":       constant.ClusterProviderBareMetal,
+		"node_name_rule": constant.NodeNameRuleHostName,
+		"version":        cluster.Version,
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (c *clusterAdoptService) end(cluster *model.Cluster, err error) {
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	_ = c.taskLogService.End(&cluster.TaskLog, err == nil, errMsg)
+	if e := db.DB.Model(&model.Cluster{}).Where("id = ?", cluster.ID).Updates(map[string]interface{}{
+		"current_task_id": "",
+		"message":         errMsg,
+	}).Error; e != nil {
+		logger.Log.Errorf("update cluster %s failed: %s", cluster.Name, e.Error())
+	}
+}
+
+// adoptHosts 按集群节点顺序生成主机，未绑定集群的已有主机直接复用
+func adoptHosts(cluster model.Cluster, req dto.ClusterAdopt) ([]model.Host, error) {
+	reqNodes := map[string]dto.ClusterAdoptNode{}
+	for _, n := range req.Nodes {
+		reqNodes[n.Name] = n
+	}
+	var hosts []model.Host
+	for _, node := range cluster.Nodes {
+		n, ok := reqNodes[node.Name]
+		if !ok {
+			return nil, fmt.Errorf("ssh info of node %s is required", node.Name)
+		}
+		delete(reqNodes, node.Name)
+		if net.ParseIP(n.Ip) == nil {
+			return nil, fmt.Errorf("invalid ip %s of node %s", n.Ip, n.Name)
+		}
+		credentialID := n.CredentialID
+		if credentialID == "" {
+			credentialID = req.CredentialID
+		}
+		var credential model.Credential
+		if err := db.DB.Where("id = ?", credentialID).First(&credential).Error; err != nil {
+			return nil, fmt.Errorf("credential of node %s not found", n.Name)
+		}
+		host := model.Host{Name: n.Name, Ip: n.Ip, Port: n.Port}
+		var exist model.Host
+		if err := db.DB.Where("name = ? OR ip = ?", n.Name, n.Ip).First(&exist).Error; err == nil {
+			if exist.ClusterID != "" {
+				return nil, fmt.Errorf("host %s is already used by other cluster", exist.Name)
+			}
+			host = exist
+			host.Port = n.Port
+		} else if !gorm.IsRecordNotFoundError(err) {
+			return nil, err
+		}
+		if host.Port == 0 {
+			host.Port = 22
+		}
+		host.CredentialID = credential.ID
+		host.Credential = credential
+		zoneID := n.ZoneID
+		if zoneID == "" {
+			zoneID = req.ZoneID
+		}
+		if zoneID != "" {
+			var zone model.Zone
+			if err := db.DB.Where("id = ?", zoneID).First(&zone).Error; err != nil {
+				return nil, fmt.Errorf("zone of node %s not found", n.Name)
+			}
+			host.ZoneID = zone.ID
+		}
+		if n.BastionID != "" {
+			host.BastionID = n.BastionID
+		}
+		// 主机经区域、凭据关联的跳板机连接，保存前确认跳板机可用，避免保存后无法连接
+		if _, err := host.BastionSSHConfig(); err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, host)
+	}
+	for name := range reqNodes {
+		return nil, fmt.Errorf("node %s not found in cluster %s", name, cluster.Name)
+	}
+	return hosts, nil
+}
+
+func discoverAdoptFacts(hosts []model.Host) ([]adoptFacts, error) {
+	facts := make([]adoptFacts, len(hosts))
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i := range hosts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, err := adoptExec(hosts[i], adoptFactsScript)
+			if err != nil {
+				errs[i] = fmt.Errorf("gather facts of node %s failed: %s", hosts[i].Name, err.Error())
+				return
+			}
+			facts[i] = parseAdoptFacts(out)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return facts, nil
+}
+
+// adoptNodeRoles 校验各节点的版本、运行时和 etcd 部署方式一致，返回各节点角色和第一个 master 的下标
+func adoptNodeRoles(hosts []model.Host, facts []adoptFacts) ([]string, int, error) {
+	roles := make([]string, len(facts))
+	first := -1
+	for i, f := range facts {
+		if f.KubeletVersion == "" {
+			return nil, 0, fmt.Errorf("kubelet not found on node %s", hosts[i].Name)
+		}
+		if f.KubeletVersion != facts[0].KubeletVersion {
+			return nil, 0, fmt.Errorf("kubelet version of node %s is %s, but %s expected", hosts[i].Name, f.KubeletVersion, facts[0].KubeletVersion)
+		}
+		if f.Runtime != "docker" && f.Runtime != "containerd" {
+			return nil, 0, fmt.Errorf("container runtime %s of node %s is not supported", f.Runtime, hosts[i].Name)
+		}
+		if f.Runtime != facts[0].Runtime {
+			return nil, 0, fmt.Errorf("container runtime of node %s is %s, but %s expected", hosts[i].Name, f.Runtime, facts[0].Runtime)
+		}
+		roles[i] = constant.NodeRoleNameWorker
+		if f.Master {
+			// 受管集群的 etcd 与 master 同节点部署
+			if !f.Etcd {
+				return nil, 0, errors.New("ADOPT_EXTERNAL_ETCD_NOT_SUPPORTED")
+			}
+			// 增删 master、备份等操作通过 /usr/local/bin/etcdctl 和 pki/etcd/etcd.crt 访问 etcd，
+			// kubeadm 默认只在容器中提供 etcdctl 并使用 server.crt，不满足时拒绝接管
+			if !f.EtcdCtl || !f.EtcdCert {
+				return nil, 0, errors.New("ADOPT_ETCD_LAYOUT_NOT_SUPPORTED")
+			}
+			roles[i] = constant.NodeRoleNameMaster
+			if first == -1 {
+				first = i
+			}
+		}
+	}
+	if first == -1 {
+		return nil, 0, errors.New("no master node found")
+	}
+	return roles, first, nil
+}
+
+// fillAdoptSpec 校验各节点信息一致后，按 kubeadm-config 和 kube-proxy 配置补全集群规格
+func fillAdoptSpec(cluster *model.Cluster, hosts []model.Host, facts []adoptFacts) error {
+	roles, first, err := adoptNodeRoles(hosts, facts)
+	if err != nil {
+		return err
+	}
+	for i := range roles {
+		cluster.Nodes[i].Role = roles[i]
+	}
+
+	var manifest model.ClusterManifest
+	if err := db.DB.Where("version = ?", facts[0].KubeletVersion).Order("created_at ASC").First(&manifest).Error; err != nil {
+		return fmt.Errorf("no manifest matches kubernetes version %s", facts[0].KubeletVersion)
+	}
+	cluster.Version = manifest.Name
+
+	out, err := adoptExec(hosts[first], adoptKubectl+" kubeadm-config -o jsonpath='{.data.ClusterConfiguration}'")
+	if err != nil {
+		return fmt.Errorf("can not load kubeadm-config from node %s: %s", hosts[first].Name, err.Error())
+	}
+	adm, err := parseKubeadmConfig(out)
+	if err != nil {
+		return err
+	}
+	if adm.Etcd.External != nil {
+		return errors.New("ADOPT_EXTERNAL_ETCD_NOT_SUPPORTED")
+	}
+	out, err = adoptExec(hosts[first], adoptKubectl+" kube-proxy -o jsonpath='{.data.config\\.conf}'")
+	if err != nil {
+		return fmt.Errorf("can not load kube-proxy from node %s: %s", hosts[first].Name, err.Error())
+	}
+	proxy, err := parseKubeProxyConfig(out)
+	if err != nil {
+		return err
+	}
+
+	conf := &cluster.SpecConf
+	conf.LbMode = constant.LbModeInternal
+	conf.LbKubeApiserverIp = hosts[first].Ip
+	conf.KubeApiServerPort = 6443
+	if adm.ControlPlaneEndpoint != "" {
+		endpoint, port, err := net.SplitHostPort(adm.ControlPlaneEndpoint)
+		if err != nil {
+			endpoint = adm.ControlPlaneEndpoint
+		} else {
+			conf.KubeApiServerPort, _ = strconv.Atoi(port)
+		}
+		isMaster := false
+		for i, f := range facts {
+			if f.Master && (hosts[i].Ip == endpoint || f.Hostname == endpoint) {
+				isMaster = true
+			}
+		}
+		if endpoint != "127.0.0.1" && !isMaster {
+			conf.LbMode = constant.LbModeExternal
+			conf.LbKubeApiserverIp = endpoint
+		}
+	}
+	if conf.KubeRouter == "" {
+		conf.KubeRouter = hosts[first].Ip
+	}
+	conf.KubePodSubnet = adm.Network.PodSubnet
+	conf.KubeServiceSubnet = adm.Network.ServiceSubnet
+	conf.KubeDnsDomain = adm.Network.DnsDomain
+	conf.KubeServiceNodePortRange = adm.ApiServer.ExtraArgs.ServiceNodePortRange
+	if conf.KubeServiceNodePortRange == "" {
+		conf.KubeServiceNodePortRange = "30000-32767"
+	}
+	mask, _ := strconv.Atoi(adm.Controller.ExtraArgs.NodeCidrMaskSize)
+	if mask == 0 {
+		mask = 24
+	}
+	conf.KubeNetworkNodePrefix = mask
+	conf.KubeMaxPods = clusterUtil.MaxNodePodNumMap[mask]
+	if strings.Contains(conf.KubePodSubnet, "/") {
+		podMask, _ := strconv.Atoi(strings.Split(conf.KubePodSubnet, "/")[1])
+		conf.MaxNodeNum = (2 << (31 - podMask)) / (2 << (31 - mask))
+	}
+	conf.KubernetesAudit = "no"
+	if adm.ApiServer.ExtraArgs.AuditLogPath != "" {
+		conf.KubernetesAudit = "yes"
+	}
+	conf.KubeProxyMode = proxy.Mode
+	if conf.KubeProxyMode == "" {
+		conf.KubeProxyMode = "iptables"
+	}
+	conf.NodeportAddress = proxy.NodePortAddresses
+	conf.CgroupDriver = facts[first].CgroupDriver
+	conf.EtcdDataDir = facts[first].EtcdDataDir
+	if adm.Etcd.Local != nil && adm.Etcd.Local.DataDir != "" {
+		conf.EtcdDataDir = adm.Etcd.Local.DataDir
+	}
+	if conf.YumOperate == "" {
+		conf.YumOperate = "replace"
+	}
+	if conf.EtcdSnapshotCount == 0 {
+		conf.EtcdSnapshotCount = 50000
+		conf.EtcdCompactionRetention = 1
+		conf.EtcdMaxRequest = 10
+		conf.EtcdQuotaBackend = 8
+	}
+
+	runtime := &cluster.SpecRuntime
+	runtime.RuntimeType = facts[0].Runtime
+	if runtime.RuntimeType == "docker" {
+		runtime.DockerStorageDir = facts[0].RuntimeDir
+	} else {
+		runtime.ContainerdStorageDir = facts[0].RuntimeDir
+	}
+	if runtime.HelmVersion == "" {
+		runtime.HelmVersion = "v3"
+	}
+
+	for _, f := range facts {
+		if f.NetworkType != "" {
+			cluster.SpecNetwork.NetworkType = f.NetworkType
+			break
+		}
+	}
+	if cluster.SpecNetwork.NetworkType == "" {
+		return errors.New("can not detect network plugin of cluster")
+	}
+	return nil
+}
+
+func adoptExec(host model.Host, cmd string) (string, error) {
+	if _, _, err := host.GetHostPasswordAndPrivateKey(); err != nil {
+		return "", err
+	}
+	cfg, err := host.ToSSHConfig()
+	if err != nil {
+		return "", err
+	}
+	cfg.Retry = 1
+	client, err := ssh.New(&cfg)
+	if err != nil {
+		return "", err
+	}
+	out, err := client.CombinedOutput(cmd)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func parseAdoptFacts(out string) adoptFacts {
+	var f adoptFacts
+	for _, line := range strings.Split(out, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(kv[1])
+		switch kv[0] {
+		case "hostname":
+			f.Hostname = value
+		case "arch":
+			f.Arch = value
+		case "kubelet":
+			f.KubeletVersion = value
+		case "master":
+			f.Master = value == "true"
+		case "etcd":
+			f.Etcd = value == "true"
+		case "etcdctl":
+			f.EtcdCtl = value == "true"
+		case "etcdCert":
+			f.EtcdCert = value == "true"
+		case "etcdDataDir":
+			f.EtcdDataDir = value
+		case "runtime":
+			f.Runtime = value
+		case "runtimeDir":
+			f.RuntimeDir = value
+		case "cgroupDriver":
+			f.CgroupDriver = value
+		case "cni":
+			for _, name := range strings.Fields(value) {
+				for _, plugin := range []string{"calico", "flannel", "cilium"} {
+					if strings.Contains(name, plugin) {
+						f.NetworkType = plugin
+					}
+				}
+			}
+		}
+	}
+	if f.CgroupDriver == "" {
+		f.CgroupDriver = "cgroupfs"
+	}
+	return f
+}
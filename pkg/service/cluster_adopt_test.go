@@ -0,0 +1,107 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+)
+
+func TestParseAdoptFacts(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want adoptFacts
+	}{
+		{
+			name: "master with containerd",
+			out: `hostname=node1
+arch=x86_64
+kubelet=v1.20.6
+master=true
+etcd=true
+etcdctl=true
+etcdCert=true
+etcdDataDir=/var/lib/etcd
+runtime=containerd
+runtimeDir=/var/lib/containerd
+cgroupDriver=systemd
+cni=10-calico.conflist calico-kubeconfig
+`,
+			want: adoptFacts{Hostname: "node1", Arch: "x86_64", KubeletVersion: "v1.20.6", Master: true, Etcd: true, EtcdCtl: true, EtcdCert: true,
+				EtcdDataDir: "/var/lib/etcd", Runtime: "containerd", RuntimeDir: "/var/lib/containerd", CgroupDriver: "systemd", NetworkType: "calico"},
+		},
+		{
+			name: "worker with docker",
+			out: `hostname=node2
+arch=aarch64
+kubelet=v1.20.6
+etcdDataDir=
+runtime=docker
+runtimeDir=/data/docker
+cgroupDriver=
+cni=10-flannel.conflist
+`,
+			want: adoptFacts{Hostname: "node2", Arch: "aarch64", KubeletVersion: "v1.20.6",
+				Runtime: "docker", RuntimeDir: "/data/docker", CgroupDriver: "cgroupfs", NetworkType: "flannel"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAdoptFacts(tt.out); got != tt.want {
+				t.Errorf("parseAdoptFacts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdoptNodeRoles(t *testing.T) {
+	master := adoptFacts{KubeletVersion: "v1.20.6", Runtime: "containerd", Master: true, Etcd: true, EtcdCtl: true, EtcdCert: true}
+	worker := adoptFacts{KubeletVersion: "v1.20.6", Runtime: "containerd"}
+	kubeadmMaster := adoptFacts{KubeletVersion: "v1.20.6", Runtime: "containerd", Master: true, Etcd: true}
+	oldWorker := adoptFacts{KubeletVersion: "v1.19.8", Runtime: "containerd"}
+	crioWorker := adoptFacts{KubeletVersion: "v1.20.6", Runtime: "crio"}
+	externalEtcd := adoptFacts{KubeletVersion: "v1.20.6", Runtime: "containerd", Master: true}
+
+	tests := []struct {
+		name      string
+		facts     []adoptFacts
+		wantRoles []string
+		wantFirst int
+		wantErr   string
+	}{
+		{
+			name:      "worker before master",
+			facts:     []adoptFacts{worker, master, master},
+			wantRoles: []string{constant.NodeRoleNameWorker, constant.NodeRoleNameMaster, constant.NodeRoleNameMaster},
+			wantFirst: 1,
+		},
+		{name: "kubeadm etcd layout", facts: []adoptFacts{kubeadmMaster, worker}, wantErr: "ADOPT_ETCD_LAYOUT_NOT_SUPPORTED"},
+		{name: "external etcd", facts: []adoptFacts{externalEtcd}, wantErr: "ADOPT_EXTERNAL_ETCD_NOT_SUPPORTED"},
+		{name: "version mismatch", facts: []adoptFacts{master, oldWorker}, wantErr: "kubelet version of node n1 is v1.19.8, but v1.20.6 expected"},
+		{name: "unsupported runtime", facts: []adoptFacts{master, crioWorker}, wantErr: "container runtime crio of node n1 is not supported"},
+		{name: "no master", facts: []adoptFacts{worker}, wantErr: "no master node found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := make([]model.Host, len(tt.facts))
+			for i := range hosts {
+				hosts[i].Name = "n" + string(rune('0'+i))
+			}
+			roles, first, err := adoptNodeRoles(hosts, tt.facts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("adoptNodeRoles() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("adoptNodeRoles() error = %v", err)
+			}
+			if !reflect.DeepEqual(roles, tt.wantRoles) || first != tt.wantFirst {
+				t.Errorf("adoptNodeRoles() = %v, %d, want %v, %d", roles, first, tt.wantRoles, tt.wantFirst)
+			}
+		})
+	}
+}
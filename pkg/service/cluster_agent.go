@@ -0,0 +1,384 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/tunnel"
+	"github.com/gorilla/websocket"
+	"github.com/jinzhu/gorm"
+	"github.com/spf13/viper"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	defaultAgentImage  = "clusteroperator/server:master"
+	agentVerifyTimeout = 10 * time.Second
+)
+
+var agentServiceAccount = fmt.Sprintf("system:serviceaccount:%s:ko-agent", constant.DefaultNamespace)
+
+// agentManifestTemplate agent 的 ServiceAccount 在集群范围内只读，只能调度节点、驱逐 Pod 和管理 StorageClass，
+// 在部署组件的命名空间内拥有 admin 权限；需要集群级 RBAC 的工具由集群管理员另行授权
+var agentManifestTemplate = template.Must(template.New("agent").Parse(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: ko-agent
+  namespace: {{ .Namespace }}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: ko-agent-kube-token
+  namespace: {{ .Namespace }}
+  annotations:
+    kubernetes.io/service-account.name: ko-agent
+type: kubernetes.io/service-account-token
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: ko-agent
+  namespace: {{ .Namespace }}
+type: Opaque
+stringData:
+  token: "{{ .Token }}"
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: ko-agent
+rules:
+- apiGroups: [""]
+  resources: ["nodes", "namespaces", "pods", "pods/log", "services", "endpoints", "events", "configmaps", "serviceaccounts", "persistentvolumes", "persistentvolumeclaims", "componentstatuses"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["apps", "batch", "networking.k8s.io", "storage.k8s.io", "metrics.k8s.io", "apiextensions.k8s.io"]
+  resources: ["*"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["patch", "update"]
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["delete"]
+- apiGroups: [""]
+  resources: ["pods/eviction"]
+  verbs: ["create"]
+- apiGroups: [""]
+  resources: ["namespaces"]
+  verbs: ["create"]
+- apiGroups: ["storage.k8s.io"]
+  resources: ["storageclasses"]
+  verbs: ["create", "update", "patch", "delete"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: ko-agent
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: ko-agent
+subjects:
+- kind: ServiceAccount
+  name: ko-agent
+  namespace: {{ .Namespace }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: ko-agent
+  namespace: {{ .Namespace }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: admin
+subjects:
+- kind: ServiceAccount
+  name: ko-agent
+  namespace: {{ .Namespace }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ko-agent
+  namespace: {{ .Namespace }}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: ko-agent
+  template:
+    metadata:
+      labels:
+        app: ko-agent
+    spec:
+      serviceAccountName: ko-agent
+      containers:
+      - name: agent
+        image: {{ .Image }}
+        command: ["ko-agent"]
+        env:
+        - name: KO_AGENT_SERVER
+          value: "{{ .Server }}"
+        - name: KO_AGENT_CLUSTER
+          value: "{{ .Name }}"
+        - name: KO_AGENT_TOKEN
+          valueFrom:
+            secretKeyRef:
+              name: ko-agent
+              key: token
+        - name: KO_AGENT_KUBE_TOKEN_FILE
+          value: /var/run/ko-agent/token
+        volumeMounts:
+        - name: kube-token
+          mountPath: /var/run/ko-agent
+          readOnly: true
+      volumes:
+      - name: kube-token
+        secret:
+          secretName: ko-agent-kube-token
+`))
+
+type ClusterAgentService interface {
+	Get(clusterName string) (*dto.ClusterAgent, error)
+	Create(clusterName string, req dto.ClusterAgentCreate) (*dto.ClusterAgent, error)
+	Authenticate(clusterName, token string) error
+	Serve(clusterName, kubeToken, remoteAddr string, ws *websocket.Conn)
+}
+
+func NewClusterAgentService() ClusterAgentService {
+	return &clusterAgentService{}
+}
+
+type clusterAgentService struct {
+}
+
+// Get 只返回连接状态，token 只保存摘要，无法再次生成部署清单
+func (c *clusterAgentService) Get(clusterName string) (*dto.ClusterAgent, error) {
+	agent, err := getClusterAgent(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return toClusterAgentDTO(agent, "")
+}
+
+// Create 生成 agent 的连接 token 和部署清单，重复调用会重新生成 token，已部署的 agent 需要重新部署
+func (c *clusterAgentService) Create(clusterName string, req dto.ClusterAgentCreate) (*dto.ClusterAgent, error) {
+	u, err := url.Parse(req.ServerUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("AGENT_SERVER_URL_INVALID")
+	}
+	agent, err := getClusterAgent(clusterName)
+	if err != nil && err.Error() != "CLUSTER_AGENT_NOT_FOUND" {
+		return nil, err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+	agent.Name = clusterName
+	agent.Token = hashAgentToken(token)
+	agent.ServerUrl = req.ServerUrl
+	if err := db.DB.Save(&agent).Error; err != nil {
+		return nil, err
+	}
+	return toClusterAgentDTO(agent, token)
+}
+
+func (c *clusterAgentService) Authenticate(clusterName, token string) error {
+	agent, err := getClusterAgent(clusterName)
+	if err != nil {
+		return err
+	}
+	if token == "" || subtle.ConstantTimeCompare([]byte(agent.Token), []byte(hashAgentToken(token))) != 1 {
+		return errors.New("AGENT_TOKEN_INVALID")
+	}
+	return nil
+}
+
+// Serve 登记隧道并阻塞到连接断开，agent 上报的 ServiceAccount token 通过隧道校验后才会保存，并同步到已导入集群
+func (c *clusterAgentService) Serve(clusterName, kubeToken, remoteAddr string, ws *websocket.Conn) {
+	session, err := tunnel.Register(clusterName, ws)
+	if err != nil {
+		logger.Log.Errorf("register agent of cluster %s failed: %s", clusterName, err.Error())
+		_ = ws.Close()
+		return
+	}
+	logger.Log.Infof("agent of cluster %s connected from %s", clusterName, remoteAddr)
+	if kubeToken != "" {
+		if err := verifyAgentKubeToken(clusterName, kubeToken); err != nil {
+			logger.Log.Warnf("kube token reported by agent of cluster %s is rejected: %s", clusterName, err.Error())
+			kubeToken = ""
+		}
+	}
+	now := time.Now()
+	updates := map[string]interface{}{
+		"connected":         true,
+		"remote_addr":       remoteAddr,
+		"last_connected_at": &now,
+	}
+	if kubeToken != "" {
+		updates["kube_token"] = kubeToken
+	}
+	if err := db.DB.Model(&model.ClusterAgent{}).Where("name = ?", clusterName).Updates(updates).Error; err != nil {
+		logger.Log.Errorf("update agent of cluster %s failed: %s", clusterName, err.Error())
+	}
+	if kubeToken != "" {
+		var cluster model.Cluster
+		if err := db.DB.Preload("SpecConf").Where("name = ?", clusterName).First(&cluster).Error; err == nil &&
+			cluster.SpecConf.ConnectMode == constant.ConnectModeAgent {
+			if err := db.DB.Model(&model.ClusterSecret{}).Where("id = ?", cluster.SecretID).
+				Updates(map[string]interface{}{"kubernetes_token": kubeToken}).Error; err != nil {
+				logger.Log.Errorf("update secret of cluster %s failed: %s", clusterName, err.Error())
+			}
+		}
+	}
+
+	<-session.CloseChan()
+	logger.Log.Infof("agent of cluster %s disconnected", clusterName)
+	if !tunnel.Connected(clusterName) {
+		if err := db.DB.Model(&model.ClusterAgent{}).Where("name = ?", clusterName).
+			Updates(map[string]interface{}{"connected": false}).Error; err != nil {
+			logger.Log.Errorf("update agent of cluster %s failed: %s", clusterName, err.Error())
+		}
+	}
+}
+
+// agentApiServer 返回通过 agent 访问集群 apiserver 的地址和 agent 上报的 token
+func agentApiServer(clusterName string) (string, string, error) {
+	agent, err := getClusterAgent(clusterName)
+	if err != nil {
+		return "", "", err
+	}
+	if !tunnel.Connected(clusterName) {
+		return "", "", tunnel.ErrNotConnected
+	}
+	if agent.KubeToken == "" {
+		return "", "", errors.New("AGENT_KUBE_TOKEN_MISSING")
+	}
+	return fmt.Sprintf("%s:%d", constant.AgentKubeApiserverIp, constant.AgentKubeApiserverPort), agent.KubeToken, nil
+}
+
+// verifyAgentKubeToken 上报的 token 必须属于 agent 的 ServiceAccount，并且能够通过隧道访问 apiserver
+func verifyAgentKubeToken(clusterName, kubeToken string) error {
+	subject, err := agentTokenSubject(kubeToken)
+	if err != nil {
+		return err
+	}
+	if subject != agentServiceAccount {
+		return fmt.Errorf("token subject %s is not %s", subject, agentServiceAccount)
+	}
+	conf := &rest.Config{
+		Host:        fmt.Sprintf("https://%s:%d", constant.AgentKubeApiserverIp, constant.AgentKubeApiserverPort),
+		BearerToken: kubeToken,
+		Timeout:     agentVerifyTimeout,
+		Dial:        tunnel.Dialer(clusterName),
+	}
+	conf.Insecure = true
+	client, err := kubernetes.NewForConfig(conf)
+	if err != nil {
+		return err
+	}
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "list", Resource: "nodes"},
+		},
+	}
+	result, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !result.Status.Allowed {
+		return errors.New("token is not allowed to list nodes")
+	}
+	return nil
+}
+
+// agentTokenSubject 解析 ServiceAccount token 中的 sub，签名由 apiserver 校验
+func agentTokenSubject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("token is not a jwt")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("decode token payload failed: %v", err)
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("decode token payload failed: %v", err)
+	}
+	return claims.Subject, nil
+}
+
+func hashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func getClusterAgent(clusterName string) (model.ClusterAgent, error) {
+	var agent model.ClusterAgent
+	if err := db.DB.Where("name = ?", clusterName).First(&agent).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return agent, errors.New("CLUSTER_AGENT_NOT_FOUND")
+		}
+		return agent, err
+	}
+	return agent, nil
+}
+
+// toClusterAgentDTO token 为空时不生成部署清单
+func toClusterAgentDTO(agent model.ClusterAgent, token string) (*dto.ClusterAgent, error) {
+	// 数据库中的状态在服务重启后可能过期，以当前隧道为准
+	agent.Connected = tunnel.Connected(agent.Name)
+	item := &dto.ClusterAgent{ClusterAgent: agent}
+	if token == "" {
+		return item, nil
+	}
+	image := viper.GetString("agent.image")
+	if image == "" {
+		image = defaultAgentImage
+	}
+	var buf bytes.Buffer
+	if err := agentManifestTemplate.Execute(&buf, map[string]string{
+		"Namespace": constant.DefaultNamespace,
+		"Name":      agent.Name,
+		"Token":     token,
+		"Server":    agent.ServerUrl,
+		"Image":     image,
+	}); err != nil {
+		return nil, err
+	}
+	item.Token = token
+	item.Manifest = buf.String()
+	return item, nil
+}
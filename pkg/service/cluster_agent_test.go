@@ -0,0 +1,42 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestAgentTokenSubject(t *testing.T) {
+	jwt := func(payload string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr bool
+	}{
+		{name: "service account", token: jwt(`{"sub":"system:serviceaccount:kube-operator:ko-agent"}`), want: "system:serviceaccount:kube-operator:ko-agent"},
+		{name: "no subject", token: jwt(`{}`)},
+		{name: "not jwt", token: "plain-token", wantErr: true},
+		{name: "bad payload", token: "e30.!!!.sig", wantErr: true},
+		{name: "bad json", token: jwt(`not json`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := agentTokenSubject(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("agentTokenSubject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("agentTokenSubject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashAgentToken(t *testing.T) {
+	// 与迁移脚本中 MySQL SHA2(token, 256) 的结果一致
+	if got := hashAgentToken("abc"); got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("hashAgentToken() = %s", got)
+	}
+}
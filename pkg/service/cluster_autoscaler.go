@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	"github.com/jinzhu/gorm"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultScaleUpCooldown      = 5
+	defaultScaleDownCooldown    = 30
+	defaultScaleDownUtilization = 50
+)
+
+type ClusterAutoscalerService interface {
+	Get(clusterName string) (*dto.ClusterAutoscaler, error)
+	Save(clusterName string, req dto.ClusterAutoscalerUpdate) (*dto.ClusterAutoscaler, error)
+	Reconcile()
+}
+
+func NewClusterAutoscalerService() ClusterAutoscalerService {
+	return &clusterAutoscalerService{
+		clusterRepo:        repository.NewClusterRepository(),
+		clusterNodeService: NewClusterNodeService(),
+		taskLogService:     NewTaskLogService(),
+		msgService:         NewMsgService(),
+	}
+}
+
+type clusterAutoscalerService struct {
+	clusterRepo        repository.ClusterRepository
+	clusterNodeService ClusterNodeService
+	taskLogService     TaskLogService
+	msgService         MsgService
+}
+
+// scaleDecision 一次伸缩的结果，increase 和 nodes 最多设置一个
+type scaleDecision struct {
+	increase int
+	nodes    []string
+	event    string
+}
+
+// autoscaleState 伸缩判断所需的集群状态，kubeNodes 只包含节点池中的节点，nodes 为集群全部节点
+type autoscaleState struct {
+	kubeNodes map[string]v1.Node
+	nodes     []v1.Node
+	pods      []v1.Pod
+	pdbs      []policyv1beta1.PodDisruptionBudget
+	claims    map[string]*v1.NodeSelector
+	template  clusterUtil.NodeTemplate
+}
+
+func (c *clusterAutoscalerService) Get(clusterName string) (*dto.ClusterAutoscaler, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"Nodes"})
+	if err != nil {
+		return nil, err
+	}
+	item, err := getAutoscaler(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ClusterAutoscaler{
+		ClusterAutoscaler: item,
+		CurrentSize:       len(autoscaleMembers(cluster.Nodes, item.NodePool)),
+	}, nil
+}
+
+func (c *clusterAutoscalerService) Save(clusterName string, req dto.ClusterAutoscalerUpdate) (*dto.ClusterAutoscaler, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"Nodes"})
+	if err != nil {
+		return nil, err
+	}
+	if cluster.Provider != constant.ClusterProviderPlan {
+		return nil, errors.New("AUTOSCALER_NOT_SUPPORTED")
+	}
+	if req.MinSize < 0 || req.MaxSize <= 0 || req.MinSize > req.MaxSize {
+		return nil, errors.New("AUTOSCALER_SIZE_INVALID")
+	}
+	if req.ScaleUpCooldown < 0 || req.ScaleDownCooldown < 0 || req.ScaleDownUtilization < 0 || req.ScaleDownUtilization > 100 {
+		return nil, fmt.Errorf("invalid autoscaler cooldown or utilization")
+	}
+	if req.NodePool != "" {
+		if _, err := getNodePool(cluster.ID, req.NodePool); err != nil {
+			return nil, err
+		}
+	}
+	if req.Enabled {
+		var count int
+		if err := db.DB.Model(&model.ClusterSchedule{}).
+			Where("cluster_id = ? AND action = ? AND node_pool = ? AND enabled = ?", cluster.ID, constant.ScheduleActionScale, req.NodePool, true).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, errors.New("SCHEDULE_AUTOSCALER_CONFLICT")
+		}
+	}
+	item, err := getAutoscaler(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	item.Enabled = req.Enabled
+	item.NodePool = req.NodePool
+	item.MinSize = req.MinSize
+	item.MaxSize = req.MaxSize
+	item.ScaleUpCooldown = req.ScaleUpCooldown
+	item.ScaleDownCooldown = req.ScaleDownCooldown
+	item.ScaleDownUtilization = req.ScaleDownUtilization
+	if err := db.DB.Save(&item).Error; err != nil {
+		return nil, err
+	}
+	return &dto.ClusterAutoscaler{
+		ClusterAutoscaler: item,
+		CurrentSize:       len(autoscaleMembers(cluster.Nodes, item.NodePool)),
+	}, nil
+}
+
+// Reconcile 检查所有开启自动伸缩的集群，由定时任务调用
+func (c *clusterAutoscalerService) Reconcile() {
+	var items []model.ClusterAutoscaler
+	if err := db.DB.Where("enabled = ?", true).Find(&items).Error; err != nil {
+		logger.Log.Errorf("list cluster autoscalers failed: %s", err.Error())
+		return
+	}
+	for i := range items {
+		if err := c.reconcile(&items[i]); err != nil {
+			logger.Log.Errorf("autoscale cluster %s failed: %s", items[i].ClusterID, err.Error())
+		}
+	}
+}
+
+func (c *clusterAutoscalerService) reconcile(item *model.ClusterAutoscaler) error {
+	var m model.Cluster
+	if err := db.DB.Where("id = ?", item.ClusterID).First(&m).Error; err != nil {
+		return err
+	}
+	cluster, err := c.clusterRepo.GetWithPreload(m.Name, []string{"SpecConf", "Secret", "Nodes", "Nodes.Host", "Nodes.Host.Credential"})
+	if err != nil {
+		return err
+	}
+	// 集群有任务执行或节点状态未稳定时跳过，下个周期再检查
+	if cluster.Status != constant.StatusRunning || c.taskLogService.IsTaskOn(cluster.Name) {
+		return nil
+	}
+	for _, n := range cluster.Nodes {
+		if n.Status != constant.StatusRunning {
+			return nil
+		}
+	}
+
+	client, err := clusterUtil.NewClusterClient(&cluster)
+	if err != nil {
+		return err
+	}
+	pods, err := client.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	pdbs, err := client.PolicyV1beta1().PodDisruptionBudgets("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	pvcs, err := client.CoreV1().PersistentVolumeClaims("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	pvs, err := client.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var pool *model.ClusterNodePool
+	if item.NodePool != "" {
+		p, err := getNodePool(cluster.ID, item.NodePool)
+		if err != nil {
+			return err
+		}
+		pool = &p
+	}
+	members := autoscaleMembers(cluster.Nodes, item.NodePool)
+	state := autoscaleState{
+		kubeNodes: map[string]v1.Node{},
+		nodes:     nodes.Items,
+		pods:      pods.Items,
+		pdbs:      pdbs.Items,
+		claims:    clusterUtil.ClaimAffinities(pvcs.Items, pvs.Items),
+	}
+	for _, n := range members {
+		for _, node := range nodes.Items {
+			if node.Name == n.Name {
+				state.kubeNodes[n.Name] = node
+			}
+		}
+	}
+	state.template = autoscaleTemplate(pool, members, state.kubeNodes)
+
+	decision := decideScale(*item, members, state, time.Now())
+	if decision == nil {
+		return nil
+	}
+	return c.scale(&cluster, item, *decision)
+}
+
+func (c *clusterAutoscalerService) scale(cluster *model.Cluster, item *model.ClusterAutoscaler, decision scaleDecision) error {
+	batch := dto.NodeBatch{Pool: item.NodePool}
+	if decision.increase > 0 {
+		batch.Operation = constant.BatchOperationCreate
+		batch.Increase = decision.increase
+	} else {
+		batch.Operation = constant.BatchOperationDelete
+		batch.Nodes = decision.nodes
+	}
+	logger.Log.Infof("autoscale cluster %s: %s", cluster.Name, decision.event)
+
+	now := time.Now()
+	item.LastEvent = decision.event
+	if decision.increase > 0 {
+		item.LastScaleUpAt = &now
+	} else {
+		item.LastScaleDownAt = &now
+	}
+	err := c.clusterNodeService.Batch(cluster.Name, batch)
+	if err != nil {
+		item.LastEvent = fmt.Sprintf("%s，执行失败: %s", decision.event, err.Error())
+	} else {
+		item.LastTaskID = currentTaskID(cluster.Name)
+	}
+	if e := db.DB.Save(item).Error; e != nil {
+		logger.Log.Errorf("save cluster autoscaler failed: %s", e.Error())
+	}
+	content := map[string]string{"message": item.LastEvent, "detailName": cluster.Name}
+	if err != nil {
+		content["errMsg"] = err.Error()
+	}
+	_ = c.msgService.SendMsg(constant.ClusterAutoscale, constant.Cluster, cluster, err == nil, content)
+	return err
+}
+
+// decideScale 节点数超出上下限时调整到边界；有 pod 无法调度且能调度到节点池新增节点时扩容；
+// 没有等待调度的 pod 时每次缩容一个利用率最低、可驱逐、不违反 PodDisruptionBudget 且 pod 能调度到其他节点的节点
+func decideScale(item model.ClusterAutoscaler, members []model.ClusterNode, state autoscaleState, now time.Time) *scaleDecision {
+	size := len(members)
+	if size < item.MinSize {
+		if cooling(item.LastScaleUpAt, item.ScaleUpCooldown, now) {
+			return nil
+		}
+		return &scaleDecision{increase: item.MinSize - size, event: fmt.Sprintf("节点数 %d 低于最小值 %d，扩容 %d 个节点", size, item.MinSize, item.MinSize-size)}
+	}
+	if size > item.MaxSize {
+		if cooling(item.LastScaleDownAt, item.ScaleDownCooldown, now) {
+			return nil
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].CreatedAt.After(members[j].CreatedAt) })
+		var names []string
+		for _, n := range members[:size-item.MaxSize] {
+			names = append(names, n.Name)
+		}
+		return &scaleDecision{nodes: names, event: fmt.Sprintf("节点数 %d 高于最大值 %d，缩容节点 %v", size, item.MaxSize, names)}
+	}
+
+	pending := clusterUtil.UnschedulablePods(state.pods)
+	if len(pending) > 0 {
+		// 新增节点也无法调度的 pod 不触发扩容，同时也不缩容
+		unschedulable := clusterUtil.FitTemplate(pending, state.template, state.claims)
+		if len(unschedulable) == 0 || size >= item.MaxSize || cooling(item.LastScaleUpAt, item.ScaleUpCooldown, now) {
+			return nil
+		}
+		allocatable := v1.ResourceList{}
+		for _, n := range state.kubeNodes {
+			allocatable = n.Status.Allocatable
+			break
+		}
+		increase := clusterUtil.EstimateNodes(unschedulable, allocatable)
+		if increase > item.MaxSize-size {
+			increase = item.MaxSize - size
+		}
+		return &scaleDecision{increase: increase, event: fmt.Sprintf("%d 个 pod 无法调度，扩容 %d 个节点", len(unschedulable), increase)}
+	}
+
+	if size <= item.MinSize || cooling(item.LastScaleUpAt, item.ScaleDownCooldown, now) || cooling(item.LastScaleDownAt, item.ScaleDownCooldown, now) {
+		return nil
+	}
+	candidate, lowest := "", item.ScaleDownUtilization
+	for _, n := range members {
+		node, ok := state.kubeNodes[n.Name]
+		if !ok || !clusterUtil.Evictable(n.Name, state.pods) {
+			continue
+		}
+		u := clusterUtil.NodeUtilization(node, state.pods)
+		if u >= lowest {
+			continue
+		}
+		if !clusterUtil.DisruptionAllowed(n.Name, state.pods, state.pdbs) || !clusterUtil.FitsElsewhere(n.Name, state.nodes, state.pods, state.claims) {
+			continue
+		}
+		candidate, lowest = n.Name, u
+	}
+	if candidate == "" {
+		return nil
+	}
+	return &scaleDecision{nodes: []string{candidate}, event: fmt.Sprintf("节点 %s 资源利用率 %d%% 低于 %d%%，缩容该节点", candidate, lowest, item.ScaleDownUtilization)}
+}
+
+// autoscaleTemplate 使用节点池中已有节点的标签和污点作为新增节点的模板，节点池为空时使用节点池的配置
+func autoscaleTemplate(pool *model.ClusterNodePool, members []model.ClusterNode, kubeNodes map[string]v1.Node) clusterUtil.NodeTemplate {
+	template := clusterUtil.NodeTemplate{Labels: map[string]string{}}
+	for _, n := range members {
+		node, ok := kubeNodes[n.Name]
+		if !ok {
+			continue
+		}
+		for k, v := range node.Labels {
+			if k != v1.LabelHostname {
+				template.Labels[k] = v
+			}
+		}
+		for _, t := range node.Spec.Taints {
+			// 节点状态相关的污点由 kubelet 和控制器维护，新节点上不会存在
+			if !strings.HasPrefix(t.Key, "node.kubernetes.io/") && !strings.HasPrefix(t.Key, "node.cloudprovider.kubernetes.io/") {
+				template.Taints = append(template.Taints, t)
+			}
+		}
+		return template
+	}
+	template.Labels[v1.LabelOSStable] = "linux"
+	if pool == nil {
+		return template
+	}
+	var (
+		labels map[string]string
+		taints []model.NodePoolTaint
+	)
+	_ = json.Unmarshal([]byte(pool.Labels), &labels)
+	_ = json.Unmarshal([]byte(pool.Taints), &taints)
+	for k, v := range labels {
+		template.Labels[k] = v
+	}
+	template.Labels[constant.NodePoolLabelKey] = pool.Name
+	for _, t := range taints {
+		template.Taints = append(template.Taints, v1.Taint{Key: t.Key, Value: t.Value, Effect: v1.TaintEffect(t.Effect)})
+	}
+	return template
+}
+
+func cooling(last *time.Time, minutes int, now time.Time) bool {
+	return last != nil && now.Before(last.Add(time.Duration(minutes)*time.Minute))
+}
+
+func autoscaleMembers(nodes []model.ClusterNode, pool string) []model.ClusterNode {
+	var members []model.ClusterNode
+	for _, n := range nodes {
+		if n.Role == constant.NodeRoleNameWorker && n.NodePool == pool {
+			members = append(members, n)
+		}
+	}
+	return members
+}
+
+func getAutoscaler(clusterID string) (model.ClusterAutoscaler, error) {
+	item := model.ClusterAutoscaler{
+		ClusterID:            clusterID,
+		ScaleUpCooldown:      defaultScaleUpCooldown,
+		ScaleDownCooldown:    defaultScaleDownCooldown,
+		ScaleDownUtilization: defaultScaleDownUtilization,
+	}
+	if err := db.DB.Where("cluster_id = ?", clusterID).First(&item).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		return item, err
+	}
+	return item, nil
+}
+
+func currentTaskID(clusterName string) string {
+	var cluster model.Cluster
+	db.DB.Select("current_task_id").Where("name = ?", clusterName).First(&cluster)
+	return cluster.CurrentTaskID
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAutoscaleTemplate(t *testing.T) {
+	gpuTaint := v1.Taint{Key: "gpu", Value: "true", Effect: v1.TaintEffectNoSchedule}
+	pool := &model.ClusterNodePool{Name: "gpu", Labels: `{"accelerator":"nvidia"}`, Taints: `[{"key":"gpu","value":"true","effect":"NoSchedule"}]`}
+	members := []model.ClusterNode{{Name: "w1"}}
+	tests := []struct {
+		name      string
+		pool      *model.ClusterNodePool
+		kubeNodes map[string]v1.Node
+		want      clusterUtil.NodeTemplate
+	}{
+		{
+			name: "from existing node",
+			pool: pool,
+			kubeNodes: map[string]v1.Node{"w1": {
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.LabelHostname: "w1", v1.LabelTopologyZone: "a"}},
+				Spec:       v1.NodeSpec{Taints: []v1.Taint{gpuTaint, {Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}}},
+			}},
+			want: clusterUtil.NodeTemplate{Labels: map[string]string{v1.LabelTopologyZone: "a"}, Taints: []v1.Taint{gpuTaint}},
+		},
+		{
+			name: "from pool config",
+			pool: pool,
+			want: clusterUtil.NodeTemplate{
+				Labels: map[string]string{v1.LabelOSStable: "linux", "accelerator": "nvidia", constant.NodePoolLabelKey: "gpu"},
+				Taints: []v1.Taint{gpuTaint},
+			},
+		},
+		{
+			name: "default pool",
+			want: clusterUtil.NodeTemplate{Labels: map[string]string{v1.LabelOSStable: "linux"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoscaleTemplate(tt.pool, members, tt.kubeNodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("autoscaleTemplate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecideScaleUp(t *testing.T) {
+	pending := v1.Pod{
+		Spec: v1.PodSpec{
+			NodeSelector: map[string]string{"accelerator": "nvidia"},
+			Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+				v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi"),
+			}}}},
+		},
+		Status: v1.PodStatus{
+			Phase:      v1.PodPending,
+			Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: v1.PodReasonUnschedulable}},
+		},
+	}
+	item := model.ClusterAutoscaler{MinSize: 1, MaxSize: 3}
+	members := []model.ClusterNode{{Name: "w1"}}
+	kubeNodes := map[string]v1.Node{"w1": {Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+		v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("8Gi"),
+	}}}}
+	tests := []struct {
+		name     string
+		template clusterUtil.NodeTemplate
+		want     int
+	}{
+		{name: "fits pool", template: clusterUtil.NodeTemplate{Labels: map[string]string{"accelerator": "nvidia"}}, want: 1},
+		{name: "does not fit pool", template: clusterUtil.NodeTemplate{Labels: map[string]string{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := autoscaleState{kubeNodes: kubeNodes, pods: []v1.Pod{pending}, template: tt.template}
+			got := decideScale(item, members, state, time.Now())
+			if tt.want == 0 && got != nil || tt.want > 0 && (got == nil || got.increase != tt.want) {
+				t.Errorf("decideScale() = %+v, want increase %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -40,6 +40,7 @@ type cLusterBackupFileService struct {
 	clusterBackupStrategyRepository repository.ClusterBackupStrategyRepository
 	backupAccountRepository         repository.BackupAccountRepository
 	msgService                      MsgService
+	taskSchedulerService            TaskSchedulerService
 }
 
 func NewClusterBackupFileService() CLusterBackupFileService {
@@ -51,6 +52,7 @@ func NewClusterBackupFileService() CLusterBackupFileService {
 		clusterBackupStrategyRepository: repository.NewClusterBackupStrategyRepository(),
 		backupAccountRepository:         repository.NewBackupAccountRepository(),
 		msgService:                      NewMsgService(),
+		taskSchedulerService:            NewTaskSchedulerService(),
 	}
 }
 
@@ -149,6 +151,11 @@ func (c cLusterBackupFileService) Delete(name string) error {
 }
 
 func (c cLusterBackupFileService) Backup(creation dto.ClusterBackupFileCreate) error {
+	unlock, err := lockCluster(creation.ClusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	isON := c.taskLogService.IsTaskOn(creation.ClusterName)
 	if isON {
 		return errors.New("TASK_IN_EXECUTION")
@@ -172,7 +179,16 @@ func (c cLusterBackupFileService) Backup(creation dto.ClusterBackupFileCreate) e
 	cluster.CurrentTaskID = task.ID
 	_ = c.clusterRepo.Save(&cluster)
 
-	go c.doBackup(cluster, creation, task)
+	// 定时备份优先级低于用户发起的操作
+	priority := constant.TaskPriorityUser
+	if creation.Cron {
+		priority = constant.TaskPriorityCron
+	}
+	if err := c.taskSchedulerService.Submit(task, priority, func() {
+		c.doBackup(cluster, creation, task)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -240,6 +256,11 @@ func (c cLusterBackupFileService) doBackup(cluster model.Cluster, creation dto.C
 }
 
 func (c cLusterBackupFileService) Restore(restore dto.ClusterBackupFileRestore) error {
+	unlock, err := lockCluster(restore.ClusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	isON := c.taskLogService.IsTaskOn(restore.ClusterName)
 	if isON {
 		return errors.New("TASK_IN_EXECUTION")
@@ -273,7 +294,11 @@ func (c cLusterBackupFileService) Restore(restore dto.ClusterBackupFileRestore)
 
 	_ = c.clusterRepo.Save(&cluster)
 
-	go c.doRestore(restore, &cluster)
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.doRestore(restore, &cluster)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -331,6 +356,11 @@ func (c cLusterBackupFileService) doRestore(restore dto.ClusterBackupFileRestore
 }
 
 func (c cLusterBackupFileService) LocalRestore(clusterName string, file []byte) error {
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	isON := c.taskLogService.IsTaskOn(clusterName)
 	if isON {
 		return errors.New("TASK_IN_EXECUTION")
@@ -366,7 +396,11 @@ func (c cLusterBackupFileService) LocalRestore(clusterName string, file []byte)
 	cluster.CurrentTaskID = task.ID
 	_ = c.clusterRepo.Save(&cluster)
 
-	go c.doLocalRestore(cluster, task)
+	if err := c.taskSchedulerService.Submit(task, constant.TaskPriorityUser, func() {
+		c.doLocalRestore(cluster, task)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
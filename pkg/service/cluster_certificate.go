@@ -0,0 +1,247 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ansible"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+)
+
+const (
+	certExpireNoticeDaysKey     = "cert_expire_notice_days"
+	defaultCertExpireNoticeDays = 30
+)
+
+type ClusterCertificateService interface {
+	List(clusterName string) ([]dto.ClusterCertificate, error)
+	Refresh(clusterName string) ([]dto.ClusterCertificate, error)
+	Rotate(clusterName string) error
+	CheckExpire()
+}
+
+func NewClusterCertificateService() ClusterCertificateService {
+	return &clusterCertificateService{
+		clusterRepo:          repository.NewClusterRepository(),
+		taskLogService:       NewTaskLogService(),
+		msgService:           NewMsgService(),
+		systemSettingService: NewSystemSettingService(),
+		taskSchedulerService: NewTaskSchedulerService(),
+	}
+}
+
+type clusterCertificateService struct {
+	clusterRepo          repository.ClusterRepository
+	taskLogService       TaskLogService
+	msgService           MsgService
+	systemSettingService SystemSettingService
+	taskSchedulerService TaskSchedulerService
+}
+
+func (c *clusterCertificateService) List(clusterName string) ([]dto.ClusterCertificate, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	var certs []model.ClusterCertificate
+	if err := db.DB.Where("cluster_id = ?", cluster.ID).Order("expire_at").Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	return toCertificateDTOs(certs), nil
+}
+
+// Refresh 通过 ssh 重新读取集群各节点证书的过期时间
+func (c *clusterCertificateService) Refresh(clusterName string) ([]dto.ClusterCertificate, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"Nodes", "Nodes.Host", "Nodes.Host.Credential"})
+	if err != nil {
+		return nil, err
+	}
+	if cluster.Source != constant.ClusterSourceLocal {
+		return nil, fmt.Errorf("cluster %s is not created by ko, can not read certificates", clusterName)
+	}
+	var nodes []model.ClusterNode
+	for _, node := range cluster.Nodes {
+		if node.Status == constant.StatusRunning {
+			nodes = append(nodes, node)
+		}
+	}
+	// 没有可连接的节点时保留已有记录，避免清空证书列表
+	if len(nodes) == 0 {
+		return c.List(clusterName)
+	}
+	expiries := make([][]clusterUtil.CertExpiry, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			expiries[i], errs[i] = readNodeCertExpiry(nodes[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	var certs []model.ClusterCertificate
+	for i, node := range nodes {
+		for _, e := range expiries[i] {
+			certs = append(certs, model.ClusterCertificate{
+				ClusterID: cluster.ID,
+				NodeName:  node.Name,
+				Name:      e.Name,
+				Path:      e.Path,
+				ExpireAt:  e.ExpireAt,
+			})
+		}
+	}
+
+	tx := db.DB.Begin()
+	if err := tx.Where("cluster_id = ?", cluster.ID).Delete(&model.ClusterCertificate{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for i := range certs {
+		if err := tx.Create(&certs[i]).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	tx.Commit()
+	return toCertificateDTOs(certs), nil
+}
+
+func readNodeCertExpiry(node model.ClusterNode) ([]clusterUtil.CertExpiry, error) {
+	cfg, err := node.ToSSHConfig()
+	if err != nil {
+		return nil, fmt.Errorf("connect node %s failed: %v", node.Name, err)
+	}
+	client, err := ssh.New(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect node %s failed: %v", node.Name, err)
+	}
+	expiries, err := clusterUtil.ListCertExpiry(client)
+	if err != nil {
+		return nil, fmt.Errorf("read certificates of node %s failed: %v", node.Name, err)
+	}
+	return expiries, nil
+}
+
+// Rotate 重新签发集群证书，作为任务执行并记录日志
+func (c *clusterCertificateService) Rotate(clusterName string) error {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "SpecNetwork", "SpecRuntime", "Secret", "Nodes", "Nodes.Host", "Nodes.Host.Credential", "MultiClusterRepositories"})
+	if err != nil {
+		return err
+	}
+	if cluster.Source != constant.ClusterSourceLocal {
+		return fmt.Errorf("cluster %s is not created by ko, can not rotate certificates", clusterName)
+	}
+	if cluster.Status != constant.StatusRunning {
+		return fmt.Errorf("cluster status error %s", cluster.Status)
+	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(clusterName) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
+
+	task, err := c.taskLogService.NewTerminalTask(cluster.ID, constant.TaskLogTypeClusterCertRotate)
+	if err != nil {
+		return err
+	}
+	cluster.TaskLog = *task
+	cluster.CurrentTaskID = task.ID
+	_ = c.clusterRepo.Save(&cluster)
+
+	writer, err := ansible.CreateAnsibleLogWriterWithId(cluster.Name, task.ID)
+	if err != nil {
+		return fmt.Errorf("create log error %s", err.Error())
+	}
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		if err := adm.RotateCertificates(adm.NewAnsibleHelper(cluster, writer)); err != nil {
+			logger.Log.Errorf("rotate certificates of cluster %s failed: %s", cluster.Name, err.Error())
+			_ = c.taskLogService.End(&cluster.TaskLog, false, err.Error())
+			_ = db.DB.Model(&model.Cluster{}).Where("id = ?", cluster.ID).Update("current_task_id", "").Error
+			_ = c.msgService.SendMsg(constant.ClusterCertRotate, constant.Cluster, cluster, false, map[string]string{"errMsg": err.Error()})
+			return
+		}
+		_ = c.taskLogService.End(&cluster.TaskLog, true, "")
+		cluster.CurrentTaskID = ""
+		_ = c.clusterRepo.Save(&cluster)
+		if _, err := c.Refresh(cluster.Name); err != nil {
+			logger.Log.Errorf("refresh certificates of cluster %s failed: %s", cluster.Name, err.Error())
+		}
+		_ = c.msgService.SendMsg(constant.ClusterCertRotate, constant.Cluster, cluster, true, map[string]string{})
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CheckExpire 刷新所有集群证书，并对即将过期的集群发送通知
+func (c *clusterCertificateService) CheckExpire() {
+	noticeDays := defaultCertExpireNoticeDays
+	if setting, err := c.systemSettingService.Get(certExpireNoticeDaysKey); err == nil {
+		if days, err := strconv.Atoi(setting.Value); err == nil && days > 0 {
+			noticeDays = days
+		}
+	}
+	var clusters []model.Cluster
+	if err := db.DB.Where("status = ? AND source = ?", constant.StatusRunning, constant.ClusterSourceLocal).Find(&clusters).Error; err != nil {
+		logger.Log.Errorf("list clusters error %s", err.Error())
+		return
+	}
+	for _, cluster := range clusters {
+		certs, err := c.Refresh(cluster.Name)
+		if err != nil {
+			logger.Log.Errorf("refresh certificates of cluster %s failed: %s", cluster.Name, err.Error())
+			continue
+		}
+		var expiring *dto.ClusterCertificate
+		for i := range certs {
+			if certs[i].RemainingDays <= noticeDays && (expiring == nil || certs[i].ExpireAt.Before(expiring.ExpireAt)) {
+				expiring = &certs[i]
+			}
+		}
+		if expiring == nil {
+			continue
+		}
+		message := translateMsg("CLUSTER_CERT_EXPIRE_NOTICE", map[string]interface{}{
+			"Cluster": cluster.Name,
+			"Node":    expiring.NodeName,
+			"Cert":    expiring.Name,
+			"Days":    expiring.RemainingDays,
+		})
+		if err := c.msgService.SendMsg(constant.ClusterCertExpire, constant.Cluster, cluster, false, map[string]string{"message": message, "errMsg": message}); err != nil {
+			logger.Log.Infof("send certificate expire msg error,%s", err.Error())
+		}
+	}
+}
+
+func toCertificateDTOs(certs []model.ClusterCertificate) []dto.ClusterCertificate {
+	var result []dto.ClusterCertificate
+	for _, cert := range certs {
+		result = append(result, dto.ClusterCertificate{
+			ClusterCertificate: cert,
+			RemainingDays:      int(math.Floor(time.Until(cert.ExpireAt).Hours() / 24)),
+		})
+	}
+	return result
+}
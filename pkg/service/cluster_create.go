@@ -17,7 +17,26 @@ func (c clusterService) Create(creation dto.ClusterCreate) (*dto.Cluster, error)
 	loginfo, _ := json.Marshal(creation)
 	logger.Log.WithFields(logrus.Fields{"cluster_creation": string(loginfo)}).Debugf("start to create the cluster %s", creation.Name)
 
+	unlock, err := lockCluster(creation.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	cluster := creation.ClusterCreateDto2Mo()
+	if cluster.Provider != constant.ClusterProviderPlan && !creation.SkipPreflight {
+		roles := map[string]string{}
+		for _, node := range creation.Nodes {
+			roles[node.HostName] = node.Role
+		}
+		report, err := c.hostPreflightService.CheckCluster(cluster, roles)
+		if err != nil {
+			return nil, err
+		}
+		if err := preflightError(report); err != nil {
+			return nil, err
+		}
+	}
 	tx := db.DB.Begin()
 	var project model.Project
 	if err := tx.Where("name = ?", creation.ProjectName).First(&project).Error; err != nil {
@@ -135,7 +154,11 @@ func (c clusterService) Create(creation dto.ClusterCreate) (*dto.Cluster, error)
 	tx.Commit()
 
 	logger.Log.Infof("init db data of cluster %s successful, now start to create cluster", cluster.Name)
-	go c.clusterInitService.Init(*cluster, writer)
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.clusterInitService.Init(*cluster, writer)
+	}); err != nil {
+		return nil, err
+	}
 
 	return &dto.Cluster{Cluster: *cluster}, nil
 }
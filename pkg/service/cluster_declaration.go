@@ -0,0 +1,627 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
+	"github.com/ghodss/yaml"
+	"github.com/jinzhu/gorm"
+)
+
+type ClusterDeclarationService interface {
+	Export(clusterName string) ([]byte, error)
+	Apply(content []byte, dryRun bool) (*dto.ClusterApplyResult, error)
+}
+
+func NewClusterDeclarationService() ClusterDeclarationService {
+	return &clusterDeclarationService{
+		clusterRepo:           repository.NewClusterRepository(),
+		clusterService:        NewClusterService(),
+		clusterNodeService:    NewClusterNodeService(),
+		clusterUpgradeService: NewClusterUpgradeService(),
+		componentService:      NewComponentService(),
+		clusterToolService:    NewClusterToolService(),
+		nodePoolService:       NewClusterNodePoolService(),
+		clusterSpecService:    NewClusterSpecService(),
+	}
+}
+
+type clusterDeclarationService struct {
+	clusterRepo           repository.ClusterRepository
+	clusterService        ClusterService
+	clusterNodeService    ClusterNodeService
+	clusterUpgradeService ClusterUpgradeService
+	componentService      ComponentService
+	clusterToolService    ClusterToolService
+	nodePoolService       ClusterNodePoolService
+	clusterSpecService    ClusterSpecService
+}
+
+// Export 将集群导出为单个 YAML 文档
+func (c *clusterDeclarationService) Export(clusterName string) ([]byte, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "SpecRuntime", "SpecNetwork", "SpecComponent", "Nodes", "Nodes.Host", "Plan"})
+	if err != nil {
+		return nil, err
+	}
+	if cluster.Source != constant.ClusterSourceLocal {
+		return nil, errors.New("CLUSTER_DECLARATION_NOT_SUPPORTED")
+	}
+	var project model.Project
+	if err := db.DB.Where("id = ?", cluster.ProjectID).First(&project).Error; err != nil {
+		return nil, err
+	}
+
+	spec := declarationSpec(cluster, project.Name)
+	pools, err := c.nodePoolService.List(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	decl := dto.ClusterDeclaration{
+		APIVersion: dto.ClusterDeclarationVersion,
+		Kind:       dto.ClusterDeclarationKind,
+		Spec:       spec,
+		Components: []dto.ClusterDeclarationComponent{},
+		Tools:      []dto.ClusterDeclarationTool{},
+		NodePools:  pools,
+	}
+	for _, sc := range cluster.SpecComponent {
+		if sc.Status == constant.StatusDisabled {
+			continue
+		}
+		item := dto.ClusterDeclarationComponent{Name: sc.Name, Type: sc.Type, Version: sc.Version}
+		_ = json.Unmarshal([]byte(sc.Vars), &item.Vars)
+		decl.Components = append(decl.Components, item)
+	}
+	tools, err := c.clusterToolService.List(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tools {
+		if !toolEnabled(t) {
+			continue
+		}
+		decl.Tools = append(decl.Tools, dto.ClusterDeclarationTool{Name: t.Name, Version: t.Version, Vars: t.Vars})
+	}
+	return yaml.Marshal(&decl)
+}
+
+// declarationSpec 由集群记录生成声明中的 spec
+func declarationSpec(cluster model.Cluster, projectName string) dto.ClusterCreate {
+	spec := dto.ClusterCreate{
+		Name:          cluster.Name,
+		ProjectName:   projectName,
+		NodeNameRule:  cluster.NodeNameRule,
+		Version:       cluster.Version,
+		Architectures: cluster.Architectures,
+		Provider:      cluster.Provider,
+		Plan:          cluster.Plan.Name,
+		YumOperate:    cluster.SpecConf.YumOperate,
+
+		NetworkType:             cluster.SpecNetwork.NetworkType,
+		CiliumVersion:           cluster.SpecNetwork.CiliumVersion,
+		CiliumTunnelMode:        cluster.SpecNetwork.CiliumTunnelMode,
+		CiliumNativeRoutingCidr: cluster.SpecNetwork.CiliumNativeRoutingCidr,
+		FlannelBackend:          cluster.SpecNetwork.FlannelBackend,
+		CalicoIpv4PoolIpip:      cluster.SpecNetwork.CalicoIpv4PoolIpip,
+		NetworkInterface:        cluster.SpecNetwork.NetworkInterface,
+		NetworkCidr:             cluster.SpecNetwork.NetworkCidr,
+
+		KubePodSubnet:            cluster.SpecConf.KubePodSubnet,
+		MaxNodeNum:               cluster.SpecConf.MaxNodeNum,
+		KubeServiceSubnet:        cluster.SpecConf.KubeServiceSubnet,
+		KubeProxyMode:            cluster.SpecConf.KubeProxyMode,
+		CgroupDriver:             cluster.SpecConf.CgroupDriver,
+		KubeDnsDomain:            cluster.SpecConf.KubeDnsDomain,
+		KubernetesAudit:          cluster.SpecConf.KubernetesAudit,
+		NodeportAddress:          cluster.SpecConf.NodeportAddress,
+		KubeServiceNodePortRange: cluster.SpecConf.KubeServiceNodePortRange,
+
+		RuntimeType:          cluster.SpecRuntime.RuntimeType,
+		DockerMirrorRegistry: cluster.SpecRuntime.DockerMirrorRegistry,
+		DockerRemoteApi:      cluster.SpecRuntime.DockerRemoteApi,
+		DockerSubnet:         cluster.SpecRuntime.DockerSubnet,
+		DockerStorageDir:     cluster.SpecRuntime.DockerStorageDir,
+		ContainerdStorageDir: cluster.SpecRuntime.ContainerdStorageDir,
+		HelmVersion:          cluster.SpecRuntime.HelmVersion,
+
+		EtcdDataDir:             cluster.SpecConf.EtcdDataDir,
+		EtcdSnapshotCount:       cluster.SpecConf.EtcdSnapshotCount,
+		EtcdCompactionRetention: cluster.SpecConf.EtcdCompactionRetention,
+		EtcdMaxRequest:          cluster.SpecConf.EtcdMaxRequest,
+		EtcdQuotaBackend:        cluster.SpecConf.EtcdQuotaBackend,
+
+		LbMode:             cluster.SpecConf.LbMode,
+		LbKubeApiserverIp:  cluster.SpecConf.LbKubeApiserverIp,
+		KubeApiServerPort:  cluster.SpecConf.KubeApiServerPort,
+		MasterScheduleType: cluster.SpecConf.MasterScheduleType,
+		WorkerAmount:       cluster.SpecConf.WorkerAmount,
+	}
+	// 创建时由单节点最大 pod 数计算子网掩码，导出时按掩码还原
+	if cluster.SpecConf.KubeNetworkNodePrefix > 0 {
+		spec.MaxNodePodNum = 1 << (32 - cluster.SpecConf.KubeNetworkNodePrefix)
+	}
+	switch cluster.Provider {
+	case constant.ClusterProviderBareMetal:
+		sort.Slice(cluster.Nodes, func(i, j int) bool { return cluster.Nodes[i].Name < cluster.Nodes[j].Name })
+		for _, n := range cluster.Nodes {
+			spec.Nodes = append(spec.Nodes, dto.NodeCreate{HostName: n.Host.Name, Role: n.Role, NodePool: n.NodePool})
+		}
+	case constant.ClusterProviderPlan:
+		// 节点池中的节点由节点池的 size 描述
+		spec.WorkerAmount = 0
+		for _, n := range cluster.Nodes {
+			if n.Role == constant.NodeRoleNameWorker && n.NodePool == "" {
+				spec.WorkerAmount++
+			}
+		}
+	}
+	return spec
+}
+
+// Apply 集群不存在时创建集群，存在时计算差异并依次执行组件、工具、节点池、配置变更、扩缩容和升级操作。
+// 配置变更、扩缩容和升级是长时间任务，每次只启动一个，其余操作标记为 pending，任务完成后再次 apply 即可继续
+func (c *clusterDeclarationService) Apply(content []byte, dryRun bool) (*dto.ClusterApplyResult, error) {
+	var decl dto.ClusterDeclaration
+	if err := yaml.Unmarshal(content, &decl); err != nil {
+		return nil, fmt.Errorf("parse cluster declaration failed: %v", err)
+	}
+	if decl.Kind != dto.ClusterDeclarationKind {
+		return nil, fmt.Errorf("unsupported kind %s", decl.Kind)
+	}
+	if decl.Spec.Name == "" {
+		return nil, errors.New("spec.name is required")
+	}
+	result := &dto.ClusterApplyResult{Cluster: decl.Spec.Name}
+
+	cluster, err := c.clusterRepo.GetWithPreload(decl.Spec.Name, []string{"SpecConf", "SpecRuntime", "SpecNetwork", "SpecComponent", "Nodes", "Nodes.Host", "Plan"})
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, err
+	}
+	if cluster.ID == "" {
+		// 节点池在集群创建后才存在，创建时的节点不能指定节点池
+		for _, n := range decl.Spec.Nodes {
+			if n.NodePool != "" {
+				return nil, fmt.Errorf("host %s can not join node pool %s before the cluster is created", n.HostName, n.NodePool)
+			}
+		}
+		result.Operations = append(result.Operations, dto.ClusterApplyOperation{Type: dto.ApplyOperationCreate, Version: decl.Spec.Version, Status: dto.ApplyStatusPlanned})
+		for _, p := range decl.NodePools {
+			result.Operations = append(result.Operations, dto.ClusterApplyOperation{Type: dto.ApplyOperationSyncNodePool, Pool: p.Name, Status: dto.ApplyStatusPlanned})
+		}
+		// 组件和工具需要集群创建完成后才能启用，集群运行后再次 apply 即可
+		deferred := createDeferredOperations(decl)
+		if dryRun {
+			for i := range deferred {
+				deferred[i].Status = dto.ApplyStatusPlanned
+			}
+			result.Operations = append(result.Operations, deferred...)
+			return result, nil
+		}
+		if _, err := c.clusterService.Create(decl.Spec); err != nil {
+			return nil, err
+		}
+		result.Operations[0].Status = dto.ApplyStatusApplied
+		for i, p := range decl.NodePools {
+			if _, err := c.nodePoolService.Create(decl.Spec.Name, p); err != nil {
+				return result, fmt.Errorf("%s %s failed: %v", dto.ApplyOperationSyncNodePool, p.Name, err)
+			}
+			result.Operations[i+1].Status = dto.ApplyStatusApplied
+		}
+		result.Operations = append(result.Operations, deferred...)
+		return result, nil
+	}
+	if cluster.Source != constant.ClusterSourceLocal {
+		return nil, errors.New("CLUSTER_DECLARATION_NOT_SUPPORTED")
+	}
+
+	ops, err := c.diff(cluster, decl)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun && len(ops) > 0 && cluster.Status != constant.StatusRunning {
+		return nil, fmt.Errorf("cluster status error %s", cluster.Status)
+	}
+	started := false
+	for i := range ops {
+		op := &ops[i]
+		if dryRun {
+			op.Status = dto.ApplyStatusPlanned
+			continue
+		}
+		long := op.Type == dto.ApplyOperationAddNodes || op.Type == dto.ApplyOperationRemoveNodes || op.Type == dto.ApplyOperationUpgrade || op.Type == dto.ApplyOperationUpdateSpec
+		if long && started {
+			op.Status = dto.ApplyStatusPending
+			continue
+		}
+		if err := c.execute(cluster, decl, *op); err != nil {
+			result.Operations = ops[:i]
+			return result, fmt.Errorf("%s %v failed: %v", op.Type, op.Targets, err)
+		}
+		op.Status = dto.ApplyStatusApplied
+		started = started || long
+	}
+	result.Operations = ops
+	return result, nil
+}
+
+// diff 按组件、工具、节点池、配置、缩容、扩容、升级的顺序生成操作
+func (c *clusterDeclarationService) diff(cluster model.Cluster, decl dto.ClusterDeclaration) ([]dto.ClusterApplyOperation, error) {
+	var ops []dto.ClusterApplyOperation
+
+	if decl.Components != nil {
+		enabled := map[string]bool{}
+		for _, sc := range cluster.SpecComponent {
+			if sc.Status != constant.StatusDisabled {
+				enabled[sc.Name] = true
+			}
+		}
+		declared := map[string]bool{}
+		for _, item := range decl.Components {
+			declared[item.Name] = true
+			if !enabled[item.Name] {
+				ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationEnableComponent, Targets: []string{item.Name}, Version: item.Version})
+			}
+		}
+		for _, sc := range cluster.SpecComponent {
+			if enabled[sc.Name] && !declared[sc.Name] {
+				ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationDisableComponent, Targets: []string{sc.Name}})
+			}
+		}
+	}
+
+	if decl.Tools != nil {
+		tools, err := c.clusterToolService.List(cluster.Name)
+		if err != nil {
+			return nil, err
+		}
+		declared := map[string]bool{}
+		for _, item := range decl.Tools {
+			declared[item.Name] = true
+		}
+		for _, t := range tools {
+			if toolEnabled(t) && !declared[t.Name] {
+				ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationDisableTool, Targets: []string{t.Name}})
+			}
+			if !toolEnabled(t) && declared[t.Name] {
+				ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationEnableTool, Targets: []string{t.Name}})
+			}
+		}
+	}
+
+	if len(decl.NodePools) > 0 {
+		pools, err := c.nodePoolService.List(cluster.Name)
+		if err != nil {
+			return nil, err
+		}
+		current := map[string]dto.ClusterNodePool{}
+		for _, p := range pools {
+			p.Size = 0
+			current[p.Name] = p
+		}
+		for _, p := range decl.NodePools {
+			p.Size = 0
+			existing, ok := current[p.Name]
+			if !ok || !reflect.DeepEqual(toNodePoolModel(existing), toNodePoolModel(p)) {
+				ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationSyncNodePool, Pool: p.Name})
+			}
+		}
+	}
+
+	update, err := diffSpec(declarationSpec(cluster, decl.Spec.ProjectName), decl.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if fields := specUpdateFields(update); len(fields) > 0 {
+		ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationUpdateSpec, Targets: fields})
+	}
+
+	nodeOps, err := diffNodes(cluster, decl)
+	if err != nil {
+		return nil, err
+	}
+	ops = append(ops, nodeOps...)
+
+	if decl.Spec.Version != "" && decl.Spec.Version != cluster.Version {
+		ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationUpgrade, Version: decl.Spec.Version})
+	}
+	return ops, nil
+}
+
+// declarationSpecSkipped 由节点、升级操作处理或创建后无意义的字段，组件开关在集群创建后由 components 描述
+var declarationSpecSkipped = map[string]bool{
+	"name":                  true,
+	"projectName":           true,
+	"version":               true,
+	"workerAmount":          true,
+	"nodes":                 true,
+	"skipPreflight":         true,
+	"enableDnsCache":        true,
+	"dnsCacheVersion":       true,
+	"ingressControllerType": true,
+	"supportGpu":            true,
+}
+
+// diffSpec 对比导出的 spec 与声明中的 spec，声明中未填写的字段保持不变。
+// 运行中集群允许修改的字段转换为配置变更，其余字段的变化返回错误
+func diffSpec(current, desired dto.ClusterCreate) (dto.ClusterSpecUpdate, error) {
+	update := dto.ClusterSpecUpdate{Conf: map[string]interface{}{}, Runtime: map[string]interface{}{}}
+	currentValues, err := specValues(current)
+	if err != nil {
+		return update, err
+	}
+	desiredValues, err := specValues(desired)
+	if err != nil {
+		return update, err
+	}
+	var fields, unsupported []string
+	for field := range desiredValues {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		value := desiredValues[field]
+		if declarationSpecSkipped[field] || reflect.DeepEqual(currentValues[field], value) {
+			continue
+		}
+		if _, ok := adm.SpecFieldPhases(adm.SpecScopeConf, field); ok {
+			update.Conf[field] = value
+			continue
+		}
+		if _, ok := adm.SpecFieldPhases(adm.SpecScopeRuntime, field); ok {
+			update.Runtime[field] = value
+			continue
+		}
+		unsupported = append(unsupported, field)
+	}
+	if len(unsupported) > 0 {
+		return update, fmt.Errorf("spec.%s can not be changed on an existing cluster", strings.Join(unsupported, ", spec."))
+	}
+	return update, nil
+}
+
+// specValues 将 spec 转换为字段名到取值的映射，零值视为未填写
+func specValues(spec dto.ClusterCreate) (map[string]interface{}, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	for field, value := range values {
+		switch v := value.(type) {
+		case nil:
+			delete(values, field)
+		case string:
+			if v == "" {
+				delete(values, field)
+			}
+		case float64:
+			if v == 0 {
+				delete(values, field)
+			}
+		case bool:
+			if !v {
+				delete(values, field)
+			}
+		}
+	}
+	return values, nil
+}
+
+func specUpdateFields(update dto.ClusterSpecUpdate) []string {
+	var fields []string
+	for field := range update.Conf {
+		fields = append(fields, adm.SpecScopeConf+"."+field)
+	}
+	for field := range update.Runtime {
+		fields = append(fields, adm.SpecScopeRuntime+"."+field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// createDeferredOperations 新建集群时声明的组件和工具，集群运行后才能执行
+func createDeferredOperations(decl dto.ClusterDeclaration) []dto.ClusterApplyOperation {
+	var ops []dto.ClusterApplyOperation
+	for _, item := range decl.Components {
+		ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationEnableComponent, Targets: []string{item.Name}, Version: item.Version, Status: dto.ApplyStatusPending})
+	}
+	for _, item := range decl.Tools {
+		ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationEnableTool, Targets: []string{item.Name}, Version: item.Version, Status: dto.ApplyStatusPending})
+	}
+	return ops
+}
+
+// diffNodes 对比节点，worker 按节点池批量变更，master 每次只变更一个
+func diffNodes(cluster model.Cluster, decl dto.ClusterDeclaration) ([]dto.ClusterApplyOperation, error) {
+	spec := decl.Spec
+	var ops []dto.ClusterApplyOperation
+	var workers []model.ClusterNode
+	poolWorkers := map[string][]model.ClusterNode{}
+	for _, n := range cluster.Nodes {
+		if n.Role != constant.NodeRoleNameWorker {
+			continue
+		}
+		if n.NodePool != "" {
+			poolWorkers[n.NodePool] = append(poolWorkers[n.NodePool], n)
+			continue
+		}
+		workers = append(workers, n)
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].Name < workers[j].Name })
+
+	if cluster.Provider == constant.ClusterProviderPlan {
+		for _, p := range decl.NodePools {
+			switch current := len(poolWorkers[p.Name]); {
+			case p.Size > current:
+				ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameWorker, Pool: p.Name, Count: p.Size - current})
+			case p.Size < current:
+				ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationRemoveNodes, Role: constant.NodeRoleNameWorker, Pool: p.Name, Count: current - p.Size})
+			}
+		}
+		switch {
+		case spec.WorkerAmount > len(workers):
+			ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameWorker, Count: spec.WorkerAmount - len(workers)})
+		case spec.WorkerAmount < len(workers):
+			var names []string
+			for _, n := range workers[spec.WorkerAmount:] {
+				names = append(names, n.Name)
+			}
+			ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationRemoveNodes, Role: constant.NodeRoleNameWorker, Targets: names})
+		}
+		return ops, nil
+	}
+
+	desired := map[string]dto.NodeCreate{}
+	for _, n := range spec.Nodes {
+		desired[n.HostName] = n
+	}
+	current := map[string]bool{}
+	removed := map[string][]string{}
+	removedWorkers := map[string][]string{}
+	for _, n := range cluster.Nodes {
+		current[n.Host.Name] = true
+		d, ok := desired[n.Host.Name]
+		if !ok {
+			if n.Role == constant.NodeRoleNameWorker {
+				removedWorkers[n.NodePool] = append(removedWorkers[n.NodePool], n.Name)
+			}
+			removed[n.Role] = append(removed[n.Role], n.Name)
+			continue
+		}
+		if d.Role != n.Role {
+			return nil, fmt.Errorf("can not change role of host %s from %s to %s", n.Host.Name, n.Role, d.Role)
+		}
+		if d.NodePool != n.NodePool {
+			return nil, fmt.Errorf("can not move host %s from node pool %q to %q", n.Host.Name, n.NodePool, d.NodePool)
+		}
+	}
+	added := map[string][]string{}
+	addedWorkers := map[string][]string{}
+	for _, n := range spec.Nodes {
+		if current[n.HostName] {
+			continue
+		}
+		if n.Role == constant.NodeRoleNameWorker {
+			addedWorkers[n.NodePool] = append(addedWorkers[n.NodePool], n.HostName)
+		}
+		added[n.Role] = append(added[n.Role], n.HostName)
+	}
+
+	for _, pool := range sortedKeys(removedWorkers) {
+		sort.Strings(removedWorkers[pool])
+		ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationRemoveNodes, Role: constant.NodeRoleNameWorker, Pool: pool, Targets: removedWorkers[pool]})
+	}
+	for _, pool := range sortedKeys(addedWorkers) {
+		ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameWorker, Pool: pool, Targets: addedWorkers[pool]})
+	}
+	// 先扩容 master 再缩容，避免 etcd 成员数临时减少
+	for _, host := range added[constant.NodeRoleNameMaster] {
+		ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameMaster, Targets: []string{host}})
+	}
+	for _, name := range removed[constant.NodeRoleNameMaster] {
+		ops = append(ops, dto.ClusterApplyOperation{Type: dto.ApplyOperationRemoveNodes, Role: constant.NodeRoleNameMaster, Targets: []string{name}})
+	}
+	return ops, nil
+}
+
+func (c *clusterDeclarationService) execute(cluster model.Cluster, decl dto.ClusterDeclaration, op dto.ClusterApplyOperation) error {
+	clusterName := cluster.Name
+	switch op.Type {
+	case dto.ApplyOperationEnableComponent:
+		for _, item := range decl.Components {
+			if item.Name == op.Targets[0] {
+				return c.componentService.Create(&dto.ComponentCreate{
+					ClusterName: clusterName,
+					Name:        item.Name,
+					Type:        item.Type,
+					Version:     item.Version,
+					Vars:        item.Vars,
+				})
+			}
+		}
+	case dto.ApplyOperationDisableComponent:
+		return c.componentService.Delete(clusterName, op.Targets[0])
+	case dto.ApplyOperationEnableTool, dto.ApplyOperationDisableTool:
+		tools, err := c.clusterToolService.List(clusterName)
+		if err != nil {
+			return err
+		}
+		for _, t := range tools {
+			if t.Name != op.Targets[0] {
+				continue
+			}
+			if op.Type == dto.ApplyOperationDisableTool {
+				_, err = c.clusterToolService.Disable(clusterName, t)
+				return err
+			}
+			for _, item := range decl.Tools {
+				if item.Name == t.Name {
+					if item.Version != "" {
+						t.Version = item.Version
+					}
+					if item.Vars != nil {
+						t.Vars = item.Vars
+					}
+				}
+			}
+			_, err = c.clusterToolService.Enable(clusterName, t)
+			return err
+		}
+	case dto.ApplyOperationSyncNodePool:
+		for _, item := range decl.NodePools {
+			if item.Name != op.Pool {
+				continue
+			}
+			if _, err := c.nodePoolService.Get(clusterName, item.Name); err != nil {
+				if err.Error() != "NODE_POOL_NOT_FOUND" {
+					return err
+				}
+				_, err = c.nodePoolService.Create(clusterName, item)
+				return err
+			}
+			_, err := c.nodePoolService.Update(clusterName, item.Name, item)
+			return err
+		}
+	case dto.ApplyOperationUpdateSpec:
+		update, err := diffSpec(declarationSpec(cluster, decl.Spec.ProjectName), decl.Spec)
+		if err != nil {
+			return err
+		}
+		_, err = c.clusterSpecService.Update(clusterName, update)
+		return err
+	case dto.ApplyOperationAddNodes:
+		return c.clusterNodeService.Batch(clusterName, dto.NodeBatch{Operation: constant.BatchOperationCreate, Role: op.Role, Pool: op.Pool, Hosts: op.Targets, Increase: op.Count})
+	case dto.ApplyOperationRemoveNodes:
+		return c.clusterNodeService.Batch(clusterName, dto.NodeBatch{Operation: constant.BatchOperationDelete, Role: op.Role, Pool: op.Pool, Nodes: op.Targets, Increase: op.Count})
+	case dto.ApplyOperationUpgrade:
+		return c.clusterUpgradeService.Upgrade(dto.ClusterUpgrade{ClusterName: clusterName, Version: op.Version})
+	}
+	return nil
+}
+
+func toolEnabled(t dto.ClusterTool) bool {
+	return t.Status != constant.StatusWaiting && t.Status != constant.StatusTerminating
+}
+
+func sortedKeys(m map[string][]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+)
+
+func declarationNode(name, host, role, pool string) model.ClusterNode {
+	return model.ClusterNode{Name: name, Role: role, NodePool: pool, Host: model.Host{Name: host}}
+}
+
+func TestDiffNodes(t *testing.T) {
+	bareMetal := model.Cluster{
+		Provider: constant.ClusterProviderBareMetal,
+		Nodes: []model.ClusterNode{
+			declarationNode("m1", "host-m1", constant.NodeRoleNameMaster, ""),
+			declarationNode("w1", "host-w1", constant.NodeRoleNameWorker, ""),
+			declarationNode("w2", "host-w2", constant.NodeRoleNameWorker, "gpu"),
+		},
+	}
+	plan := model.Cluster{
+		Provider: constant.ClusterProviderPlan,
+		Nodes: []model.ClusterNode{
+			declarationNode("m1", "host-m1", constant.NodeRoleNameMaster, ""),
+			declarationNode("w1", "host-w1", constant.NodeRoleNameWorker, ""),
+			declarationNode("w2", "host-w2", constant.NodeRoleNameWorker, ""),
+			declarationNode("w3", "host-w3", constant.NodeRoleNameWorker, "gpu"),
+		},
+	}
+	tests := []struct {
+		name    string
+		cluster model.Cluster
+		decl    dto.ClusterDeclaration
+		want    []dto.ClusterApplyOperation
+		wantErr string
+	}{
+		{
+			name:    "bare metal unchanged",
+			cluster: bareMetal,
+			decl: dto.ClusterDeclaration{Spec: dto.ClusterCreate{Nodes: []dto.NodeCreate{
+				{HostName: "host-m1", Role: constant.NodeRoleNameMaster},
+				{HostName: "host-w1", Role: constant.NodeRoleNameWorker},
+				{HostName: "host-w2", Role: constant.NodeRoleNameWorker, NodePool: "gpu"},
+			}}},
+		},
+		{
+			name:    "bare metal replace master and workers",
+			cluster: bareMetal,
+			decl: dto.ClusterDeclaration{Spec: dto.ClusterCreate{Nodes: []dto.NodeCreate{
+				{HostName: "host-m2", Role: constant.NodeRoleNameMaster},
+				{HostName: "host-w1", Role: constant.NodeRoleNameWorker},
+				{HostName: "host-w3", Role: constant.NodeRoleNameWorker, NodePool: "gpu"},
+			}}},
+			want: []dto.ClusterApplyOperation{
+				{Type: dto.ApplyOperationRemoveNodes, Role: constant.NodeRoleNameWorker, Pool: "gpu", Targets: []string{"w2"}},
+				{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameWorker, Pool: "gpu", Targets: []string{"host-w3"}},
+				{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameMaster, Targets: []string{"host-m2"}},
+				{Type: dto.ApplyOperationRemoveNodes, Role: constant.NodeRoleNameMaster, Targets: []string{"m1"}},
+			},
+		},
+		{
+			name:    "bare metal role change",
+			cluster: bareMetal,
+			decl: dto.ClusterDeclaration{Spec: dto.ClusterCreate{Nodes: []dto.NodeCreate{
+				{HostName: "host-m1", Role: constant.NodeRoleNameWorker},
+			}}},
+			wantErr: "can not change role of host host-m1",
+		},
+		{
+			name:    "bare metal move to another pool",
+			cluster: bareMetal,
+			decl: dto.ClusterDeclaration{Spec: dto.ClusterCreate{Nodes: []dto.NodeCreate{
+				{HostName: "host-m1", Role: constant.NodeRoleNameMaster},
+				{HostName: "host-w1", Role: constant.NodeRoleNameWorker, NodePool: "gpu"},
+			}}},
+			wantErr: "can not move host host-w1",
+		},
+		{
+			name:    "plan scale workers and pools",
+			cluster: plan,
+			decl: dto.ClusterDeclaration{
+				Spec:      dto.ClusterCreate{WorkerAmount: 1},
+				NodePools: []dto.ClusterNodePool{{Name: "gpu", Size: 3}},
+			},
+			want: []dto.ClusterApplyOperation{
+				{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameWorker, Pool: "gpu", Count: 2},
+				{Type: dto.ApplyOperationRemoveNodes, Role: constant.NodeRoleNameWorker, Targets: []string{"w2"}},
+			},
+		},
+		{
+			name:    "plan add workers",
+			cluster: plan,
+			decl:    dto.ClusterDeclaration{Spec: dto.ClusterCreate{WorkerAmount: 4}},
+			want: []dto.ClusterApplyOperation{
+				{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameWorker, Count: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffNodes(tt.cluster, tt.decl)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("diffNodes() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffNodes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffSpec(t *testing.T) {
+	current := dto.ClusterCreate{
+		Name:                 "c1",
+		Version:              "v1.20.6-ko1",
+		KubeProxyMode:        "iptables",
+		DockerMirrorRegistry: "enable",
+		EtcdSnapshotCount:    50000,
+		KubePodSubnet:        "10.244.0.0/18",
+		WorkerAmount:         3,
+	}
+	tests := []struct {
+		name    string
+		desired dto.ClusterCreate
+		want    dto.ClusterSpecUpdate
+		wantErr string
+	}{
+		{
+			name:    "omitted fields unchanged",
+			desired: dto.ClusterCreate{Name: "c1", Version: "v1.20.10-ko1", WorkerAmount: 5, SupportGpu: "enable"},
+			want:    dto.ClusterSpecUpdate{Conf: map[string]interface{}{}, Runtime: map[string]interface{}{}},
+		},
+		{
+			name:    "reconfigurable fields",
+			desired: dto.ClusterCreate{KubeProxyMode: "ipvs", DockerMirrorRegistry: "disable", EtcdSnapshotCount: 10000, KubePodSubnet: "10.244.0.0/18"},
+			want: dto.ClusterSpecUpdate{
+				Conf:    map[string]interface{}{"kubeProxyMode": "ipvs", "etcdSnapshotCount": float64(10000)},
+				Runtime: map[string]interface{}{"dockerMirrorRegistry": "disable"},
+			},
+		},
+		{
+			name:    "immutable fields",
+			desired: dto.ClusterCreate{KubeProxyMode: "ipvs", KubePodSubnet: "10.100.0.0/16", RuntimeType: "containerd"},
+			wantErr: "spec.kubePodSubnet, spec.runtimeType can not be changed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffSpec(current, tt.desired)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("diffSpec() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	cluster := model.Cluster{
+		Name:     "c1",
+		Version:  "v1.20.6-ko1",
+		Provider: constant.ClusterProviderBareMetal,
+		SpecConf: model.ClusterSpecConf{KubeProxyMode: "iptables"},
+		SpecComponent: []model.ClusterSpecComponent{
+			{Name: "gpu", Status: constant.StatusEnabled},
+			{Name: "dns-cache", Status: constant.StatusDisabled},
+		},
+		Nodes: []model.ClusterNode{declarationNode("m1", "host-m1", constant.NodeRoleNameMaster, "")},
+	}
+	decl := dto.ClusterDeclaration{
+		Spec: dto.ClusterCreate{
+			Name:          "c1",
+			Version:       "v1.20.10-ko1",
+			KubeProxyMode: "ipvs",
+			Nodes: []dto.NodeCreate{
+				{HostName: "host-m1", Role: constant.NodeRoleNameMaster},
+				{HostName: "host-w1", Role: constant.NodeRoleNameWorker},
+			},
+		},
+		Components: []dto.ClusterDeclarationComponent{{Name: "dns-cache", Version: "1.17.0"}},
+	}
+	want := []dto.ClusterApplyOperation{
+		{Type: dto.ApplyOperationEnableComponent, Targets: []string{"dns-cache"}, Version: "1.17.0"},
+		{Type: dto.ApplyOperationDisableComponent, Targets: []string{"gpu"}},
+		{Type: dto.ApplyOperationUpdateSpec, Targets: []string{"conf.kubeProxyMode"}},
+		{Type: dto.ApplyOperationAddNodes, Role: constant.NodeRoleNameWorker, Targets: []string{"host-w1"}},
+		{Type: dto.ApplyOperationUpgrade, Version: "v1.20.10-ko1"},
+	}
+	got, err := (&clusterDeclarationService{}).diff(cluster, decl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diff() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCreateDeferredOperations(t *testing.T) {
+	decl := dto.ClusterDeclaration{
+		Components: []dto.ClusterDeclarationComponent{{Name: "gpu", Version: "v1"}},
+		Tools:      []dto.ClusterDeclarationTool{{Name: "prometheus", Version: "v2"}},
+	}
+	want := []dto.ClusterApplyOperation{
+		{Type: dto.ApplyOperationEnableComponent, Targets: []string{"gpu"}, Version: "v1", Status: dto.ApplyStatusPending},
+		{Type: dto.ApplyOperationEnableTool, Targets: []string{"prometheus"}, Version: "v2", Status: dto.ApplyStatusPending},
+	}
+	if got := createDeferredOperations(decl); !reflect.DeepEqual(got, want) {
+		t.Errorf("createDeferredOperations() = %+v, want %+v", got, want)
+	}
+}
@@ -149,12 +149,20 @@ func checkHostSSHConnected(c model.Cluster) dto.ClusterHealthHook {
 		wg.Add(1)
 		go func(n int) {
 			defer wg.Done()
-			if err := ipaddr.Ping(c.Nodes[n].Host.Ip); err != nil {
+			sshCfg, err := c.Nodes[n].ToSSHConfig()
+			if err != nil {
 				result.Level = StatusWarning
-				result.Msg += fmt.Sprintf("Ping %s failed: %s,", c.Nodes[n].Host.Ip, err.Error())
+				result.Msg += fmt.Sprintf("SSH %s failed: %s,", c.Nodes[n].Host.Ip, err.Error())
 				return
 			}
-			sshCfg := c.Nodes[n].ToSSHConfig()
+			// 经跳板机连接的主机不能从本地直接 ping 通，只检查 ssh 连接
+			if sshCfg.Proxy == nil {
+				if err := ipaddr.Ping(c.Nodes[n].Host.Ip); err != nil {
+					result.Level = StatusWarning
+					result.Msg += fmt.Sprintf("Ping %s failed: %s,", c.Nodes[n].Host.Ip, err.Error())
+					return
+				}
+			}
 			sshClient, err := ssh.New(&sshCfg)
 			if err != nil {
 				result.Level = StatusWarning
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/cloud_provider"
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	wakeCheckInterval = 15 * time.Second
+	wakeCheckTimeout  = 15 * time.Minute
+)
+
+type ClusterHibernateService interface {
+	Hibernate(clusterName string) error
+	Wake(clusterName string) error
+}
+
+func NewClusterHibernateService() ClusterHibernateService {
+	return &clusterHibernateService{
+		clusterRepo:          repository.NewClusterRepository(),
+		taskLogService:       NewTaskLogService(),
+		msgService:           NewMsgService(),
+		kubernetesService:    NewKubernetesService(),
+		clusterHealthService: NewClusterHealthService(),
+		taskSchedulerService: NewTaskSchedulerService(),
+	}
+}
+
+type clusterHibernateService struct {
+	clusterRepo          repository.ClusterRepository
+	taskLogService       TaskLogService
+	msgService           MsgService
+	kubernetesService    KubernetesService
+	clusterHealthService ClusterHealthService
+	taskSchedulerService TaskSchedulerService
+}
+
+// Hibernate 驱逐 worker 上的负载后依次关闭 worker 和 master 虚拟机
+func (c *clusterHibernateService) Hibernate(clusterName string) error {
+	cluster, err := c.load(clusterName)
+	if err != nil {
+		return err
+	}
+	if cluster.Status != constant.StatusRunning {
+		return fmt.Errorf("cluster status error %s", cluster.Status)
+	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(clusterName) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
+	cloudClient, err := newClusterCloudClient(cluster)
+	if err != nil {
+		return err
+	}
+	if err := c.start(&cluster, constant.TaskLogTypeClusterHibernate, constant.StatusHibernating); err != nil {
+		return err
+	}
+
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		masters, workers := hibernateHosts(cluster)
+		for _, n := range cluster.Nodes {
+			if n.Role != constant.NodeRoleNameWorker {
+				continue
+			}
+			// 关机前尽量驱逐负载，驱逐失败不影响休眠
+			if err := c.kubernetesService.DrainNode(cluster.Name, n.Name); err != nil {
+				logger.Log.Errorf("drain node %s before hibernate failed: %s", n.Name, err.Error())
+			}
+		}
+		err := cloudClient.PowerOff(workers)
+		if err == nil {
+			err = cloudClient.PowerOff(masters)
+		}
+		if err == nil {
+			c.end(&cluster, constant.ClusterHibernate, constant.StatusHibernated, nil)
+			return
+		}
+		// 关机失败时部分虚拟机可能已关闭，重新开机并恢复调度，集群回到运行状态
+		if e := c.restore(cluster, cloudClient, masters, workers); e != nil {
+			c.end(&cluster, constant.ClusterHibernate, constant.StatusFailed, fmt.Errorf("%v, restore cluster failed: %v", err, e))
+			return
+		}
+		c.end(&cluster, constant.ClusterHibernate, constant.StatusRunning, err)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Wake 依次开启 master 和 worker 虚拟机，集群健康检查通过后恢复调度
+func (c *clusterHibernateService) Wake(clusterName string) error {
+	cluster, err := c.load(clusterName)
+	if err != nil {
+		return err
+	}
+	if cluster.Status != constant.StatusHibernated {
+		return errors.New("CLUSTER_NOT_HIBERNATED")
+	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(clusterName) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
+	cloudClient, err := newClusterCloudClient(cluster)
+	if err != nil {
+		return err
+	}
+	if err := c.start(&cluster, constant.TaskLogTypeClusterWake, constant.StatusWaking); err != nil {
+		return err
+	}
+
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		masters, workers := hibernateHosts(cluster)
+		if err := cloudClient.PowerOn(masters); err != nil {
+			c.end(&cluster, constant.ClusterWake, constant.StatusHibernated, err)
+			return
+		}
+		if err := cloudClient.PowerOn(workers); err != nil {
+			c.end(&cluster, constant.ClusterWake, constant.StatusHibernated, err)
+			return
+		}
+		if err := db.DB.Model(&model.ClusterNode{}).Where("cluster_id = ?", cluster.ID).
+			Updates(map[string]interface{}{"status": constant.StatusRunning}).Error; err != nil {
+			c.end(&cluster, constant.ClusterWake, constant.StatusHibernated, err)
+			return
+		}
+
+		var lastErr error
+		if err := wait.Poll(wakeCheckInterval, wakeCheckTimeout, func() (bool, error) {
+			result, err := c.clusterHealthService.HealthCheck(cluster.Name)
+			if err != nil {
+				lastErr = err
+				return false, nil
+			}
+			if result.Level == StatusError {
+				lastErr = healthError(result)
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			if lastErr != nil {
+				err = fmt.Errorf("wait cluster healthy timeout: %v", lastErr)
+			}
+			// 虚拟机已全部开机，健康检查超时只记录错误，集群仍回到运行状态，避免无法再次操作
+			c.uncordonWorkers(cluster)
+			c.end(&cluster, constant.ClusterWake, constant.StatusRunning, err)
+			return
+		}
+
+		c.uncordonWorkers(cluster)
+		c.end(&cluster, constant.ClusterWake, constant.StatusRunning, nil)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// restore 休眠失败后重新开启虚拟机并恢复 worker 调度
+func (c *clusterHibernateService) restore(cluster model.Cluster, cloudClient cloud_provider.CloudClient, masters, workers []string) error {
+	if err := cloudClient.PowerOn(masters); err != nil {
+		return err
+	}
+	if err := cloudClient.PowerOn(workers); err != nil {
+		return err
+	}
+	c.uncordonWorkers(cluster)
+	return nil
+}
+
+func (c *clusterHibernateService) uncordonWorkers(cluster model.Cluster) {
+	for _, n := range cluster.Nodes {
+		if n.Role != constant.NodeRoleNameWorker {
+			continue
+		}
+		if err := c.kubernetesService.CordonNode(dto.Cordon{Name: n.Name, Cluster: cluster.Name, SetUnschedulable: false}); err != nil {
+			logger.Log.Errorf("uncordon node %s failed: %s", n.Name, err.Error())
+		}
+	}
+}
+
+func (c *clusterHibernateService) load(clusterName string) (model.Cluster, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "Secret", "Plan", "Plan.Region", "Nodes", "Nodes.Host", "Nodes.Host.Credential"})
+	if err != nil {
+		return cluster, err
+	}
+	if cluster.Source != constant.ClusterSourceLocal || cluster.Provider != constant.ClusterProviderPlan {
+		return cluster, errors.New("HIBERNATE_NOT_SUPPORTED")
+	}
+	return cluster, nil
+}
+
+func (c *clusterHibernateService) start(cluster *model.Cluster, taskType, status string) error {
+	tasklog, err := c.taskLogService.NewTerminalTask(cluster.ID, taskType)
+	if err != nil {
+		return err
+	}
+	cluster.TaskLog = *tasklog
+	cluster.CurrentTaskID = tasklog.ID
+	cluster.Status = status
+	return c.clusterRepo.Save(cluster)
+}
+
+// end 结束任务并设置集群状态，休眠时节点状态同步为 Hibernated，避免节点被标记为 Lost
+func (c *clusterHibernateService) end(cluster *model.Cluster, msgName, status string, err error) {
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+		logger.Log.Errorf("%s cluster %s failed: %s", msgName, cluster.Name, errMsg)
+	}
+	if status == constant.StatusHibernated {
+		if e := db.DB.Model(&model.ClusterNode{}).Where("cluster_id = ?", cluster.ID).
+			Updates(map[string]interface{}{"status": constant.StatusHibernated}).Error; e != nil {
+			logger.Log.Errorf("update node status of cluster %s failed: %s", cluster.Name, e.Error())
+		}
+	}
+	_ = c.taskLogService.End(&cluster.TaskLog, err == nil, errMsg)
+	cluster.Status = status
+	cluster.Message = errMsg
+	cluster.CurrentTaskID = ""
+	_ = c.clusterRepo.Save(cluster)
+	content := map[string]string{"detailName": cluster.Name}
+	if err != nil {
+		content["errMsg"] = errMsg
+	}
+	_ = c.msgService.SendMsg(msgName, constant.Cluster, cluster, err == nil, content)
+}
+
+func hibernateHosts(cluster model.Cluster) ([]string, []string) {
+	var masters, workers []string
+	for _, n := range cluster.Nodes {
+		if n.Role == constant.NodeRoleNameMaster {
+			masters = append(masters, n.Host.Name)
+		} else {
+			workers = append(workers, n.Host.Name)
+		}
+	}
+	return masters, workers
+}
+
+// newClusterCloudClient 使用部署计划所在区域的参数创建云平台客户端，虚拟机名称与主机名称一致
+func newClusterCloudClient(cluster model.Cluster) (cloud_provider.CloudClient, error) {
+	providerVars := map[string]interface{}{}
+	providerVars["provider"] = cluster.Plan.Region.Provider
+	providerVars["datacenter"] = cluster.Plan.Region.Datacenter
+	_ = json.Unmarshal([]byte(cluster.Plan.Region.Vars), &providerVars)
+	cloudClient := cloud_provider.NewCloudClient(providerVars)
+	if cloudClient == nil {
+		return nil, fmt.Errorf("unsupported provider %s", cluster.Plan.Region.Provider)
+	}
+	return cloudClient, nil
+}
+
+func healthError(result *dto.ClusterHealth) error {
+	for _, hook := range result.Hooks {
+		if hook.Level == StatusError {
+			return fmt.Errorf("%s: %s", hook.Name, hook.Msg)
+		}
+	}
+	return errors.New(StatusError)
+}
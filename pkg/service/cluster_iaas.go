@@ -71,20 +71,7 @@ func (c clusterIaasService) LoadMetalNodes(creation *dto.ClusterCreate, cluster
 			return fmt.Errorf("can bind host %s to cluster", nc.HostName)
 		}
 
-		switch cluster.NodeNameRule {
-		case constant.NodeNameRuleDefault:
-			if n.Role == constant.NodeRoleNameMaster {
-				n.Name = fmt.Sprintf("%s-%s-%d", cluster.Name, constant.NodeRoleNameMaster, masterNo)
-				masterNo++
-			} else {
-				n.Name = fmt.Sprintf("%s-%s-%d", cluster.Name, constant.NodeRoleNameWorker, workerNo)
-				workerNo++
-			}
-		case constant.NodeNameRuleIP:
-			n.Name = host.Ip
-		case constant.NodeNameRuleHostName:
-			n.Name = host.Name
-		}
+		n.Name = metalNodeName(cluster, n.Role, host, &masterNo, &workerNo)
 
 		n.HostID = host.ID
 		if err := tx.Create(&n).Error; err != nil {
@@ -96,6 +83,25 @@ func (c clusterIaasService) LoadMetalNodes(creation *dto.ClusterCreate, cluster
 	return nil
 }
 
+func metalNodeName(cluster *model.Cluster, role string, host model.Host, masterNo, workerNo *int) string {
+	switch cluster.NodeNameRule {
+	case constant.NodeNameRuleDefault:
+		if role == constant.NodeRoleNameMaster {
+			name := fmt.Sprintf("%s-%s-%d", cluster.Name, constant.NodeRoleNameMaster, *masterNo)
+			*masterNo++
+			return name
+		}
+		name := fmt.Sprintf("%s-%s-%d", cluster.Name, constant.NodeRoleNameWorker, *workerNo)
+		*workerNo++
+		return name
+	case constant.NodeNameRuleIP:
+		return host.Ip
+	case constant.NodeNameRuleHostName:
+		return host.Name
+	}
+	return ""
+}
+
 func (c clusterIaasService) LoadPlanNodes(cluster *model.Cluster) error {
 	if len(cluster.Nodes) > 0 {
 		return nil
@@ -194,10 +200,7 @@ func (c clusterIaasService) createNodes(cluster model.Cluster, hosts []*model.Ho
 
 func (c clusterIaasService) createHosts(cluster model.Cluster, plan model.Plan) ([]*model.Host, error) {
 	var hosts []*model.Host
-	masterAmount := 1
-	if plan.DeployTemplate != constant.SINGLE {
-		masterAmount = 3
-	}
+	masterAmount := plan.MasterAmount()
 	planVars := map[string]string{}
 	_ = json.Unmarshal([]byte(plan.Vars), &planVars)
 
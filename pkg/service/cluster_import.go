@@ -14,6 +14,7 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/model"
 	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
 	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/tunnel"
 	"github.com/icza/dyno"
 	"github.com/jinzhu/gorm"
 	"github.com/sirupsen/logrus"
@@ -56,6 +57,19 @@ func (c clusterImportService) Import(clusterImport dto.ClusterImport) error {
 	if err != nil {
 		return err
 	}
+	if clusterImport.ConnectMode == constant.ConnectModeAgent {
+		name := clusterImport.Name
+		if clusterImport.IsKoCluster {
+			name = clusterImport.KoClusterInfo.Name
+		}
+		apiServer, token, err := agentApiServer(name)
+		if err != nil {
+			return err
+		}
+		clusterImport.AuthenticationMode = constant.AuthenticationModeBearer
+		clusterImport.ApiServer = apiServer
+		clusterImport.Token = token
+	}
 	cluster, err := clusterImport.ClusterImportDto2Mo()
 	if err != nil {
 		return err
@@ -372,6 +386,15 @@ func getInfoFromDaemonset(client *kubernetes.Clientset) (string, error) {
 
 func (c clusterImportService) LoadClusterInfo(loadInfo *dto.ClusterLoad) (dto.ClusterLoadInfo, error) {
 	var clusterInfo dto.ClusterLoadInfo
+	if loadInfo.ConnectMode == constant.ConnectModeAgent {
+		apiServer, token, err := agentApiServer(loadInfo.Name)
+		if err != nil {
+			return clusterInfo, err
+		}
+		loadInfo.AuthenticationMode = constant.AuthenticationModeBearer
+		loadInfo.ApiServer = apiServer
+		loadInfo.Token = token
+	}
 	if loadInfo.AuthenticationMode == constant.AuthenticationModeConfigFile {
 		server, err := dto.LoadApiserverFromConfig(loadInfo.ConfigContent)
 		if err != nil {
@@ -413,6 +436,9 @@ func (c clusterImportService) LoadClusterInfo(loadInfo *dto.ClusterLoad) (dto.Cl
 		}
 		connConf = *itemConfig
 	}
+	if loadInfo.ConnectMode == constant.ConnectModeAgent {
+		connConf.Dial = tunnel.Dialer(loadInfo.Name)
+	}
 
 	kubeClient, err := kubernetes.NewForConfig(&connConf)
 	if err != nil {
@@ -439,19 +465,9 @@ func (c clusterImportService) LoadClusterInfo(loadInfo *dto.ClusterLoad) (dto.Cl
 	if err != nil {
 		return clusterInfo, fmt.Errorf("can not load kubeadm-config from cluster: %s", err.Error())
 	}
-	admConfig := kubeAdmMap.Data["ClusterConfiguration"]
-	admCfy := make(map[interface{}]interface{})
-	if err := yaml.Unmarshal([]byte(admConfig), &admCfy); err != nil {
-		return clusterInfo, fmt.Errorf("kubeadm-config yaml unmarshall failed: %s", err.Error())
-	}
-	admInterface := dyno.ConvertMapI2MapS(admCfy)
-	kk, err := json.Marshal(admInterface)
+	data, err := parseKubeadmConfig(kubeAdmMap.Data["ClusterConfiguration"])
 	if err != nil {
-		return clusterInfo, fmt.Errorf("kubeadm-config json marshall failed: %s", err.Error())
-	}
-	var data admConfigStruct
-	if err := json.Unmarshal(kk, &data); err != nil {
-		return clusterInfo, fmt.Errorf("kubeadm-config json unmarshall failed: %s", err.Error())
+		return clusterInfo, err
 	}
 	if strings.Contains(data.ControlPlaneEndpoint, ":") {
 		apiServerInCM := strings.Split(data.ControlPlaneEndpoint, ":")[0]
@@ -490,19 +506,9 @@ func (c clusterImportService) LoadClusterInfo(loadInfo *dto.ClusterLoad) (dto.Cl
 	if err != nil {
 		return clusterInfo, fmt.Errorf("can not load kube-proxy from cluster: %s", err.Error())
 	}
-	proxyConfig := kubeProxyMap.Data["config.conf"]
-	proxyCfy := make(map[interface{}]interface{})
-	if err := yaml.Unmarshal([]byte(proxyConfig), &proxyCfy); err != nil {
-		return clusterInfo, fmt.Errorf("kube-proxy yaml unmarshall failed: %s", err.Error())
-	}
-	proxyInterface := dyno.ConvertMapI2MapS(proxyCfy)
-	kk2, err := json.Marshal(proxyInterface)
+	data2, err := parseKubeProxyConfig(kubeProxyMap.Data["config.conf"])
 	if err != nil {
-		return clusterInfo, fmt.Errorf("kube-proxy json marshall failed: %s", err.Error())
-	}
-	var data2 proxyConfigStruct
-	if err := json.Unmarshal(kk2, &data2); err != nil {
-		return clusterInfo, fmt.Errorf("kube-proxy json unmarshall failed: %s", err.Error())
+		return clusterInfo, err
 	}
 	clusterInfo.KubeProxyMode = data2.Mode
 	if len(data2.NodePortAddresses) != 0 {
@@ -518,7 +524,43 @@ func (c clusterImportService) LoadClusterInfo(loadInfo *dto.ClusterLoad) (dto.Cl
 	return clusterInfo, nil
 }
 
+func parseKubeadmConfig(content string) (admConfigStruct, error) {
+	var data admConfigStruct
+	admCfy := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal([]byte(content), &admCfy); err != nil {
+		return data, fmt.Errorf("kubeadm-config yaml unmarshall failed: %s", err.Error())
+	}
+	admInterface := dyno.ConvertMapI2MapS(admCfy)
+	kk, err := json.Marshal(admInterface)
+	if err != nil {
+		return data, fmt.Errorf("kubeadm-config json marshall failed: %s", err.Error())
+	}
+	if err := json.Unmarshal(kk, &data); err != nil {
+		return data, fmt.Errorf("kubeadm-config json unmarshall failed: %s", err.Error())
+	}
+	return data, nil
+}
+
+func parseKubeProxyConfig(content string) (proxyConfigStruct, error) {
+	var data proxyConfigStruct
+	proxyCfy := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal([]byte(content), &proxyCfy); err != nil {
+		return data, fmt.Errorf("kube-proxy yaml unmarshall failed: %s", err.Error())
+	}
+	proxyInterface := dyno.ConvertMapI2MapS(proxyCfy)
+	kk, err := json.Marshal(proxyInterface)
+	if err != nil {
+		return data, fmt.Errorf("kube-proxy json marshall failed: %s", err.Error())
+	}
+	if err := json.Unmarshal(kk, &data); err != nil {
+		return data, fmt.Errorf("kube-proxy json unmarshall failed: %s", err.Error())
+	}
+	return data, nil
+}
+
 type admConfigStruct struct {
+	KubernetesVersion    string           `json:"kubernetesVersion"`
+	Etcd                 etcdStruct       `json:"etcd"`
 	ApiServer            apiServerStruct  `json:"apiServer"`
 	ControlPlaneEndpoint string           `json:"controlPlaneEndpoint"`
 	Controller           ControllerStruct `json:"controllerManager"`
@@ -538,6 +580,15 @@ type ControllerStruct struct {
 	ExtraArgs extraArgsStruct `json:"extraArgs"`
 }
 
+type etcdStruct struct {
+	Local    *localEtcdStruct       `json:"local"`
+	External map[string]interface{} `json:"external"`
+}
+
+type localEtcdStruct struct {
+	DataDir string `json:"dataDir"`
+}
+
 type networkStruct struct {
 	DnsDomain     string `json:"dnsDomain"`
 	PodSubnet     string `json:"podSubnet"`
@@ -46,9 +46,6 @@ type clusterInitService struct {
 }
 
 func (c clusterInitService) Init(cluster model.Cluster, writer io.Writer) {
-	cluster.TaskLog.Phase = constant.TaskLogStatusWaiting
-	_ = c.taskLogService.Save(&cluster.TaskLog)
-
 	if cluster.Provider == constant.ClusterProviderPlan {
 		if err := c.clusterIaasService.LoadPlanNodes(&cluster); err != nil {
 			_ = c.taskLogService.End(&cluster.TaskLog, false, err.Error())
@@ -190,7 +187,10 @@ func (c clusterInitService) GatherKubernetesToken(cluster model.Cluster) error {
 	if err != nil {
 		return err
 	}
-	sshConfig := master.ToSSHConfig()
+	sshConfig, err := master.ToSSHConfig()
+	if err != nil {
+		return err
+	}
 	client, err := ssh.New(&sshConfig)
 	if err != nil {
 		return err
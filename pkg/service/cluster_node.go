@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/db"
@@ -27,35 +28,41 @@ type ClusterNodeService interface {
 	List(clusterName string) ([]dto.Node, error)
 	Batch(clusterName string, batch dto.NodeBatch) error
 	Recreate(clusterName string, batch dto.NodeBatch) error
+	ResumeAddWorker(cluster model.Cluster, writer io.Writer) error
+	ResumeMaster(cluster model.Cluster, writer io.Writer) error
 	Page(num, size int, isPolling, clusterName string) (*dto.NodePage, error)
 }
 
 func NewClusterNodeService() ClusterNodeService {
 	return &clusterNodeService{
-		ClusterService: NewClusterService(),
-		clusterRepo:    repository.NewClusterRepository(),
-		NodeRepo:       repository.NewClusterNodeRepository(),
-		taskLogService: NewTaskLogService(),
-		HostRepo:       repository.NewHostRepository(),
-		planService:    NewPlanService(),
-		vmConfigRepo:   repository.NewVmConfigRepository(),
-		ntpServerRepo:  repository.NewNtpServerRepository(),
-		hostService:    NewHostService(),
-		msgService:     NewMsgService(),
+		ClusterService:       NewClusterService(),
+		clusterRepo:          repository.NewClusterRepository(),
+		NodeRepo:             repository.NewClusterNodeRepository(),
+		taskLogService:       NewTaskLogService(),
+		HostRepo:             repository.NewHostRepository(),
+		planService:          NewPlanService(),
+		vmConfigRepo:         repository.NewVmConfigRepository(),
+		ntpServerRepo:        repository.NewNtpServerRepository(),
+		hostService:          NewHostService(),
+		msgService:           NewMsgService(),
+		taskSchedulerService: NewTaskSchedulerService(),
+		hostPreflightService: NewHostPreflightService(),
 	}
 }
 
 type clusterNodeService struct {
-	ClusterService ClusterService
-	clusterRepo    repository.ClusterRepository
-	NodeRepo       repository.ClusterNodeRepository
-	taskLogService TaskLogService
-	HostRepo       repository.HostRepository
-	planService    PlanService
-	vmConfigRepo   repository.VmConfigRepository
-	ntpServerRepo  repository.NtpServerRepository
-	hostService    HostService
-	msgService     MsgService
+	ClusterService       ClusterService
+	clusterRepo          repository.ClusterRepository
+	NodeRepo             repository.ClusterNodeRepository
+	taskLogService       TaskLogService
+	HostRepo             repository.HostRepository
+	planService          PlanService
+	vmConfigRepo         repository.VmConfigRepository
+	ntpServerRepo        repository.NtpServerRepository
+	hostService          HostService
+	msgService           MsgService
+	taskSchedulerService TaskSchedulerService
+	hostPreflightService HostPreflightService
 }
 
 func (c *clusterNodeService) Get(clusterName, name string) (*dto.Node, error) {
@@ -84,6 +91,13 @@ func (c clusterNodeService) Page(num, size int, isPolling, clusterName string) (
 	if err != nil {
 		return nil, err
 	}
+	// 休眠集群的虚拟机已关机，直接返回数据库中的节点
+	if cluster.Status == constant.StatusHibernated {
+		return &dto.NodePage{
+			Items: syncNodeStatus(mNodes, &v1.NodeList{}, cluster.Source, "true"),
+			Total: count,
+		}, nil
+	}
 	client, err := clusterUtil.NewClusterClient(&cluster)
 	if err != nil {
 		return nil, err
@@ -109,6 +123,9 @@ func (c clusterNodeService) List(clusterName string) ([]dto.Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	if cluster.Status == constant.StatusHibernated {
+		return syncNodeStatus(mNodes, &v1.NodeList{}, cluster.Source, "true"), nil
+	}
 	client, err := clusterUtil.NewClusterClient(&cluster)
 	if err != nil {
 		return nil, err
@@ -135,14 +152,61 @@ func (c *clusterNodeService) Batch(clusterName string, item dto.NodeBatch) error
 			return errors.New("NODE_ALREADY_RUNNING_TASK")
 		}
 	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	isON := c.taskLogService.IsTaskOn(clusterName)
 	if isON {
 		return errors.New("TASK_IN_EXECUTION")
 	}
+	// 节点池扩容未指定主机时先选择主机，预检查覆盖实际加入集群的主机
+	if item.Operation == constant.BatchOperationCreate && item.Role != constant.NodeRoleNameMaster && item.Pool != "" &&
+		len(item.Hosts) == 0 && cluster.Provider == constant.ClusterProviderBareMetal {
+		pool, err := getNodePool(cluster.ID, item.Pool)
+		if err != nil {
+			return err
+		}
+		hosts, err := selectPoolHosts(&cluster, pool, item.Increase)
+		if err != nil {
+			return err
+		}
+		for _, h := range hosts {
+			item.Hosts = append(item.Hosts, h.Name)
+		}
+	}
+	if item.Operation == constant.BatchOperationCreate && len(item.Hosts) > 0 && !item.SkipPreflight {
+		role := item.Role
+		if role == "" {
+			role = constant.NodeRoleNameWorker
+		}
+		roles := map[string]string{}
+		for _, host := range item.Hosts {
+			roles[host] = role
+		}
+		report, err := c.hostPreflightService.CheckCluster(&cluster, roles)
+		if err != nil {
+			return err
+		}
+		if err := preflightError(report); err != nil {
+			return err
+		}
+	}
+	if item.Role == constant.NodeRoleNameMaster {
+		return c.batchMaster(&cluster, currentNodes, item)
+	}
 	switch item.Operation {
 	case constant.BatchOperationCreate:
 		return c.batchCreate(&cluster, currentNodes, item)
 	case constant.BatchOperationDelete:
+		if item.Pool != "" {
+			names, err := poolNodesForDelete(currentNodes, item)
+			if err != nil {
+				return err
+			}
+			item.Nodes = names
+		}
 		if err := db.DB.Model(&model.ClusterNode{}).Where("name in (?)", item.Nodes).
 			Updates(map[string]interface{}{"Status": constant.StatusTerminating, "Message": ""}).Error; err != nil {
 			logger.Log.Errorf("can not update node status %s", err.Error())
@@ -164,7 +228,11 @@ func (c *clusterNodeService) Batch(clusterName string, item dto.NodeBatch) error
 		cluster.CurrentTaskID = tasklog.ID
 		_ = c.clusterRepo.Save(&cluster)
 
-		go c.removeNodes(&cluster, item, currentNodes, nodesForDelete)
+		if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+			c.removeNodes(&cluster, item, currentNodes, nodesForDelete)
+		}); err != nil {
+			return err
+		}
 		return nil
 	}
 	return nil
@@ -363,10 +431,50 @@ func (c *clusterNodeService) Recreate(clusterName string, batch dto.NodeBatch) e
 		"log_id": cluster.TaskLog.ID,
 	}).Debugf("get ansible writer log of cluster %s successful, now start to init the cluster", cluster.Name)
 
-	go c.addWorkInit(&cluster, nodes, writer, "recreate")
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.addWorkInit(&cluster, nodes, writer, "recreate")
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResumeAddWorker 服务重启后继续执行被中断的扩容任务
+func (c *clusterNodeService) ResumeAddWorker(cluster model.Cluster, writer io.Writer) error {
+	var nodes []model.ClusterNode
+	if err := db.DB.Where("current_task_id = ?", cluster.TaskLog.ID).Preload("Host").Preload("Host.Credential").Find(&nodes).Error; err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("can not find nodes of task %s", cluster.TaskLog.ID)
+	}
+	operation := resumeAddWorkerOperation(cluster.Provider, nodes)
+	if operation == "" {
+		if err := db.DB.Where("id = ?", cluster.PlanID).Preload("Zones").Preload("Region").First(&cluster.Plan).Error; err != nil {
+			return err
+		}
+	}
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, cluster.TaskLog.Priority, func() {
+		c.addWorkInit(&cluster, nodes, writer, operation)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
+// resumeAddWorkerOperation 自动模式下主机尚未创建完成时需要重新创建主机，与首次扩容一致；其他情况与重试一致，只重新执行 playbook
+func resumeAddWorkerOperation(provider string, nodes []model.ClusterNode) string {
+	if provider != constant.ClusterProviderPlan {
+		return "recreate"
+	}
+	for _, n := range nodes {
+		if n.Status == constant.StatusCreating {
+			return ""
+		}
+	}
+	return "recreate"
+}
+
 // db 存在，cluster 不存在  ====>  失联
 func syncNodeStatus(nodesInDB []model.ClusterNode, kubeNodes *v1.NodeList, source, isPolling string) []dto.Node {
 	var (
@@ -38,9 +38,19 @@ func (c clusterNodeService) batchCreate(cluster *model.Cluster, currentNodes []m
 	)
 	hostNames = append(hostNames, item.Hosts...)
 
+	var pool *model.ClusterNodePool
+	if item.Pool != "" {
+		p, err := getNodePool(cluster.ID, item.Pool)
+		if err != nil {
+			return err
+		}
+		pool = &p
+	}
+
 	logger.Log.Info("start create cluster nodes")
 	switch cluster.Provider {
 	case constant.ClusterProviderBareMetal:
+		// 节点池未指定的主机已在 Batch 中选择
 		var hosts []model.Host
 		if err := db.DB.Where("name in (?)", hostNames).
 			Preload("Volumes").
@@ -48,7 +58,15 @@ func (c clusterNodeService) batchCreate(cluster *model.Cluster, currentNodes []m
 			Find(&hosts).Error; err != nil {
 			return fmt.Errorf("get hosts failed: %v", err)
 		}
-		ns, err := c.createNodeModels(cluster, currentNodes, hosts)
+		if pool != nil {
+			selector := pool.GetHostSelector()
+			for _, h := range hosts {
+				if !selector.Match(h) {
+					return fmt.Errorf("host %s does not match node pool %s", h.Name, pool.Name)
+				}
+			}
+		}
+		ns, err := c.createNodeModels(cluster, currentNodes, hosts, constant.NodeRoleNameWorker, item.Pool)
 		if err != nil {
 			return fmt.Errorf("create node model failed: %v", err)
 		}
@@ -61,11 +79,11 @@ func (c clusterNodeService) batchCreate(cluster *model.Cluster, currentNodes []m
 			return fmt.Errorf("load plan failed: %v", err)
 		}
 		cluster.Plan = plan
-		hosts, err := c.createHostModels(cluster, item.Increase)
+		hosts, err := c.createHostModels(cluster, item.Increase, pool)
 		if err != nil {
 			return fmt.Errorf("create host model failed: %v", err)
 		}
-		ns, err := c.createNodeModels(cluster, currentNodes, hosts)
+		ns, err := c.createNodeModels(cluster, currentNodes, hosts, constant.NodeRoleNameWorker, item.Pool)
 		if err != nil {
 			return fmt.Errorf("create node model failed: %v", err)
 		}
@@ -77,7 +95,11 @@ func (c clusterNodeService) batchCreate(cluster *model.Cluster, currentNodes []m
 	}
 	cluster.Nodes = append(cluster.Nodes, newNodes...)
 
-	go c.addWorkInit(cluster, newNodes, writer, "")
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.addWorkInit(cluster, newNodes, writer, "")
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -103,6 +125,11 @@ func (c *clusterNodeService) addWorkInit(cluster *model.Cluster, nodes []model.C
 	cluster.TaskLog.CreatedAt = time.Now()
 	_ = c.taskLogService.Save(&cluster.TaskLog)
 
+	if err := c.registerNodePools(cluster, nodes); err != nil {
+		c.updateNodeStatus(cluster, constant.ClusterAddWorker, constant.StatusFailed, newNodeIDs, err)
+		return
+	}
+
 	logger.Log.Info("start binding nodes to cluster")
 	var (
 		nodeIds   []string
@@ -137,6 +164,7 @@ func (c *clusterNodeService) addWorkInit(cluster *model.Cluster, nodes []model.C
 		case constant.TaskLogStatusSuccess:
 			cancel()
 			c.updateNodeStatus(cluster, constant.ClusterAddWorker, constant.StatusRunning, nodeIds, fmt.Errorf(result.Message))
+			c.applyNodePools(cluster, nodes)
 			cluster.CurrentTaskID = ""
 			_ = c.clusterRepo.Save(cluster)
 			return
@@ -148,6 +176,50 @@ func (c *clusterNodeService) addWorkInit(cluster *model.Cluster, nodes []model.C
 	}
 }
 
+// poolNodeNames 按节点池分组节点名称，不属于节点池的节点不返回
+func poolNodeNames(nodes []model.ClusterNode) map[string][]string {
+	pools := map[string][]string{}
+	for _, n := range nodes {
+		if n.NodePool != "" {
+			pools[n.NodePool] = append(pools[n.NodePool], n.Name)
+		}
+	}
+	return pools
+}
+
+// registerNodePools 节点加入集群前以不可调度状态注册节点池的节点
+func (c *clusterNodeService) registerNodePools(cluster *model.Cluster, nodes []model.ClusterNode) error {
+	for name, nodeNames := range poolNodeNames(nodes) {
+		pool, err := getNodePool(cluster.ID, name)
+		if err != nil {
+			return err
+		}
+		if err := registerPoolNodes(cluster, pool, nodeNames); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyNodePools 节点加入集群后再次同步节点池的 labels 和 taints，成功后 uncordon；
+// 失败时节点保持不可调度，不影响节点状态
+func (c *clusterNodeService) applyNodePools(cluster *model.Cluster, nodes []model.ClusterNode) {
+	for name, nodeNames := range poolNodeNames(nodes) {
+		pool, err := getNodePool(cluster.ID, name)
+		if err != nil {
+			logger.Log.Errorf("get node pool %s failed: %v", name, err)
+			continue
+		}
+		if err := applyNodePool(cluster, nil, pool, nodeNames); err != nil {
+			logger.Log.Errorf("apply node pool %s to nodes %v failed: %v", name, nodeNames, err)
+			continue
+		}
+		if err := uncordonPoolNodes(cluster, nodeNames); err != nil {
+			logger.Log.Errorf("uncordon nodes %v of node pool %s failed: %v", nodeNames, name, err)
+		}
+	}
+}
+
 func (c clusterNodeService) doCreate(ctx context.Context, aHelper adm.AnsibleHelper, statusChan chan adm.AnsibleHelper) {
 	ad := adm.NewClusterAdm()
 	for {
@@ -163,7 +235,17 @@ func (c clusterNodeService) doCreate(ctx context.Context, aHelper adm.AnsibleHel
 	}
 }
 
-func (c clusterNodeService) createNodeModels(cluster *model.Cluster, currentNodes []model.ClusterNode, hosts []model.Host) ([]model.ClusterNode, error) {
+// nextNodeName 默认命名规则下按序号查找未使用的节点名称
+func nextNodeName(clusterName, role string, used map[string]interface{}) string {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s-%s-%d", clusterName, role, i)
+		if _, ok := used[name]; !ok {
+			return name
+		}
+	}
+}
+
+func (c clusterNodeService) createNodeModels(cluster *model.Cluster, currentNodes []model.ClusterNode, hosts []model.Host, role, pool string) ([]model.ClusterNode, error) {
 	var newNodes []model.ClusterNode
 	hash := map[string]interface{}{}
 	for _, n := range currentNodes {
@@ -173,14 +255,8 @@ func (c clusterNodeService) createNodeModels(cluster *model.Cluster, currentNode
 		var name string
 		switch cluster.NodeNameRule {
 		case constant.NodeNameRuleDefault:
-			for i := 1; i < len(currentNodes)+len(hosts); i++ {
-				name = fmt.Sprintf("%s-%s-%d", cluster.Name, constant.NodeRoleNameWorker, i)
-				if _, ok := hash[name]; ok {
-					continue
-				}
-				hash[name] = nil
-				break
-			}
+			name = nextNodeName(cluster.Name, role, hash)
+			hash[name] = nil
 		case constant.NodeNameRuleIP:
 			name = host.Ip
 			if _, ok := hash[name]; ok {
@@ -198,7 +274,8 @@ func (c clusterNodeService) createNodeModels(cluster *model.Cluster, currentNode
 			Name:      name,
 			ClusterID: cluster.ID,
 			HostID:    host.ID,
-			Role:      constant.NodeRoleNameWorker,
+			Role:      role,
+			NodePool:  pool,
 			Status:    constant.StatusWaiting,
 			Host:      host,
 		}
@@ -221,7 +298,16 @@ func (c clusterNodeService) createNodeModels(cluster *model.Cluster, currentNode
 	return newNodes, nil
 }
 
-func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase int) ([]model.Host, error) {
+// createHostModels 创建 plan 集群的 worker 主机，指定节点池时使用节点池的虚拟机配置和可用区
+func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase int, pool *model.ClusterNodePool) ([]model.Host, error) {
+	zones := poolZones(cluster.Plan, pool)
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("no zone available in plan %s", cluster.Plan.Name)
+	}
+	prefix := fmt.Sprintf("%s-worker", cluster.Name)
+	if pool != nil {
+		prefix = fmt.Sprintf("%s-%s", cluster.Name, pool.Name)
+	}
 	var hosts []*model.Host
 	hash := map[string]interface{}{}
 	for _, node := range cluster.Nodes {
@@ -232,7 +318,7 @@ func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase in
 	for i := 0; i < increase; i++ {
 		var name string
 		for k := 0; k < increase+len(hosts); k++ {
-			n := fmt.Sprintf("%s-worker-%d", cluster.Name, k+1)
+			n := fmt.Sprintf("%s-%d", prefix, k+1)
 			if _, ok := hash[n]; !ok {
 				name = n
 				hash[name] = nil
@@ -247,8 +333,11 @@ func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase in
 		if cluster.Plan.Region.Provider != constant.OpenStack {
 			planVars := map[string]string{}
 			_ = json.Unmarshal([]byte(cluster.Plan.Vars), &planVars)
-			role := getHostRole(newHost.Name)
-			workerConfig, err := c.vmConfigRepo.Get(planVars[fmt.Sprintf("%sModel", role)])
+			vmConfig := planVars[fmt.Sprintf("%sModel", constant.NodeRoleNameWorker)]
+			if pool != nil && pool.VmConfig != "" {
+				vmConfig = pool.VmConfig
+			}
+			workerConfig, err := c.vmConfigRepo.Get(vmConfig)
 			if err != nil {
 				return nil, err
 			}
@@ -257,7 +346,7 @@ func (c clusterNodeService) createHostModels(cluster *model.Cluster, increase in
 		}
 		newHosts = append(newHosts, newHost)
 	}
-	group := allocateZone(cluster.Plan.Zones, newHosts)
+	group := allocateZone(zones, newHosts)
 	for k, v := range group {
 		providerVars := map[string]interface{}{}
 		providerVars["provider"] = cluster.Plan.Region.Provider
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ansible"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+)
+
+// batchMaster 控制节点每次只增加或移除一个，保证 etcd 成员变更期间 quorum 可用
+func (c *clusterNodeService) batchMaster(cluster *model.Cluster, currentNodes []model.ClusterNode, item dto.NodeBatch) error {
+	if cluster.Provider != constant.ClusterProviderBareMetal {
+		return errors.New("MASTER_SCALE_NOT_SUPPORTED")
+	}
+	switch item.Operation {
+	case constant.BatchOperationCreate:
+		if len(item.Hosts) != 1 {
+			return errors.New("MASTER_CHANGE_ONE_AT_A_TIME")
+		}
+		if err := adm.NewClusterAdm().CheckPlaybooks(constant.TaskLogTypeClusterMasterExtend); err != nil {
+			return err
+		}
+		return c.addMaster(cluster, currentNodes, item.Hosts[0])
+	case constant.BatchOperationDelete:
+		if len(item.Nodes) != 1 {
+			return errors.New("MASTER_CHANGE_ONE_AT_A_TIME")
+		}
+		if err := adm.NewClusterAdm().CheckPlaybooks(constant.TaskLogTypeClusterMasterShrink); err != nil {
+			return err
+		}
+		return c.removeMaster(cluster, currentNodes, item.Nodes[0])
+	}
+	return nil
+}
+
+func (c *clusterNodeService) addMaster(cluster *model.Cluster, currentNodes []model.ClusterNode, hostName string) error {
+	control := firstRunningMaster(currentNodes, "")
+	if control.ID == "" {
+		return errors.New("CLUSTER_NO_AVAILABLE_MASTER")
+	}
+	var host model.Host
+	if err := db.DB.Where("name = ?", hostName).Preload("Volumes").Preload("Credential").First(&host).Error; err != nil {
+		return fmt.Errorf("get host %s failed: %v", hostName, err)
+	}
+	if host.ClusterID != "" {
+		return fmt.Errorf("host %s is already used by other cluster", hostName)
+	}
+
+	tasklog := model.TaskLog{
+		ClusterID: cluster.ID,
+		Type:      constant.TaskLogTypeClusterMasterExtend,
+		Phase:     constant.StatusWaiting,
+	}
+	if err := c.taskLogService.Start(&tasklog); err != nil {
+		return err
+	}
+	cluster.TaskLog = tasklog
+	cluster.CurrentTaskID = tasklog.ID
+	_ = c.clusterRepo.Save(cluster)
+
+	nodes, err := c.createNodeModels(cluster, currentNodes, []model.Host{host}, constant.NodeRoleNameMaster, "")
+	if err != nil {
+		_ = c.taskLogService.End(&cluster.TaskLog, false, err.Error())
+		return fmt.Errorf("create node model failed: %v", err)
+	}
+	writer, err := ansible.CreateAnsibleLogWriterWithId(cluster.Name, cluster.TaskLog.ID)
+	if err != nil {
+		return err
+	}
+	cluster.Nodes = append(cluster.Nodes, nodes...)
+
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.runMaster(cluster, nodes[0], control, writer)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *clusterNodeService) removeMaster(cluster *model.Cluster, currentNodes []model.ClusterNode, nodeName string) error {
+	var node model.ClusterNode
+	for _, n := range currentNodes {
+		if n.Name == nodeName {
+			node = n
+		}
+	}
+	if node.ID == "" || node.Role != constant.NodeRoleNameMaster {
+		return fmt.Errorf("%s is not a master of cluster %s", nodeName, cluster.Name)
+	}
+	control := firstRunningMaster(currentNodes, node.Name)
+	if control.ID == "" {
+		return errors.New("CLUSTER_NO_AVAILABLE_MASTER")
+	}
+	cfg, err := control.ToSSHConfig()
+	if err != nil {
+		return err
+	}
+	client, err := ssh.New(&cfg)
+	if err != nil {
+		return err
+	}
+	if err := adm.CheckRemoveMasterQuorum(client, node); err != nil {
+		return err
+	}
+
+	tasklog := model.TaskLog{
+		ClusterID: cluster.ID,
+		Type:      constant.TaskLogTypeClusterMasterShrink,
+		Phase:     constant.StatusWaiting,
+	}
+	if err := c.taskLogService.Start(&tasklog); err != nil {
+		return err
+	}
+	cluster.TaskLog = tasklog
+	cluster.CurrentTaskID = tasklog.ID
+	_ = c.clusterRepo.Save(cluster)
+
+	writer, err := ansible.CreateAnsibleLogWriterWithId(cluster.Name, cluster.TaskLog.ID)
+	if err != nil {
+		return err
+	}
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.runMaster(cluster, node, control, writer)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResumeMaster 服务重启后继续执行被中断的控制节点变更任务
+func (c *clusterNodeService) ResumeMaster(cluster model.Cluster, writer io.Writer) error {
+	var node model.ClusterNode
+	if err := db.DB.Where("current_task_id = ?", cluster.TaskLog.ID).Preload("Host").Preload("Host.Credential").First(&node).Error; err != nil {
+		return fmt.Errorf("can not find node of task %s", cluster.TaskLog.ID)
+	}
+	control := firstRunningMaster(cluster.Nodes, node.Name)
+	if control.ID == "" {
+		return errors.New("CLUSTER_NO_AVAILABLE_MASTER")
+	}
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, cluster.TaskLog.Priority, func() {
+		c.runMaster(&cluster, node, control, writer)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *clusterNodeService) runMaster(cluster *model.Cluster, node, control model.ClusterNode, writer io.Writer) {
+	group, status, operation := "new-master", constant.StatusInitializing, constant.ClusterAddMaster
+	if cluster.TaskLog.Type == constant.TaskLogTypeClusterMasterShrink {
+		group, status, operation = "del-master", constant.StatusTerminating, constant.ClusterRemoveMaster
+		// playbook 使用切换后的 apiserver 入口，任务成功后才保存
+		switchApiserverEntry(&cluster.SpecConf, node, control)
+	}
+
+	cluster.TaskLog.Phase = constant.TaskLogStatusRunning
+	cluster.TaskLog.CreatedAt = time.Now()
+	_ = c.taskLogService.Save(&cluster.TaskLog)
+
+	if err := db.DB.Model(&model.ClusterNode{}).Where("id = ?", node.ID).
+		Updates(map[string]interface{}{"Status": status, "Message": "", "CurrentTaskID": cluster.TaskLog.ID}).Error; err != nil {
+		c.masterFailed(cluster, operation, node, err)
+		return
+	}
+	for i := range cluster.Nodes {
+		if cluster.Nodes[i].ID == node.ID {
+			cluster.Nodes[i].Status = status
+		}
+	}
+
+	admCluster := adm.NewAnsibleHelperWithMaster(*cluster, group, node, control, writer)
+	statusChan := make(chan adm.AnsibleHelper)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go c.doMaster(ctx, cluster.TaskLog.Type, *admCluster, statusChan)
+	for {
+		result := <-statusChan
+		cluster.TaskLog.Phase = result.Status
+		cluster.TaskLog.Message = result.Message
+		cluster.TaskLog.Details = result.LogDetail
+		_ = c.taskLogService.Save(&cluster.TaskLog)
+		switch result.Status {
+		case constant.TaskLogStatusSuccess:
+			cancel()
+			if group == "del-master" {
+				if err := c.releaseMaster(node); err != nil {
+					c.masterFailed(cluster, operation, node, err)
+					return
+				}
+				if err := db.DB.Save(&cluster.SpecConf).Error; err != nil {
+					logger.Log.Errorf("save apiserver entry of cluster %s failed: %s", cluster.Name, err.Error())
+				}
+			}
+			c.updateNodeStatus(cluster, operation, constant.StatusRunning, []string{node.ID}, nil)
+			releaseMasterTask(cluster)
+			return
+		case constant.TaskLogStatusFailed:
+			cancel()
+			c.masterFailed(cluster, operation, node, fmt.Errorf(result.Message))
+			return
+		}
+	}
+}
+
+// masterFailed 标记节点和任务失败并释放集群，不保存内存中切换后的 apiserver 入口
+func (c *clusterNodeService) masterFailed(cluster *model.Cluster, operation string, node model.ClusterNode, err error) {
+	c.updateNodeStatus(cluster, operation, constant.StatusFailed, []string{node.ID}, err)
+	releaseMasterTask(cluster)
+}
+
+// releaseMasterTask 只清除集群的当前任务，cluster.Nodes 中可能包含已经删除的节点，不能整体保存
+func releaseMasterTask(cluster *model.Cluster) {
+	cluster.CurrentTaskID = ""
+	if err := db.DB.Model(&model.Cluster{}).Where("id = ?", cluster.ID).Update("current_task_id", "").Error; err != nil {
+		logger.Log.Errorf("release cluster %s failed: %s", cluster.Name, err.Error())
+	}
+}
+
+// switchApiserverEntry 被移除的 master 作为 apiserver 入口时切换到 control
+func switchApiserverEntry(spec *model.ClusterSpecConf, node, control model.ClusterNode) {
+	if spec.KubeRouter == node.Host.Ip {
+		spec.KubeRouter = control.Host.Ip
+	}
+	if spec.LbMode == constant.LbModeInternal && spec.LbKubeApiserverIp == node.Host.Ip {
+		spec.LbKubeApiserverIp = control.Host.Ip
+	}
+}
+
+func (c clusterNodeService) doMaster(ctx context.Context, taskType string, aHelper adm.AnsibleHelper, statusChan chan adm.AnsibleHelper) {
+	ad := adm.NewClusterAdm()
+	for {
+		var err error
+		if taskType == constant.TaskLogTypeClusterMasterShrink {
+			err = ad.OnRemoveMaster(&aHelper)
+		} else {
+			err = ad.OnAddMaster(&aHelper)
+		}
+		if err != nil {
+			aHelper.Message = err.Error()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case statusChan <- aHelper:
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// releaseMaster 释放主机并删除节点记录
+func (c *clusterNodeService) releaseMaster(node model.ClusterNode) error {
+	tx := db.DB.Begin()
+	if err := tx.Model(&model.Host{}).Where("id = ?", node.HostID).Update(map[string]interface{}{"ClusterID": ""}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("resource_id = ? AND resource_type = ?", node.HostID, constant.ResourceHost).
+		Delete(&model.ClusterResource{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("id = ?", node.ID).Delete(&model.ClusterNode{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Commit()
+	logger.Log.Infof("master %s removed", node.Name)
+	return nil
+}
+
+func firstRunningMaster(nodes []model.ClusterNode, exclude string) model.ClusterNode {
+	for _, n := range nodes {
+		if n.Role == constant.NodeRoleNameMaster && n.Status == constant.StatusRunning && n.Name != exclude {
+			return n
+		}
+	}
+	return model.ClusterNode{}
+}
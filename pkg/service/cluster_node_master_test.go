@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+)
+
+func TestSwitchApiserverEntry(t *testing.T) {
+	node := model.ClusterNode{Host: model.Host{Ip: "172.16.10.11"}}
+	control := model.ClusterNode{Host: model.Host{Ip: "172.16.10.12"}}
+	tests := []struct {
+		name string
+		spec model.ClusterSpecConf
+		want model.ClusterSpecConf
+	}{
+		{
+			name: "removed node is entry",
+			spec: model.ClusterSpecConf{KubeRouter: "172.16.10.11", LbMode: constant.LbModeInternal, LbKubeApiserverIp: "172.16.10.11"},
+			want: model.ClusterSpecConf{KubeRouter: "172.16.10.12", LbMode: constant.LbModeInternal, LbKubeApiserverIp: "172.16.10.12"},
+		},
+		{
+			name: "external load balancer",
+			spec: model.ClusterSpecConf{KubeRouter: "172.16.10.13", LbMode: constant.LbModeExternal, LbKubeApiserverIp: "172.16.10.11"},
+			want: model.ClusterSpecConf{KubeRouter: "172.16.10.13", LbMode: constant.LbModeExternal, LbKubeApiserverIp: "172.16.10.11"},
+		},
+		{
+			name: "other entry",
+			spec: model.ClusterSpecConf{KubeRouter: "172.16.10.13", LbMode: constant.LbModeInternal, LbKubeApiserverIp: "172.16.10.13"},
+			want: model.ClusterSpecConf{KubeRouter: "172.16.10.13", LbMode: constant.LbModeInternal, LbKubeApiserverIp: "172.16.10.13"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			switchApiserverEntry(&tt.spec, node, control)
+			if tt.spec.KubeRouter != tt.want.KubeRouter || tt.spec.LbKubeApiserverIp != tt.want.LbKubeApiserverIp {
+				t.Errorf("switchApiserverEntry() = %s %s, want %s %s", tt.spec.KubeRouter, tt.spec.LbKubeApiserverIp, tt.want.KubeRouter, tt.want.LbKubeApiserverIp)
+			}
+		})
+	}
+}
+
+func TestNextNodeName(t *testing.T) {
+	tests := []struct {
+		name string
+		role string
+		used []string
+		want string
+	}{
+		{name: "master on all in one", role: constant.NodeRoleNameMaster, used: []string{"c1-master-1"}, want: "c1-master-2"},
+		{name: "three masters without workers", role: constant.NodeRoleNameMaster, used: []string{"c1-master-1", "c1-master-2", "c1-master-3"}, want: "c1-master-4"},
+		{name: "reuse removed master name", role: constant.NodeRoleNameMaster, used: []string{"c1-master-1", "c1-master-3", "c1-worker-1"}, want: "c1-master-2"},
+		{name: "first worker", role: constant.NodeRoleNameWorker, used: []string{"c1-master-1"}, want: "c1-worker-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := map[string]interface{}{}
+			for _, n := range tt.used {
+				used[n] = nil
+			}
+			if got := nextNodeName("c1", tt.role, used); got != tt.want {
+				t.Errorf("nextNodeName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	"github.com/jinzhu/gorm"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var nodePoolNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+type ClusterNodePoolService interface {
+	List(clusterName string) ([]dto.ClusterNodePool, error)
+	Get(clusterName, name string) (*dto.ClusterNodePool, error)
+	Create(clusterName string, req dto.ClusterNodePool) (*dto.ClusterNodePool, error)
+	Update(clusterName, name string, req dto.ClusterNodePool) (*dto.ClusterNodePool, error)
+	Delete(clusterName, name string) error
+}
+
+func NewClusterNodePoolService() ClusterNodePoolService {
+	return &clusterNodePoolService{
+		clusterRepo:  repository.NewClusterRepository(),
+		vmConfigRepo: repository.NewVmConfigRepository(),
+	}
+}
+
+type clusterNodePoolService struct {
+	clusterRepo  repository.ClusterRepository
+	vmConfigRepo repository.VmConfigRepository
+}
+
+func (c *clusterNodePoolService) List(clusterName string) ([]dto.ClusterNodePool, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	var pools []model.ClusterNodePool
+	if err := db.DB.Where("cluster_id = ?", cluster.ID).Order("name").Find(&pools).Error; err != nil {
+		return nil, err
+	}
+	sizes, err := nodePoolSizes(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := []dto.ClusterNodePool{}
+	for _, p := range pools {
+		item := toNodePoolDTO(p)
+		item.Size = sizes[p.Name]
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (c *clusterNodePoolService) Get(clusterName, name string) (*dto.ClusterNodePool, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := getNodePool(cluster.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	sizes, err := nodePoolSizes(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	item := toNodePoolDTO(pool)
+	item.Size = sizes[pool.Name]
+	return &item, nil
+}
+
+func (c *clusterNodePoolService) Create(clusterName string, req dto.ClusterNodePool) (*dto.ClusterNodePool, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"Plan", "Plan.Region", "Plan.Zones"})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validate(cluster, req); err != nil {
+		return nil, err
+	}
+	var count int
+	if err := db.DB.Model(&model.ClusterNodePool{}).Where("cluster_id = ? AND name = ?", cluster.ID, req.Name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("NODE_POOL_EXISTS")
+	}
+	pool := toNodePoolModel(req)
+	pool.ClusterID = cluster.ID
+	if err := db.DB.Create(&pool).Error; err != nil {
+		return nil, err
+	}
+	item := toNodePoolDTO(pool)
+	return &item, nil
+}
+
+// Update 修改节点池定义，labels 和 taints 的变更会同步到节点池中运行的节点，
+// vmConfig、zones 和 kubeletReserved 只对之后加入的节点生效
+func (c *clusterNodePoolService) Update(clusterName, name string, req dto.ClusterNodePool) (*dto.ClusterNodePool, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "Secret", "Plan", "Plan.Region", "Plan.Zones", "Nodes", "Nodes.Host", "Nodes.Host.Credential"})
+	if err != nil {
+		return nil, err
+	}
+	old, err := getNodePool(cluster.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	req.Name = old.Name
+	if err := c.validate(cluster, req); err != nil {
+		return nil, err
+	}
+	pool := toNodePoolModel(req)
+	pool.ID = old.ID
+	pool.ClusterID = old.ClusterID
+	pool.CreatedAt = old.CreatedAt
+
+	var nodeNames []string
+	for _, n := range cluster.Nodes {
+		if n.NodePool == pool.Name && n.Status == constant.StatusRunning {
+			nodeNames = append(nodeNames, n.Name)
+		}
+	}
+	if len(nodeNames) > 0 && (old.Labels != pool.Labels || old.Taints != pool.Taints) {
+		if err := applyNodePool(&cluster, &old, pool, nodeNames); err != nil {
+			return nil, err
+		}
+	}
+	if err := db.DB.Save(&pool).Error; err != nil {
+		return nil, err
+	}
+	item := toNodePoolDTO(pool)
+	item.Size = len(nodeNames)
+	return &item, nil
+}
+
+func (c *clusterNodePoolService) Delete(clusterName, name string) error {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return err
+	}
+	pool, err := getNodePool(cluster.ID, name)
+	if err != nil {
+		return err
+	}
+	var count int
+	if err := db.DB.Model(&model.ClusterNode{}).Where("cluster_id = ? AND node_pool = ?", cluster.ID, pool.Name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("NODE_POOL_NOT_EMPTY")
+	}
+	return db.DB.Delete(&pool).Error
+}
+
+func (c *clusterNodePoolService) validate(cluster model.Cluster, req dto.ClusterNodePool) error {
+	if !validNodePoolName(req.Name) {
+		return errors.New("NODE_POOL_NAME_INVALID")
+	}
+	for _, t := range req.Taints {
+		switch v1.TaintEffect(t.Effect) {
+		case v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("invalid taint effect %s of key %s", t.Effect, t.Key)
+		}
+	}
+	if cluster.Provider != constant.ClusterProviderPlan {
+		if req.VmConfig != "" || len(req.Zones) > 0 {
+			return errors.New("vmConfig and zones are only supported by plan clusters")
+		}
+		return nil
+	}
+	if req.HostSelector != nil {
+		return errors.New("hostSelector is only supported by bare metal clusters")
+	}
+	if req.VmConfig != "" {
+		// openstack 按部署计划中的 flavor 创建虚拟机，不使用虚拟机配置
+		if cluster.Plan.Region.Provider == constant.OpenStack {
+			return errors.New("vmConfig is not supported by openstack plans")
+		}
+		if _, err := c.vmConfigRepo.Get(req.VmConfig); err != nil {
+			return fmt.Errorf("vm config %s not found", req.VmConfig)
+		}
+	}
+	zones := map[string]bool{}
+	for _, z := range cluster.Plan.Zones {
+		zones[z.Name] = true
+	}
+	for _, z := range req.Zones {
+		if !zones[z] {
+			return fmt.Errorf("zone %s not in plan %s", z, cluster.Plan.Name)
+		}
+	}
+	return nil
+}
+
+// validNodePoolName 节点池主机名为 <集群名>-<节点池名>-<序号>，名称中包含 master 段时会被识别为 master 主机
+func validNodePoolName(name string) bool {
+	return nodePoolNamePattern.MatchString(name) && !strings.Contains("-"+name+"-", "-"+constant.NodeRoleNameMaster+"-")
+}
+
+func getNodePool(clusterID, name string) (model.ClusterNodePool, error) {
+	var pool model.ClusterNodePool
+	if err := db.DB.Where("cluster_id = ? AND name = ?", clusterID, name).First(&pool).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return pool, errors.New("NODE_POOL_NOT_FOUND")
+		}
+		return pool, err
+	}
+	return pool, nil
+}
+
+func nodePoolSizes(clusterID string) (map[string]int, error) {
+	var nodes []model.ClusterNode
+	if err := db.DB.Select("node_pool").Where("cluster_id = ? AND node_pool <> ''", clusterID).Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	sizes := map[string]int{}
+	for _, n := range nodes {
+		sizes[n.NodePool]++
+	}
+	return sizes, nil
+}
+
+// poolZones 节点池未指定 zone 时使用部署计划的全部 zone
+func poolZones(plan model.Plan, pool *model.ClusterNodePool) []model.Zone {
+	if pool == nil {
+		return plan.Zones
+	}
+	var names []string
+	_ = json.Unmarshal([]byte(pool.Zones), &names)
+	if len(names) == 0 {
+		return plan.Zones
+	}
+	var zones []model.Zone
+	for _, z := range plan.Zones {
+		for _, name := range names {
+			if z.Name == name {
+				zones = append(zones, z)
+			}
+		}
+	}
+	return zones
+}
+
+// registerPoolNodes 节点加入集群前预先创建不可调度的 node 并设置节点池的 labels 和 taints，
+// kubelet 注册时沿用已存在的 node，节点加入后、uncordon 之前不会有 pod 调度到节点上
+func registerPoolNodes(cluster *model.Cluster, pool model.ClusterNodePool, nodeNames []string) error {
+	client, err := clusterUtil.NewClusterClient(cluster)
+	if err != nil {
+		return err
+	}
+	for _, name := range nodeNames {
+		node := &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1.NodeSpec{Unschedulable: true},
+		}
+		if _, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err == nil {
+			continue
+		} else if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("register node %s failed: %v", name, err)
+		}
+		node, err = client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		node.Spec.Unschedulable = true
+		if _, err := client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("cordon node %s failed: %v", name, err)
+		}
+	}
+	return applyNodePool(cluster, nil, pool, nodeNames)
+}
+
+// uncordonPoolNodes 节点池的 labels 和 taints 设置完成后允许调度
+func uncordonPoolNodes(cluster *model.Cluster, nodeNames []string) error {
+	client, err := clusterUtil.NewClusterClient(cluster)
+	if err != nil {
+		return err
+	}
+	for _, name := range nodeNames {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		node.Spec.Unschedulable = false
+		if _, err := client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("uncordon node %s failed: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// applyNodePool 为节点设置节点池的 labels 和 taints，old 不为空时先移除旧定义中的 labels 和 taints
+func applyNodePool(cluster *model.Cluster, old *model.ClusterNodePool, pool model.ClusterNodePool, nodeNames []string) error {
+	client, err := clusterUtil.NewClusterClient(cluster)
+	if err != nil {
+		return err
+	}
+	var (
+		labels, oldLabels map[string]string
+		taints, oldTaints []model.NodePoolTaint
+	)
+	_ = json.Unmarshal([]byte(pool.Labels), &labels)
+	_ = json.Unmarshal([]byte(pool.Taints), &taints)
+	if old != nil {
+		_ = json.Unmarshal([]byte(old.Labels), &oldLabels)
+		_ = json.Unmarshal([]byte(old.Taints), &oldTaints)
+	}
+	managed := map[string]bool{}
+	for _, t := range append(oldTaints, taints...) {
+		managed[t.Key] = true
+	}
+
+	for _, name := range nodeNames {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		for k := range oldLabels {
+			delete(node.Labels, k)
+		}
+		for k, v := range labels {
+			node.Labels[k] = v
+		}
+		node.Labels[constant.NodePoolLabelKey] = pool.Name
+
+		var nodeTaints []v1.Taint
+		for _, t := range node.Spec.Taints {
+			if !managed[t.Key] {
+				nodeTaints = append(nodeTaints, t)
+			}
+		}
+		for _, t := range taints {
+			nodeTaints = append(nodeTaints, v1.Taint{Key: t.Key, Value: t.Value, Effect: v1.TaintEffect(t.Effect)})
+		}
+		node.Spec.Taints = nodeTaints
+		if _, err := client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("update node %s failed: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func toNodePoolModel(item dto.ClusterNodePool) model.ClusterNodePool {
+	pool := model.ClusterNodePool{
+		Name:     item.Name,
+		VmConfig: item.VmConfig,
+	}
+	if len(item.Zones) > 0 {
+		zones, _ := json.Marshal(item.Zones)
+		pool.Zones = string(zones)
+	}
+	if item.HostSelector != nil {
+		selector, _ := json.Marshal(item.HostSelector)
+		pool.HostSelector = string(selector)
+	}
+	if len(item.Labels) > 0 {
+		labels, _ := json.Marshal(item.Labels)
+		pool.Labels = string(labels)
+	}
+	if len(item.Taints) > 0 {
+		taints, _ := json.Marshal(item.Taints)
+		pool.Taints = string(taints)
+	}
+	if item.KubeletReserved != nil {
+		reserved, _ := json.Marshal(item.KubeletReserved)
+		pool.KubeletReserved = string(reserved)
+	}
+	return pool
+}
+
+func toNodePoolDTO(pool model.ClusterNodePool) dto.ClusterNodePool {
+	item := dto.ClusterNodePool{
+		Name:     pool.Name,
+		VmConfig: pool.VmConfig,
+	}
+	_ = json.Unmarshal([]byte(pool.Zones), &item.Zones)
+	_ = json.Unmarshal([]byte(pool.Labels), &item.Labels)
+	_ = json.Unmarshal([]byte(pool.Taints), &item.Taints)
+	if pool.HostSelector != "" {
+		item.HostSelector = &model.NodePoolHostSelector{}
+		_ = json.Unmarshal([]byte(pool.HostSelector), item.HostSelector)
+	}
+	if pool.KubeletReserved != "" {
+		item.KubeletReserved = &model.NodePoolKubeletReserved{}
+		_ = json.Unmarshal([]byte(pool.KubeletReserved), item.KubeletReserved)
+	}
+	return item
+}
+
+// selectPoolHosts 从集群所属项目的空闲主机中选择满足节点池条件的主机
+func selectPoolHosts(cluster *model.Cluster, pool model.ClusterNodePool, increase int) ([]model.Host, error) {
+	var projectResource model.ProjectResource
+	if err := db.DB.Where("resource_id = ? AND resource_type = ?", cluster.ID, constant.ResourceCluster).First(&projectResource).Error; err != nil {
+		return nil, fmt.Errorf("can not find project resource %s", err.Error())
+	}
+	var hostResources []model.ProjectResource
+	if err := db.DB.Where("project_id = ? AND resource_type = ?", projectResource.ProjectID, constant.ResourceHost).Find(&hostResources).Error; err != nil {
+		return nil, err
+	}
+	var hostIDs []string
+	for _, r := range hostResources {
+		hostIDs = append(hostIDs, r.ResourceID)
+	}
+	var candidates []model.Host
+	if len(hostIDs) > 0 {
+		if err := db.DB.Where("id in (?) AND cluster_id = '' AND status = ?", hostIDs, constant.StatusRunning).
+			Preload("Volumes").
+			Preload("Credential").
+			Order("name").
+			Find(&candidates).Error; err != nil {
+			return nil, err
+		}
+	}
+	selector := pool.GetHostSelector()
+	var hosts []model.Host
+	for _, h := range candidates {
+		if len(hosts) == increase {
+			break
+		}
+		if selector.Match(h) {
+			hosts = append(hosts, h)
+		}
+	}
+	if len(hosts) < increase {
+		return nil, errors.New("NODE_POOL_NO_MATCH_HOST")
+	}
+	return hosts, nil
+}
+
+// poolNodesForDelete 未指定节点时从节点池中选择最近加入的节点缩容
+func poolNodesForDelete(currentNodes []model.ClusterNode, item dto.NodeBatch) ([]string, error) {
+	var members []model.ClusterNode
+	for _, n := range currentNodes {
+		if n.Role == constant.NodeRoleNameWorker && n.NodePool == item.Pool {
+			members = append(members, n)
+		}
+	}
+	if len(item.Nodes) > 0 {
+		for _, name := range item.Nodes {
+			found := false
+			for _, n := range members {
+				found = found || n.Name == name
+			}
+			if !found {
+				return nil, fmt.Errorf("node %s is not in node pool %s", name, item.Pool)
+			}
+		}
+		return item.Nodes, nil
+	}
+	if item.Increase > len(members) {
+		return nil, fmt.Errorf("node pool %s only has %d nodes", item.Pool, len(members))
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].CreatedAt.After(members[j].CreatedAt) })
+	var names []string
+	for _, n := range members[:item.Increase] {
+		names = append(names, n.Name)
+	}
+	return names, nil
+}
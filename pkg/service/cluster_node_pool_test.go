@@ -0,0 +1,94 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+)
+
+func TestValidNodePoolName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "gpu", want: true},
+		{name: "gpu-a100", want: true},
+		{name: "masterful", want: true},
+		{name: "master"},
+		{name: "master-gpu"},
+		{name: "gpu-master"},
+		{name: "a-master-b"},
+		{name: "GPU"},
+		{name: "-gpu"},
+		{name: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validNodePoolName(tt.name); got != tt.want {
+				t.Errorf("validNodePoolName(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolNodesForDelete(t *testing.T) {
+	now := time.Now()
+	nodes := []model.ClusterNode{
+		{Name: "m1", Role: constant.NodeRoleNameMaster, NodePool: "gpu"},
+		{Name: "w1", Role: constant.NodeRoleNameWorker, NodePool: "gpu", BaseModel: common.BaseModel{CreatedAt: now.Add(-time.Hour)}},
+		{Name: "w2", Role: constant.NodeRoleNameWorker, NodePool: "gpu", BaseModel: common.BaseModel{CreatedAt: now}},
+		{Name: "w3", Role: constant.NodeRoleNameWorker},
+	}
+	tests := []struct {
+		name    string
+		item    dto.NodeBatch
+		want    []string
+		wantErr bool
+	}{
+		{name: "newest first", item: dto.NodeBatch{Pool: "gpu", Increase: 1}, want: []string{"w2"}},
+		{name: "masters are not members", item: dto.NodeBatch{Pool: "gpu", Increase: 3}, wantErr: true},
+		{name: "named nodes", item: dto.NodeBatch{Pool: "gpu", Nodes: []string{"w1"}}, want: []string{"w1"}},
+		{name: "named master", item: dto.NodeBatch{Pool: "gpu", Nodes: []string{"m1"}}, wantErr: true},
+		{name: "node of other pool", item: dto.NodeBatch{Pool: "gpu", Nodes: []string{"w3"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := poolNodesForDelete(nodes, tt.item)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("poolNodesForDelete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("poolNodesForDelete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolZones(t *testing.T) {
+	plan := model.Plan{Zones: []model.Zone{{Name: "a"}, {Name: "b"}}}
+	tests := []struct {
+		name string
+		pool *model.ClusterNodePool
+		want []string
+	}{
+		{name: "no pool", want: []string{"a", "b"}},
+		{name: "no zones", pool: &model.ClusterNodePool{}, want: []string{"a", "b"}},
+		{name: "selected", pool: &model.ClusterNodePool{Zones: `["b"]`}, want: []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, z := range poolZones(plan, tt.pool) {
+				got = append(got, z.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("poolZones() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
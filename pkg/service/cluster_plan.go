@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/version"
+)
+
+// ClusterPlanService 预览创建、升级集群时将要执行的内容，不写数据库也不调用 kobe
+type ClusterPlanService interface {
+	PlanCreate(creation dto.ClusterCreate) (*dto.ClusterPlan, error)
+	PlanUpgrade(upgrade dto.ClusterUpgrade) (*dto.ClusterPlan, error)
+}
+
+func NewClusterPlanService() ClusterPlanService {
+	return &clusterPlanService{
+		clusterRepo:         repository.NewClusterRepository(),
+		clusterManifestRepo: repository.NewClusterManifestRepository(),
+	}
+}
+
+type clusterPlanService struct {
+	clusterRepo         repository.ClusterRepository
+	clusterManifestRepo repository.ClusterManifestRepository
+}
+
+func (c *clusterPlanService) PlanCreate(creation dto.ClusterCreate) (*dto.ClusterPlan, error) {
+	cluster := creation.ClusterCreateDto2Mo()
+	var project model.Project
+	if err := db.DB.Where("name = ?", creation.ProjectName).First(&project).Error; err != nil {
+		return nil, fmt.Errorf("select project failed, err: %v", err)
+	}
+	cluster.ProjectID = project.ID
+	if _, err := adm.GetManiFestBy(cluster.Version); err != nil {
+		return nil, fmt.Errorf("can not find manifest %s, err: %v", cluster.Version, err)
+	}
+
+	if cluster.Provider == constant.ClusterProviderPlan {
+		if err := db.DB.Where("name = ?", creation.Plan).Preload("Zones").Preload("Region").First(&cluster.Plan).Error; err != nil {
+			return nil, fmt.Errorf("select plan %s failed, err: %s", creation.Plan, err.Error())
+		}
+		cluster.Nodes = planNodes(cluster)
+	} else {
+		nodes, err := metalNodes(cluster, creation.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		cluster.Nodes = nodes
+	}
+
+	plan := adm.NewClusterAdm().Plan(*cluster, constant.TaskLogTypeClusterCreate)
+	return &plan, nil
+}
+
+func (c *clusterPlanService) PlanUpgrade(upgrade dto.ClusterUpgrade) (*dto.ClusterPlan, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(upgrade.ClusterName, []string{"SpecConf", "SpecNetwork", "SpecRuntime", "Nodes", "Nodes.Host", "Nodes.Host.Credential", "Nodes.Host.Zone", "MultiClusterRepositories"})
+	if err != nil {
+		return nil, fmt.Errorf("can not get cluster %s error %s", upgrade.ClusterName, err.Error())
+	}
+	if cluster.Source == constant.ClusterSourceExternal {
+		return nil, errors.New("CLUSTER_IS_NOT_LOCAL")
+	}
+	if len(upgrade.Version) != 0 {
+		cluster.UpgradeVersion = upgrade.Version
+	}
+	if _, err := adm.GetManiFestBy(cluster.UpgradeVersion); err != nil {
+		return nil, fmt.Errorf("can not find manifest %s, err: %v", cluster.UpgradeVersion, err)
+	}
+
+	manifests, err := c.clusterManifestRepo.ListByStatus()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, m := range manifests {
+		names = append(names, m.Name)
+	}
+	hops, err := version.UpgradePath(cluster.Version, cluster.UpgradeVersion, names)
+	if err != nil {
+		return nil, err
+	}
+
+	taskType := constant.TaskLogTypeClusterUpgrade
+	if upgrade.Strategy == constant.UpgradeStrategyRolling {
+		taskType = constant.TaskLogTypeClusterRollingUpgrade
+	}
+	plan := adm.NewClusterAdm().Plan(cluster, taskType, hops...)
+	return &plan, nil
+}
+
+func metalNodes(cluster *model.Cluster, creations []dto.NodeCreate) ([]model.ClusterNode, error) {
+	var nodes []model.ClusterNode
+	workerNo, masterNo := 1, 1
+	for _, nc := range creations {
+		var host model.Host
+		if err := db.DB.Where("name = ?", nc.HostName).Preload("Credential").First(&host).Error; err != nil {
+			return nil, fmt.Errorf("can not find host %s", nc.HostName)
+		}
+		if host.ClusterID != "" {
+			return nil, fmt.Errorf("host %s is already used by other cluster", nc.HostName)
+		}
+		nodes = append(nodes, model.ClusterNode{
+			Name:   metalNodeName(cluster, nc.Role, host, &masterNo, &workerNo),
+			Role:   nc.Role,
+			HostID: host.ID,
+			Host:   host,
+		})
+	}
+	return nodes, nil
+}
+
+// planNodes 部署计划的主机在创建时才分配 IP，这里按部署模板的 master 数量和命名规则生成节点
+func planNodes(cluster *model.Cluster) []model.ClusterNode {
+	var nodes []model.ClusterNode
+	for i := 0; i < cluster.Plan.MasterAmount(); i++ {
+		name := fmt.Sprintf("%s-%s-%d", cluster.Name, constant.NodeRoleNameMaster, i+1)
+		nodes = append(nodes, model.ClusterNode{Name: name, Role: constant.NodeRoleNameMaster, Host: model.Host{Name: name, Port: 22}})
+	}
+	for i := 0; i < cluster.SpecConf.WorkerAmount; i++ {
+		name := fmt.Sprintf("%s-%s-%d", cluster.Name, constant.NodeRoleNameWorker, i+1)
+		nodes = append(nodes, model.ClusterNode{Name: name, Role: constant.NodeRoleNameWorker, Host: model.Host{Name: name, Port: 22}})
+	}
+	return nodes
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ansible"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ClusterRuntimeService interface {
+	Migrate(clusterName string) error
+	Resume(cluster model.Cluster, writer io.Writer)
+}
+
+func NewClusterRuntimeService() ClusterRuntimeService {
+	return &clusterRuntimeService{
+		clusterRepo:          repository.NewClusterRepository(),
+		taskLogService:       NewTaskLogService(),
+		msgService:           NewMsgService(),
+		kubernetesService:    NewKubernetesService(),
+		taskSchedulerService: NewTaskSchedulerService(),
+	}
+}
+
+type clusterRuntimeService struct {
+	clusterRepo          repository.ClusterRepository
+	taskLogService       TaskLogService
+	msgService           MsgService
+	kubernetesService    KubernetesService
+	taskSchedulerService TaskSchedulerService
+}
+
+// Migrate 将集群容器运行时从 docker 逐个节点迁移到 containerd，全部节点完成后才修改集群配置
+func (c *clusterRuntimeService) Migrate(clusterName string) error {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "SpecNetwork", "SpecRuntime", "Secret", "Nodes", "Nodes.Host", "Nodes.Host.Credential", "MultiClusterRepositories"})
+	if err != nil {
+		return err
+	}
+	if cluster.Source != constant.ClusterSourceLocal {
+		return errors.New("RUNTIME_MIGRATION_NOT_SUPPORTED")
+	}
+	if cluster.SpecRuntime.RuntimeType != "docker" {
+		return errors.New("RUNTIME_ALREADY_CONTAINERD")
+	}
+	if cluster.Status != constant.StatusRunning {
+		return fmt.Errorf("cluster status error %s", cluster.Status)
+	}
+	// 全部节点迁移完成后才修改集群配置，不能跳过未运行的节点
+	for _, n := range cluster.Nodes {
+		if n.Status != constant.StatusRunning {
+			return errors.New("RUNTIME_MIGRATION_NODE_NOT_RUNNING")
+		}
+	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(clusterName) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
+	if err := adm.RequirePlaybooks(adm.MigrateRuntimePlaybooks()...); err != nil {
+		return err
+	}
+
+	tasklog := model.TaskLog{
+		ClusterID: cluster.ID,
+		Type:      constant.TaskLogTypeClusterRuntimeMigrate,
+	}
+	if err := c.taskLogService.Start(&tasklog); err != nil {
+		return err
+	}
+	cluster.TaskLog = tasklog
+	cluster.CurrentTaskID = tasklog.ID
+	_ = c.clusterRepo.Save(&cluster)
+
+	writer, err := ansible.CreateAnsibleLogWriterWithId(cluster.Name, tasklog.ID)
+	if err != nil {
+		return err
+	}
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.do(&cluster, writer)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Resume 服务重启后继续迁移，已迁移的节点会被跳过
+func (c *clusterRuntimeService) Resume(cluster model.Cluster, writer io.Writer) {
+	c.do(&cluster, writer)
+}
+
+func (c *clusterRuntimeService) do(cluster *model.Cluster, writer io.Writer) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cluster.TaskLog.Phase = constant.TaskLogStatusRunning
+	_ = c.taskLogService.Save(&cluster.TaskLog)
+
+	admCluster := adm.NewAnsibleHelper(*cluster, writer)
+	admCluster.Migration = c.runtimeMigration(*cluster)
+	statusChan := make(chan adm.AnsibleHelper)
+	go c.doMigrate(ctx, *admCluster, statusChan)
+	for {
+		result := <-statusChan
+		cluster.TaskLog.Phase = result.Status
+		cluster.TaskLog.Message = result.Message
+		cluster.TaskLog.Details = result.LogDetail
+		_ = c.taskLogService.Save(&cluster.TaskLog)
+		switch result.Status {
+		case constant.TaskLogStatusSuccess:
+			_ = c.taskLogService.End(&cluster.TaskLog, true, "")
+			cluster.SpecRuntime.RuntimeType = "containerd"
+			if err := db.DB.Save(&cluster.SpecRuntime).Error; err != nil {
+				logger.Log.Errorf("save runtime of cluster %s failed: %s", cluster.Name, err.Error())
+			}
+			cluster.CurrentTaskID = ""
+			_ = c.clusterRepo.Save(cluster)
+			_ = c.msgService.SendMsg(constant.ClusterRuntimeMigrate, constant.Cluster, cluster, true, map[string]string{"detailName": cluster.Name})
+			return
+		case constant.TaskLogStatusFailed:
+			_ = c.taskLogService.End(&cluster.TaskLog, false, result.Message)
+			// 运行时配置未修改，只清除当前任务，不保存整个集群
+			cluster.CurrentTaskID = ""
+			if err := db.DB.Model(&model.Cluster{}).Where("id = ?", cluster.ID).Update("current_task_id", "").Error; err != nil {
+				logger.Log.Errorf("release task of cluster %s failed: %s", cluster.Name, err.Error())
+			}
+			_ = c.msgService.SendMsg(constant.ClusterRuntimeMigrate, constant.Cluster, cluster, false, map[string]string{"errMsg": result.Message, "detailName": cluster.Name})
+			return
+		}
+	}
+}
+
+func (c *clusterRuntimeService) doMigrate(ctx context.Context, aHelper adm.AnsibleHelper, statusChan chan adm.AnsibleHelper) {
+	ad := adm.NewClusterAdm()
+	for {
+		if err := ad.OnMigrateRuntime(&aHelper); err != nil {
+			aHelper.Message = err.Error()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case statusChan <- aHelper:
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// runtimeMigration worker 先迁移，master 最后逐个迁移
+func (c *clusterRuntimeService) runtimeMigration(cluster model.Cluster) *adm.RuntimeMigration {
+	m := &adm.RuntimeMigration{
+		StorageDir: cluster.SpecRuntime.ContainerdStorageDir,
+		Drain: func(node string) error {
+			return c.kubernetesService.DrainNode(cluster.Name, node)
+		},
+		Uncordon: func(node string) error {
+			return c.kubernetesService.CordonNode(dto.Cordon{Name: node, Cluster: cluster.Name, SetUnschedulable: false})
+		},
+		Migrated: func(node string) bool {
+			client, err := clusterUtil.NewClusterClient(&cluster)
+			if err != nil {
+				return false
+			}
+			n, err := client.CoreV1().Nodes().Get(context.TODO(), node, metav1.GetOptions{})
+			if err != nil {
+				return false
+			}
+			if !strings.HasPrefix(n.Status.NodeInfo.ContainerRuntimeVersion, "containerd://") {
+				return false
+			}
+			for _, cond := range n.Status.Conditions {
+				if cond.Type == "Ready" {
+					return cond.Status == "True"
+				}
+			}
+			return false
+		},
+	}
+	// 包含全部节点，继续执行时未运行的节点迁移失败，集群配置保持不变
+	var masters []string
+	for _, n := range cluster.Nodes {
+		if n.Role == constant.NodeRoleNameMaster {
+			masters = append(masters, n.Name)
+		} else {
+			m.Nodes = append(m.Nodes, n.Name)
+		}
+	}
+	m.Nodes = append(m.Nodes, masters...)
+	return m
+}
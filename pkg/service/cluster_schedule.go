@@ -0,0 +1,299 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/jinzhu/gorm"
+	"github.com/robfig/cron/v3"
+)
+
+type ClusterScheduleService interface {
+	List(clusterName string) ([]model.ClusterSchedule, error)
+	Create(clusterName string, req dto.ClusterSchedule) (*model.ClusterSchedule, error)
+	Update(clusterName, name string, req dto.ClusterSchedule) (*model.ClusterSchedule, error)
+	Delete(clusterName, name string) error
+	RunDue()
+}
+
+func NewClusterScheduleService() ClusterScheduleService {
+	return &clusterScheduleService{
+		clusterRepo:             repository.NewClusterRepository(),
+		clusterNodeService:      NewClusterNodeService(),
+		clusterHibernateService: NewClusterHibernateService(),
+		taskLogService:          NewTaskLogService(),
+		msgService:              NewMsgService(),
+	}
+}
+
+type clusterScheduleService struct {
+	clusterRepo             repository.ClusterRepository
+	clusterNodeService      ClusterNodeService
+	clusterHibernateService ClusterHibernateService
+	taskLogService          TaskLogService
+	msgService              MsgService
+}
+
+func (c *clusterScheduleService) List(clusterName string) ([]model.ClusterSchedule, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	items := []model.ClusterSchedule{}
+	if err := db.DB.Where("cluster_id = ?", cluster.ID).Order("name").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (c *clusterScheduleService) Create(clusterName string, req dto.ClusterSchedule) (*model.ClusterSchedule, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+	if err := validateSchedule(cluster, req, enabled); err != nil {
+		return nil, err
+	}
+	var count int
+	if err := db.DB.Model(&model.ClusterSchedule{}).Where("cluster_id = ? AND name = ?", cluster.ID, req.Name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("SCHEDULE_EXISTS")
+	}
+	item := model.ClusterSchedule{
+		ClusterID:   cluster.ID,
+		Name:        req.Name,
+		Cron:        req.Cron,
+		Action:      req.Action,
+		NodePool:    req.NodePool,
+		WorkerCount: req.WorkerCount,
+		Enabled:     enabled,
+	}
+	if err := db.DB.Create(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (c *clusterScheduleService) Update(clusterName, name string, req dto.ClusterSchedule) (*model.ClusterSchedule, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	item, err := getSchedule(cluster.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	req.Name = item.Name
+	enabled := item.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	if err := validateSchedule(cluster, req, enabled); err != nil {
+		return nil, err
+	}
+	// 修改表达式后从当前时间重新计算下次执行时间
+	if item.Cron != req.Cron || (!item.Enabled && enabled) {
+		now := time.Now()
+		item.LastRunAt = &now
+	}
+	item.Cron = req.Cron
+	item.Action = req.Action
+	item.NodePool = req.NodePool
+	item.WorkerCount = req.WorkerCount
+	item.Enabled = enabled
+	if err := db.DB.Save(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (c *clusterScheduleService) Delete(clusterName, name string) error {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return err
+	}
+	item, err := getSchedule(cluster.ID, name)
+	if err != nil {
+		return err
+	}
+	return db.DB.Delete(&item).Error
+}
+
+// RunDue 执行到期的定时任务，由定时任务每分钟调用；错过的多次执行只补执行一次
+func (c *clusterScheduleService) RunDue() {
+	var items []model.ClusterSchedule
+	if err := db.DB.Where("enabled = ?", true).Find(&items).Error; err != nil {
+		logger.Log.Errorf("list cluster schedules failed: %s", err.Error())
+		return
+	}
+	now := time.Now()
+	for i := range items {
+		item := &items[i]
+		schedule, err := cron.ParseStandard(item.Cron)
+		if err != nil {
+			continue
+		}
+		last := item.CreatedAt
+		if item.LastRunAt != nil {
+			last = *item.LastRunAt
+		}
+		if schedule.Next(last).After(now) {
+			continue
+		}
+		if err := c.run(item, now); err != nil {
+			logger.Log.Errorf("run cluster schedule %s failed: %s", item.Name, err.Error())
+		}
+	}
+}
+
+func (c *clusterScheduleService) run(item *model.ClusterSchedule, now time.Time) error {
+	var cluster model.Cluster
+	if err := db.DB.Preload("Nodes").Where("id = ?", item.ClusterID).First(&cluster).Error; err != nil {
+		return err
+	}
+	// 集群正在执行其他任务时不记录执行时间，下个周期重试
+	if c.taskLogService.IsTaskOn(cluster.Name) {
+		return nil
+	}
+
+	var (
+		event string
+		err   error
+	)
+	switch item.Action {
+	case constant.ScheduleActionHibernate:
+		if cluster.Status == constant.StatusHibernated {
+			event = "集群已休眠，跳过"
+			break
+		}
+		event = "休眠集群"
+		err = c.clusterHibernateService.Hibernate(cluster.Name)
+	case constant.ScheduleActionWake:
+		if cluster.Status == constant.StatusRunning {
+			event = "集群已运行，跳过"
+			break
+		}
+		event = "唤醒集群"
+		err = c.clusterHibernateService.Wake(cluster.Name)
+	default:
+		event, err = c.scale(cluster, item)
+	}
+
+	item.LastRunAt = &now
+	item.LastMessage = event
+	if err != nil {
+		item.LastMessage = fmt.Sprintf("%s，执行失败: %s", event, err.Error())
+	}
+	if e := db.DB.Save(item).Error; e != nil {
+		logger.Log.Errorf("save cluster schedule failed: %s", e.Error())
+	}
+	content := map[string]string{"message": fmt.Sprintf("%s: %s", item.Name, item.LastMessage), "detailName": cluster.Name}
+	if err != nil {
+		content["errMsg"] = err.Error()
+	}
+	_ = c.msgService.SendMsg(constant.ClusterSchedule, constant.Cluster, &cluster, err == nil, content)
+	return err
+}
+
+// scale 将节点池中的 worker 数量调整为 workerCount，缩容时优先删除最新加入的节点
+func (c *clusterScheduleService) scale(cluster model.Cluster, item *model.ClusterSchedule) (string, error) {
+	if cluster.Status != constant.StatusRunning {
+		return fmt.Sprintf("集群状态为 %s，跳过伸缩", cluster.Status), nil
+	}
+	// 节点池已开启自动伸缩时由自动伸缩管理节点数，避免两者互相抵消
+	autoscaler, err := getAutoscaler(cluster.ID)
+	if err != nil {
+		return "", err
+	}
+	if scheduleConflictsAutoscaler(autoscaler, item.NodePool) {
+		return "节点池已开启自动伸缩，跳过伸缩", nil
+	}
+	batch, event := scheduleScaleBatch(autoscaleMembers(cluster.Nodes, item.NodePool), item.NodePool, item.WorkerCount)
+	if batch.Operation == "" {
+		return event, nil
+	}
+	return event, c.clusterNodeService.Batch(cluster.Name, batch)
+}
+
+// scheduleScaleBatch 计算将节点池调整为 workerCount 个 worker 的批量操作，无需调整时 Operation 为空
+func scheduleScaleBatch(members []model.ClusterNode, pool string, workerCount int) (dto.NodeBatch, string) {
+	size := len(members)
+	batch := dto.NodeBatch{Pool: pool}
+	switch {
+	case workerCount > size:
+		batch.Operation = constant.BatchOperationCreate
+		batch.Increase = workerCount - size
+	case workerCount < size:
+		sort.Slice(members, func(i, j int) bool { return members[i].CreatedAt.After(members[j].CreatedAt) })
+		batch.Operation = constant.BatchOperationDelete
+		for _, n := range members[:size-workerCount] {
+			batch.Nodes = append(batch.Nodes, n.Name)
+		}
+	default:
+		return batch, fmt.Sprintf("节点数已为 %d，跳过伸缩", size)
+	}
+	return batch, fmt.Sprintf("节点数由 %d 调整为 %d", size, workerCount)
+}
+
+// scheduleConflictsAutoscaler 同一节点池不能同时开启定时伸缩和自动伸缩
+func scheduleConflictsAutoscaler(autoscaler model.ClusterAutoscaler, pool string) bool {
+	return autoscaler.Enabled && autoscaler.NodePool == pool
+}
+
+func validateSchedule(cluster model.Cluster, req dto.ClusterSchedule, enabled bool) error {
+	if !nodePoolNamePattern.MatchString(req.Name) {
+		return errors.New("SCHEDULE_NAME_INVALID")
+	}
+	if cluster.Provider != constant.ClusterProviderPlan {
+		return errors.New("HIBERNATE_NOT_SUPPORTED")
+	}
+	if _, err := cron.ParseStandard(req.Cron); err != nil {
+		return fmt.Errorf("invalid cron expression %s: %s", req.Cron, err.Error())
+	}
+	switch req.Action {
+	case constant.ScheduleActionScale:
+		if req.WorkerCount < 0 {
+			return errors.New("SCHEDULE_WORKER_COUNT_INVALID")
+		}
+		if req.NodePool != "" {
+			if _, err := getNodePool(cluster.ID, req.NodePool); err != nil {
+				return err
+			}
+		}
+		if enabled {
+			autoscaler, err := getAutoscaler(cluster.ID)
+			if err != nil {
+				return err
+			}
+			if scheduleConflictsAutoscaler(autoscaler, req.NodePool) {
+				return errors.New("SCHEDULE_AUTOSCALER_CONFLICT")
+			}
+		}
+	case constant.ScheduleActionHibernate, constant.ScheduleActionWake:
+	default:
+		return fmt.Errorf("invalid schedule action %s", req.Action)
+	}
+	return nil
+}
+
+func getSchedule(clusterID, name string) (model.ClusterSchedule, error) {
+	var item model.ClusterSchedule
+	if err := db.DB.Where("cluster_id = ? AND name = ?", clusterID, name).First(&item).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return item, errors.New("SCHEDULE_NOT_FOUND")
+		}
+		return item, err
+	}
+	return item, nil
+}
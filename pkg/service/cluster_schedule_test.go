@@ -0,0 +1,81 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
+)
+
+func TestScheduleScaleBatch(t *testing.T) {
+	now := time.Now()
+	members := func() []model.ClusterNode {
+		return []model.ClusterNode{
+			{Name: "w1", BaseModel: common.BaseModel{CreatedAt: now.Add(-2 * time.Hour)}},
+			{Name: "w2", BaseModel: common.BaseModel{CreatedAt: now}},
+			{Name: "w3", BaseModel: common.BaseModel{CreatedAt: now.Add(-time.Hour)}},
+		}
+	}
+	tests := []struct {
+		name        string
+		workerCount int
+		want        dto.NodeBatch
+	}{
+		{name: "unchanged", workerCount: 3, want: dto.NodeBatch{Pool: "gpu"}},
+		{name: "scale up", workerCount: 5, want: dto.NodeBatch{Pool: "gpu", Operation: constant.BatchOperationCreate, Increase: 2}},
+		{name: "scale down newest first", workerCount: 1, want: dto.NodeBatch{Pool: "gpu", Operation: constant.BatchOperationDelete, Nodes: []string{"w2", "w3"}}},
+		{name: "scale to zero", workerCount: 0, want: dto.NodeBatch{Pool: "gpu", Operation: constant.BatchOperationDelete, Nodes: []string{"w2", "w3", "w1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, event := scheduleScaleBatch(members(), "gpu", tt.workerCount)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scheduleScaleBatch() = %+v, want %+v", got, tt.want)
+			}
+			if event == "" {
+				t.Errorf("scheduleScaleBatch() event is empty")
+			}
+		})
+	}
+}
+
+func TestScheduleConflictsAutoscaler(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoscaler model.ClusterAutoscaler
+		pool       string
+		want       bool
+	}{
+		{name: "autoscaler disabled", autoscaler: model.ClusterAutoscaler{NodePool: "gpu"}, pool: "gpu"},
+		{name: "same pool", autoscaler: model.ClusterAutoscaler{Enabled: true, NodePool: "gpu"}, pool: "gpu", want: true},
+		{name: "default pool", autoscaler: model.ClusterAutoscaler{Enabled: true}, pool: "", want: true},
+		{name: "other pool", autoscaler: model.ClusterAutoscaler{Enabled: true, NodePool: "gpu"}, pool: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheduleConflictsAutoscaler(tt.autoscaler, tt.pool); got != tt.want {
+				t.Errorf("scheduleConflictsAutoscaler() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHibernateHosts(t *testing.T) {
+	cluster := model.Cluster{Nodes: []model.ClusterNode{
+		{Role: constant.NodeRoleNameMaster, Host: model.Host{Name: "m1"}},
+		{Role: constant.NodeRoleNameWorker, Host: model.Host{Name: "w1"}},
+		{Role: constant.NodeRoleNameMaster, Host: model.Host{Name: "m2"}},
+		{Role: constant.NodeRoleNameWorker, Host: model.Host{Name: "w2"}},
+	}}
+	masters, workers := hibernateHosts(cluster)
+	if !reflect.DeepEqual(masters, []string{"m1", "m2"}) {
+		t.Errorf("masters = %v, want [m1 m2]", masters)
+	}
+	if !reflect.DeepEqual(workers, []string{"w1", "w2"}) {
+		t.Errorf("workers = %v, want [w1 w2]", workers)
+	}
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/errorf"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ansible"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/preflight"
+)
+
+type ClusterSpecService interface {
+	Update(clusterName string, req dto.ClusterSpecUpdate) (*dto.ClusterSpecUpdateResult, error)
+}
+
+func NewClusterSpecService() ClusterSpecService {
+	return &clusterSpecService{
+		clusterRepo:          repository.NewClusterRepository(),
+		taskLogService:       NewTaskLogService(),
+		msgService:           NewMsgService(),
+		taskSchedulerService: NewTaskSchedulerService(),
+	}
+}
+
+type clusterSpecService struct {
+	clusterRepo          repository.ClusterRepository
+	taskLogService       TaskLogService
+	msgService           MsgService
+	taskSchedulerService TaskSchedulerService
+}
+
+// Update 校验并应用运行中集群的配置变更，只重新执行受影响的步骤，成功后才保存新配置
+func (c *clusterSpecService) Update(clusterName string, req dto.ClusterSpecUpdate) (*dto.ClusterSpecUpdateResult, error) {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "SpecNetwork", "SpecRuntime", "Secret", "Nodes", "Nodes.Host", "Nodes.Host.Credential", "MultiClusterRepositories"})
+	if err != nil {
+		return nil, err
+	}
+	if cluster.Source != constant.ClusterSourceLocal {
+		return nil, errors.New("CLUSTER_SPEC_NOT_SUPPORTED")
+	}
+
+	var result dto.ClusterSpecUpdateResult
+	var names []string
+	confChanges, confPhases, err := mergeSpec(adm.SpecScopeConf, &cluster.SpecConf, req.Conf)
+	if err != nil {
+		return nil, err
+	}
+	runtimeChanges, runtimePhases, err := mergeSpec(adm.SpecScopeRuntime, &cluster.SpecRuntime, req.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	result.Changes = append(confChanges, runtimeChanges...)
+	names = append(confPhases, runtimePhases...)
+	if len(result.Changes) == 0 {
+		return &result, nil
+	}
+	ad := adm.NewClusterAdm()
+	result.Phases = ad.SpecPhases(names)
+	if req.DryRun {
+		return &result, nil
+	}
+	if cluster.Status != constant.StatusRunning {
+		return nil, fmt.Errorf("cluster status error %s", cluster.Status)
+	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(clusterName) {
+		return nil, errors.New("TASK_IN_EXECUTION")
+	}
+	if err := adm.RequirePlaybooks(ad.SpecPlaybooks(names)...); err != nil {
+		return nil, err
+	}
+	if hasSpecChange(result.Changes, "kubeProxyMode") && cluster.SpecConf.KubeProxyMode == "ipvs" {
+		if err := checkIpvsModules(cluster.Nodes); err != nil {
+			return nil, err
+		}
+	}
+
+	tasklog := model.TaskLog{
+		ClusterID: cluster.ID,
+		Type:      constant.TaskLogTypeClusterSpecUpdate,
+	}
+	if err := c.taskLogService.Start(&tasklog); err != nil {
+		return nil, err
+	}
+	cluster.TaskLog = tasklog
+	cluster.CurrentTaskID = tasklog.ID
+	if err := db.DB.Model(&model.Cluster{}).Where("id = ?", cluster.ID).Update("current_task_id", tasklog.ID).Error; err != nil {
+		return nil, err
+	}
+	writer, err := ansible.CreateAnsibleLogWriterWithId(cluster.Name, tasklog.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.TaskID = tasklog.ID
+
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.do(cluster, names, writer)
+	}); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *clusterSpecService) do(cluster model.Cluster, names []string, writer io.Writer) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	admCluster := adm.NewAnsibleHelper(cluster, writer)
+	statusChan := make(chan adm.AnsibleHelper)
+	go c.doUpdate(ctx, *admCluster, names, statusChan)
+	for {
+		result := <-statusChan
+		cluster.TaskLog.Phase = result.Status
+		cluster.TaskLog.Message = result.Message
+		cluster.TaskLog.Details = result.LogDetail
+		_ = c.taskLogService.Save(&cluster.TaskLog)
+		switch result.Status {
+		case constant.TaskLogStatusSuccess:
+			// 配置保存失败时任务标记为失败，避免集群实际配置与记录不一致却显示成功
+			if err := c.saveSpec(cluster); err != nil {
+				logger.Log.Errorf("save spec of cluster %s failed: %s", cluster.Name, err.Error())
+				c.end(cluster, fmt.Errorf("save spec failed: %s", err.Error()))
+				return
+			}
+			c.end(cluster, nil)
+			return
+		case constant.TaskLogStatusFailed:
+			logger.Log.Errorf("update spec of cluster %s failed: %s", cluster.Name, result.Message)
+			c.end(cluster, errors.New(result.Message))
+			return
+		}
+	}
+}
+
+// end 结束任务并清除集群当前任务，失败时集群配置保持不变，可以重新提交变更
+func (c *clusterSpecService) end(cluster model.Cluster, err error) {
+	if err != nil {
+		_ = c.taskLogService.End(&cluster.TaskLog, false, err.Error())
+	} else {
+		_ = c.taskLogService.End(&cluster.TaskLog, true, "")
+	}
+	if e := db.DB.Model(&model.Cluster{}).Where("id = ?", cluster.ID).Update("current_task_id", "").Error; e != nil {
+		logger.Log.Errorf("release task of cluster %s failed: %s", cluster.Name, e.Error())
+	}
+}
+
+func (c *clusterSpecService) doUpdate(ctx context.Context, aHelper adm.AnsibleHelper, names []string, statusChan chan adm.AnsibleHelper) {
+	ad := adm.NewClusterAdm()
+	for {
+		if err := ad.OnUpdateSpec(&aHelper, names); err != nil {
+			aHelper.Message = err.Error()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case statusChan <- aHelper:
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (c *clusterSpecService) saveSpec(cluster model.Cluster) error {
+	tx := db.DB.Begin()
+	if err := tx.Save(&cluster.SpecConf).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Save(&cluster.SpecRuntime).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// mergeSpec 将变更合并到 spec 中，返回实际发生变化的字段和需要重新执行的步骤
+func mergeSpec(scope string, spec interface{}, changes map[string]interface{}) ([]dto.ClusterSpecChange, []string, error) {
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &current); err != nil {
+		return nil, nil, err
+	}
+
+	var fields []string
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	var (
+		diff   []dto.ClusterSpecChange
+		phases []string
+	)
+	for _, field := range fields {
+		p, ok := adm.SpecFieldPhases(scope, field)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s.%s can not be changed on a running cluster", scope, field)
+		}
+		if reflect.DeepEqual(current[field], changes[field]) {
+			continue
+		}
+		if err := adm.ValidateSpecField(scope, field, changes[field]); err != nil {
+			return nil, nil, err
+		}
+		diff = append(diff, dto.ClusterSpecChange{Scope: scope, Field: field, Old: current[field], New: changes[field]})
+		phases = append(phases, p...)
+		current[field] = changes[field]
+	}
+
+	raw, err = json.Marshal(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(raw, spec); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s spec: %v", scope, err)
+	}
+	return diff, phases, nil
+}
+
+func hasSpecChange(changes []dto.ClusterSpecChange, field string) bool {
+	for _, c := range changes {
+		if c.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIpvsModules 切换到 ipvs 模式前检查全部节点是否提供 ipvs 需要的内核模块，无法连接的节点同样拒绝
+func checkIpvsModules(nodes []model.ClusterNode) error {
+	var (
+		lock sync.Mutex
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, preflightConcurrency)
+		errs errorf.CErrFs
+	)
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(node model.ClusterNode) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			facts, checks := runHostPreflight(node.Host, preflight.Options{Modules: preflight.IpvsModules})
+			lock.Lock()
+			defer lock.Unlock()
+			for _, check := range checks {
+				if check.Name == preflight.CheckConnection && check.Level == preflight.LevelFail {
+					errs = errs.Add(errorf.New("HOST_PREFLIGHT_FAILED", node.Name, check.Name, check.Msg))
+				}
+			}
+			if len(facts.MissingModules) > 0 {
+				errs = errs.Add(errorf.New("CLUSTER_SPEC_IPVS_MODULES_MISSING", node.Name, strings.Join(facts.MissingModules, ", ")))
+			}
+		}(n)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
+)
+
+func TestMergeSpec(t *testing.T) {
+	tests := []struct {
+		name       string
+		changes    map[string]interface{}
+		wantDiff   []dto.ClusterSpecChange
+		wantPhases []string
+		wantMode   string
+		wantErr    string
+	}{
+		{
+			name:     "no changes",
+			wantMode: "iptables",
+		},
+		{
+			name:     "same value",
+			changes:  map[string]interface{}{"kubeProxyMode": "iptables"},
+			wantMode: "iptables",
+		},
+		{
+			name:       "changed",
+			changes:    map[string]interface{}{"kubeProxyMode": "ipvs", "kubernetesAudit": "no"},
+			wantDiff:   []dto.ClusterSpecChange{{Scope: adm.SpecScopeConf, Field: "kubeProxyMode", Old: "iptables", New: "ipvs"}},
+			wantPhases: []string{"EnsureReconfigureKubeProxy"},
+			wantMode:   "ipvs",
+		},
+		{
+			name:     "not changeable",
+			changes:  map[string]interface{}{"kubePodSubnet": "10.0.0.0/16"},
+			wantErr:  "conf.kubePodSubnet can not be changed on a running cluster",
+			wantMode: "iptables",
+		},
+		{
+			name:     "invalid type",
+			changes:  map[string]interface{}{"kubeMaxPods": "many"},
+			wantErr:  "invalid conf.kubeMaxPods",
+			wantMode: "iptables",
+		},
+		{
+			name:     "invalid proxy mode",
+			changes:  map[string]interface{}{"kubeProxyMode": "foo"},
+			wantErr:  "invalid conf.kubeProxyMode",
+			wantMode: "iptables",
+		},
+		{
+			name:     "negative max pods",
+			changes:  map[string]interface{}{"kubeMaxPods": float64(-1)},
+			wantErr:  "invalid conf.kubeMaxPods",
+			wantMode: "iptables",
+		},
+		{
+			name:     "malformed node port range",
+			changes:  map[string]interface{}{"kubeServiceNodePortRange": "30000:32767"},
+			wantErr:  "invalid conf.kubeServiceNodePortRange",
+			wantMode: "iptables",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := model.ClusterSpecConf{KubeProxyMode: "iptables", KubernetesAudit: "no", KubeMaxPods: 110}
+			diff, phases, err := mergeSpec(adm.SpecScopeConf, &conf, tt.changes)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("mergeSpec() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeSpec() error = %v", err)
+			}
+			if !reflect.DeepEqual(diff, tt.wantDiff) || !reflect.DeepEqual(phases, tt.wantPhases) {
+				t.Errorf("mergeSpec() = %v, %v, want %v, %v", diff, phases, tt.wantDiff, tt.wantPhases)
+			}
+			if conf.KubeProxyMode != tt.wantMode {
+				t.Errorf("kubeProxyMode = %s, want %s", conf.KubeProxyMode, tt.wantMode)
+			}
+		})
+	}
+}
@@ -42,18 +42,20 @@ type ClusterStorageProvisionerService interface {
 }
 
 type clusterStorageProvisionerService struct {
-	provisionerRepo repository.ClusterStorageProvisionerRepository
-	clusterService  ClusterService
-	clusterRepo     repository.ClusterRepository
-	taskLogService  TaskLogService
+	provisionerRepo      repository.ClusterStorageProvisionerRepository
+	clusterService       ClusterService
+	clusterRepo          repository.ClusterRepository
+	taskLogService       TaskLogService
+	taskSchedulerService TaskSchedulerService
 }
 
 func NewClusterStorageProvisionerService() ClusterStorageProvisionerService {
 	return &clusterStorageProvisionerService{
-		provisionerRepo: repository.NewClusterStorageProvisionerRepository(),
-		clusterService:  NewClusterService(),
-		clusterRepo:     repository.NewClusterRepository(),
-		taskLogService:  NewTaskLogService(),
+		provisionerRepo:      repository.NewClusterStorageProvisionerRepository(),
+		clusterService:       NewClusterService(),
+		clusterRepo:          repository.NewClusterRepository(),
+		taskLogService:       NewTaskLogService(),
+		taskSchedulerService: NewTaskSchedulerService(),
 	}
 }
 
@@ -148,32 +150,33 @@ func (c clusterStorageProvisionerService) CreateStorageProvisioner(clusterName s
 		return err
 	}
 
-	go c.docreate(&cluster, task, dp, creation.Vars, writer)
-	return nil
+	return submitDetail(c.taskSchedulerService, c.taskLogService, &task, dp.Name, "provisioner", constant.TaskLogTypeProvisioner, func() {
+		c.docreate(&cluster, &task, dp, creation.Vars, writer)
+	})
 }
 
-func (c clusterStorageProvisionerService) docreate(cluster *model.Cluster, task model.TaskLogDetail, dp model.ClusterStorageProvisioner, vars map[string]interface{}, writer io.Writer) {
+func (c clusterStorageProvisionerService) docreate(cluster *model.Cluster, task *model.TaskLogDetail, dp model.ClusterStorageProvisioner, vars map[string]interface{}, writer io.Writer) {
 	admCluster, err := c.loadAdmCluster(*cluster, dp, vars, constant.StatusEnabled)
 	if err != nil {
-		_ = c.taskLogService.EndDetail(&task, dp.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, dp.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerProvisioner(dp, constant.StatusFailed, err)
 		return
 	}
 
 	client, err := clusterUtil.NewClusterClient(cluster)
 	if err != nil {
-		_ = c.taskLogService.EndDetail(&task, dp.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, dp.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerProvisioner(dp, constant.StatusFailed, err)
 		return
 	}
 
 	playbook := strings.ReplaceAll(task.Task, " (enable)", "")
 	if err := phases.RunPlaybookAndGetResult(admCluster.Kobe, playbook, "", writer); err != nil {
-		_ = c.taskLogService.EndDetail(&task, dp.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, dp.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerProvisioner(dp, constant.StatusFailed, err)
 		return
 	}
-	_ = c.taskLogService.EndDetail(&task, dp.Name, "provisioner", constant.TaskLogStatusSuccess, "")
+	_ = c.taskLogService.EndDetail(task, dp.Name, "provisioner", constant.TaskLogStatusSuccess, "")
 	dp.Status = constant.StatusWaiting
 	if err := db.DB.Save(&dp).Error; err != nil {
 		logger.Log.Errorf("save storage provisioner status err: %s", err.Error())
@@ -228,26 +231,26 @@ func (c clusterStorageProvisionerService) DeleteStorageProvisioner(clusterName s
 		return err
 	}
 
-	go c.dodelete(&cluster, task, provisioner, Vars, writer)
-
-	return nil
+	return submitDetail(c.taskSchedulerService, c.taskLogService, &task, provisioner.Name, "provisioner", constant.TaskLogTypeProvisioner, func() {
+		c.dodelete(&cluster, &task, provisioner, Vars, writer)
+	})
 }
 
-func (c clusterStorageProvisionerService) dodelete(cluster *model.Cluster, task model.TaskLogDetail, provisioner model.ClusterStorageProvisioner, vars map[string]interface{}, writer io.Writer) {
+func (c clusterStorageProvisionerService) dodelete(cluster *model.Cluster, task *model.TaskLogDetail, provisioner model.ClusterStorageProvisioner, vars map[string]interface{}, writer io.Writer) {
 	admCluster, err := c.loadAdmCluster(*cluster, provisioner, vars, constant.StatusDisabled)
 	if err != nil {
-		_ = c.taskLogService.EndDetail(&task, provisioner.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, provisioner.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerProvisioner(provisioner, constant.StatusFailed, err)
 		return
 	}
 
 	playbook := strings.ReplaceAll(task.Task, " (disable)", "")
 	if err := phases.RunPlaybookAndGetResult(admCluster.Kobe, playbook, "", writer); err != nil {
-		_ = c.taskLogService.EndDetail(&task, provisioner.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, provisioner.Name, "provisioner", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerProvisioner(provisioner, constant.StatusFailed, err)
 		return
 	}
-	_ = c.taskLogService.EndDetail(&task, provisioner.Name, "provisioner", constant.TaskLogStatusSuccess, "")
+	_ = c.taskLogService.EndDetail(task, provisioner.Name, "provisioner", constant.TaskLogStatusSuccess, "")
 	_ = db.DB.Where("id = ?", provisioner.ID).Delete(&model.ClusterStorageProvisioner{})
 }
 
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
+)
+
+type ClusterTaskService interface {
+	Pause(clusterName, taskID string) error
+	Resume(clusterName, taskID string) error
+	Skip(clusterName, taskID, handler string) error
+	Cancel(clusterName, taskID string) error
+}
+
+func NewClusterTaskService() ClusterTaskService {
+	return &clusterTaskService{
+		clusterRepo:    repository.NewClusterRepository(),
+		taskLogService: NewTaskLogService(),
+	}
+}
+
+type clusterTaskService struct {
+	clusterRepo    repository.ClusterRepository
+	taskLogService TaskLogService
+}
+
+func (c *clusterTaskService) Pause(clusterName, taskID string) error {
+	task, err := c.getTask(clusterName, taskID)
+	if err != nil {
+		return err
+	}
+	if isTaskEnd(task) {
+		return errors.New("TASK_NOT_RUNNING")
+	}
+	if err := adm.PauseTask(task.ID); err != nil {
+		return err
+	}
+	return c.record(task, constant.TaskActionPause, "")
+}
+
+func (c *clusterTaskService) Resume(clusterName, taskID string) error {
+	task, err := c.getTask(clusterName, taskID)
+	if err != nil {
+		return err
+	}
+	if task.Phase != constant.TaskLogStatusPaused && !adm.IsTaskPaused(task.ID) {
+		return errors.New("TASK_NOT_PAUSED")
+	}
+	if err := adm.ResumeTask(task.ID); err != nil {
+		return err
+	}
+	return c.record(task, constant.TaskActionResume, "")
+}
+
+// Skip 跳过指定步骤，运行中的任务在执行到该步骤时生效，失败的任务在重试时生效
+func (c *clusterTaskService) Skip(clusterName, taskID, handler string) error {
+	task, err := c.getTask(clusterName, taskID)
+	if err != nil {
+		return err
+	}
+	if task.Phase == constant.TaskLogStatusSuccess {
+		return errors.New("TASK_NOT_RUNNING")
+	}
+	exist := false
+	for _, name := range adm.NewClusterAdm().HandlerNames(task.Type) {
+		if name == handler {
+			exist = true
+			break
+		}
+	}
+	if !exist {
+		return fmt.Errorf("handler %s not found in task %s", handler, task.Type)
+	}
+	for _, detail := range task.Details {
+		if detail.Task == handler && detail.Status == constant.TaskLogStatusSuccess {
+			return fmt.Errorf("handler %s is already finished", handler)
+		}
+	}
+	if err := adm.SkipTask(task.ID, handler); err != nil {
+		return err
+	}
+	return c.record(task, constant.TaskActionSkip, handler)
+}
+
+func (c *clusterTaskService) Cancel(clusterName, taskID string) error {
+	task, err := c.getTask(clusterName, taskID)
+	if err != nil {
+		return err
+	}
+	if isTaskEnd(task) {
+		return errors.New("TASK_NOT_RUNNING")
+	}
+	if err := adm.CancelTask(task.ID); err != nil {
+		return err
+	}
+	return c.record(task, constant.TaskActionCancel, "")
+}
+
+func (c *clusterTaskService) getTask(clusterName, taskID string) (model.TaskLog, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return model.TaskLog{}, err
+	}
+	task, err := c.taskLogService.GetByID(taskID)
+	if err != nil {
+		return task, err
+	}
+	if task.ClusterID != cluster.ID {
+		return task, fmt.Errorf("task %s does not belong to cluster %s", taskID, clusterName)
+	}
+	if len(adm.NewClusterAdm().HandlerNames(task.Type)) == 0 {
+		return task, fmt.Errorf("task type %s does not support this operation", task.Type)
+	}
+	return task, nil
+}
+
+// record 干预操作同时记录到重试记录和步骤记录中，步骤记录用于任务详情和日志流展示
+func (c *clusterTaskService) record(task model.TaskLog, action, message string) error {
+	now := time.Now().Unix()
+	retrylog := &model.TaskRetryLog{
+		ClusterID:      task.ClusterID,
+		TaskLogID:      task.ID,
+		Action:         action,
+		Message:        message,
+		LastFailedTime: now,
+		RestartTime:    now,
+	}
+	if err := db.DB.Create(retrylog).Error; err != nil {
+		return err
+	}
+	detail := &model.TaskLogDetail{
+		Name:          constant.TaskLogDetailNameAction,
+		Task:          strings.ToUpper(action),
+		TaskLogID:     task.ID,
+		ClusterID:     task.ClusterID,
+		LastProbeTime: now,
+		StartTime:     now,
+		EndTime:       now,
+		Status:        constant.TaskLogStatusSuccess,
+		Message:       message,
+	}
+	return db.DB.Create(detail).Error
+}
+
+func isTaskEnd(task model.TaskLog) bool {
+	return task.Phase == constant.TaskLogStatusSuccess || task.Phase == constant.TaskLogStatusFailed
+}
@@ -223,6 +223,11 @@ func (c clusterToolService) Disable(clusterName string, tool dto.ClusterTool) (d
 	if err != nil {
 		return tool, err
 	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return tool, err
+	}
+	defer unlock()
 	var hosts []string
 	port := cluster.SpecConf.KubeApiServerPort
 	for _, node := range cluster.Nodes {
@@ -266,6 +271,11 @@ func (c clusterToolService) Enable(clusterName string, tool dto.ClusterTool) (dt
 	if err != nil {
 		return tool, err
 	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return tool, err
+	}
+	defer unlock()
 	availableHost := cluster.SpecConf.KubeRouter
 	if cluster.Source != constant.ClusterSourceExternal {
 		var hosts []string
@@ -310,6 +320,11 @@ func (c clusterToolService) Upgrade(clusterName string, tool dto.ClusterTool) (d
 	if err != nil {
 		return tool, err
 	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return tool, err
+	}
+	defer unlock()
 	availableHost := cluster.SpecConf.KubeRouter
 	if cluster.Source != constant.ClusterSourceExternal {
 		var hosts []string
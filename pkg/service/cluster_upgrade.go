@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -18,33 +20,49 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
 	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/ansible"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/version"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ClusterUpgradeService interface {
 	Upgrade(upgrade dto.ClusterUpgrade) error
+	Resume(cluster model.Cluster, writer io.Writer)
+	Rollback(clusterName string) error
+	Path(clusterName, target string) (*dto.ClusterUpgradePath, error)
 }
 
 func NewClusterUpgradeService() ClusterUpgradeService {
 	return &clusterUpgradeService{
-		clusterService: NewClusterService(),
-		msgService:     NewMsgService(),
-		clusterRepo:    repository.NewClusterRepository(),
-		taskLogService: NewTaskLogService(),
+		clusterService:       NewClusterService(),
+		msgService:           NewMsgService(),
+		clusterRepo:          repository.NewClusterRepository(),
+		clusterSpecRepo:      repository.NewClusterSpecRepository(),
+		clusterManifestRepo:  repository.NewClusterManifestRepository(),
+		taskLogService:       NewTaskLogService(),
+		kubernetesService:    NewKubernetesService(),
+		clusterHealthService: NewClusterHealthService(),
+		taskSchedulerService: NewTaskSchedulerService(),
 	}
 }
 
 type clusterUpgradeService struct {
-	clusterService ClusterService
-	msgService     MsgService
-	clusterRepo    repository.ClusterRepository
-	taskLogService TaskLogService
+	clusterService       ClusterService
+	msgService           MsgService
+	clusterRepo          repository.ClusterRepository
+	clusterSpecRepo      repository.ClusterSpecRepository
+	clusterManifestRepo  repository.ClusterManifestRepository
+	taskLogService       TaskLogService
+	kubernetesService    KubernetesService
+	clusterHealthService ClusterHealthService
+	taskSchedulerService TaskSchedulerService
 }
 
 func (c *clusterUpgradeService) Upgrade(upgrade dto.ClusterUpgrade) error {
 	loginfo, _ := json.Marshal(upgrade)
 	logger.Log.WithFields(logrus.Fields{"cluster_upgrade_info": string(loginfo)}).Debugf("start to upgrade the cluster %s", upgrade.ClusterName)
 
-	cluster, err := c.clusterRepo.GetWithPreload(upgrade.ClusterName, []string{"SpecConf", "SpecNetwork", "SpecRuntime", "Nodes", "Nodes.Host", "Nodes.Host.Credential", "Nodes.Host.Zone", "MultiClusterRepositories"})
+	cluster, err := c.clusterRepo.GetWithPreload(upgrade.ClusterName, []string{"SpecConf", "SpecNetwork", "SpecRuntime", "Secret", "Nodes", "Nodes.Host", "Nodes.Host.Credential", "Nodes.Host.Zone", "MultiClusterRepositories"})
 	if err != nil {
 		return fmt.Errorf("can not get cluster %s error %s", upgrade.ClusterName, err.Error())
 	}
@@ -55,14 +73,36 @@ func (c *clusterUpgradeService) Upgrade(upgrade dto.ClusterUpgrade) error {
 		return fmt.Errorf("cluster status error %s", cluster.Status)
 	}
 
+	target := cluster.UpgradeVersion
+	if len(upgrade.Version) != 0 {
+		target = upgrade.Version
+	}
+	if _, err := c.upgradePath(cluster.Version, target); err != nil {
+		return err
+	}
+
+	unlock, err := lockCluster(upgrade.ClusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	taskType := constant.TaskLogTypeClusterUpgrade
+	if upgrade.Strategy == constant.UpgradeStrategyRolling {
+		taskType = constant.TaskLogTypeClusterRollingUpgrade
+		if err := adm.NewClusterAdm().CheckPlaybooks(taskType); err != nil {
+			return err
+		}
+	}
+
 	tasklog, _ := c.taskLogService.GetByID(cluster.CurrentTaskID)
 	if tasklog.ID != "" {
 		cluster.TaskLog = tasklog
 	}
 
 	//从错误后继续
-	if cluster.TaskLog.Phase == constant.TaskLogStatusFailed && cluster.TaskLog.Type == constant.TaskLogTypeClusterUpgrade {
-		if err := c.taskLogService.RestartTask(&cluster, constant.TaskLogTypeClusterUpgrade); err != nil {
+	if cluster.TaskLog.Phase == constant.TaskLogStatusFailed && cluster.TaskLog.Type == taskType {
+		if err := c.taskLogService.RestartTask(&cluster, taskType); err != nil {
 			return err
 		}
 	} else {
@@ -72,13 +112,21 @@ func (c *clusterUpgradeService) Upgrade(upgrade dto.ClusterUpgrade) error {
 		}
 		cluster.TaskLog = model.TaskLog{
 			ClusterID: cluster.ID,
-			Type:      constant.TaskLogTypeClusterUpgrade,
+			Type:      taskType,
 			Phase:     constant.TaskLogStatusWaiting,
 		}
 		if err := c.taskLogService.Save(&cluster.TaskLog); err != nil {
 			return fmt.Errorf("reset contidion err %s", err.Error())
 		}
 	}
+	// 任务创建后再保存滚动升级参数，集群有其他任务时不修改配置
+	if upgrade.Strategy == constant.UpgradeStrategyRolling {
+		cluster.SpecConf.UpgradeBatchSize = upgrade.BatchSize
+		cluster.SpecConf.UpgradeAutoRollback = upgrade.AutoRollback
+		if err := c.clusterSpecRepo.SaveConf(&cluster.SpecConf); err != nil {
+			return fmt.Errorf("save cluster spec error %s", err.Error())
+		}
+	}
 
 	// 创建日志
 	writer, err := ansible.CreateAnsibleLogWriterWithId(cluster.Name, cluster.TaskLog.ID)
@@ -101,60 +149,177 @@ func (c *clusterUpgradeService) Upgrade(upgrade dto.ClusterUpgrade) error {
 	}
 
 	logger.Log.Infof("update db data of cluster %s successful, now start to upgrade cluster", cluster.Name)
-	go c.do(&cluster, writer)
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		c.do(&cluster, writer)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
+// Resume 服务重启后继续执行被中断的升级任务
+func (c *clusterUpgradeService) Resume(cluster model.Cluster, writer io.Writer) {
+	cluster.Status = constant.StatusUpgrading
+	_ = c.clusterRepo.Save(&cluster)
+	c.do(&cluster, writer)
+}
+
 func (c *clusterUpgradeService) do(cluster *model.Cluster, writer io.Writer) {
+	hops, err := c.upgradePath(cluster.Version, cluster.UpgradeVersion)
+	if err != nil {
+		c.upgradeFailed(cluster, err.Error())
+		return
+	}
+	// 兼容升级前已经开始执行、步骤记录未区分版本的任务
+	details := adm.FlowDetails(cluster.TaskLog.Details)
+	legacy := len(details) > 0 && details[0].Name == ""
+	progress := hopProgress(cluster.TaskLog.Details)
+	for _, hop := range hops {
+		detail, ok := progress[hop]
+		if ok && detail.Status == constant.TaskLogStatusSuccess {
+			// 服务重启后继续执行时跳过已经完成的版本
+			cluster.Version = hop
+			continue
+		}
+		if !ok {
+			detail = model.TaskLogDetail{
+				Name:      constant.TaskLogDetailNameUpgradeHop,
+				Task:      hop,
+				TaskLogID: cluster.TaskLog.ID,
+				ClusterID: cluster.ID,
+			}
+		}
+		detail.Status = constant.TaskLogStatusRunning
+		detail.Message = ""
+		if err := c.taskLogService.StartDetail(&detail); err != nil {
+			logger.Log.Infof("save upgrade progress failed %v", err)
+		}
+
+		_, _ = fmt.Fprintf(writer, "\n======== upgrade %s -> %s ========\n", cluster.Version, hop)
+		detailName := hop
+		if legacy {
+			detailName = ""
+		}
+		result := c.runHop(cluster, hop, detailName, writer)
+		detail.Status = result.Status
+		detail.Message = result.Message
+		detail.EndTime = time.Now().Unix()
+		if err := db.DB.Save(&detail).Error; err != nil {
+			logger.Log.Infof("save upgrade progress failed %v", err)
+		}
+		if result.Status != constant.TaskLogStatusSuccess {
+			c.upgradeFailed(cluster, result.Message)
+			return
+		}
+		// 中间版本只记录在升级进度中，任务失败时随集群一起保存，重试时从该版本继续
+		cluster.Version = hop
+	}
+
+	if err := c.taskLogService.End(&cluster.TaskLog, true, ""); err != nil {
+		logger.Log.Infof("save task failed %v", err)
+	}
+	_ = os.Remove(adm.UpgradeSnapshotPath(cluster.Name, cluster.TaskLog.ID))
+	logger.Log.Infof("cluster %s upgrade successful!", cluster.Name)
+	cluster.Status = constant.StatusRunning
+	cluster.Message = ""
+	cluster.CurrentTaskID = ""
+	_ = c.clusterRepo.Save(cluster)
+	_ = c.msgService.SendMsg(constant.ClusterUpgrade, constant.Cluster, cluster, true, map[string]string{"detailName": cluster.Name})
+}
+
+// hopProgress 按目标版本返回各跳的进度记录
+func hopProgress(details []model.TaskLogDetail) map[string]model.TaskLogDetail {
+	progress := map[string]model.TaskLogDetail{}
+	for _, d := range details {
+		if d.Name == constant.TaskLogDetailNameUpgradeHop {
+			progress[d.Task] = d
+		}
+	}
+	return progress
+}
+
+// hopPhase 中间版本升级成功时整个任务仍在执行，最后一跳完成后由 End 标记成功
+func hopPhase(status string) string {
+	if status == constant.TaskLogStatusSuccess {
+		return constant.TaskLogStatusRunning
+	}
+	return status
+}
+
+// runHop 执行一跳升级直到成功或失败
+func (c *clusterUpgradeService) runHop(cluster *model.Cluster, hop, detailName string, writer io.Writer) adm.AnsibleHelper {
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	admCluster := adm.NewAnsibleHelper(*cluster, writer)
+	admCluster.ClusterUpgradeVersion = hop
+	admCluster.DetailName = detailName
+	if cluster.TaskLog.Type == constant.TaskLogTypeClusterRollingUpgrade {
+		admCluster.Rolling = c.rollingUpgrade(*cluster)
+	}
 	statusChan := make(chan adm.AnsibleHelper)
 	go c.doUpgrade(ctx, *admCluster, statusChan)
 	for {
 		result := <-statusChan
-		switch cluster.TaskLog.Phase {
-		case constant.TaskLogStatusSuccess:
-			if err := c.taskLogService.End(&cluster.TaskLog, true, ""); err != nil {
-				logger.Log.Infof("save task failed %v", err)
-			}
-			logger.Log.Infof("cluster %s upgrade successful!", cluster.Name)
-			cluster.Status = constant.StatusRunning
-			cluster.Message = result.Message
-			cluster.CurrentTaskID = ""
-			_ = c.clusterRepo.Save(cluster)
-
-			_ = c.msgService.SendMsg(constant.ClusterUpgrade, constant.Cluster, cluster, true, map[string]string{"detailName": cluster.Name})
-			cluster.Version = cluster.UpgradeVersion
-			_ = db.DB.Save(&cluster).Error
-			cancel()
-			return
-		case constant.TaskLogStatusFailed:
-			if err := c.taskLogService.End(&cluster.TaskLog, false, result.Message); err != nil {
-				logger.Log.Infof("save task failed %v", err)
-			}
-			logger.Log.Infof("cluster %s upgrade failed!", cluster.Name)
-			cluster.Status = constant.StatusFailed
-			cluster.Message = result.Message
-			_ = c.clusterRepo.Save(cluster)
-
-			_ = c.msgService.SendMsg(constant.ClusterUpgrade, constant.Cluster, cluster, false, map[string]string{"errMsg": result.Message, "detailName": cluster.Name})
-			cancel()
-			return
-		default:
-			cluster.TaskLog.Phase = result.Status
-			cluster.TaskLog.Message = result.Message
-			cluster.TaskLog.Details = result.LogDetail
-			if err := c.taskLogService.Save(&cluster.TaskLog); err != nil {
-				logger.Log.Infof("save task failed %v", err)
-			}
+		cluster.TaskLog.Phase = hopPhase(result.Status)
+		cluster.TaskLog.Message = result.Message
+		cluster.TaskLog.Details = result.LogDetail
+		if err := c.taskLogService.Save(&cluster.TaskLog); err != nil {
+			logger.Log.Infof("save task failed %v", err)
+		}
+		switch result.Status {
+		case constant.TaskLogStatusSuccess, constant.TaskLogStatusFailed:
+			return result
 		}
 	}
 }
 
+func (c *clusterUpgradeService) upgradeFailed(cluster *model.Cluster, message string) {
+	if err := c.taskLogService.End(&cluster.TaskLog, false, message); err != nil {
+		logger.Log.Infof("save task failed %v", err)
+	}
+	logger.Log.Infof("cluster %s upgrade failed!", cluster.Name)
+	cluster.Status = constant.StatusFailed
+	cluster.Message = message
+	_ = c.clusterRepo.Save(cluster)
+	_ = c.msgService.SendMsg(constant.ClusterUpgrade, constant.Cluster, cluster, false, map[string]string{"errMsg": message, "detailName": cluster.Name})
+}
+
+// upgradePath 在启用的版本清单中计算升级路径
+func (c *clusterUpgradeService) upgradePath(current, target string) ([]string, error) {
+	manifests, err := c.clusterManifestRepo.ListByStatus()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, m := range manifests {
+		names = append(names, m.Name)
+	}
+	return version.UpgradePath(current, target, names)
+}
+
+// Path 查询集群升级到 target 需要依次经过的版本
+func (c *clusterUpgradeService) Path(clusterName, target string) (*dto.ClusterUpgradePath, error) {
+	cluster, err := c.clusterRepo.Get(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	hops, err := c.upgradePath(cluster.Version, target)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ClusterUpgradePath{Current: cluster.Version, Target: target, Hops: hops}, nil
+}
+
 func (c clusterUpgradeService) doUpgrade(ctx context.Context, aHelper adm.AnsibleHelper, statusChan chan adm.AnsibleHelper) {
 	ad := adm.NewClusterAdm()
 	for {
-		if err := ad.OnUpgrade(&aHelper); err != nil {
+		var err error
+		if aHelper.Rolling != nil {
+			err = ad.OnRollingUpgrade(&aHelper)
+		} else {
+			err = ad.OnUpgrade(&aHelper)
+		}
+		if err != nil {
 			aHelper.Message = err.Error()
 		}
 		select {
@@ -166,6 +331,123 @@ func (c clusterUpgradeService) doUpgrade(ctx context.Context, aHelper adm.Ansibl
 	}
 }
 
+// rollingUpgrade 滚动升级时节点驱逐、恢复调度和健康检查的实现
+func (c *clusterUpgradeService) rollingUpgrade(cluster model.Cluster) *adm.RollingUpgrade {
+	r := &adm.RollingUpgrade{
+		BatchSize:    cluster.SpecConf.UpgradeBatchSize,
+		AutoRollback: cluster.SpecConf.UpgradeAutoRollback,
+		Drain: func(node string) error {
+			return c.kubernetesService.DrainNode(cluster.Name, node)
+		},
+		Uncordon: func(node string) error {
+			return c.kubernetesService.CordonNode(dto.Cordon{Name: node, Cluster: cluster.Name, SetUnschedulable: false})
+		},
+		Upgraded: func(node, version string) bool {
+			client, err := clusterUtil.NewClusterClient(&cluster)
+			if err != nil {
+				return false
+			}
+			n, err := client.CoreV1().Nodes().Get(context.TODO(), node, metav1.GetOptions{})
+			if err != nil {
+				return false
+			}
+			return strings.HasPrefix(version, n.Status.NodeInfo.KubeletVersion+"-")
+		},
+		HealthCheck: func() error {
+			result, err := c.clusterHealthService.HealthCheck(cluster.Name)
+			if err != nil {
+				return err
+			}
+			if result.Level == StatusSuccess {
+				return nil
+			}
+			var msgs []string
+			for _, hook := range result.Hooks {
+				if hook.Level != StatusSuccess {
+					msgs = append(msgs, hook.Name+": "+hook.Msg)
+				}
+			}
+			return errors.New(strings.Join(msgs, "; "))
+		},
+	}
+	for _, n := range cluster.Nodes {
+		if n.Status != constant.StatusRunning {
+			continue
+		}
+		if n.Role == constant.NodeRoleNameMaster {
+			r.Masters = append(r.Masters, n.Name)
+		} else {
+			r.Workers = append(r.Workers, n.Name)
+		}
+	}
+	return r
+}
+
+// Rollback 升级失败后降级节点，并使用升级前备份的 etcd 快照恢复集群
+func (c *clusterUpgradeService) Rollback(clusterName string) error {
+	cluster, err := c.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "SpecNetwork", "SpecRuntime", "Secret", "Nodes", "Nodes.Host", "Nodes.Host.Credential", "MultiClusterRepositories"})
+	if err != nil {
+		return err
+	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(clusterName) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
+	tasklog, err := c.taskLogService.GetByID(cluster.CurrentTaskID)
+	if err != nil {
+		return errors.New("CLUSTER_UPGRADE_NOT_FAILED")
+	}
+	if tasklog.Phase != constant.TaskLogStatusFailed ||
+		(tasklog.Type != constant.TaskLogTypeClusterUpgrade && tasklog.Type != constant.TaskLogTypeClusterRollingUpgrade) {
+		return errors.New("CLUSTER_UPGRADE_NOT_FAILED")
+	}
+	upgradeTaskID := tasklog.ID
+	if _, err := os.Stat(adm.UpgradeSnapshotPath(cluster.Name, upgradeTaskID)); err != nil {
+		return fmt.Errorf("etcd snapshot of upgrade task %s not found: %s", upgradeTaskID, err.Error())
+	}
+	if err := adm.RequirePlaybooks(adm.RollbackPlaybooks()...); err != nil {
+		return err
+	}
+
+	task, err := c.taskLogService.NewTerminalTask(cluster.ID, constant.TaskLogTypeRestore)
+	if err != nil {
+		return err
+	}
+	cluster.TaskLog = *task
+	cluster.CurrentTaskID = task.ID
+	_ = c.clusterRepo.Save(&cluster)
+
+	writer, err := ansible.CreateAnsibleLogWriterWithId(cluster.Name, task.ID)
+	if err != nil {
+		return fmt.Errorf("create log error %s", err.Error())
+	}
+	if err := c.taskSchedulerService.Submit(&cluster.TaskLog, constant.TaskPriorityUser, func() {
+		admCluster := adm.NewAnsibleHelper(cluster, writer)
+		admCluster.Rolling = c.rollingUpgrade(cluster)
+		if err := adm.RollbackUpgrade(admCluster, upgradeTaskID); err != nil {
+			logger.Log.Errorf("rollback cluster %s failed: %s", cluster.Name, err.Error())
+			_ = c.taskLogService.End(&cluster.TaskLog, false, err.Error())
+			_ = c.msgService.SendMsg(constant.ClusterRestore, constant.Cluster, cluster, false, map[string]string{"errMsg": err.Error()})
+			return
+		}
+		_ = c.taskLogService.End(&cluster.TaskLog, true, "")
+		_ = os.Remove(adm.UpgradeSnapshotPath(cluster.Name, upgradeTaskID))
+		cluster.Status = constant.StatusRunning
+		cluster.Message = ""
+		cluster.UpgradeVersion = cluster.Version
+		cluster.CurrentTaskID = ""
+		_ = c.clusterRepo.Save(&cluster)
+		_ = c.msgService.SendMsg(constant.ClusterRestore, constant.Cluster, cluster, true, map[string]string{})
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c clusterUpgradeService) updateToolVersion(version, clusterID string) error {
 	var (
 		tools    []model.ClusterTool
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+)
+
+func TestHopProgress(t *testing.T) {
+	details := []model.TaskLogDetail{
+		{Name: "v1.20.10", Task: "EnsureUpgradeKubernetes", Status: constant.TaskLogStatusSuccess},
+		{Name: constant.TaskLogDetailNameUpgradeHop, Task: "v1.20.10", Status: constant.TaskLogStatusSuccess},
+		{Name: constant.TaskLogDetailNameUpgradeHop, Task: "v1.21.8", Status: constant.TaskLogStatusFailed},
+		{Name: constant.TaskLogDetailNameAction, Task: "PAUSE", Status: constant.TaskLogStatusSuccess},
+	}
+	progress := hopProgress(details)
+	if len(progress) != 2 {
+		t.Fatalf("hopProgress() = %v, want 2 hops", progress)
+	}
+	if progress["v1.20.10"].Status != constant.TaskLogStatusSuccess || progress["v1.21.8"].Status != constant.TaskLogStatusFailed {
+		t.Errorf("hopProgress() = %v", progress)
+	}
+}
+
+func TestHopPhase(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{status: constant.TaskLogStatusSuccess, want: constant.TaskLogStatusRunning},
+		{status: constant.TaskLogStatusRunning, want: constant.TaskLogStatusRunning},
+		{status: constant.TaskLogStatusPaused, want: constant.TaskLogStatusPaused},
+		{status: constant.TaskLogStatusFailed, want: constant.TaskLogStatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := hopPhase(tt.status); got != tt.want {
+				t.Errorf("hopPhase() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -41,16 +41,18 @@ type ComponentService interface {
 }
 
 type componentService struct {
-	clusterRepo    repository.ClusterRepository
-	taskLogService TaskLogService
+	clusterRepo          repository.ClusterRepository
+	taskLogService       TaskLogService
+	taskSchedulerService TaskSchedulerService
 }
 
 //  disable Initializing Waiting Failed enable Terminated
 
 func NewComponentService() ComponentService {
 	return &componentService{
-		clusterRepo:    repository.NewClusterRepository(),
-		taskLogService: NewTaskLogService(),
+		clusterRepo:          repository.NewClusterRepository(),
+		taskLogService:       NewTaskLogService(),
+		taskSchedulerService: NewTaskSchedulerService(),
 	}
 }
 
@@ -141,6 +143,14 @@ func (c *componentService) Create(creation *dto.ComponentCreate) error {
 	if err != nil {
 		return err
 	}
+	unlock, err := lockCluster(creation.ClusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(creation.ClusterName) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
 	if err := db.DB.Where("name = ? AND version = ? AND cluster_id = ?", creation.Name, creation.Version, cluster.ID).First(&component).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
 		return err
 	}
@@ -186,32 +196,33 @@ func (c *componentService) Create(creation *dto.ComponentCreate) error {
 		return err
 	}
 
-	go c.docreate(&cluster, task, component, creation.Vars, writer)
-	return nil
+	return submitDetail(c.taskSchedulerService, c.taskLogService, &task, component.Name, "component", constant.TaskLogTypeComponent, func() {
+		c.docreate(&cluster, &task, component, creation.Vars, writer)
+	})
 }
 
-func (c componentService) docreate(cluster *model.Cluster, task model.TaskLogDetail, component model.ClusterSpecComponent, vars map[string]interface{}, writer io.Writer) {
+func (c componentService) docreate(cluster *model.Cluster, task *model.TaskLogDetail, component model.ClusterSpecComponent, vars map[string]interface{}, writer io.Writer) {
 	admCluster, err := c.loadAdmCluster(*cluster, component, vars, constant.StatusEnabled)
 	if err != nil {
-		_ = c.taskLogService.EndDetail(&task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerComponent(component, constant.StatusDisabled, err)
 		return
 	}
 
 	client, err := clusterUtil.NewClusterClient(cluster)
 	if err != nil {
-		_ = c.taskLogService.EndDetail(&task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerComponent(component, constant.StatusDisabled, err)
 		return
 	}
 
 	playbook := strings.ReplaceAll(task.Task, " (enable)", "")
 	if err := phases.RunPlaybookAndGetResult(admCluster.Kobe, playbook, "", writer); err != nil {
-		_ = c.taskLogService.EndDetail(&task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerComponent(component, constant.StatusFailed, err)
 		return
 	}
-	_ = c.taskLogService.EndDetail(&task, component.Name, "component", constant.TaskLogStatusSuccess, "")
+	_ = c.taskLogService.EndDetail(task, component.Name, "component", constant.TaskLogStatusSuccess, "")
 	component.Status = constant.StatusWaiting
 	if err := db.DB.Save(&component).Error; err != nil {
 		logger.Log.Errorf("save component status err: %s", err.Error())
@@ -226,6 +237,14 @@ func (c componentService) Delete(clusterName, name string) error {
 	if err != nil {
 		return err
 	}
+	unlock, err := lockCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if c.taskLogService.IsTaskOn(clusterName) {
+		return errors.New("TASK_IN_EXECUTION")
+	}
 	db.DB.Where("name = ? AND cluster_id = ?", name, cluster.ID).First(&component)
 	if component.ID == "" {
 		return errors.New("not found")
@@ -256,25 +275,25 @@ func (c componentService) Delete(clusterName, name string) error {
 		return err
 	}
 
-	go c.dodelete(&cluster, task, component, writer)
-
-	return nil
+	return submitDetail(c.taskSchedulerService, c.taskLogService, &task, component.Name, "component", constant.TaskLogTypeComponent, func() {
+		c.dodelete(&cluster, &task, component, writer)
+	})
 }
 
-func (c componentService) dodelete(cluster *model.Cluster, task model.TaskLogDetail, component model.ClusterSpecComponent, writer io.Writer) {
+func (c componentService) dodelete(cluster *model.Cluster, task *model.TaskLogDetail, component model.ClusterSpecComponent, writer io.Writer) {
 	admCluster, err := c.loadAdmCluster(*cluster, component, map[string]interface{}{}, constant.StatusDisabled)
 	if err != nil {
-		_ = c.taskLogService.EndDetail(&task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerComponent(component, constant.StatusFailed, err)
 		return
 	}
 	playbook := strings.ReplaceAll(task.Task, " (disable)", "")
 	if err := phases.RunPlaybookAndGetResult(admCluster.Kobe, playbook, "", writer); err != nil {
-		_ = c.taskLogService.EndDetail(&task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
+		_ = c.taskLogService.EndDetail(task, component.Name, "component", constant.TaskLogStatusFailed, err.Error())
 		c.errHandlerComponent(component, constant.StatusFailed, err)
 		return
 	}
-	_ = c.taskLogService.EndDetail(&task, component.Name, "component", constant.TaskLogStatusSuccess, "")
+	_ = c.taskLogService.EndDetail(task, component.Name, "component", constant.TaskLogStatusSuccess, "")
 	_ = db.DB.Where("id = ?", component.ID).Delete(&model.ClusterSpecComponent{})
 }
 
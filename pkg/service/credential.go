@@ -127,6 +127,7 @@ func (c credentialService) Create(creation dto.CredentialCreate) (*dto.Credentia
 		Username:   creation.Username,
 		PrivateKey: creation.PrivateKey,
 		Type:       creation.Type,
+		BastionID:  creation.BastionID,
 	}
 	err = c.credentialRepo.Save(&credential)
 	if err != nil {
@@ -145,6 +146,7 @@ func (c credentialService) Update(name string, update dto.CredentialUpdate) (*dt
 		credential.Username = update.Username
 	}
 	credential.Type = update.Type
+	credential.BastionID = update.BastionID
 	if update.Type == constant.Password {
 		if update.Password == "" {
 			return nil, errors.New("PASSWORD_CAN_NOT_NULL")
@@ -25,9 +25,11 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/model"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model/common"
 	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/inventory"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/kobe"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
 	"github.com/ClusterOperator/kobe/api"
+	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
@@ -44,7 +46,11 @@ type HostService interface {
 	Batch(op dto.HostOp) error
 	DownloadTemplateFile() error
 	RunGetHostConfig(host *model.Host)
+	GetHostConfig(host *model.Host) error
 	ImportHosts(file []byte) error
+	ImportHostFile(format string, file []byte, dryRun bool) (*dto.HostImportResult, error)
+	ExportHosts(format string, projectName string) ([]byte, error)
+	ListInventoryHistory(name string) ([]dto.HostInventoryHistory, error)
 }
 
 type hostService struct {
@@ -71,6 +77,8 @@ func (h *hostService) Get(name string) (*dto.Host, error) {
 
 	if err := db.DB.Where("name = ?", name).
 		Preload("Volumes").
+		Preload("Disks", func(d *gorm.DB) *gorm.DB { return d.Order("name") }).
+		Preload("Nics", func(d *gorm.DB) *gorm.DB { return d.Order("name") }).
 		Preload("Credential").
 		Preload("Zone").
 		Preload("Cluster").
@@ -95,6 +103,10 @@ func (h *hostService) List(projectName string, conditions condition.Conditions)
 	)
 
 	d := db.DB.Model(model.Host{})
+	conditions, err := withHostInventoryConditions(&d, conditions)
+	if err != nil {
+		return hostDTOs, err
+	}
 	if err := dbUtil.WithConditions(&d, model.Host{}, conditions); err != nil {
 		return hostDTOs, nil
 	}
@@ -163,6 +175,10 @@ func (h *hostService) Page(num, size int, projectName string, conditions conditi
 		clusterResources []model.ClusterResource
 	)
 	d := db.DB.Model(model.Host{})
+	conditions, err := withHostInventoryConditions(&d, conditions)
+	if err != nil {
+		return &p, err
+	}
 	if err := dbUtil.WithConditions(&d, model.Host{}, conditions); err != nil {
 		return &p, err
 	}
@@ -284,6 +300,7 @@ func (h *hostService) Create(creation dto.HostCreate) (*dto.Host, error) {
 		FlexIp:       creation.FlexIp,
 		Port:         creation.Port,
 		CredentialID: credential.ID,
+		BastionID:    creation.BastionID,
 		Credential:   credential,
 		Status:       constant.StatusInitializing,
 	}
@@ -371,6 +388,7 @@ func (h *hostService) Update(host dto.HostUptate) (*dto.Host, error) {
 		"FlexIp":       host.FlexIp,
 		"Port":         host.Port,
 		"CredentialID": credential.ID,
+		"BastionID":    host.BastionID,
 		"Status":       constant.StatusInitializing,
 	}).Error; err != nil {
 		tx.Rollback()
@@ -383,6 +401,7 @@ func (h *hostService) Update(host dto.HostUptate) (*dto.Host, error) {
 		FlexIp:       host.FlexIp,
 		Port:         host.Port,
 		CredentialID: credential.ID,
+		BastionID:    host.BastionID,
 		Credential:   credential,
 		Status:       constant.StatusInitializing,
 	}
@@ -477,20 +496,15 @@ func (h *hostService) Batch(op dto.HostOp) error {
 }
 
 func (h *hostService) GetHostGpu(host *model.Host) error {
-	password, privateKey, err := host.GetHostPasswordAndPrivateKey()
+	if _, _, err := host.GetHostPasswordAndPrivateKey(); err != nil {
+		return err
+	}
+	cfg, err := host.ToSSHConfig()
 	if err != nil {
+		host.Status = model.SshError
 		return err
 	}
-	client, err := ssh.New(&ssh.Config{
-		User:        host.Credential.Username,
-		Host:        host.Ip,
-		Port:        host.Port,
-		Password:    password,
-		PrivateKey:  privateKey,
-		PassPhrase:  nil,
-		DialTimeOut: 5 * time.Second,
-		Retry:       3,
-	})
+	client, err := ssh.New(&cfg)
 	if err != nil {
 		host.Status = model.SshError
 		return err
@@ -519,20 +533,15 @@ func (h *hostService) GetHostGpu(host *model.Host) error {
 }
 
 func (h *hostService) GetHostMem(host *model.Host) error {
-	password, privateKey, err := host.GetHostPasswordAndPrivateKey()
+	if _, _, err := host.GetHostPasswordAndPrivateKey(); err != nil {
+		return err
+	}
+	cfg, err := host.ToSSHConfig()
 	if err != nil {
+		host.Status = model.SshError
 		return err
 	}
-	client, err := ssh.New(&ssh.Config{
-		User:        host.Credential.Username,
-		Host:        host.Ip,
-		Port:        host.Port,
-		Password:    password,
-		PrivateKey:  privateKey,
-		PassPhrase:  nil,
-		DialTimeOut: 5 * time.Second,
-		Retry:       3,
-	})
+	client, err := ssh.New(&cfg)
 	if err != nil {
 		host.Status = model.SshError
 		return err
@@ -585,6 +594,13 @@ func (h *hostService) RunGetHostConfig(host *model.Host) {
 	}
 	host.Status = constant.StatusRunning
 	_ = h.hostRepo.Save(host)
+	tx := db.DB.Begin()
+	if err := saveHostInventory(tx, host); err != nil {
+		tx.Rollback()
+		logger.Log.Errorf("save inventory of host %s error: %s", host.Name, err.Error())
+		return
+	}
+	tx.Commit()
 }
 
 func (h *hostService) GetHostConfig(host *model.Host) error {
@@ -598,6 +614,10 @@ func (h *hostService) GetHostConfig(host *model.Host) error {
 	if err != nil {
 		return err
 	}
+	vars, err := host.InventoryVars()
+	if err != nil {
+		return err
+	}
 	ansible := kobe.NewAnsible(&kobe.Config{
 		Inventory: &api.Inventory{
 			Hosts: []*api.Host{
@@ -608,7 +628,7 @@ func (h *hostService) GetHostConfig(host *model.Host) error {
 					User:       host.Credential.Username,
 					Password:   password,
 					PrivateKey: string(privateKey),
-					Vars:       map[string]string{},
+					Vars:       vars,
 				},
 			},
 			Groups: []*api.Group{
@@ -692,6 +712,9 @@ func (h *hostService) GetHostConfig(host *model.Host) error {
 			}
 		}
 		host.Volumes = volumes
+		inv := inventory.FromFacts(result)
+		h.getHostInventory(host, &inv)
+		host.SetInventory(inv)
 	}
 	err = h.GetHostMem(host)
 	if err != nil {
@@ -877,6 +900,10 @@ func syncHostInfoWithDB(host *model.Host) error {
 		tx.Rollback()
 		return err
 	}
+	if err := saveHostInventory(tx, host); err != nil {
+		tx.Rollback()
+		return err
+	}
 	tx.Commit()
 	return nil
 }
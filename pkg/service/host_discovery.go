@@ -0,0 +1,367 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/page"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/errorf"
+	"github.com/ClusterOperator/ClusterOperator/pkg/ha"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ipaddr"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// 单次扫描的最大地址数量
+	discoveryMaxAddresses = 1024
+	// 同时探测的地址数量
+	discoveryConcurrency = 32
+)
+
+type HostDiscoveryService interface {
+	Page(num, size int) (*page.Page, error)
+	List() ([]dto.HostDiscovery, error)
+	Get(id string) (*dto.HostDiscovery, error)
+	Create(creation dto.HostDiscoveryCreate) (*dto.HostDiscovery, error)
+	Delete(id string) error
+	Accept(id string, accept dto.HostDiscoveryAccept) ([]dto.Host, error)
+}
+
+type hostDiscoveryService struct {
+	hostService HostService
+}
+
+func NewHostDiscoveryService() HostDiscoveryService {
+	return &hostDiscoveryService{
+		hostService: NewHostService(),
+	}
+}
+
+func (h *hostDiscoveryService) Page(num, size int) (*page.Page, error) {
+	var (
+		p           page.Page
+		discoveries []model.HostDiscovery
+		items       []dto.HostDiscovery
+	)
+	if err := db.DB.Model(&model.HostDiscovery{}).
+		Count(&p.Total).
+		Preload("Credential").
+		Order("created_at desc").
+		Offset((num - 1) * size).
+		Limit(size).
+		Find(&discoveries).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range discoveries {
+		items = append(items, dto.HostDiscovery{HostDiscovery: d, CredentialName: d.Credential.Name})
+	}
+	p.Items = items
+	return &p, nil
+}
+
+func (h *hostDiscoveryService) List() ([]dto.HostDiscovery, error) {
+	var (
+		discoveries []model.HostDiscovery
+		items       []dto.HostDiscovery
+	)
+	if err := db.DB.Preload("Credential").Order("created_at desc").Find(&discoveries).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range discoveries {
+		items = append(items, dto.HostDiscovery{HostDiscovery: d, CredentialName: d.Credential.Name})
+	}
+	return items, nil
+}
+
+func (h *hostDiscoveryService) Get(id string) (*dto.HostDiscovery, error) {
+	var discovery model.HostDiscovery
+	if err := db.DB.Where("id = ?", id).
+		Preload("Credential").
+		Preload("Hosts", func(d *gorm.DB) *gorm.DB { return d.Order("ip") }).
+		First(&discovery).Error; err != nil {
+		return nil, err
+	}
+	return &dto.HostDiscovery{HostDiscovery: discovery, CredentialName: discovery.Credential.Name}, nil
+}
+
+// Create 创建扫描任务并在后台扫描，已经注册的主机和仓库地址不再扫描
+func (h *hostDiscoveryService) Create(creation dto.HostDiscoveryCreate) (*dto.HostDiscovery, error) {
+	ips, err := ipaddr.CidrHosts(creation.Cidr, discoveryMaxAddresses)
+	if err != nil {
+		return nil, err
+	}
+	var credential model.Credential
+	if err := db.DB.Where("id = ?", creation.CredentialID).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	var registered []string
+	if err := db.DB.Model(&model.Host{}).Where("ip in (?)", ips).Pluck("ip", &registered).Error; err != nil {
+		return nil, err
+	}
+	var registries []string
+	if err := db.DB.Model(&model.SystemRegistry{}).Where("hostname in (?)", ips).Pluck("hostname", &registries).Error; err != nil {
+		return nil, err
+	}
+	exists := map[string]bool{}
+	for _, ip := range append(registered, registries...) {
+		exists[ip] = true
+	}
+	var candidates []string
+	for _, ip := range ips {
+		if !exists[ip] {
+			candidates = append(candidates, ip)
+		}
+	}
+
+	discovery := model.HostDiscovery{
+		Cidr:         creation.Cidr,
+		Port:         creation.Port,
+		CredentialID: credential.ID,
+		Credential:   credential,
+		Status:       constant.StatusRunning,
+		Total:        len(candidates),
+	}
+	if err := db.DB.Create(&discovery).Error; err != nil {
+		return nil, err
+	}
+	go h.scan(discovery, candidates)
+	return &dto.HostDiscovery{HostDiscovery: discovery, CredentialName: credential.Name}, nil
+}
+
+// scan 扫描期间持有租约，副本退出后由故障恢复将未完成的扫描标记为失败
+func (h *hostDiscoveryService) scan(discovery model.HostDiscovery, ips []string) {
+	lease := ha.DiscoveryPrefix + discovery.ID
+	if _, err := ha.TryLock(lease); err != nil {
+		logger.Log.Errorf("lock host discovery %s failed: %s", discovery.Cidr, err.Error())
+	}
+	defer ha.Unlock(lease)
+	var (
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, discoveryConcurrency)
+		scanned int
+	)
+	for _, ip := range ips {
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			h.probe(discovery, ip)
+
+			lock.Lock()
+			defer lock.Unlock()
+			scanned++
+			if err := db.DB.Model(&model.HostDiscovery{}).Where("id = ?", discovery.ID).Update("scanned", scanned).Error; err != nil {
+				logger.Log.Errorf("update progress of host discovery %s failed: %s", discovery.Cidr, err.Error())
+			}
+		}(ip)
+	}
+	wg.Wait()
+	if err := db.DB.Model(&model.HostDiscovery{}).Where("id = ?", discovery.ID).Update("status", constant.StatusSuccess).Error; err != nil {
+		logger.Log.Errorf("update status of host discovery %s failed: %s", discovery.Cidr, err.Error())
+	}
+	logger.Log.Infof("host discovery %s finished", discovery.Cidr)
+}
+
+// probe 依次 ping、ssh 登录并采集主机信息，能够登录的主机记录为待导入主机
+func (h *hostDiscoveryService) probe(discovery model.HostDiscovery, ip string) {
+	host := model.Host{
+		Name:         ip,
+		Ip:           ip,
+		Port:         discovery.Port,
+		CredentialID: discovery.CredentialID,
+		Credential:   discovery.Credential,
+	}
+	cfg, err := host.ToSSHConfig()
+	if err != nil {
+		return
+	}
+	cfg.Retry = 0
+	// 经跳板机连接时本地无法 ping 通目标地址
+	if cfg.Proxy == nil {
+		if err := ipaddr.Ping(ip); err != nil {
+			return
+		}
+	}
+	client, err := ssh.New(&cfg)
+	if err != nil {
+		return
+	}
+	hostname, err := client.CombinedOutput("hostname")
+	if err != nil {
+		return
+	}
+
+	found := model.DiscoveredHost{
+		DiscoveryID: discovery.ID,
+		Ip:          ip,
+		Hostname:    strings.TrimSpace(string(hostname)),
+		Status:      constant.StatusPending,
+	}
+	if err := h.hostService.GetHostConfig(&host); err != nil {
+		found.Message = err.Error()
+	}
+	found.Os = host.Os
+	found.OsVersion = host.OsVersion
+	found.Architecture = host.Architecture
+	found.CpuCore = host.CpuCore
+	found.Memory = host.Memory
+	found.GpuNum = host.GpuNum
+	found.GpuInfo = host.GpuInfo
+	found.HasGpu = host.HasGpu
+	if err := db.DB.Create(&found).Error; err != nil {
+		logger.Log.Errorf("save discovered host %s failed: %s", ip, err.Error())
+	}
+}
+
+func (h *hostDiscoveryService) Delete(id string) error {
+	tx := db.DB.Begin()
+	if err := tx.Where("discovery_id = ?", id).Delete(&model.DiscoveredHost{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("id = ?", id).Delete(&model.HostDiscovery{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+// Accept 将待导入的主机导入到项目和区域，未指定名称时使用主机名，主机名已被使用时使用地址
+func (h *hostDiscoveryService) Accept(id string, accept dto.HostDiscoveryAccept) ([]dto.Host, error) {
+	var discovery model.HostDiscovery
+	if err := db.DB.Where("id = ?", id).Preload("Credential").First(&discovery).Error; err != nil {
+		return nil, err
+	}
+	var project model.Project
+	if err := db.DB.Where("name = ?", accept.Project).First(&project).Error; err != nil {
+		return nil, err
+	}
+	var zone model.Zone
+	if accept.Zone != "" {
+		if err := db.DB.Where("name = ?", accept.Zone).First(&zone).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 同一地址重复提交时只导入一次
+	var (
+		ips   []string
+		items []dto.HostDiscoveryAcceptItem
+		seen  = map[string]bool{}
+	)
+	for _, item := range accept.Items {
+		if seen[item.Ip] {
+			continue
+		}
+		seen[item.Ip] = true
+		ips = append(ips, item.Ip)
+		items = append(items, item)
+	}
+	var found []model.DiscoveredHost
+	if err := db.DB.Where("discovery_id = ? AND ip in (?) AND status = ?", id, ips, constant.StatusPending).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	pending := map[string]model.DiscoveredHost{}
+	for _, f := range found {
+		pending[f.Ip] = f
+	}
+	var registries []string
+	if err := db.DB.Model(&model.SystemRegistry{}).Where("hostname in (?)", ips).Pluck("hostname", &registries).Error; err != nil {
+		return nil, err
+	}
+	var errs errorf.CErrFs
+	for _, ip := range registries {
+		errs = errs.Add(errorf.New("DISCOVERED_HOST_IS_LOCAL_HOST", ip))
+	}
+	for _, ip := range ips {
+		if _, ok := pending[ip]; !ok {
+			errs = errs.Add(errorf.New("DISCOVERED_HOST_NOT_FOUND", ip))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var hosts []model.Host
+	tx := db.DB.Begin()
+	for _, item := range items {
+		f := pending[item.Ip]
+		// 在事务中检查地址，避免与发现后手动添加或同时导入的主机重复
+		var ipCount int
+		if err := tx.Model(&model.Host{}).Where("ip = ?", f.Ip).Count(&ipCount).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if ipCount > 0 {
+			tx.Rollback()
+			return nil, errorf.CErrFs{errorf.New("DISCOVERED_HOST_IP_EXISTS", f.Ip)}
+		}
+		name := item.Name
+		if name == "" {
+			name = f.Hostname
+			var count int
+			if err := tx.Model(&model.Host{}).Where("name = ?", name).Count(&count).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if name == "" || count > 0 {
+				name = "host-" + strings.ReplaceAll(f.Ip, ".", "-")
+			}
+		}
+		host := model.Host{
+			Name:         name,
+			Ip:           f.Ip,
+			Port:         discovery.Port,
+			CredentialID: discovery.CredentialID,
+			Credential:   discovery.Credential,
+			ZoneID:       zone.ID,
+			Os:           f.Os,
+			OsVersion:    f.OsVersion,
+			Architecture: f.Architecture,
+			CpuCore:      f.CpuCore,
+			Memory:       f.Memory,
+			GpuNum:       f.GpuNum,
+			GpuInfo:      f.GpuInfo,
+			HasGpu:       f.HasGpu,
+			Status:       constant.StatusInitializing,
+		}
+		if err := tx.Create(&host).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := tx.Create(&model.ProjectResource{
+			ResourceType: constant.ResourceHost,
+			ResourceID:   host.ID,
+			ProjectID:    project.ID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := tx.Model(&model.DiscoveredHost{}).Where("id = ?", f.ID).Update("status", constant.StatusSuccess).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		hosts = append(hosts, host)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("accept discovered hosts failed: " + err.Error())
+	}
+
+	var result []dto.Host
+	for i := range hosts {
+		// 重新采集主机信息并同步磁盘信息
+		go h.hostService.RunGetHostConfig(&hosts[i])
+		result = append(result, dto.Host{Host: hosts[i]})
+	}
+	return result, nil
+}
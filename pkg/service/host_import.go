@@ -0,0 +1,348 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/condition"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/errorf"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/hostfile"
+)
+
+const (
+	hostImportCreate    = "create"
+	hostImportUpdate    = "update"
+	hostImportUnchanged = "unchanged"
+)
+
+// hostImportPlan 单条记录校验通过后的变更，host 为待保存的主机，bind 表示需要绑定到项目
+type hostImportPlan struct {
+	item    dto.HostImportItem
+	host    model.Host
+	project model.Project
+	bind    bool
+}
+
+// ImportHostFile 导入 csv、json、yaml 格式的主机清单，先校验全部记录并一次返回所有错误，
+// 全部通过后才保存；已存在的同名同 IP 主机更新端口、凭据、区域、架构和标签，已加入集群的主机不能修改端口和凭据，
+// dryRun 时只返回变更预览
+func (h *hostService) ImportHostFile(format string, file []byte, dryRun bool) (*dto.HostImportResult, error) {
+	records, rowErrs, err := hostfile.Decode(format, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("HOST_IMPORT_ERROR_NULL")
+	}
+	var errs errorf.CErrFs
+	invalid := map[int]bool{}
+	for _, e := range rowErrs {
+		invalid[e.Row] = true
+		row := strconv.Itoa(e.Row)
+		switch {
+		case e.Field == "port":
+			errs = errs.Add(errorf.New("HOST_IMPORT_WRONG_FORMAT", row))
+		case e.Field == "labels":
+			errs = errs.Add(errorf.New("HOST_IMPORT_INVALID_LABELS", e.Value, row))
+		case e.Field == "ip":
+			errs = errs.Add(errorf.New("HOST_IMPORT_INVALID_IP", e.Value, row))
+		case e.Field == "record":
+			errs = errs.Add(errorf.New("HOST_IMPORT_INVALID_RECORD", row, e.Value))
+		default:
+			errs = errs.Add(errorf.New("HOST_IMPORT_NOT_COMPLETE_VALUE", row))
+		}
+	}
+
+	var (
+		credentials []model.Credential
+		projects    []model.Project
+		zones       []model.Zone
+		hosts       []model.Host
+		resources   []model.ProjectResource
+		registries  []model.SystemRegistry
+	)
+	if err := db.DB.Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	if err := db.DB.Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	if err := db.DB.Find(&zones).Error; err != nil {
+		return nil, err
+	}
+	if err := db.DB.Find(&hosts).Error; err != nil {
+		return nil, err
+	}
+	if err := db.DB.Where("resource_type = ?", constant.ResourceHost).Find(&resources).Error; err != nil {
+		return nil, err
+	}
+	if err := db.DB.Find(&registries).Error; err != nil {
+		return nil, err
+	}
+	registryIps := map[string]bool{}
+	for _, r := range registries {
+		registryIps[r.Hostname] = true
+	}
+	credentialMap := map[string]model.Credential{}
+	for _, c := range credentials {
+		credentialMap[c.Name] = c
+	}
+	projectMap := map[string]model.Project{}
+	projectNames := map[string]string{}
+	for _, p := range projects {
+		projectMap[p.Name] = p
+		projectNames[p.ID] = p.Name
+	}
+	zoneMap := map[string]model.Zone{}
+	for _, z := range zones {
+		zoneMap[z.Name] = z
+	}
+	hostsByName := map[string]model.Host{}
+	hostsByIp := map[string]model.Host{}
+	for _, host := range hosts {
+		hostsByName[host.Name] = host
+		hostsByIp[host.Ip] = host
+	}
+	hostProjects := map[string]string{}
+	for _, r := range resources {
+		hostProjects[r.ResourceID] = projectNames[r.ProjectID]
+	}
+
+	var plans []hostImportPlan
+	names := map[string]int{}
+	ips := map[string]int{}
+	for i, record := range records {
+		row := i + 1
+		rowStr := strconv.Itoa(row)
+		if invalid[row] {
+			continue
+		}
+		if first, ok := names[record.Name]; ok {
+			errs = errs.Add(errorf.New("HOST_IMPORT_DUPLICATE", rowStr, record.Name, strconv.Itoa(first)))
+			continue
+		}
+		names[record.Name] = row
+		if first, ok := ips[record.Ip]; ok {
+			errs = errs.Add(errorf.New("HOST_IMPORT_DUPLICATE", rowStr, record.Ip, strconv.Itoa(first)))
+			continue
+		}
+		ips[record.Ip] = row
+
+		failed := false
+		if registryIps[record.Ip] {
+			errs = errs.Add(errorf.New("HOST_IMPORT_IS_LOCAL_HOST", record.Ip, rowStr))
+			failed = true
+		}
+		credential, ok := credentialMap[record.Credential]
+		if !ok {
+			errs = errs.Add(errorf.New("HOST_IMPORT_CREDENTIAL_NOT_FOUND", rowStr))
+			failed = true
+		}
+		project, ok := projectMap[record.Project]
+		if !ok {
+			errs = errs.Add(errorf.New("HOST_IMPORT_PROJECT_NOT_FOUND", rowStr))
+			failed = true
+		}
+		var zone model.Zone
+		if record.Zone != "" {
+			if zone, ok = zoneMap[record.Zone]; !ok {
+				errs = errs.Add(errorf.New("HOST_IMPORT_ZONE_NOT_FOUND", record.Zone, rowStr))
+				failed = true
+			}
+		}
+		arch, ok := importArchitecture(record.Architecture)
+		if !ok {
+			errs = errs.Add(errorf.New("HOST_IMPORT_INVALID_ARCHITECTURE", record.Architecture, rowStr))
+			failed = true
+		}
+		existing, exists := hostsByName[record.Name]
+		if exists && existing.Ip != record.Ip {
+			errs = errs.Add(errorf.New("HOST_IMPORT_IP_CONFLICT", record.Name, existing.Ip, rowStr))
+			failed = true
+		} else if other, used := hostsByIp[record.Ip]; used && other.Name != record.Name {
+			errs = errs.Add(errorf.New("HOST_IMPORT_IP_CONFLICT", other.Name, record.Ip, rowStr))
+			failed = true
+		}
+		if exists && hostProjects[existing.ID] != "" && hostProjects[existing.ID] != record.Project {
+			errs = errs.Add(errorf.New("HOST_IMPORT_PROJECT_CONFLICT", record.Name, hostProjects[existing.ID], rowStr))
+			failed = true
+		}
+		port := record.Port
+		if port == 0 {
+			port = 22
+		}
+		// 已加入集群的主机修改端口或凭据会影响集群后续的运维任务，需要先移出集群
+		if !failed && exists && existing.ClusterID != "" && (existing.Port != port || existing.CredentialID != credential.ID) {
+			errs = errs.Add(errorf.New("HOST_IMPORT_IN_CLUSTER", record.Name, rowStr))
+			failed = true
+		}
+		if failed {
+			continue
+		}
+		plan := hostImportPlan{
+			item:    dto.HostImportItem{Row: row, Name: record.Name, Ip: record.Ip, Project: record.Project},
+			project: project,
+		}
+		if !exists {
+			plan.item.Action = hostImportCreate
+			plan.host = model.Host{
+				Name:         record.Name,
+				Ip:           record.Ip,
+				Port:         port,
+				CredentialID: credential.ID,
+				Credential:   credential,
+				ZoneID:       zone.ID,
+				Architecture: arch,
+				Status:       constant.StatusInitializing,
+			}
+			plan.host.SetLabels(record.Labels)
+			plan.bind = true
+			plans = append(plans, plan)
+			continue
+		}
+
+		host := existing
+		if host.Port != port {
+			host.Port = port
+			plan.item.Changes = append(plan.item.Changes, "port")
+		}
+		if host.CredentialID != credential.ID {
+			host.CredentialID = credential.ID
+			plan.item.Changes = append(plan.item.Changes, "credential")
+		}
+		if record.Zone != "" && host.ZoneID != zone.ID {
+			host.ZoneID = zone.ID
+			plan.item.Changes = append(plan.item.Changes, "zone")
+		}
+		if arch != "" && host.Architecture != arch {
+			host.Architecture = arch
+			plan.item.Changes = append(plan.item.Changes, "architecture")
+		}
+		if record.Labels != nil && !reflect.DeepEqual(host.GetLabels(), record.Labels) {
+			host.SetLabels(record.Labels)
+			plan.item.Changes = append(plan.item.Changes, "labels")
+		}
+		if hostProjects[existing.ID] == "" {
+			plan.bind = true
+			plan.item.Changes = append(plan.item.Changes, "project")
+		}
+		plan.item.Action = hostImportUnchanged
+		if len(plan.item.Changes) > 0 {
+			plan.item.Action = hostImportUpdate
+		}
+		plan.host = host
+		plans = append(plans, plan)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	result := dto.HostImportResult{DryRun: dryRun, Items: []dto.HostImportItem{}}
+	for _, plan := range plans {
+		result.Items = append(result.Items, plan.item)
+	}
+	if dryRun {
+		return &result, nil
+	}
+
+	var created []model.Host
+	tx := db.DB.Begin()
+	for _, plan := range plans {
+		host := plan.host
+		switch plan.item.Action {
+		case hostImportCreate:
+			if err := tx.Create(&host).Error; err != nil {
+				tx.Rollback()
+				return nil, errorf.CErrFs{}.Add(errorf.New("HOST_IMPORT_FAILED_SAVE", host.Name, err.Error()))
+			}
+			created = append(created, host)
+		case hostImportUpdate:
+			if err := tx.Model(&model.Host{}).Where("id = ?", host.ID).Updates(map[string]interface{}{
+				"port":          host.Port,
+				"credential_id": host.CredentialID,
+				"zone_id":       host.ZoneID,
+				"architecture":  host.Architecture,
+				"labels":        host.Labels,
+			}).Error; err != nil {
+				tx.Rollback()
+				return nil, errorf.CErrFs{}.Add(errorf.New("HOST_IMPORT_FAILED_SAVE", host.Name, err.Error()))
+			}
+		}
+		if plan.bind {
+			if err := tx.Create(&model.ProjectResource{
+				ResourceType: constant.ResourceHost,
+				ResourceID:   host.ID,
+				ProjectID:    plan.project.ID,
+			}).Error; err != nil {
+				tx.Rollback()
+				return nil, errorf.CErrFs{}.Add(errorf.New("HOST_RESOURCE_FAILED_BIND", host.Name, err.Error()))
+			}
+		}
+		if err := tx.Model(&model.Ip{}).Where("address = ?", host.Ip).Update("status", constant.IpUsed).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	for i := range created {
+		go h.RunGetHostConfig(&created[i])
+	}
+	return &result, nil
+}
+
+// ExportHosts 按导入文件的格式导出主机清单，projectName 为空时导出全部主机
+func (h *hostService) ExportHosts(format string, projectName string) ([]byte, error) {
+	var (
+		credentials []model.Credential
+		records     []hostfile.Record
+	)
+	items, err := h.List(projectName, condition.TODO())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.DB.Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	credentialNames := map[string]string{}
+	for _, c := range credentials {
+		credentialNames[c.ID] = c.Name
+	}
+	for _, item := range items {
+		host := item.Host
+		labels := host.GetLabels()
+		if len(labels) == 0 {
+			labels = nil
+		}
+		records = append(records, hostfile.Record{
+			Name:         host.Name,
+			Ip:           host.Ip,
+			Port:         host.Port,
+			Credential:   credentialNames[host.CredentialID],
+			Project:      item.ProjectName,
+			Zone:         item.ZoneName,
+			Architecture: host.Architecture,
+			Labels:       labels,
+		})
+	}
+	return hostfile.Encode(format, records)
+}
+
+// importArchitecture 统一导入文件中的架构名称，为空时由同步主机信息时获取
+func importArchitecture(arch string) (string, bool) {
+	switch arch {
+	case "":
+		return "", true
+	case constant.ArchAMD64, constant.ArchitectureOfAMD64:
+		return constant.ArchitectureOfAMD64, true
+	case constant.ArchARM64, constant.ArchitectureOfARM64:
+		return constant.ArchitectureOfARM64, true
+	}
+	return "", false
+}
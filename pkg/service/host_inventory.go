@@ -0,0 +1,131 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/controller/condition"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	dbUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/inventory"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	hostDiskConditionFields = []string{"name", "model", "size", "rotational", "mount", "fs_type"}
+	hostNicConditionFields  = []string{"name", "mac", "mtu", "speed", "addresses"}
+)
+
+// withHostInventoryConditions 处理 disk.、nic. 开头的条件，如 disk.rotational、nic.speed，返回其余主机字段的条件
+func withHostInventoryConditions(d **gorm.DB, conditions condition.Conditions) (condition.Conditions, error) {
+	conditions, err := dbUtil.WithRelatedConditions(d, conditions, "disk", "ko_host_disk", "host_id", hostDiskConditionFields)
+	if err != nil {
+		return nil, err
+	}
+	return dbUtil.WithRelatedConditions(d, conditions, "nic", "ko_host_nic", "host_id", hostNicConditionFields)
+}
+
+// getHostInventory 补充 ansible 未采集的 cgroup 版本和 cpu flags，失败时不影响主机同步
+func (h *hostService) getHostInventory(host *model.Host, inv *inventory.Inventory) {
+	cfg, err := host.ToSSHConfig()
+	if err != nil {
+		logger.Log.Errorf("gather inventory of host %s error: %s", host.Name, err.Error())
+		return
+	}
+	client, err := ssh.New(&cfg)
+	if err != nil {
+		logger.Log.Errorf("gather inventory of host %s error: %s", host.Name, err.Error())
+		return
+	}
+	stdout, _, _, err := client.Exec(inventory.Script)
+	if err != nil {
+		logger.Log.Errorf("gather inventory of host %s error: %s", host.Name, err.Error())
+		return
+	}
+	inventory.ParseScript(inv, stdout)
+}
+
+// saveHostInventory 保存采集到的磁盘和网卡，与上一次记录不同时记录历史，未采集到时不处理
+func saveHostInventory(tx *gorm.DB, host *model.Host) error {
+	if host.ID == "" || host.Disks == nil {
+		return nil
+	}
+	if err := tx.Model(&model.Host{}).Where("id = ?", host.ID).Updates(map[string]interface{}{
+		"kernel_version": host.KernelVersion,
+		"cgroup_version": host.CgroupVersion,
+		"cpu_model":      host.CpuModel,
+		"cpu_flags":      host.CpuFlags,
+	}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("host_id = ?", host.ID).Delete(&model.HostDisk{}).Error; err != nil {
+		return err
+	}
+	for i := range host.Disks {
+		host.Disks[i].HostID = host.ID
+		if err := tx.Create(&host.Disks[i]).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("host_id = ?", host.ID).Delete(&model.HostNic{}).Error; err != nil {
+		return err
+	}
+	for i := range host.Nics {
+		host.Nics[i].HostID = host.ID
+		if err := tx.Create(&host.Nics[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	cur := host.GetInventory()
+	var (
+		last    model.HostInventoryHistory
+		changes []string
+	)
+	if err := tx.Where("host_id = ?", host.ID).Order("created_at desc").First(&last).Error; err != nil {
+		if !gorm.IsRecordNotFoundError(err) {
+			return err
+		}
+	} else {
+		var old inventory.Inventory
+		_ = json.Unmarshal([]byte(last.Inventory), &old)
+		if changes = inventory.Diff(old, cur); len(changes) == 0 {
+			return nil
+		}
+	}
+	buf, err := json.Marshal(cur)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&model.HostInventoryHistory{
+		HostID:    host.ID,
+		Inventory: string(buf),
+		Changes:   strings.Join(changes, "\n"),
+	}).Error
+}
+
+// ListInventoryHistory 按时间倒序返回主机硬件信息的变化记录，第一次采集的记录没有变更说明
+func (h *hostService) ListInventoryHistory(name string) ([]dto.HostInventoryHistory, error) {
+	var host model.Host
+	if err := db.DB.Where("name = ?", name).First(&host).Error; err != nil {
+		return nil, err
+	}
+	var histories []model.HostInventoryHistory
+	if err := db.DB.Where("host_id = ?", host.ID).Order("created_at desc").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	items := []dto.HostInventoryHistory{}
+	for _, history := range histories {
+		item := dto.HostInventoryHistory{HostInventoryHistory: history, Changes: []string{}}
+		_ = json.Unmarshal([]byte(history.Inventory), &item.Inventory)
+		if history.Changes != "" {
+			item.Changes = strings.Split(history.Changes, "\n")
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/errorf"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/preflight"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+)
+
+// 同时检查的主机数量
+const preflightConcurrency = 10
+
+type HostPreflightService interface {
+	Check(req dto.HostPreflight) (*dto.HostPreflightReport, error)
+	CheckCluster(cluster *model.Cluster, roles map[string]string) (*dto.HostPreflightReport, error)
+}
+
+func NewHostPreflightService() HostPreflightService {
+	return &hostPreflightService{}
+}
+
+type hostPreflightService struct{}
+
+// preflightSpec 检查项中与集群配置相关的部分
+type preflightSpec struct {
+	StorageDir        string
+	KubeProxyMode     string
+	KubeApiServerPort int
+}
+
+func (h *hostPreflightService) Check(req dto.HostPreflight) (*dto.HostPreflightReport, error) {
+	role := req.Role
+	if role == "" {
+		role = constant.NodeRoleNameMaster
+	}
+	roles := map[string]string{}
+	for _, name := range req.Hosts {
+		roles[name] = role
+	}
+	return h.checkNodes(roles, preflightSpec{StorageDir: req.StorageDir, KubeProxyMode: req.KubeProxyMode}, nil)
+}
+
+// CheckCluster 按集群配置检查即将加入集群的主机，key 为主机名称，value 为节点角色，集群已有的节点只用于检查主机名和 product_uuid 是否重复
+func (h *hostPreflightService) CheckCluster(cluster *model.Cluster, roles map[string]string) (*dto.HostPreflightReport, error) {
+	spec := preflightSpec{
+		StorageDir:        cluster.SpecRuntime.DockerStorageDir,
+		KubeProxyMode:     cluster.SpecConf.KubeProxyMode,
+		KubeApiServerPort: cluster.SpecConf.KubeApiServerPort,
+	}
+	if cluster.SpecRuntime.RuntimeType == "containerd" {
+		spec.StorageDir = cluster.SpecRuntime.ContainerdStorageDir
+	}
+	var peers []model.Host
+	for _, node := range cluster.Nodes {
+		if node.Status == constant.StatusRunning && node.Host.ID != "" {
+			peers = append(peers, node.Host)
+		}
+	}
+	return h.checkNodes(roles, spec, peers)
+}
+
+// checkNodes 检查 roles 中的主机，peers 只用于检查主机名和 product_uuid 是否重复
+func (h *hostPreflightService) checkNodes(roles map[string]string, spec preflightSpec, peers []model.Host) (*dto.HostPreflightReport, error) {
+	var names []string
+	for name := range roles {
+		names = append(names, name)
+	}
+	var hosts []model.Host
+	if err := db.DB.Preload("Credential").Where("name in (?)", names).Find(&hosts).Error; err != nil {
+		return nil, err
+	}
+	found := map[string]bool{}
+	for _, host := range hosts {
+		found[host.Name] = true
+	}
+	var errs errorf.CErrFs
+	for _, name := range names {
+		if !found[name] {
+			errs = errs.Add(errorf.New("HOST_IS_NOT_FOUND", name))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var (
+		lock      sync.Mutex
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, preflightConcurrency)
+		results   = map[string]*dto.HostPreflightResult{}
+		facts     = map[string]preflight.Facts{}
+		peerFacts = map[string]preflight.Facts{}
+	)
+	run := func(host model.Host, opts preflight.Options, peer bool) {
+		defer wg.Done()
+		sem <- struct{}{}
+		defer func() { <-sem }()
+		f, checks := runHostPreflight(host, opts)
+		lock.Lock()
+		defer lock.Unlock()
+		if peer {
+			peerFacts[host.Name] = f
+			return
+		}
+		facts[host.Name] = f
+		results[host.Name] = &dto.HostPreflightResult{Name: host.Name, Ip: host.Ip, Role: roles[host.Name], Checks: checks}
+	}
+	for _, host := range hosts {
+		wg.Add(1)
+		go run(host, preflightOptions(roles[host.Name], spec), false)
+	}
+	for _, host := range peers {
+		wg.Add(1)
+		go run(host, preflight.Options{}, true)
+	}
+	wg.Wait()
+
+	for name, checks := range preflight.CheckDuplicates(facts, peerFacts) {
+		results[name].Checks = append(results[name].Checks, checks...)
+	}
+	report := dto.HostPreflightReport{Level: preflight.LevelPass, Hosts: []dto.HostPreflightResult{}}
+	for _, result := range results {
+		result.Level = preflight.Level(result.Checks)
+		report.Hosts = append(report.Hosts, *result)
+		switch {
+		case result.Level == preflight.LevelFail:
+			report.Level = preflight.LevelFail
+		case result.Level == preflight.LevelWarn && report.Level == preflight.LevelPass:
+			report.Level = preflight.LevelWarn
+		}
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		return report.Hosts[i].Name < report.Hosts[j].Name
+	})
+	return &report, nil
+}
+
+func runHostPreflight(host model.Host, opts preflight.Options) (preflight.Facts, []preflight.Check) {
+	if _, _, err := host.GetHostPasswordAndPrivateKey(); err != nil {
+		return preflight.Facts{}, []preflight.Check{{Name: preflight.CheckConnection, Level: preflight.LevelFail, Msg: err.Error()}}
+	}
+	cfg, err := host.ToSSHConfig()
+	if err != nil {
+		return preflight.Facts{}, []preflight.Check{{Name: preflight.CheckConnection, Level: preflight.LevelFail, Msg: err.Error()}}
+	}
+	cfg.Retry = 0
+	client, err := ssh.New(&cfg)
+	if err != nil {
+		return preflight.Facts{}, []preflight.Check{{Name: preflight.CheckConnection, Level: preflight.LevelFail, Msg: err.Error()}}
+	}
+	return preflight.Run(client, opts)
+}
+
+func preflightOptions(role string, spec preflightSpec) preflight.Options {
+	opts := preflight.Options{
+		Ports:      preflight.WorkerPorts,
+		Modules:    preflight.BaseModules,
+		StorageDir: spec.StorageDir,
+	}
+	if role == constant.NodeRoleNameMaster {
+		opts.Ports = append([]int{}, preflight.MasterPorts...)
+		if spec.KubeApiServerPort != 0 && spec.KubeApiServerPort != 6443 {
+			opts.Ports = append(opts.Ports, spec.KubeApiServerPort)
+		}
+	}
+	if spec.KubeProxyMode == "ipvs" {
+		opts.Modules = append(append([]string{}, preflight.BaseModules...), preflight.IpvsModules...)
+	}
+	return opts
+}
+
+// preflightError 检查未通过时返回各主机未通过的检查项
+func preflightError(report *dto.HostPreflightReport) error {
+	if report.Level != preflight.LevelFail {
+		return nil
+	}
+	var errs errorf.CErrFs
+	for _, host := range report.Hosts {
+		for _, check := range host.Checks {
+			if check.Level == preflight.LevelFail {
+				errs = errs.Add(errorf.New("HOST_PREFLIGHT_FAILED", host.Name, check.Name, check.Msg))
+			}
+		}
+	}
+	return errs
+}
@@ -19,6 +19,7 @@ type KubernetesService interface {
 	CreateSecret(req dto.SourceSecretCreate) error
 	CordonNode(req dto.Cordon) error
 	EvictPod(req dto.Evict) error
+	DrainNode(clusterName, nodeName string) error
 	Delete(req dto.SourceDelete) error
 }
 
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	clusterUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/cluster"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	drainInterval = 5 * time.Second
+	drainTimeout  = 5 * time.Minute
+)
+
+// DrainNode 设置节点不可调度并驱逐节点上的 pod，DaemonSet 和静态 pod 不驱逐
+func (k kubernetesService) DrainNode(clusterName, nodeName string) error {
+	if err := k.CordonNode(dto.Cordon{Name: nodeName, Cluster: clusterName, SetUnschedulable: true}); err != nil {
+		return err
+	}
+	cluster, err := k.clusterRepo.GetWithPreload(clusterName, []string{"SpecConf", "Secret", "Nodes", "Nodes.Host", "Nodes.Host.Credential"})
+	if err != nil {
+		return err
+	}
+	client, err := clusterUtil.NewClusterClient(&cluster)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	if err := wait.Poll(drainInterval, drainTimeout, func() (bool, error) {
+		pods, err := evictablePods(client, nodeName)
+		if err != nil {
+			return false, err
+		}
+		if len(pods) == 0 {
+			return true, nil
+		}
+		for _, pod := range pods {
+			// PodDisruptionBudget 不满足时返回 429，等待下一轮重试
+			if err := k.EvictPod(dto.Evict{Name: pod.Name, Namespace: pod.Namespace, Cluster: clusterName}); err != nil && !apierrors.IsNotFound(err) {
+				lastErr = err
+			}
+		}
+		return false, nil
+	}); err != nil {
+		if lastErr != nil {
+			return fmt.Errorf("drain node %s timeout: %v", nodeName, lastErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func evictablePods(client *kubernetes.Clientset, nodeName string) ([]v1.Pod, error) {
+	podList, err := client.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": nodeName}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pods []v1.Pod
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if _, ok := pod.Annotations[v1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		controller := metav1.GetControllerOf(&pod)
+		if controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
@@ -7,17 +7,39 @@ import (
 	"html/template"
 	"io"
 	"reflect"
+	"sync"
 
 	"github.com/ClusterOperator/ClusterOperator/bindata"
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/db"
 	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/i18n"
 	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model"
 	msgClient "github.com/ClusterOperator/ClusterOperator/pkg/util/msg"
 	"github.com/jinzhu/gorm"
+	irisI18n "github.com/kataras/iris/v12/i18n"
 )
 
+var (
+	msgI18n     *irisI18n.I18n
+	msgI18nOnce sync.Once
+)
+
+// translateMsg 按默认语言翻译定时任务等非请求上下文中生成的消息，data 用于填充文案中的模板变量
+func translateMsg(key string, data map[string]interface{}) string {
+	msgI18nOnce.Do(func() {
+		msgI18n = irisI18n.New()
+		if err := msgI18n.LoadAssets(i18n.AssetNames, i18n.Asset, model.ZH, "en-US"); err != nil {
+			logger.Log.Errorf("load message locales error: %s", err.Error())
+		}
+	})
+	if msg := msgI18n.Tr(model.ZH, key, data); msg != "" {
+		return msg
+	}
+	return key
+}
+
 type MsgService interface {
 	SendMsg(name, scope string, resource interface{}, success bool, content map[string]string) error
 }
@@ -85,7 +107,7 @@ func (m msgService) SendMsg(name, scope string, resource interface{}, success bo
 			content["title"] = fmt.Sprintf("%s失败", title)
 		}
 	}
-	if name == constant.LicenseExpires {
+	if name == constant.LicenseExpires || name == constant.ClusterCertExpire || name == constant.ClusterAutoscale || name == constant.ClusterSchedule {
 		content["title"] = content["message"]
 	}
 
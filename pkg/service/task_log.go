@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -12,7 +13,10 @@ import (
 	"github.com/ClusterOperator/ClusterOperator/pkg/controller/page"
 	"github.com/ClusterOperator/ClusterOperator/pkg/db"
 	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/ha"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/service/cluster/adm"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/ansible"
 	uuid "github.com/satori/go.uuid"
 )
@@ -30,6 +34,7 @@ type TaskLogService interface {
 	NewTerminalTask(clusterID string, logtype string) (*model.TaskLog, error)
 
 	IsTaskOn(clusterName string) bool
+	Stream(id string, offset int64, done <-chan struct{}, send func(dto.TaskStreamEvent) error) error
 
 	StartDetail(detail *model.TaskLogDetail) error
 	EndDetail(detail *model.TaskLogDetail, name, taskType, statu, message string) error
@@ -142,13 +147,17 @@ func (c *taskLogService) GetTaskDetailByID(id string) (*dto.TaskLog, error) {
 		tasklog   model.TaskLog
 		retrylogs []model.TaskRetryLog
 	)
-	if err := db.DB.Where("id = ?", id).Preload("Details").First(&tasklog).Error; err != nil {
+	if err := db.DB.Where("id = ?", id).Preload("Details").Preload("Details.Failures").First(&tasklog).Error; err != nil {
 		return &dto.TaskLog{TaskLog: tasklog}, err
 	}
 	if err := db.DB.Where("task_log_id = ?", id).Find(&retrylogs).Error; err != nil {
 		return &dto.TaskLog{TaskLog: tasklog}, err
 	}
 	for _, re := range retrylogs {
+		// 暂停、继续、跳过、取消等人工干预记录已经保存在步骤记录中
+		if re.Action != "" {
+			continue
+		}
 		item := model.TaskLogDetail{
 			Task:      constant.TaskLogStatusFailed,
 			TaskLogID: re.TaskLogID,
@@ -176,6 +185,37 @@ func (c *taskLogService) GetTaskDetailByID(id string) (*dto.TaskLog, error) {
 	return &dto.TaskLog{TaskLog: tasklog}, nil
 }
 
+// locateFailures 任务结束后在完整日志中定位失败记录并保存，LogOffset 可作为 /tasks/{id}/stream 的 offset 参数
+func locateFailures(tasklog *model.TaskLog) {
+	var failures []model.TaskLogFailure
+	if err := db.DB.Where("task_log_detail_id in (?) AND log_line = ?",
+		db.DB.Model(&model.TaskLogDetail{}).Select("id").Where("task_log_id = ?", tasklog.ID).SubQuery(), 0).
+		Find(&failures).Error; err != nil || len(failures) == 0 {
+		return
+	}
+	var cluster model.Cluster
+	if err := db.DB.Where("id = ?", tasklog.ClusterID).First(&cluster).Error; err != nil {
+		return
+	}
+	for _, failure := range failures {
+		file, err := os.Open(ansible.GetAnsibleLogPath(cluster.Name, tasklog.ID))
+		if err != nil {
+			return
+		}
+		offset, line, ok := ansible.LocateFailure(file, failure.Task, failure.Host)
+		_ = file.Close()
+		if !ok {
+			continue
+		}
+		if err := db.DB.Model(&model.TaskLogFailure{}).Where("id = ?", failure.ID).Updates(map[string]interface{}{
+			"log_offset": offset,
+			"log_line":   line,
+		}).Error; err != nil {
+			logger.Log.Errorf("save location of task failure %s failed: %s", failure.ID, err.Error())
+		}
+	}
+}
+
 func (c *taskLogService) GetTaskLogByID(clusterId, logId string) (*dto.Logs, error) {
 	var cluster model.Cluster
 	if err := db.DB.Where("id = ?", clusterId).First(&cluster).Error; err != nil {
@@ -284,6 +324,19 @@ func (c *taskLogService) EndDetail(detail *model.TaskLogDetail, name, taskType,
 	return db.DB.Save(detail).Error
 }
 
+// lockCluster 获取集群操作锁，多副本部署时避免多个副本同时通过 IsTaskOn 检查并对同一集群发起任务
+func lockCluster(clusterName string) (func(), error) {
+	lease := ha.ClusterPrefix + clusterName
+	ok, err := ha.TryLock(lease)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("TASK_IN_EXECUTION")
+	}
+	return func() { ha.Unlock(lease) }, nil
+}
+
 func (c *taskLogService) IsTaskOn(clusterName string) bool {
 	var (
 		cluster model.Cluster
@@ -298,7 +351,11 @@ func (c *taskLogService) IsTaskOn(clusterName string) bool {
 	if err := db.DB.Where("id = ?", cluster.CurrentTaskID).First(&log).Error; err != nil {
 		return false
 	}
-	return !(log.Phase == constant.TaskLogStatusFailed || log.Phase == constant.TaskLogStatusSuccess)
+	if !(log.Phase == constant.TaskLogStatusFailed || log.Phase == constant.TaskLogStatusSuccess) {
+		return true
+	}
+	// 任务取消后 kobe 中仍在执行的 playbook 结束前不能发起其他任务
+	return adm.IsPlaybookDetached(log.ID)
 }
 
 func (c *taskLogService) Save(taskLog *model.TaskLog) error {
@@ -330,6 +387,7 @@ func (c *taskLogService) End(log *model.TaskLog, success bool, message string) e
 		log.Phase = constant.TaskLogStatusFailed
 	}
 	log.EndTime = time.Now().Unix()
+	log.Finished = true
 	for i := 0; i < len(log.Details); i++ {
 		if log.Details[i].Status == constant.TaskLogStatusRunning {
 			log.Details[i].Status = status
@@ -338,8 +396,22 @@ func (c *taskLogService) End(log *model.TaskLog, success bool, message string) e
 		}
 	}
 	log.Message = message
+	// 失败的任务重试时跳过的步骤仍然生效
+	release := adm.ResetTask
+	if success {
+		release = adm.ReleaseTask
+	}
+	if err := release(log.ID); err != nil {
+		logger.Log.Errorf("release control of task %s failed: %s", log.ID, err.Error())
+	}
 
-	return db.DB.Save(log).Error
+	if err := db.DB.Save(log).Error; err != nil {
+		return err
+	}
+	if !success {
+		locateFailures(log)
+	}
+	return nil
 }
 
 func (c taskLogService) RestartTask(cluster *model.Cluster, operation string) error {
@@ -369,7 +441,11 @@ func (c taskLogService) RestartTask(cluster *model.Cluster, operation string) er
 	if err := db.DB.Create(retrylog).Error; err != nil {
 		return err
 	}
+	if err := adm.ResetTask(cluster.TaskLog.ID); err != nil {
+		return err
+	}
 	cluster.TaskLog.Phase = constant.TaskLogStatusWaiting
+	cluster.TaskLog.Finished = false
 	if err := c.Save(&cluster.TaskLog); err != nil {
 		return fmt.Errorf("reset contidion err %s", err.Error())
 	}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ansible"
+)
+
+const (
+	taskStreamInterval  = time.Second
+	taskStreamChunkSize = 64 * 1024
+	// taskStreamStatusInterval 日志没有增长时读取任务状态的间隔
+	taskStreamStatusInterval = 5 * time.Second
+)
+
+// taskStreamSnapshot 同一任务的多个日志流共享的任务状态，每个任务在 taskStreamInterval 内只查询一次数据库
+type taskStreamSnapshot struct {
+	tasklog   model.TaskLog
+	fetchedAt time.Time
+}
+
+var (
+	taskStreamSnapshots   = map[string]*taskStreamSnapshot{}
+	taskStreamSnapshotsMu sync.Mutex
+)
+
+func loadTaskStreamSnapshot(id string) (model.TaskLog, error) {
+	taskStreamSnapshotsMu.Lock()
+	defer taskStreamSnapshotsMu.Unlock()
+	now := time.Now()
+	for key, s := range taskStreamSnapshots {
+		if now.Sub(s.fetchedAt) > taskStreamStatusInterval {
+			delete(taskStreamSnapshots, key)
+		}
+	}
+	if s, ok := taskStreamSnapshots[id]; ok && now.Sub(s.fetchedAt) < taskStreamInterval {
+		return s.tasklog, nil
+	}
+	var tasklog model.TaskLog
+	if err := db.DB.Where("id = ?", id).Preload("Details").First(&tasklog).Error; err != nil {
+		return tasklog, err
+	}
+	taskStreamSnapshots[id] = &taskStreamSnapshot{tasklog: tasklog, fetchedAt: now}
+	return tasklog, nil
+}
+
+// Stream 从 offset 处推送任务的 ansible 日志以及阶段、子任务的状态变化，任务结束且日志读完后返回
+func (c *taskLogService) Stream(id string, offset int64, done <-chan struct{}, send func(dto.TaskStreamEvent) error) error {
+	var (
+		tasklog model.TaskLog
+		cluster model.Cluster
+	)
+	if err := db.DB.Where("id = ?", id).First(&tasklog).Error; err != nil {
+		return err
+	}
+	if err := db.DB.Where("id = ?", tasklog.ClusterID).First(&cluster).Error; err != nil {
+		return err
+	}
+	logPath := ansible.GetAnsibleLogPath(cluster.Name, id)
+
+	var (
+		f        *os.File
+		phase    string
+		details  = map[string]string{}
+		buf      = make([]byte, taskStreamChunkSize)
+		polledAt time.Time
+	)
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+	ticker := time.NewTicker(taskStreamInterval)
+	defer ticker.Stop()
+	for {
+		if f == nil {
+			file, err := os.Open(logPath)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			f = file
+		}
+		// 日志有新内容或超过间隔时才读取任务状态，减少数据库查询
+		grown := false
+		if f != nil {
+			if info, err := f.Stat(); err == nil && info.Size() > offset {
+				grown = true
+			}
+		}
+		if !grown && time.Since(polledAt) < taskStreamStatusInterval {
+			select {
+			case <-done:
+				return nil
+			case <-ticker.C:
+			}
+			continue
+		}
+		polledAt = time.Now()
+
+		// 先读取任务状态再读取日志，保证结束事件之前已推送全部日志
+		var err error
+		if tasklog, err = loadTaskStreamSnapshot(id); err != nil {
+			return err
+		}
+		if tasklog.Phase != phase {
+			phase = tasklog.Phase
+			if err := send(dto.TaskStreamEvent{Type: constant.TaskStreamEventPhase, Offset: offset, Phase: tasklog.Phase, Message: tasklog.Message}); err != nil {
+				return err
+			}
+		}
+		for i := range tasklog.Details {
+			detail := tasklog.Details[i]
+			state := fmt.Sprintf("%s/%d/%s", detail.Status, detail.EndTime, detail.Message)
+			if details[detail.ID] == state {
+				continue
+			}
+			details[detail.ID] = state
+			if err := send(dto.TaskStreamEvent{Type: constant.TaskStreamEventDetail, Offset: offset, Detail: &detail}); err != nil {
+				return err
+			}
+		}
+
+		if f != nil {
+			offset, err = streamTaskLog(f, offset, buf, tasklog.Finished, func(data []byte, offset int64) error {
+				return send(dto.TaskStreamEvent{Type: constant.TaskStreamEventLog, Offset: offset, Data: string(data)})
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// 多阶段任务的中间阶段 Phase 也可能为 SUCCESS，以 Finished 判断任务结束
+		if tasklog.Finished {
+			return send(dto.TaskStreamEvent{Type: constant.TaskStreamEventEnd, Offset: offset, Phase: tasklog.Phase, Message: tasklog.Message})
+		}
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// streamTaskLog 从 offset 处按块推送日志，返回新的 offset。
+// 任务执行中只推送完整的行，避免截断多字节字符，超长的行按块推送；任务结束后推送剩余的全部内容
+func streamTaskLog(r io.ReaderAt, offset int64, buf []byte, finished bool, send func(data []byte, offset int64) error) (int64, error) {
+	for {
+		n, err := r.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			return offset, err
+		}
+		data := buf[:n]
+		if !finished {
+			if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+				data = data[:i+1]
+			} else if n < len(buf) {
+				data = nil
+			}
+		}
+		if len(data) == 0 {
+			return offset, nil
+		}
+		offset += int64(len(data))
+		if err := send(data, offset); err != nil {
+			return offset, err
+		}
+		if n < len(buf) {
+			return offset, nil
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStreamTaskLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		offset     int64
+		chunkSize  int
+		finished   bool
+		want       []string
+		wantOffset int64
+	}{
+		{name: "complete lines", content: "a\nb\n", chunkSize: 16, want: []string{"a\nb\n"}, wantOffset: 4},
+		{name: "resume from offset", content: "a\nb\nc\n", offset: 2, chunkSize: 16, want: []string{"b\nc\n"}, wantOffset: 6},
+		{name: "offset at end", content: "a\n", offset: 2, chunkSize: 16, wantOffset: 2},
+		{name: "hold partial line while running", content: "a\nb", chunkSize: 16, want: []string{"a\n"}, wantOffset: 2},
+		{name: "only partial line while running", content: "ab", chunkSize: 16, wantOffset: 0},
+		{name: "flush partial line when finished", content: "a\nb", offset: 2, chunkSize: 16, finished: true, want: []string{"b"}, wantOffset: 3},
+		{name: "read in chunks", content: "ab\ncd\nef\n", chunkSize: 4, want: []string{"ab\n", "cd\n", "ef\n"}, wantOffset: 9},
+		{name: "long line pushed by chunk", content: "abcdefgh\n", chunkSize: 4, want: []string{"abcd", "efgh", "\n"}, wantOffset: 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			offset, err := streamTaskLog(strings.NewReader(tt.content), tt.offset, make([]byte, tt.chunkSize), tt.finished, func(data []byte, offset int64) error {
+				got = append(got, string(data))
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("streamTaskLog() sent %q, want %q", got, tt.want)
+			}
+			if offset != tt.wantOffset {
+				t.Errorf("streamTaskLog() offset = %d, want %d", offset, tt.wantOffset)
+			}
+		})
+	}
+}
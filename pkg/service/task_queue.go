@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/ha"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/repository"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ansible"
+)
+
+// 可以在服务重启后从断点继续执行的任务类型
+var resumableTaskTypes = []string{
+	constant.TaskLogTypeClusterCreate,
+	constant.TaskLogTypeClusterUpgrade,
+	constant.TaskLogTypeClusterRollingUpgrade,
+	constant.TaskLogTypeClusterNodeExtend,
+	constant.TaskLogTypeClusterMasterExtend,
+	constant.TaskLogTypeClusterMasterShrink,
+	constant.TaskLogTypeClusterRuntimeMigrate,
+}
+
+type TaskQueueService interface {
+	ListInterrupted() ([]model.TaskLog, error)
+	Resume(tasks []model.TaskLog) error
+}
+
+func NewTaskQueueService() TaskQueueService {
+	return &taskQueueService{
+		clusterRepo:           repository.NewClusterRepository(),
+		taskLogService:        NewTaskLogService(),
+		clusterInitService:    NewClusterInitService(),
+		clusterUpgradeService: NewClusterUpgradeService(),
+		clusterNodeService:    NewClusterNodeService(),
+		clusterRuntimeService: NewClusterRuntimeService(),
+		taskSchedulerService:  NewTaskSchedulerService(),
+	}
+}
+
+type taskQueueService struct {
+	clusterRepo           repository.ClusterRepository
+	taskLogService        TaskLogService
+	clusterInitService    ClusterInitService
+	clusterUpgradeService ClusterUpgradeService
+	clusterNodeService    ClusterNodeService
+	clusterRuntimeService ClusterRuntimeService
+	taskSchedulerService  TaskSchedulerService
+}
+
+// ListInterrupted 查询服务停止时仍在执行，且可以继续执行的任务
+func (t *taskQueueService) ListInterrupted() ([]model.TaskLog, error) {
+	var (
+		tasks []model.TaskLog
+		datas []model.TaskLog
+	)
+	if err := db.DB.Where("finished = ? AND type in (?)", false, resumableTaskTypes).
+		Preload("Details").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	// 其他副本上执行或等待执行的任务
+	alive, err := ha.Alive(ha.TaskPrefix)
+	if err != nil {
+		return nil, err
+	}
+	aliveTasks := map[string]bool{}
+	for _, id := range alive {
+		aliveTasks[id] = true
+	}
+	for _, task := range tasks {
+		if aliveTasks[task.ID] {
+			continue
+		}
+		var cluster model.Cluster
+		if err := db.DB.Where("id = ?", task.ClusterID).First(&cluster).Error; err != nil {
+			continue
+		}
+		if cluster.CurrentTaskID != task.ID {
+			continue
+		}
+		if nodeStatus := resumableNodeStatus(task.Type); len(nodeStatus) > 0 {
+			var count int
+			if err := db.DB.Model(&model.ClusterNode{}).Where("current_task_id = ? AND status in (?)", task.ID, nodeStatus).Count(&count).Error; err != nil || count == 0 {
+				continue
+			}
+		}
+		datas = append(datas, task)
+	}
+	return datas, nil
+}
+
+// resumableNodeStatus 节点变更任务在节点进入这些状态后才可继续，其他任务类型返回空
+func resumableNodeStatus(taskType string) []string {
+	switch taskType {
+	case constant.TaskLogTypeClusterNodeExtend:
+		// 自动模式下主机创建阶段为 Creating，继续执行时会重新创建主机
+		return []string{constant.StatusCreating, constant.StatusInitializing}
+	case constant.TaskLogTypeClusterMasterExtend:
+		return []string{constant.StatusInitializing}
+	case constant.TaskLogTypeClusterMasterShrink:
+		return []string{constant.StatusTerminating}
+	}
+	return nil
+}
+
+// Resume 按照任务类型以原优先级重新调度，已完成的 Ensure* 步骤不会重复执行，无法继续的任务标记为失败并返回错误
+func (t *taskQueueService) Resume(tasks []model.TaskLog) error {
+	var failed []string
+	for i := range tasks {
+		task := tasks[i]
+		if err := t.resume(task); err != nil {
+			// 其他副本已接管的任务不能标记为失败
+			if err == errTaskSubmitted {
+				logger.Log.Infof("task %s is resumed by another replica", task.ID)
+				continue
+			}
+			logger.Log.Errorf("resume task %s failed: %s", task.ID, err.Error())
+			failed = append(failed, fmt.Sprintf("%s: %s", task.ID, err.Error()))
+			if err := t.taskLogService.End(&task, false, err.Error()); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %s", task.ID, err.Error()))
+			}
+			continue
+		}
+		logger.Log.Infof("resume task %s (%s) successful", task.ID, task.Type)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("resume tasks failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (t *taskQueueService) resume(task model.TaskLog) error {
+	var cluster model.Cluster
+	if err := db.DB.Where("id = ?", task.ClusterID).First(&cluster).Error; err != nil {
+		return err
+	}
+	cluster, err := t.clusterRepo.GetWithPreload(cluster.Name, []string{"SpecConf", "SpecNetwork", "SpecRuntime", "Secret", "Nodes", "Nodes.Host", "Nodes.Host.Credential", "Nodes.Host.Zone", "MultiClusterRepositories"})
+	if err != nil {
+		return err
+	}
+	cluster.TaskLog = task
+
+	writer, err := ansible.CreateAnsibleLogWriterWithId(cluster.Name, task.ID)
+	if err != nil {
+		return fmt.Errorf("create log error %s", err.Error())
+	}
+	_, _ = fmt.Fprintf(writer, "\n======== task %s resumed after server restart ========\n", task.ID)
+
+	switch task.Type {
+	case constant.TaskLogTypeClusterCreate:
+		if cluster.Provider == constant.ClusterProviderPlan && len(cluster.Nodes) == 0 {
+			if err := db.DB.Where("id = ?", cluster.PlanID).Preload("Zones").Preload("Region").First(&cluster.Plan).Error; err != nil {
+				return err
+			}
+		}
+		if err := t.taskSchedulerService.Submit(&cluster.TaskLog, task.Priority, func() {
+			t.clusterInitService.Init(cluster, writer)
+		}); err != nil {
+			return err
+		}
+	case constant.TaskLogTypeClusterUpgrade, constant.TaskLogTypeClusterRollingUpgrade:
+		if err := t.taskSchedulerService.Submit(&cluster.TaskLog, task.Priority, func() {
+			t.clusterUpgradeService.Resume(cluster, writer)
+		}); err != nil {
+			return err
+		}
+	case constant.TaskLogTypeClusterNodeExtend:
+		return t.clusterNodeService.ResumeAddWorker(cluster, writer)
+	case constant.TaskLogTypeClusterMasterExtend, constant.TaskLogTypeClusterMasterShrink:
+		return t.clusterNodeService.ResumeMaster(cluster, writer)
+	case constant.TaskLogTypeClusterRuntimeMigrate:
+		if err := t.taskSchedulerService.Submit(&cluster.TaskLog, task.Priority, func() {
+			t.clusterRuntimeService.Resume(cluster, writer)
+		}); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("task type %s can not be resumed", task.Type)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+)
+
+func TestResumableNodeStatus(t *testing.T) {
+	tests := []struct {
+		taskType string
+		want     []string
+	}{
+		{taskType: constant.TaskLogTypeClusterNodeExtend, want: []string{constant.StatusCreating, constant.StatusInitializing}},
+		{taskType: constant.TaskLogTypeClusterMasterExtend, want: []string{constant.StatusInitializing}},
+		{taskType: constant.TaskLogTypeClusterMasterShrink, want: []string{constant.StatusTerminating}},
+		{taskType: constant.TaskLogTypeClusterUpgrade},
+		{taskType: constant.TaskLogTypeClusterCreate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.taskType, func(t *testing.T) {
+			if got := resumableNodeStatus(tt.taskType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resumableNodeStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResumeAddWorkerOperation(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   []string
+		want     string
+	}{
+		{name: "bare metal", provider: constant.ClusterProviderBareMetal, status: []string{constant.StatusInitializing}, want: "recreate"},
+		{name: "plan hosts created", provider: constant.ClusterProviderPlan, status: []string{constant.StatusInitializing, constant.StatusInitializing}, want: "recreate"},
+		{name: "plan hosts creating", provider: constant.ClusterProviderPlan, status: []string{constant.StatusInitializing, constant.StatusCreating}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nodes []model.ClusterNode
+			for _, status := range tt.status {
+				nodes = append(nodes, model.ClusterNode{Status: status})
+			}
+			if got := resumeAddWorkerOperation(tt.provider, nodes); got != tt.want {
+				t.Errorf("resumeAddWorkerOperation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
+	"github.com/ClusterOperator/ClusterOperator/pkg/db"
+	"github.com/ClusterOperator/ClusterOperator/pkg/dto"
+	"github.com/ClusterOperator/ClusterOperator/pkg/ha"
+	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
+	"github.com/ClusterOperator/ClusterOperator/pkg/model"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/scheduler"
+)
+
+// 全局任务调度器，所有会执行 playbook 的任务都需要经过调度器执行
+// 等待队列和并发限制只在本副本内生效，多副本部署时总并发数为各副本限制之和，Queue 只返回本副本的任务
+var taskScheduler = scheduler.New(scheduler.Limits{})
+
+// errTaskSubmitted 任务租约已被本副本或其他副本持有，任务正在执行
+var errTaskSubmitted = errors.New("task is already submitted")
+
+type TaskSchedulerService interface {
+	Submit(task *model.TaskLog, priority int, run func()) error
+	Queue() (*dto.TaskQueue, error)
+}
+
+func NewTaskSchedulerService() TaskSchedulerService {
+	return &taskSchedulerService{}
+}
+
+type taskSchedulerService struct{}
+
+// Submit 任务进入等待队列并标记为 Waiting，获得执行槽位后标记为 Running 并执行 run。
+// 任务已由本副本或其他副本执行时返回错误；获取租约失败时任务标记为失败并释放集群，避免集群一直处于任务中
+func (t *taskSchedulerService) Submit(task *model.TaskLog, priority int, run func()) error {
+	// 持有任务租约期间，其他副本的故障恢复不会处理该任务
+	lease := ha.TaskPrefix + task.ID
+	ok, err := ha.TryLock(lease)
+	if err != nil {
+		logger.Log.Errorf("lock task %s failed: %s", task.ID, err.Error())
+		failTask(task, fmt.Sprintf("lock task failed: %s", err.Error()))
+		return err
+	}
+	if !ok {
+		return errTaskSubmitted
+	}
+	setTaskPriority(task, priority)
+	setTaskFinished(task, false)
+	// 服务重启后继续执行的任务可能处于暂停状态，保持原状态
+	if task.Phase != constant.TaskLogStatusPaused {
+		setTaskPhase(task, constant.TaskLogStatusWaiting)
+	}
+	taskScheduler.Submit(scheduler.Job{
+		ID:       task.ID,
+		Type:     task.Type,
+		Priority: priority,
+		Run: func() {
+			defer ha.Unlock(lease)
+			if task.Phase == constant.TaskLogStatusWaiting {
+				setTaskPhase(task, constant.TaskLogStatusRunning)
+			}
+			run()
+			// run 返回后任务不再需要继续执行，副本在 run 执行期间退出时保持未完成
+			setTaskFinished(task, true)
+		},
+	})
+	return nil
+}
+
+func (t *taskSchedulerService) Queue() (*dto.TaskQueue, error) {
+	limits := taskScheduler.Limits()
+	running, waiting := taskScheduler.Snapshot()
+	queue := dto.TaskQueue{
+		MaxConcurrent: limits.Global,
+		TypeLimits:    limits.Types,
+		Running:       []dto.TaskQueueItem{},
+		Waiting:       []dto.TaskQueueItem{},
+	}
+	var ids []string
+	for _, item := range append(running, waiting...) {
+		ids = append(ids, item.ID)
+	}
+	if len(ids) == 0 {
+		return &queue, nil
+	}
+
+	var (
+		tasks    []model.TaskLog
+		clusters []model.Cluster
+	)
+	if err := db.DB.Where("id in (?)", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	var clusterIDs []string
+	for _, task := range tasks {
+		clusterIDs = append(clusterIDs, task.ClusterID)
+	}
+	if err := db.DB.Where("id in (?)", clusterIDs).Find(&clusters).Error; err != nil {
+		return nil, err
+	}
+	clusterNames := map[string]string{}
+	for _, cluster := range clusters {
+		clusterNames[cluster.ID] = cluster.Name
+	}
+	taskClusters := map[string]string{}
+	for _, task := range tasks {
+		taskClusters[task.ID] = clusterNames[task.ClusterID]
+	}
+
+	toItem := func(item scheduler.Item) dto.TaskQueueItem {
+		data := dto.TaskQueueItem{
+			TaskLogID:   item.ID,
+			ClusterName: taskClusters[item.ID],
+			Type:        item.Type,
+			Priority:    item.Priority,
+			Position:    item.Position,
+			SubmitTime:  item.SubmitTime.Unix(),
+		}
+		if !item.StartTime.IsZero() {
+			data.StartTime = item.StartTime.Unix()
+		}
+		return data
+	}
+	for _, item := range running {
+		queue.Running = append(queue.Running, toItem(item))
+	}
+	for _, item := range waiting {
+		queue.Waiting = append(queue.Waiting, toItem(item))
+	}
+	return &queue, nil
+}
+
+// submitDetail 组件和存储插件只记录任务详情，为其创建任务日志后经过调度器执行，run 结束后按任务详情的状态结束任务
+// kind 为 component 或 provisioner，创建任务日志失败时任务详情标记为失败
+func submitDetail(taskSchedulerService TaskSchedulerService, taskLogService TaskLogService, detail *model.TaskLogDetail, name, kind, taskType string, run func()) error {
+	task, err := taskLogService.NewTerminalTask(detail.ClusterID, taskType)
+	if err != nil {
+		_ = taskLogService.EndDetail(detail, name, kind, constant.TaskLogStatusFailed, err.Error())
+		return err
+	}
+	if err := taskSchedulerService.Submit(task, constant.TaskPriorityUser, func() {
+		run()
+		_ = taskLogService.End(task, detail.Status == constant.TaskLogStatusSuccess, detail.Message)
+	}); err != nil {
+		_ = taskLogService.EndDetail(detail, name, kind, constant.TaskLogStatusFailed, err.Error())
+		return err
+	}
+	return nil
+}
+
+func setTaskPhase(task *model.TaskLog, phase string) {
+	task.Phase = phase
+	if err := db.DB.Model(&model.TaskLog{}).Where("id = ?", task.ID).Update("phase", phase).Error; err != nil {
+		logger.Log.Errorf("update phase of task %s failed: %s", task.ID, err.Error())
+	}
+}
+
+// failTask 任务未能提交时结束任务，并释放仍由该任务占用的集群
+func failTask(task *model.TaskLog, message string) {
+	task.Phase = constant.TaskLogStatusFailed
+	task.Message = message
+	task.EndTime = time.Now().Unix()
+	task.Finished = true
+	if err := db.DB.Model(&model.TaskLog{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+		"phase":    task.Phase,
+		"message":  task.Message,
+		"end_time": task.EndTime,
+		"finished": true,
+	}).Error; err != nil {
+		logger.Log.Errorf("end task %s failed: %s", task.ID, err.Error())
+	}
+	if err := db.DB.Model(&model.Cluster{}).Where("id = ? AND current_task_id = ?", task.ClusterID, task.ID).Update("current_task_id", "").Error; err != nil {
+		logger.Log.Errorf("release task %s of cluster failed: %s", task.ID, err.Error())
+	}
+}
+
+func setTaskPriority(task *model.TaskLog, priority int) {
+	task.Priority = priority
+	if err := db.DB.Model(&model.TaskLog{}).Where("id = ?", task.ID).Update("priority", priority).Error; err != nil {
+		logger.Log.Errorf("update priority of task %s failed: %s", task.ID, err.Error())
+	}
+}
+
+func setTaskFinished(task *model.TaskLog, finished bool) {
+	task.Finished = finished
+	if err := db.DB.Model(&model.TaskLog{}).Where("id = ?", task.ID).Update("finished", finished).Error; err != nil {
+		logger.Log.Errorf("update finished of task %s failed: %s", task.ID, err.Error())
+	}
+}
+
+// InitTaskSchedulerPhase 启动时加载任务并发限制，TypeLimits 的 key 为任务类型，不区分大小写
+type InitTaskSchedulerPhase struct {
+	MaxConcurrent int
+	TypeLimits    map[string]string
+}
+
+func (i *InitTaskSchedulerPhase) Init() error {
+	limits := scheduler.Limits{Global: i.MaxConcurrent, Types: map[string]int{}}
+	for taskType, value := range i.TypeLimits {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		limits.Types[strings.ToUpper(taskType)] = limit
+	}
+	taskScheduler.SetLimits(limits)
+	return nil
+}
+
+func (i *InitTaskSchedulerPhase) PhaseName() string {
+	return "task scheduler"
+}
@@ -269,6 +269,7 @@ func (z zoneService) Create(creation dto.ZoneCreate) (*dto.Zone, error) {
 		Vars:         string(vars),
 		RegionID:     region.ID,
 		CredentialID: credential.ID,
+		BastionID:    creation.BastionID,
 		IpPoolID:     ipPool.ID,
 		Status:       constant.Ready,
 	}
@@ -317,6 +318,7 @@ func (z zoneService) Update(name string, update dto.ZoneUpdate) (*dto.Zone, erro
 	zone.Vars = string(vars)
 	zone.RegionID = update.RegionID
 	zone.IpPoolID = ipPool.ID
+	zone.BastionID = update.BastionID
 
 	if update.CredentialName != "" {
 		credentialService := NewCredentialService()
@@ -1,10 +1,12 @@
 package ansible
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/util/file"
@@ -45,8 +47,12 @@ func CreateAnsibleLogWriterWithId(clusterName string, logId string) (io.Writer,
 	return writer, nil
 }
 
+func GetAnsibleLogPath(clusterName string, logId string) string {
+	return path.Join(constant.DefaultAnsibleLogDir, clusterName, fmt.Sprintf("%s.log", logId))
+}
+
 func GetAnsibleLogReader(clusterName string, logId string) (io.Reader, error) {
-	logPath := path.Join(constant.DefaultAnsibleLogDir, clusterName, fmt.Sprintf("%s.log", logId))
+	logPath := GetAnsibleLogPath(clusterName, logId)
 	result, err := os.OpenFile(logPath, os.O_RDONLY, 0755)
 	if err != nil {
 		return result, errors.Wrap(err, fmt.Sprintf("get ansible log of cluster: %s logid: %s failed: %v", clusterName, logId, err))
@@ -62,3 +68,32 @@ func GetNodeAnsibleLogReader(clusterName string, nodeName string, logId string)
 	}
 	return result, nil
 }
+
+// LocateFailure 在 ansible 日志中查找主机执行任务失败的输出行，返回该行的字节偏移和行号（从 1 开始），重试的任务以最后一次为准
+func LocateFailure(r io.Reader, task string, host string) (int64, int, bool) {
+	var (
+		offset, found   int64
+		line, foundLine int
+		inTask          bool
+	)
+	reader := bufio.NewReader(r)
+	for {
+		b, err := reader.ReadBytes('\n')
+		if len(b) > 0 {
+			line++
+			text := string(b)
+			if strings.HasPrefix(text, "TASK [") {
+				inTask = strings.Contains(text, task+"]")
+			} else if inTask && (strings.HasPrefix(text, "fatal: ["+host+"]") ||
+				strings.HasPrefix(text, "failed: ["+host+"]") ||
+				strings.HasPrefix(text, "fatal: ["+host+" ")) {
+				found, foundLine = offset, line
+			}
+			offset += int64(len(b))
+		}
+		if err != nil {
+			break
+		}
+	}
+	return found, foundLine, foundLine > 0
+}
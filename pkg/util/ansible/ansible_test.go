@@ -0,0 +1,49 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+)
+
+const failureLog = `PLAY [kube-master] *************************************************************
+
+TASK [kubernetes : Init cluster] ***********************************************
+ok: [master1]
+fatal: [master2]: FAILED! => {"changed": true, "rc": 1}
+
+TASK [kubernetes : Join cluster] ***********************************************
+fatal: [worker1]: UNREACHABLE! => {"changed": false, "unreachable": true}
+
+----task retry----
+TASK [kubernetes : Init cluster] ***********************************************
+failed: [master2] (item=kubelet) => {"changed": true, "rc": 2}
+`
+
+func TestLocateFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     string
+		host     string
+		wantLine int
+		wantOK   bool
+	}{
+		{name: "last retry wins", task: "Init cluster", host: "master2", wantLine: 12, wantOK: true},
+		{name: "unreachable", task: "Join cluster", host: "worker1", wantLine: 8, wantOK: true},
+		{name: "succeeded host", task: "Init cluster", host: "master1", wantOK: false},
+		{name: "other task", task: "Join cluster", host: "master2", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, line, ok := LocateFailure(strings.NewReader(failureLog), tt.task, tt.host)
+			if ok != tt.wantOK || line != tt.wantLine {
+				t.Fatalf("LocateFailure() = %d, %v, want %d, %v", line, ok, tt.wantLine, tt.wantOK)
+			}
+			if ok {
+				lines := strings.SplitAfter(failureLog, "\n")
+				if !strings.HasPrefix(failureLog[offset:], lines[line-1]) {
+					t.Errorf("offset %d does not point to line %d", offset, line)
+				}
+			}
+		})
+	}
+}
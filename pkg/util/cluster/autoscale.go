@@ -0,0 +1,329 @@
+package cluster
+
+import (
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// UnschedulablePods 返回因资源不足等原因无法调度的 pod
+func UnschedulablePods(pods []v1.Pod) []v1.Pod {
+	var result []v1.Pod
+	for _, p := range pods {
+		if p.Status.Phase != v1.PodPending || p.Spec.NodeName != "" {
+			continue
+		}
+		for _, c := range p.Status.Conditions {
+			if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse && c.Reason == v1.PodReasonUnschedulable {
+				result = append(result, p)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// EstimateNodes 按 pod 的 cpu 和内存 requests 估算需要新增的节点数，至少为 1
+func EstimateNodes(pods []v1.Pod, allocatable v1.ResourceList) int {
+	cpu, memory := podsRequests(pods)
+	count := 1
+	if n := ceilDiv(cpu.MilliValue(), allocatable.Cpu().MilliValue()); n > count {
+		count = n
+	}
+	if n := ceilDiv(memory.Value(), allocatable.Memory().Value()); n > count {
+		count = n
+	}
+	return count
+}
+
+// NodeUtilization 返回节点上 pod 的 cpu 和内存 requests 占可分配资源的最大百分比
+func NodeUtilization(node v1.Node, pods []v1.Pod) int {
+	var running []v1.Pod
+	for _, p := range pods {
+		if p.Spec.NodeName == node.Name && p.Status.Phase != v1.PodSucceeded && p.Status.Phase != v1.PodFailed {
+			running = append(running, p)
+		}
+	}
+	cpu, memory := podsRequests(running)
+	utilization := 0
+	if alloc := node.Status.Allocatable.Cpu().MilliValue(); alloc > 0 {
+		utilization = int(cpu.MilliValue() * 100 / alloc)
+	}
+	if alloc := node.Status.Allocatable.Memory().Value(); alloc > 0 {
+		if u := int(memory.Value() * 100 / alloc); u > utilization {
+			utilization = u
+		}
+	}
+	return utilization
+}
+
+// Evictable 节点上的 pod 都由控制器管理且不使用本地存储时才可以缩容，DaemonSet 的 pod 不影响判断
+func Evictable(nodeName string, pods []v1.Pod) bool {
+	for _, p := range pods {
+		if p.Spec.NodeName != nodeName || p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if _, ok := p.Annotations[v1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		if len(p.OwnerReferences) == 0 {
+			return false
+		}
+		if !isDaemonSetPod(p) && usesLocalStorage(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// usesLocalStorage 使用 hostPath 或非内存 emptyDir 的 pod 驱逐后数据会丢失
+func usesLocalStorage(p v1.Pod) bool {
+	for _, volume := range p.Spec.Volumes {
+		if volume.HostPath != nil {
+			return true
+		}
+		if volume.EmptyDir != nil && volume.EmptyDir.Medium != v1.StorageMediumMemory {
+			return true
+		}
+	}
+	return false
+}
+
+func podsRequests(pods []v1.Pod) (resource.Quantity, resource.Quantity) {
+	var cpu, memory resource.Quantity
+	for _, p := range pods {
+		if isDaemonSetPod(p) {
+			continue
+		}
+		for _, c := range p.Spec.Containers {
+			cpu.Add(*c.Resources.Requests.Cpu())
+			memory.Add(*c.Resources.Requests.Memory())
+		}
+	}
+	return cpu, memory
+}
+
+func isDaemonSetPod(p v1.Pod) bool {
+	for _, o := range p.OwnerReferences {
+		if o.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilDiv(a, b int64) int {
+	if b <= 0 {
+		return 0
+	}
+	return int((a + b - 1) / b)
+}
+
+// NodeTemplate 节点池新增节点的标签和污点
+type NodeTemplate struct {
+	Labels map[string]string
+	Taints []v1.Taint
+}
+
+// FitTemplate 返回能够调度到节点池新增节点上的 pod，claims 为 PVC 对应 PV 的节点亲和性
+func FitTemplate(pods []v1.Pod, template NodeTemplate, claims map[string]*v1.NodeSelector) []v1.Pod {
+	var result []v1.Pod
+	for _, p := range pods {
+		if PodFits(p, template.Labels, template.Taints, claims) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// PodFits 判断 pod 的 nodeSelector、必需的节点亲和性、污点容忍以及已绑定 PV 的节点亲和性是否满足节点的标签和污点
+func PodFits(pod v1.Pod, nodeLabels map[string]string, taints []v1.Taint, claims map[string]*v1.NodeSelector) bool {
+	for k, v := range pod.Spec.NodeSelector {
+		if nodeLabels[k] != v {
+			return false
+		}
+	}
+	if a := pod.Spec.Affinity; a != nil && a.NodeAffinity != nil && a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		if !matchNodeSelector(a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution, nodeLabels) {
+			return false
+		}
+	}
+	for i := range taints {
+		if taints[i].Effect == v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(pod.Spec.Tolerations, &taints[i]) {
+			return false
+		}
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		if selector := claims[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName]; selector != nil && !matchNodeSelector(selector, nodeLabels) {
+			return false
+		}
+	}
+	return true
+}
+
+// ClaimAffinities 返回已绑定 PVC 对应 PV 的节点亲和性，key 为 namespace/name
+func ClaimAffinities(pvcs []v1.PersistentVolumeClaim, pvs []v1.PersistentVolume) map[string]*v1.NodeSelector {
+	affinities := map[string]*v1.NodeSelector{}
+	for _, pv := range pvs {
+		if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+			affinities[pv.Name] = pv.Spec.NodeAffinity.Required
+		}
+	}
+	claims := map[string]*v1.NodeSelector{}
+	for _, pvc := range pvcs {
+		if selector, ok := affinities[pvc.Spec.VolumeName]; ok {
+			claims[pvc.Namespace+"/"+pvc.Name] = selector
+		}
+	}
+	return claims
+}
+
+// FitsElsewhere 判断节点上需要驱逐的 pod 能否按 requests 调度到其他可调度的节点上
+func FitsElsewhere(nodeName string, nodes []v1.Node, pods []v1.Pod, claims map[string]*v1.NodeSelector) bool {
+	type capacity struct {
+		node        v1.Node
+		cpu, memory int64
+	}
+	var targets []*capacity
+	for _, n := range nodes {
+		if n.Name == nodeName || n.Spec.Unschedulable || !nodeReady(n) {
+			continue
+		}
+		var running []v1.Pod
+		for _, p := range pods {
+			if p.Spec.NodeName == n.Name && p.Status.Phase != v1.PodSucceeded && p.Status.Phase != v1.PodFailed {
+				running = append(running, p)
+			}
+		}
+		cpu, memory := podsRequests(running)
+		targets = append(targets, &capacity{
+			node:   n,
+			cpu:    n.Status.Allocatable.Cpu().MilliValue() - cpu.MilliValue(),
+			memory: n.Status.Allocatable.Memory().Value() - memory.Value(),
+		})
+	}
+	for _, p := range evictedPods(nodeName, pods) {
+		cpu, memory := podsRequests([]v1.Pod{p})
+		placed := false
+		for _, t := range targets {
+			if t.cpu < cpu.MilliValue() || t.memory < memory.Value() || !PodFits(p, t.node.Labels, t.node.Spec.Taints, claims) {
+				continue
+			}
+			t.cpu -= cpu.MilliValue()
+			t.memory -= memory.Value()
+			placed = true
+			break
+		}
+		if !placed {
+			return false
+		}
+	}
+	return true
+}
+
+// DisruptionAllowed 判断驱逐节点上的 pod 是否超出 PodDisruptionBudget 允许的中断数
+func DisruptionAllowed(nodeName string, pods []v1.Pod, pdbs []policyv1beta1.PodDisruptionBudget) bool {
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		count := 0
+		for _, p := range evictedPods(nodeName, pods) {
+			if p.Namespace == pdb.Namespace && selector.Matches(labels.Set(p.Labels)) {
+				count++
+			}
+		}
+		if count > int(pdb.Status.DisruptionsAllowed) {
+			return false
+		}
+	}
+	return true
+}
+
+// evictedPods 缩容节点时需要驱逐的 pod，不包含 DaemonSet 和静态 pod
+func evictedPods(nodeName string, pods []v1.Pod) []v1.Pod {
+	var result []v1.Pod
+	for _, p := range pods {
+		if p.Spec.NodeName != nodeName || p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed || isDaemonSetPod(p) {
+			continue
+		}
+		if _, ok := p.Annotations[v1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func nodeReady(node v1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func toleratesTaint(tolerations []v1.Toleration, taint *v1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchNodeSelector 多个 term 之间为或关系，term 内的条件为与关系
+func matchNodeSelector(selector *v1.NodeSelector, nodeLabels map[string]string) bool {
+	for _, term := range selector.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 {
+			continue
+		}
+		matched := true
+		for _, req := range term.MatchExpressions {
+			if !matchRequirement(req, nodeLabels) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRequirement(req v1.NodeSelectorRequirement, nodeLabels map[string]string) bool {
+	var op selection.Operator
+	switch req.Operator {
+	case v1.NodeSelectorOpIn:
+		op = selection.In
+	case v1.NodeSelectorOpNotIn:
+		op = selection.NotIn
+	case v1.NodeSelectorOpExists:
+		op = selection.Exists
+	case v1.NodeSelectorOpDoesNotExist:
+		op = selection.DoesNotExist
+	case v1.NodeSelectorOpGt:
+		op = selection.GreaterThan
+	case v1.NodeSelectorOpLt:
+		op = selection.LessThan
+	default:
+		return false
+	}
+	r, err := labels.NewRequirement(req.Key, op, req.Values)
+	if err != nil {
+		return false
+	}
+	return r.Matches(labels.Set(nodeLabels))
+}
@@ -0,0 +1,270 @@
+package cluster
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPod(name, nodeName, cpu, memory string, phase v1.PodPhase, owner string) v1.Pod {
+	p := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+			Containers: []v1.Container{{
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse(cpu),
+					v1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+		Status: v1.PodStatus{Phase: phase},
+	}
+	if owner != "" {
+		p.OwnerReferences = []metav1.OwnerReference{{Kind: owner, Name: name}}
+	}
+	return p
+}
+
+func withVolume(p v1.Pod, source v1.VolumeSource) v1.Pod {
+	p.Spec.Volumes = append(p.Spec.Volumes, v1.Volume{Name: "data", VolumeSource: source})
+	return p
+}
+
+func testNode(name, cpu, memory string, labels map[string]string, taints ...v1.Taint) v1.Node {
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       v1.NodeSpec{Taints: taints},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory)},
+			Conditions:  []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+func TestUnschedulablePods(t *testing.T) {
+	pending := testPod("a", "", "1", "1Gi", v1.PodPending, "ReplicaSet")
+	pending.Status.Conditions = []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: v1.PodReasonUnschedulable}}
+	waiting := testPod("b", "", "1", "1Gi", v1.PodPending, "ReplicaSet")
+	bound := testPod("c", "node-1", "1", "1Gi", v1.PodPending, "ReplicaSet")
+	got := UnschedulablePods([]v1.Pod{pending, waiting, bound})
+	if len(got) != 1 || got[0].Name != "a" {
+		t.Errorf("UnschedulablePods() = %v, want [a]", got)
+	}
+}
+
+func TestEstimateNodes(t *testing.T) {
+	allocatable := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("4"),
+		v1.ResourceMemory: resource.MustParse("8Gi"),
+	}
+	tests := []struct {
+		name string
+		pods []v1.Pod
+		want int
+	}{
+		{"small", []v1.Pod{testPod("a", "", "100m", "128Mi", v1.PodPending, "ReplicaSet")}, 1},
+		{"cpu bound", []v1.Pod{
+			testPod("a", "", "3", "1Gi", v1.PodPending, "ReplicaSet"),
+			testPod("b", "", "3", "1Gi", v1.PodPending, "ReplicaSet"),
+		}, 2},
+		{"memory bound", []v1.Pod{
+			testPod("a", "", "1", "6Gi", v1.PodPending, "ReplicaSet"),
+			testPod("b", "", "1", "6Gi", v1.PodPending, "ReplicaSet"),
+			testPod("c", "", "1", "6Gi", v1.PodPending, "ReplicaSet"),
+		}, 3},
+		{"daemonset ignored", []v1.Pod{testPod("a", "", "16", "64Gi", v1.PodPending, "DaemonSet")}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateNodes(tt.pods, allocatable); got != tt.want {
+				t.Errorf("EstimateNodes() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeUtilization(t *testing.T) {
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("4"),
+			v1.ResourceMemory: resource.MustParse("8Gi"),
+		}},
+	}
+	pods := []v1.Pod{
+		testPod("a", "node-1", "1", "1Gi", v1.PodRunning, "ReplicaSet"),
+		testPod("b", "node-1", "500m", "3Gi", v1.PodRunning, "ReplicaSet"),
+		testPod("c", "node-1", "2", "2Gi", v1.PodSucceeded, "Job"),
+		testPod("d", "node-2", "4", "8Gi", v1.PodRunning, "ReplicaSet"),
+	}
+	if got := NodeUtilization(node, pods); got != 50 {
+		t.Errorf("NodeUtilization() = %d, want 50", got)
+	}
+}
+
+func TestEvictable(t *testing.T) {
+	tests := []struct {
+		name string
+		pods []v1.Pod
+		want bool
+	}{
+		{"managed", []v1.Pod{testPod("a", "node-1", "1", "1Gi", v1.PodRunning, "ReplicaSet")}, true},
+		{"bare pod", []v1.Pod{testPod("a", "node-1", "1", "1Gi", v1.PodRunning, "")}, false},
+		{"bare pod finished", []v1.Pod{testPod("a", "node-1", "1", "1Gi", v1.PodSucceeded, "")}, true},
+		{"other node", []v1.Pod{testPod("a", "node-2", "1", "1Gi", v1.PodRunning, "")}, true},
+		{"empty dir", []v1.Pod{withVolume(testPod("a", "node-1", "1", "1Gi", v1.PodRunning, "ReplicaSet"), v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}})}, false},
+		{"memory empty dir", []v1.Pod{withVolume(testPod("a", "node-1", "1", "1Gi", v1.PodRunning, "ReplicaSet"), v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{Medium: v1.StorageMediumMemory}})}, true},
+		{"host path", []v1.Pod{withVolume(testPod("a", "node-1", "1", "1Gi", v1.PodRunning, "ReplicaSet"), v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/data"}})}, false},
+		{"daemonset host path", []v1.Pod{withVolume(testPod("a", "node-1", "1", "1Gi", v1.PodRunning, "DaemonSet"), v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/var/log"}})}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evictable("node-1", tt.pods); got != tt.want {
+				t.Errorf("Evictable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodFits(t *testing.T) {
+	gpuTaint := v1.Taint{Key: "gpu", Value: "true", Effect: v1.TaintEffectNoSchedule}
+	zoneA := &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{
+		{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+	}}}}
+	claims := map[string]*v1.NodeSelector{"default/data": zoneA}
+	tests := []struct {
+		name   string
+		modify func(p *v1.Pod)
+		labels map[string]string
+		taints []v1.Taint
+		want   bool
+	}{
+		{name: "plain", modify: func(p *v1.Pod) {}, want: true},
+		{name: "node selector matched", modify: func(p *v1.Pod) { p.Spec.NodeSelector = map[string]string{"pool": "gpu"} }, labels: map[string]string{"pool": "gpu"}, want: true},
+		{name: "node selector missed", modify: func(p *v1.Pod) { p.Spec.NodeSelector = map[string]string{"pool": "gpu"} }, labels: map[string]string{"pool": "cpu"}},
+		{
+			name: "required affinity",
+			modify: func(p *v1.Pod) {
+				p.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "pool", Operator: v1.NodeSelectorOpNotIn, Values: []string{"gpu"}}}}},
+				}}}
+			},
+			labels: map[string]string{"pool": "gpu"},
+		},
+		{name: "untolerated taint", modify: func(p *v1.Pod) {}, taints: []v1.Taint{gpuTaint}},
+		{name: "prefer no schedule taint", modify: func(p *v1.Pod) {}, taints: []v1.Taint{{Key: "gpu", Effect: v1.TaintEffectPreferNoSchedule}}, want: true},
+		{
+			name:   "tolerated taint",
+			modify: func(p *v1.Pod) { p.Spec.Tolerations = []v1.Toleration{{Key: "gpu", Operator: v1.TolerationOpExists}} },
+			taints: []v1.Taint{gpuTaint},
+			want:   true,
+		},
+		{
+			name: "volume in other zone",
+			modify: func(p *v1.Pod) {
+				*p = withVolume(*p, v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}})
+			},
+			labels: map[string]string{v1.LabelTopologyZone: "b"},
+		},
+		{
+			name: "volume in same zone",
+			modify: func(p *v1.Pod) {
+				*p = withVolume(*p, v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}})
+			},
+			labels: map[string]string{v1.LabelTopologyZone: "a"},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPod("a", "", "1", "1Gi", v1.PodPending, "ReplicaSet")
+			p.Namespace = "default"
+			tt.modify(&p)
+			if got := PodFits(p, tt.labels, tt.taints, claims); got != tt.want {
+				t.Errorf("PodFits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimAffinities(t *testing.T) {
+	selector := &v1.NodeSelector{}
+	pvs := []v1.PersistentVolume{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pv-1"}, Spec: v1.PersistentVolumeSpec{NodeAffinity: &v1.VolumeNodeAffinity{Required: selector}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pv-2"}},
+	}
+	pvcs := []v1.PersistentVolumeClaim{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}, Spec: v1.PersistentVolumeClaimSpec{VolumeName: "pv-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}, Spec: v1.PersistentVolumeClaimSpec{VolumeName: "pv-2"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "c"}},
+	}
+	got := ClaimAffinities(pvcs, pvs)
+	if len(got) != 1 || got["default/a"] != selector {
+		t.Errorf("ClaimAffinities() = %v, want only default/a", got)
+	}
+}
+
+func TestFitsElsewhere(t *testing.T) {
+	pod := testPod("a", "node-1", "1", "1Gi", v1.PodRunning, "ReplicaSet")
+	tests := []struct {
+		name  string
+		nodes []v1.Node
+		pods  []v1.Pod
+		want  bool
+	}{
+		{name: "enough capacity", nodes: []v1.Node{testNode("node-1", "2", "4Gi", nil), testNode("node-2", "2", "4Gi", nil)}, pods: []v1.Pod{pod}, want: true},
+		{
+			name:  "no capacity",
+			nodes: []v1.Node{testNode("node-1", "2", "4Gi", nil), testNode("node-2", "2", "4Gi", nil)},
+			pods:  []v1.Pod{pod, testPod("b", "node-2", "1500m", "1Gi", v1.PodRunning, "ReplicaSet")},
+		},
+		{name: "tainted node", nodes: []v1.Node{testNode("node-2", "2", "4Gi", nil, v1.Taint{Key: "gpu", Effect: v1.TaintEffectNoSchedule})}, pods: []v1.Pod{pod}},
+		{name: "only node", nodes: []v1.Node{testNode("node-1", "2", "4Gi", nil)}, pods: []v1.Pod{pod}},
+		{name: "nothing to evict", nodes: []v1.Node{testNode("node-1", "2", "4Gi", nil)}, pods: []v1.Pod{testPod("d", "node-1", "1", "1Gi", v1.PodRunning, "DaemonSet")}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FitsElsewhere("node-1", tt.nodes, tt.pods, nil); got != tt.want {
+				t.Errorf("FitsElsewhere() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisruptionAllowed(t *testing.T) {
+	pod := func(name, node string) v1.Pod {
+		p := testPod(name, node, "1", "1Gi", v1.PodRunning, "ReplicaSet")
+		p.Namespace = "default"
+		p.Labels = map[string]string{"app": "web"}
+		return p
+	}
+	pdb := func(namespace string, allowed int32) policyv1beta1.PodDisruptionBudget {
+		return policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+			Spec:       policyv1beta1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			Status:     policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+	pods := []v1.Pod{pod("a", "node-1"), pod("b", "node-1"), pod("c", "node-2")}
+	tests := []struct {
+		name string
+		pdbs []policyv1beta1.PodDisruptionBudget
+		want bool
+	}{
+		{name: "no pdb", want: true},
+		{name: "allowed", pdbs: []policyv1beta1.PodDisruptionBudget{pdb("default", 2)}, want: true},
+		{name: "not enough", pdbs: []policyv1beta1.PodDisruptionBudget{pdb("default", 1)}},
+		{name: "other namespace", pdbs: []policyv1beta1.PodDisruptionBudget{pdb("kube-system", 0)}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DisruptionAllowed("node-1", pods, tt.pdbs); got != tt.want {
+				t.Errorf("DisruptionAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
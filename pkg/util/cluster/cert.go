@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+)
+
+// certPaths 控制面和 kubelet 证书，节点上不存在的路径会被忽略
+var certPaths = []string{
+	"/etc/kubernetes/pki/*.crt",
+	"/etc/kubernetes/pki/etcd/*.crt",
+	"/var/lib/kubelet/pki/kubelet.crt",
+	"/var/lib/kubelet/pki/kubelet-client-current.pem",
+}
+
+// kubeconfigPaths 内嵌了客户端证书的 kubeconfig，证书过期后对应组件无法访问 apiserver
+var kubeconfigPaths = []string{
+	"/etc/kubernetes/admin.conf",
+	"/etc/kubernetes/controller-manager.conf",
+	"/etc/kubernetes/scheduler.conf",
+}
+
+const certEndDateLayout = "Jan _2 15:04:05 2006 MST"
+
+type CertExpiry struct {
+	Name     string
+	Path     string
+	ExpireAt time.Time
+}
+
+// ListCertExpiry 读取节点上证书的过期时间
+func ListCertExpiry(client ssh.Interface) ([]CertExpiry, error) {
+	cmd := fmt.Sprintf(`for f in %s; do [ -f "$f" ] && echo "$f $(sudo openssl x509 -noout -enddate -in "$f")"; done; `+
+		`for f in %s; do [ -f "$f" ] && d=$(sudo grep 'client-certificate-data:' "$f" | awk '{print $2}') && [ -n "$d" ] && echo "$f $(echo "$d" | base64 -d | openssl x509 -noout -enddate)"; done; true`,
+		strings.Join(certPaths, " "), strings.Join(kubeconfigPaths, " "))
+	stdout, stderr, code, err := client.Exec(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("read certificates failed: %s", strings.TrimSpace(stderr))
+	}
+	return parseCertExpiry(stdout)
+}
+
+// parseCertExpiry 解析 "<path> notAfter=<date>" 格式的输出
+func parseCertExpiry(out string) ([]CertExpiry, error) {
+	var certs []CertExpiry
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		i := strings.Index(line, " notAfter=")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid certificate line: %s", line)
+		}
+		expireAt, err := time.Parse(certEndDateLayout, strings.TrimSpace(line[i+len(" notAfter="):]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate end date: %s", line)
+		}
+		certs = append(certs, CertExpiry{
+			Name:     strings.TrimSuffix(path.Base(line[:i]), path.Ext(line[:i])),
+			Path:     line[:i],
+			ExpireAt: expireAt,
+		})
+	}
+	return certs, nil
+}
@@ -0,0 +1,29 @@
+package cluster
+
+import "testing"
+
+func TestParseCertExpiry(t *testing.T) {
+	out := "/etc/kubernetes/pki/apiserver.crt notAfter=Mar  4 08:12:30 2027 GMT\n" +
+		"/var/lib/kubelet/pki/kubelet-client-current.pem notAfter=Dec 25 01:02:03 2026 GMT\n" +
+		"/etc/kubernetes/admin.conf notAfter=Mar  4 08:12:31 2027 GMT\n"
+	certs, err := parseCertExpiry(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 3 {
+		t.Fatalf("expect 3 certificates, got %d", len(certs))
+	}
+	if certs[0].Name != "apiserver" || certs[0].ExpireAt.Day() != 4 || certs[0].ExpireAt.Year() != 2027 {
+		t.Errorf("unexpected certificate %+v", certs[0])
+	}
+	if certs[1].Name != "kubelet-client-current" || certs[1].Path != "/var/lib/kubelet/pki/kubelet-client-current.pem" {
+		t.Errorf("unexpected certificate %+v", certs[1])
+	}
+	if certs[2].Name != "admin" || certs[2].Path != "/etc/kubernetes/admin.conf" {
+		t.Errorf("unexpected certificate %+v", certs[2])
+	}
+
+	if _, err := parseCertExpiry("/etc/kubernetes/pki/ca.crt unable to load certificate"); err == nil {
+		t.Error("expect error for invalid output")
+	}
+}
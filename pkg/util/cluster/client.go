@@ -1,13 +1,17 @@
 package cluster
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"sync"
 
 	"github.com/ClusterOperator/ClusterOperator/pkg/constant"
 	"github.com/ClusterOperator/ClusterOperator/pkg/logger"
 	"github.com/ClusterOperator/ClusterOperator/pkg/model"
-	"github.com/ClusterOperator/ClusterOperator/pkg/util/net"
+	netUtil "github.com/ClusterOperator/ClusterOperator/pkg/util/net"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/tunnel"
 	"github.com/pkg/errors"
 	extensionClientSet "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/kubernetes"
@@ -17,17 +21,7 @@ import (
 )
 
 func NewClusterClient(cluster *model.Cluster) (*kubernetes.Clientset, error) {
-	var hosts []string
-	port := cluster.SpecConf.KubeApiServerPort
-	hosts = append(hosts, fmt.Sprintf("%s:%d", cluster.SpecConf.LbKubeApiserverIp, port))
-	for _, node := range cluster.Nodes {
-		if node.Role == constant.NodeRoleNameMaster {
-			if len(node.Host.Ip) != 0 {
-				hosts = append(hosts, fmt.Sprintf("%s:%d", node.Host.Ip, port))
-			}
-		}
-	}
-	availableHost, err := SelectAliveHost(hosts)
+	availableHost, err := LoadAvailableHost(cluster)
 	if err != nil {
 		return nil, err
 	}
@@ -67,24 +61,63 @@ func LoadConnConf(cluster *model.Cluster, availableHost string) (*rest.Config, e
 		}
 		connConf = *itemConfig
 	}
+	if cluster.SpecConf.ConnectMode == constant.ConnectModeAgent {
+		connConf.Dial = tunnel.Dialer(cluster.Name)
+		return &connConf, nil
+	}
+	proxy, err := BastionProxy(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		connConf.Dial = bastionDialer(proxy)
+	}
 	return &connConf, nil
 }
 
-func LoadAvailableHost(cluster *model.Cluster) (string, error) {
-	var hosts []string
-	port := cluster.SpecConf.KubeApiServerPort
-	hosts = append(hosts, fmt.Sprintf("%s:%d", cluster.SpecConf.LbKubeApiserverIp, port))
+// BastionProxy 主节点经跳板机连接时返回跳板机的 ssh 配置，集群需要预加载 Nodes.Host
+func BastionProxy(cluster *model.Cluster) (*ssh.Config, error) {
 	for _, node := range cluster.Nodes {
 		if node.Role == constant.NodeRoleNameMaster {
-			if len(node.Host.Ip) != 0 {
-				hosts = append(hosts, fmt.Sprintf("%s:%d", node.Host.Ip, port))
-			}
+			return node.Host.BastionSSHConfig()
 		}
 	}
-	return SelectAliveHost(hosts)
+	return nil, nil
 }
 
-func NewClusterExtensionClient(cluster *model.Cluster) (*extensionClientSet.Clientset, error) {
+func bastionDialer(proxy *ssh.Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, err := ssh.New(proxy)
+		if err != nil {
+			return nil, err
+		}
+		return client.Dial(network, addr)
+	}
+}
+
+// selectAliveHostViaBastion 经跳板机依次检测地址连通性，返回第一个可连接的地址
+func selectAliveHostViaBastion(proxy *ssh.Config, hosts []string) (string, error) {
+	dial := bastionDialer(proxy)
+	for _, h := range hosts {
+		conn, err := dial(context.Background(), "tcp", h)
+		if err != nil {
+			logger.Log.Warnf("dial host %s via bastion %s falied: %+v", h, proxy.Host, err)
+			continue
+		}
+		_ = conn.Close()
+		return h, nil
+	}
+	return "", errors.New("NO_MASTER_AVAILABLE")
+}
+
+// LoadAvailableHost 通过 agent 连接的集群不检测地址连通性，只要求隧道在线；主节点使用跳板机时经跳板机检测
+func LoadAvailableHost(cluster *model.Cluster) (string, error) {
+	if cluster.SpecConf.ConnectMode == constant.ConnectModeAgent {
+		if !tunnel.Connected(cluster.Name) {
+			return "", tunnel.ErrNotConnected
+		}
+		return fmt.Sprintf("%s:%d", cluster.SpecConf.LbKubeApiserverIp, cluster.SpecConf.KubeApiServerPort), nil
+	}
 	var hosts []string
 	port := cluster.SpecConf.KubeApiServerPort
 	hosts = append(hosts, fmt.Sprintf("%s:%d", cluster.SpecConf.LbKubeApiserverIp, port))
@@ -95,7 +128,18 @@ func NewClusterExtensionClient(cluster *model.Cluster) (*extensionClientSet.Clie
 			}
 		}
 	}
-	availableHost, err := SelectAliveHost(hosts)
+	proxy, err := BastionProxy(cluster)
+	if err != nil {
+		return "", err
+	}
+	if proxy != nil {
+		return selectAliveHostViaBastion(proxy, hosts)
+	}
+	return SelectAliveHost(hosts)
+}
+
+func NewClusterExtensionClient(cluster *model.Cluster) (*extensionClientSet.Clientset, error) {
+	availableHost, err := LoadAvailableHost(cluster)
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +163,7 @@ func SelectAliveHost(hosts []string) (string, error) {
 		wg.Add(1)
 		go func(h string) {
 			defer wg.Done()
-			err := net.TcpPing(string(h), true)
+			err := netUtil.TcpPing(string(h), true)
 			if err != nil {
 				logger.Log.Warnf("dial host %s falied: %+v", h, err)
 				return
@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+)
+
+const (
+	etcdctl = "sudo ETCDCTL_API=3 /usr/local/bin/etcdctl --endpoints=https://127.0.0.1:2379 " +
+		"--cacert=/etc/kubernetes/pki/etcd/ca.crt " +
+		"--cert=/etc/kubernetes/pki/etcd/etcd.crt " +
+		"--key=/etc/kubernetes/pki/etcd/etcd.key"
+)
+
+var ErrEtcdQuorumBroken = errors.New("ETCD_QUORUM_BROKEN")
+
+type EtcdMember struct {
+	ID         string
+	Name       string
+	PeerURLs   []string
+	ClientURLs []string
+}
+
+type etcdMemberList struct {
+	Members []struct {
+		ID         uint64   `json:"ID"`
+		Name       string   `json:"name"`
+		PeerURLs   []string `json:"peerURLs"`
+		ClientURLs []string `json:"clientURLs"`
+	} `json:"members"`
+}
+
+type etcdEndpointHealth struct {
+	Endpoint string `json:"endpoint"`
+	Health   bool   `json:"health"`
+}
+
+func execEtcdctl(client ssh.Interface, args string) (string, error) {
+	stdout, stderr, code, err := client.Exec(etcdctl + " " + args)
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", fmt.Errorf("etcdctl %s failed: %s", args, strings.TrimSpace(stderr))
+	}
+	return stdout, nil
+}
+
+func ListEtcdMembers(client ssh.Interface) ([]EtcdMember, error) {
+	out, err := execEtcdctl(client, "member list -w json")
+	if err != nil {
+		return nil, err
+	}
+	var list etcdMemberList
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("parse etcd member list failed: %v", err)
+	}
+	var members []EtcdMember
+	for _, m := range list.Members {
+		members = append(members, EtcdMember{
+			ID:         fmt.Sprintf("%x", m.ID),
+			Name:       m.Name,
+			PeerURLs:   m.PeerURLs,
+			ClientURLs: m.ClientURLs,
+		})
+	}
+	return members, nil
+}
+
+// ListEtcdHealthyEndpoints 返回健康成员的 client 地址
+func ListEtcdHealthyEndpoints(client ssh.Interface) ([]string, error) {
+	out, err := execEtcdctl(client, "endpoint health --cluster -w json")
+	if err != nil {
+		return nil, err
+	}
+	var results []etcdEndpointHealth
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		return nil, fmt.Errorf("parse etcd endpoint health failed: %v", err)
+	}
+	var endpoints []string
+	for _, r := range results {
+		if r.Health {
+			endpoints = append(endpoints, r.Endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+func findEtcdMember(members []EtcdMember, name, ip string) *EtcdMember {
+	for i := range members {
+		if members[i].Name == name {
+			return &members[i]
+		}
+		for _, u := range members[i].PeerURLs {
+			if strings.Contains(u, "//"+ip+":") {
+				return &members[i]
+			}
+		}
+	}
+	return nil
+}
+
+// AddEtcdMember 添加 etcd 成员，成员已存在时直接返回
+func AddEtcdMember(client ssh.Interface, name, ip string) error {
+	members, err := ListEtcdMembers(client)
+	if err != nil {
+		return err
+	}
+	if findEtcdMember(members, name, ip) != nil {
+		return nil
+	}
+	_, err = execEtcdctl(client, fmt.Sprintf("member add %s --peer-urls=https://%s:2380", name, ip))
+	return err
+}
+
+// RemoveEtcdMember 移除 etcd 成员，成员不存在时直接返回
+func RemoveEtcdMember(client ssh.Interface, name, ip string) error {
+	members, err := ListEtcdMembers(client)
+	if err != nil {
+		return err
+	}
+	member := findEtcdMember(members, name, ip)
+	if member == nil {
+		return nil
+	}
+	_, err = execEtcdctl(client, "member remove "+member.ID)
+	return err
+}
+
+// IsEtcdEndpointOf 判断 client 地址是否属于该 ip
+func IsEtcdEndpointOf(endpoint, ip string) bool {
+	return strings.Contains(endpoint, "//"+ip+":")
+}
+
+// CheckEtcdQuorum 校验成员变更后健康成员数是否仍满足 quorum
+func CheckEtcdQuorum(members, healthy int) error {
+	if members < 1 || healthy < members/2+1 {
+		return ErrEtcdQuorumBroken
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package cluster
+
+import "testing"
+
+func TestCheckEtcdQuorum(t *testing.T) {
+	cases := []struct {
+		members int
+		healthy int
+		ok      bool
+	}{
+		{members: 1, healthy: 1, ok: true},
+		{members: 0, healthy: 0, ok: false},
+		{members: 2, healthy: 1, ok: false},
+		{members: 2, healthy: 2, ok: true},
+		{members: 3, healthy: 2, ok: true},
+		{members: 4, healthy: 2, ok: false},
+		{members: 4, healthy: 3, ok: true},
+	}
+	for _, c := range cases {
+		err := CheckEtcdQuorum(c.members, c.healthy)
+		if (err == nil) != c.ok {
+			t.Errorf("members %d healthy %d: expect ok %v, got %v", c.members, c.healthy, c.ok, err)
+		}
+	}
+}
@@ -162,6 +162,61 @@ func WithConditions(db **gorm.DB, model interface{}, conditions condition.Condit
 	return nil
 }
 
+// WithRelatedConditions 处理 field 以 prefix. 开头的条件，按关联表 table 中的记录过滤，foreignKey 为关联表中主表 id 的字段，
+// fields 为允许过滤的字段，每个条件单独匹配关联表中的任意一条记录，返回其余的条件
+func WithRelatedConditions(db **gorm.DB, conditions condition.Conditions, prefix, table, foreignKey string, fields []string) (condition.Conditions, error) {
+	allowed := map[string]bool{}
+	for _, f := range fields {
+		allowed[f] = true
+	}
+	rest := condition.Conditions{}
+	for k, v := range conditions {
+		if !strings.HasPrefix(v.Field, prefix+".") {
+			rest[k] = v
+			continue
+		}
+		field := strings.TrimPrefix(v.Field, prefix+".")
+		if !allowed[field] {
+			return nil, fmt.Errorf("condition %s is not supported", v.Field)
+		}
+		var (
+			where string
+			value interface{} = v.Value
+		)
+		switch strings.ToLower(v.Operator) {
+		case "like":
+			where = "%s LIKE ?"
+			value = "%" + fmt.Sprintf("%v", v.Value) + "%"
+		case "not like":
+			where = "%s NOT LIKE ?"
+			value = "%" + fmt.Sprintf("%v", v.Value) + "%"
+		case "eq":
+			where = "%s = ?"
+		case "ne":
+			where = "%s != ?"
+		case "gt":
+			where = "%s > ?"
+		case "ge":
+			where = "%s >= ?"
+		case "lt":
+			where = "%s < ?"
+		case "le":
+			where = "%s <= ?"
+		case "in":
+			val, ok := v.Value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("condition %s must be a list", v.Field)
+			}
+			where = "%s IN (?)"
+			value = val
+		default:
+			return nil, fmt.Errorf("operator %s of condition %s is not supported", v.Operator, v.Field)
+		}
+		*db = (*db).Where(fmt.Sprintf("id IN (SELECT %s FROM %s WHERE %s)", foreignKey, table, fmt.Sprintf(where, field)), value)
+	}
+	return rest, nil
+}
+
 func dealReservedWord(name string) string {
 	reservedWord := []string{"memory"}
 	for _, word := range reservedWord {
@@ -0,0 +1,302 @@
+package hostfile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Header csv 文件的表头，labels 列格式为 key=value,key=value
+var Header = []string{"name", "ip", "port", "credential", "project", "zone", "architecture", "labels"}
+
+// Record 导入导出文件中的一条主机记录
+type Record struct {
+	Name         string            `json:"name"`
+	Ip           string            `json:"ip"`
+	Port         int               `json:"port"`
+	Credential   string            `json:"credential"`
+	Project      string            `json:"project"`
+	Zone         string            `json:"zone,omitempty"`
+	Architecture string            `json:"architecture,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// RowError 单条记录的格式错误，Row 从 1 开始，csv 不计表头
+type RowError struct {
+	Row   int
+	Field string
+	Value string
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: invalid %s %q", e.Row, e.Field, e.Value)
+}
+
+// FormatOf 根据文件名后缀判断格式，无法判断时返回空
+func FormatOf(filename string) string {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")) {
+	case "csv":
+		return FormatCSV
+	case "json":
+		return FormatJSON
+	case "yaml", "yml":
+		return FormatYAML
+	}
+	return ""
+}
+
+// Decode 解析文件内容，格式错误的记录不会中断解析，全部在 errs 中返回
+func Decode(format string, data []byte) ([]Record, []RowError, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(data)
+	case FormatJSON:
+		return decodeJSON(data)
+	case FormatYAML:
+		buf, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return decodeJSON(buf)
+	}
+	return nil, nil, fmt.Errorf("can not support format %s", format)
+}
+
+// Encode 按格式输出主机记录
+func Encode(format string, records []Record) ([]byte, error) {
+	if records == nil {
+		records = []Record{}
+	}
+	switch format {
+	case FormatCSV:
+		return encodeCSV(records)
+	case FormatJSON:
+		return json.MarshalIndent(records, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(records)
+	}
+	return nil, fmt.Errorf("can not support format %s", format)
+}
+
+func decodeCSV(data []byte) ([]Record, []RowError, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"name", "ip", "port", "credential", "project"} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("column %s is required", name)
+		}
+	}
+
+	var (
+		records []Record
+		errs    []RowError
+	)
+	for row := 1; ; row++ {
+		line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(line) {
+				return strings.TrimSpace(line[i])
+			}
+			return ""
+		}
+		record := Record{
+			Name:         value("name"),
+			Ip:           value("ip"),
+			Credential:   value("credential"),
+			Project:      value("project"),
+			Zone:         value("zone"),
+			Architecture: value("architecture"),
+		}
+		if port := value("port"); port != "" {
+			if record.Port, err = strconv.Atoi(port); err != nil {
+				errs = append(errs, RowError{Row: row, Field: "port", Value: port})
+			}
+		}
+		if labels := value("labels"); labels != "" {
+			if record.Labels, err = ParseLabels(labels); err != nil {
+				errs = append(errs, RowError{Row: row, Field: "labels", Value: labels})
+			}
+		}
+		errs = append(errs, record.validate(row)...)
+		records = append(records, record)
+	}
+	return records, errs, nil
+}
+
+// decodeJSON 先拆分为单条记录再逐条解析，某一条的字段类型错误不影响其他记录
+func decodeJSON(data []byte) ([]Record, []RowError, error) {
+	var (
+		rows    []json.RawMessage
+		records []Record
+		errs    []RowError
+	)
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, nil, err
+	}
+	for i, raw := range rows {
+		row := i + 1
+		var record Record
+		if err := json.Unmarshal(raw, &record); err != nil {
+			errs = append(errs, RowError{Row: row, Field: "record", Value: err.Error()})
+			records = append(records, Record{})
+			continue
+		}
+		record.trim()
+		errs = append(errs, record.validate(row)...)
+		records = append(records, record)
+	}
+	return records, errs, nil
+}
+
+func encodeCSV(records []Record) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(Header); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if err := writer.Write([]string{r.Name, r.Ip, strconv.Itoa(r.Port), r.Credential, r.Project, r.Zone, r.Architecture, FormatLabels(r.Labels)}); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func (r *Record) trim() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Ip = strings.TrimSpace(r.Ip)
+	r.Credential = strings.TrimSpace(r.Credential)
+	r.Project = strings.TrimSpace(r.Project)
+	r.Zone = strings.TrimSpace(r.Zone)
+	r.Architecture = strings.TrimSpace(r.Architecture)
+}
+
+// validate 检查必填项，端口格式错误在解析时已经记录
+func (r Record) validate(row int) []RowError {
+	var errs []RowError
+	required := []struct {
+		field string
+		value string
+	}{
+		{"name", r.Name},
+		{"ip", r.Ip},
+		{"credential", r.Credential},
+		{"project", r.Project},
+	}
+	for _, f := range required {
+		if f.value == "" {
+			errs = append(errs, RowError{Row: row, Field: f.field})
+		}
+	}
+	if r.Ip != "" && net.ParseIP(r.Ip) == nil {
+		errs = append(errs, RowError{Row: row, Field: "ip", Value: r.Ip})
+	}
+	if r.Port < 0 || r.Port > 65535 {
+		errs = append(errs, RowError{Row: row, Field: "port", Value: strconv.Itoa(r.Port)})
+	}
+	for k := range r.Labels {
+		if k == "" {
+			errs = append(errs, RowError{Row: row, Field: "labels", Value: k})
+		}
+	}
+	return errs
+}
+
+// ParseLabels 解析 key=value,key=value 格式的标签，key 和 value 中的 \、, 和 = 需要用 \ 转义
+func ParseLabels(s string) (map[string]string, error) {
+	labels := map[string]string{}
+	var (
+		key, value strings.Builder
+		cur        = &key
+		hasValue   bool
+		escaped    bool
+	)
+	flush := func() error {
+		k, v, ok := strings.TrimSpace(key.String()), strings.TrimSpace(value.String()), hasValue
+		key.Reset()
+		value.Reset()
+		cur, hasValue = &key, false
+		if k == "" && !ok {
+			return nil
+		}
+		if k == "" || !ok {
+			return fmt.Errorf("invalid label %s", s)
+		}
+		labels[k] = v
+		return nil
+	}
+	for _, c := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == ',':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case c == '=' && !hasValue:
+			cur, hasValue = &value, true
+		default:
+			cur.WriteRune(c)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("invalid label %s", s)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`)
+
+// FormatLabels 按 key 排序输出 key=value,key=value 格式的标签，与 ParseLabels 互逆
+func FormatLabels(labels map[string]string) string {
+	var keys []string
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var items []string
+	for _, k := range keys {
+		items = append(items, labelEscaper.Replace(k)+"="+labelEscaper.Replace(labels[k]))
+	}
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,157 @@
+package hostfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	want := []Record{
+		{Name: "node1", Ip: "172.16.10.11", Port: 22, Credential: "root", Project: "kubeoperator", Zone: "zone1", Architecture: "x86_64", Labels: map[string]string{"rack": "a1", "gpu": "true"}},
+		{Name: "node2", Ip: "172.16.10.12", Port: 22, Credential: "root", Project: "kubeoperator"},
+	}
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			data: "name,ip,port,credential,project,zone,architecture,labels\n" +
+				"node1,172.16.10.11,22,root,kubeoperator,zone1,x86_64,\"rack=a1, gpu=true\"\n" +
+				"node2, 172.16.10.12,22,root,kubeoperator,,,\n",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			data: `[{"name":"node1","ip":"172.16.10.11","port":22,"credential":"root","project":"kubeoperator","zone":"zone1","architecture":"x86_64","labels":{"rack":"a1","gpu":"true"}},
+{"name":"node2","ip":" 172.16.10.12","port":22,"credential":"root","project":"kubeoperator"}]`,
+		},
+		{
+			name:   "yaml",
+			format: FormatYAML,
+			data: `- name: node1
+  ip: 172.16.10.11
+  port: 22
+  credential: root
+  project: kubeoperator
+  zone: zone1
+  architecture: x86_64
+  labels:
+    rack: a1
+    gpu: "true"
+- name: node2
+  ip: 172.16.10.12
+  port: 22
+  credential: root
+  project: kubeoperator
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs, err := Decode(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Decode() row errors = %v", errs)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Decode() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeRowErrors(t *testing.T) {
+	data := "name,ip,port,credential,project,labels\n" +
+		"node1,172.16.10.11,ssh,root,kubeoperator,\n" +
+		"node2,172.16.10.12,22,,kubeoperator,rack\n" +
+		"node3,172.16.10.13,22,root,kubeoperator,\n"
+	records, errs, err := Decode(FormatCSV, []byte(data))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(records) != 3 {
+		t.Errorf("Decode() returned %d records, want 3", len(records))
+	}
+	want := []RowError{
+		{Row: 1, Field: "port", Value: "ssh"},
+		{Row: 2, Field: "labels", Value: "rack"},
+		{Row: 2, Field: "credential"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("Decode() row errors = %v, want %v", errs, want)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	records := []Record{
+		{Name: "node1", Ip: "172.16.10.11", Port: 22, Credential: "root", Project: "kubeoperator", Labels: map[string]string{"rack": "a1", "gpu": "true"}},
+	}
+	for _, format := range []string{FormatCSV, FormatJSON, FormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := Encode(format, records)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			got, errs, err := Decode(format, data)
+			if err != nil || len(errs) > 0 {
+				t.Fatalf("Decode() error = %v, row errors = %v", err, errs)
+			}
+			if !reflect.DeepEqual(got, records) {
+				t.Errorf("Decode(Encode()) = %+v, want %+v", got, records)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONRowErrors(t *testing.T) {
+	data := `[{"name":"node1","ip":"172.16.10.11","port":"22","credential":"root","project":"kubeoperator"},
+{"name":"node2","ip":"172.16.10.300","port":22,"credential":"root","project":"kubeoperator"},
+{"name":"node3","ip":"172.16.10.13","port":22,"credential":"root","project":"kubeoperator"}]`
+	records, errs, err := Decode(FormatJSON, []byte(data))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(records) != 3 || records[2].Name != "node3" {
+		t.Errorf("Decode() = %+v, want 3 records", records)
+	}
+	if len(errs) != 2 || errs[0].Row != 1 || errs[0].Field != "record" || errs[1] != (RowError{Row: 2, Field: "ip", Value: "172.16.10.300"}) {
+		t.Errorf("Decode() row errors = %v", errs)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		labels map[string]string
+		err    bool
+	}{
+		{name: "plain", in: "gpu=true, rack=a1", labels: map[string]string{"gpu": "true", "rack": "a1"}},
+		{name: "escaped", in: `note=a\,b\=c, path=C:\\data`, labels: map[string]string{"note": "a,b=c", "path": `C:\data`}},
+		{name: "value with equal sign", in: "expr=a=b", labels: map[string]string{"expr": "a=b"}},
+		{name: "empty", in: " , ", labels: map[string]string{}},
+		{name: "missing value", in: "rack", err: true},
+		{name: "missing key", in: "=a1", err: true},
+		{name: "dangling escape", in: `rack=a1\`, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLabels(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("ParseLabels() error = %v, want error %v", err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(got, tt.labels) {
+				t.Errorf("ParseLabels() = %v, want %v", got, tt.labels)
+			}
+		})
+	}
+	labels := map[string]string{"note": "a,b=c", "path": `C:\data`}
+	if got, err := ParseLabels(FormatLabels(labels)); err != nil || !reflect.DeepEqual(got, labels) {
+		t.Errorf("ParseLabels(FormatLabels()) = %v, %v, want %v", got, err, labels)
+	}
+}
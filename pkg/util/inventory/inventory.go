@@ -0,0 +1,280 @@
+package inventory
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Script 采集 ansible setup 模块不提供的 cgroup 版本和 cpu flags，arm 的 /proc/cpuinfo 中为 Features
+const Script = "echo ==cgroup; stat -fc %T /sys/fs/cgroup/; echo ==flags; grep -m1 -E '^(flags|Features)' /proc/cpuinfo"
+
+// Inventory 主机硬件及内核信息
+type Inventory struct {
+	KernelVersion string   `json:"kernelVersion"`
+	CgroupVersion string   `json:"cgroupVersion"`
+	CpuModel      string   `json:"cpuModel"`
+	CpuFlags      []string `json:"cpuFlags"`
+	Disks         []Disk   `json:"disks"`
+	Nics          []Nic    `json:"nics"`
+}
+
+// Disk 块设备或分区，Size 单位为字节
+type Disk struct {
+	Name       string `json:"name"`
+	Model      string `json:"model"`
+	Size       int64  `json:"size"`
+	Rotational bool   `json:"rotational"`
+	Mount      string `json:"mount"`
+	FsType     string `json:"fsType"`
+}
+
+// Nic 网卡，Speed 单位为 Mb/s，未知时为 0
+type Nic struct {
+	Name      string   `json:"name"`
+	Mac       string   `json:"mac"`
+	Mtu       int      `json:"mtu"`
+	Speed     int      `json:"speed"`
+	Addresses []string `json:"addresses"`
+}
+
+// FromFacts 从 ansible setup 模块的 ansible_facts 中解析内核、cpu、磁盘和网卡信息
+func FromFacts(facts map[string]interface{}) Inventory {
+	inv := Inventory{KernelVersion: toString(facts["ansible_kernel"])}
+	// ansible_processor 依次为 序号、厂商、型号
+	if processor, ok := facts["ansible_processor"].([]interface{}); ok && len(processor) >= 3 {
+		inv.CpuModel = strings.TrimSpace(toString(processor[2]))
+	}
+
+	mounts := map[string][2]string{}
+	if list, ok := facts["ansible_mounts"].([]interface{}); ok {
+		for _, item := range list {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			device := toString(m["device"])
+			if _, exists := mounts[device]; !exists {
+				mounts[device] = [2]string{toString(m["mount"]), toString(m["fstype"])}
+			}
+		}
+	}
+	newDisk := func(name string, d map[string]interface{}, model string, rotational bool) Disk {
+		disk := Disk{
+			Name:       "/dev/" + name,
+			Model:      model,
+			Size:       toInt64(d["sectors"]) * toInt64(d["sectorsize"]),
+			Rotational: rotational,
+		}
+		// 分区的 sectorsize 可能为空，按 512 字节计算
+		if disk.Size == 0 {
+			disk.Size = toInt64(d["sectors"]) * 512
+		}
+		if m, ok := mounts[disk.Name]; ok {
+			disk.Mount, disk.FsType = m[0], m[1]
+		}
+		return disk
+	}
+	if devices, ok := facts["ansible_devices"].(map[string]interface{}); ok {
+		for name, value := range devices {
+			d, ok := value.(map[string]interface{})
+			if !ok || strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") || strings.HasPrefix(name, "sr") {
+				continue
+			}
+			model := strings.TrimSpace(toString(d["model"]))
+			rotational := toString(d["rotational"]) == "1"
+			inv.Disks = append(inv.Disks, newDisk(name, d, model, rotational))
+			// 分区没有 rotational 和 model，使用所在磁盘的
+			if partitions, ok := d["partitions"].(map[string]interface{}); ok {
+				for part, pv := range partitions {
+					p, ok := pv.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					inv.Disks = append(inv.Disks, newDisk(part, p, model, rotational))
+				}
+			}
+		}
+	}
+	sort.Slice(inv.Disks, func(i, j int) bool { return inv.Disks[i].Name < inv.Disks[j].Name })
+
+	if interfaces, ok := facts["ansible_interfaces"].([]interface{}); ok {
+		for _, item := range interfaces {
+			name := toString(item)
+			if name == "" || name == "lo" {
+				continue
+			}
+			// ansible 将网卡名称中的 - 和 : 替换为 _
+			key := "ansible_" + strings.NewReplacer("-", "_", ":", "_").Replace(name)
+			n, ok := facts[key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			nic := Nic{
+				Name:  name,
+				Mac:   toString(n["macaddress"]),
+				Mtu:   int(toInt64(n["mtu"])),
+				Speed: int(toInt64(n["speed"])),
+			}
+			if nic.Speed < 0 {
+				nic.Speed = 0
+			}
+			if v4, ok := n["ipv4"].(map[string]interface{}); ok && toString(v4["address"]) != "" {
+				nic.Addresses = append(nic.Addresses, toString(v4["address"]))
+			}
+			for _, field := range []string{"ipv4_secondaries", "ipv6"} {
+				if list, ok := n[field].([]interface{}); ok {
+					for _, a := range list {
+						if addr, ok := a.(map[string]interface{}); ok && toString(addr["address"]) != "" {
+							nic.Addresses = append(nic.Addresses, toString(addr["address"]))
+						}
+					}
+				}
+			}
+			inv.Nics = append(inv.Nics, nic)
+		}
+	}
+	sort.Slice(inv.Nics, func(i, j int) bool { return inv.Nics[i].Name < inv.Nics[j].Name })
+	return inv
+}
+
+// ParseScript 解析 Script 的输出，填充 cgroup 版本和 cpu flags
+func ParseScript(inv *Inventory, out string) {
+	section := ""
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "==") {
+			section = strings.TrimPrefix(line, "==")
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		switch section {
+		case "cgroup":
+			switch line {
+			case "cgroup2fs":
+				inv.CgroupVersion = "v2"
+			case "tmpfs":
+				inv.CgroupVersion = "v1"
+			}
+		case "flags":
+			if i := strings.Index(line, ":"); i >= 0 {
+				inv.CpuFlags = strings.Fields(line[i+1:])
+				sort.Strings(inv.CpuFlags)
+			}
+		}
+	}
+}
+
+// Diff 比较两次采集的结果，返回变更说明，没有变更时返回空
+func Diff(old, cur Inventory) []string {
+	var changes []string
+	field := func(name, a, b string) {
+		if a != b {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, a, b))
+		}
+	}
+	field("kernel", old.KernelVersion, cur.KernelVersion)
+	field("cgroup", old.CgroupVersion, cur.CgroupVersion)
+	field("cpu model", old.CpuModel, cur.CpuModel)
+	added, removed := diffStrings(old.CpuFlags, cur.CpuFlags)
+	if len(added) > 0 {
+		changes = append(changes, "cpu flags added: "+strings.Join(added, " "))
+	}
+	if len(removed) > 0 {
+		changes = append(changes, "cpu flags removed: "+strings.Join(removed, " "))
+	}
+
+	oldDisks := map[string]Disk{}
+	for _, d := range old.Disks {
+		oldDisks[d.Name] = d
+	}
+	curDisks := map[string]bool{}
+	for _, d := range cur.Disks {
+		curDisks[d.Name] = true
+		o, ok := oldDisks[d.Name]
+		switch {
+		case !ok:
+			changes = append(changes, "disk added: "+d.Name)
+		case o != d:
+			changes = append(changes, "disk changed: "+d.Name)
+		}
+	}
+	for _, d := range old.Disks {
+		if !curDisks[d.Name] {
+			changes = append(changes, "disk removed: "+d.Name)
+		}
+	}
+
+	oldNics := map[string]Nic{}
+	for _, n := range old.Nics {
+		oldNics[n.Name] = n
+	}
+	curNics := map[string]bool{}
+	for _, n := range cur.Nics {
+		curNics[n.Name] = true
+		o, ok := oldNics[n.Name]
+		switch {
+		case !ok:
+			changes = append(changes, "nic added: "+n.Name)
+		case !reflect.DeepEqual(o, n):
+			changes = append(changes, "nic changed: "+n.Name)
+		}
+	}
+	for _, n := range old.Nics {
+		if !curNics[n.Name] {
+			changes = append(changes, "nic removed: "+n.Name)
+		}
+	}
+	return changes
+}
+
+func diffStrings(old, cur []string) (added, removed []string) {
+	oldSet := map[string]bool{}
+	for _, s := range old {
+		oldSet[s] = true
+	}
+	curSet := map[string]bool{}
+	for _, s := range cur {
+		curSet[s] = true
+		if !oldSet[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range old {
+		if !curSet[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func toInt64(v interface{}) int64 {
+	switch val := v.(type) {
+	case float64:
+		return int64(val)
+	case int:
+		return int64(val)
+	case int64:
+		return val
+	case string:
+		i, _ := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		return i
+	}
+	return 0
+}
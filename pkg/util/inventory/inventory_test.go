@@ -0,0 +1,109 @@
+package inventory
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const sampleFacts = `{
+  "ansible_kernel": "3.10.0-1160.el7.x86_64",
+  "ansible_processor": ["0", "GenuineIntel", "Intel(R) Xeon(R) Gold 6230 CPU @ 2.10GHz", "1", "GenuineIntel", "Intel(R) Xeon(R) Gold 6230 CPU @ 2.10GHz"],
+  "ansible_devices": {
+    "sda": {
+      "model": "Virtual disk", "rotational": "1", "sectors": "104857600", "sectorsize": "512",
+      "partitions": {"sda1": {"sectors": "2097152", "sectorsize": 512}}
+    },
+    "nvme0n1": {"model": "INTEL SSDPE2KX010T8", "rotational": "0", "sectors": "1953525168", "sectorsize": "512", "partitions": {}},
+    "loop0": {"sectors": "0"},
+    "sr0": {"model": "VMware IDE CDR10", "sectors": "2097151", "sectorsize": "2048"}
+  },
+  "ansible_mounts": [
+    {"mount": "/boot", "device": "/dev/sda1", "fstype": "xfs"},
+    {"mount": "/data", "device": "/dev/nvme0n1", "fstype": "ext4"}
+  ],
+  "ansible_interfaces": ["lo", "eth0", "cni-podnet"],
+  "ansible_lo": {"macaddress": "00:00:00:00:00:00", "mtu": 65536},
+  "ansible_eth0": {
+    "macaddress": "00:50:56:9a:1b:2c", "mtu": 1500, "speed": 10000,
+    "ipv4": {"address": "172.16.10.11"},
+    "ipv4_secondaries": [{"address": "172.16.10.100"}],
+    "ipv6": [{"address": "fe80::250:56ff:fe9a:1b2c"}]
+  },
+  "ansible_cni_podnet": {"macaddress": "0a:58:0a:f4:00:01", "mtu": 1450, "speed": -1}
+}`
+
+func TestFromFacts(t *testing.T) {
+	var facts map[string]interface{}
+	if err := json.Unmarshal([]byte(sampleFacts), &facts); err != nil {
+		t.Fatal(err)
+	}
+	want := Inventory{
+		KernelVersion: "3.10.0-1160.el7.x86_64",
+		CpuModel:      "Intel(R) Xeon(R) Gold 6230 CPU @ 2.10GHz",
+		Disks: []Disk{
+			{Name: "/dev/nvme0n1", Model: "INTEL SSDPE2KX010T8", Size: 1953525168 * 512, Mount: "/data", FsType: "ext4"},
+			{Name: "/dev/sda", Model: "Virtual disk", Size: 104857600 * 512, Rotational: true},
+			{Name: "/dev/sda1", Model: "Virtual disk", Size: 2097152 * 512, Rotational: true, Mount: "/boot", FsType: "xfs"},
+		},
+		Nics: []Nic{
+			{Name: "cni-podnet", Mac: "0a:58:0a:f4:00:01", Mtu: 1450},
+			{Name: "eth0", Mac: "00:50:56:9a:1b:2c", Mtu: 1500, Speed: 10000,
+				Addresses: []string{"172.16.10.11", "172.16.10.100", "fe80::250:56ff:fe9a:1b2c"}},
+		},
+	}
+	if got := FromFacts(facts); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromFacts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		out    string
+		cgroup string
+		flags  []string
+	}{
+		{name: "cgroup v1 on x86", out: "==cgroup\ntmpfs\n==flags\nflags\t\t: sse2 fpu avx\n", cgroup: "v1", flags: []string{"avx", "fpu", "sse2"}},
+		{name: "cgroup v2 on arm", out: "==cgroup\ncgroup2fs\n==flags\nFeatures\t: fp asimd aes\n", cgroup: "v2", flags: []string{"aes", "asimd", "fp"}},
+		{name: "unknown", out: "==cgroup\n==flags\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inv Inventory
+			ParseScript(&inv, tt.out)
+			if inv.CgroupVersion != tt.cgroup || !reflect.DeepEqual(inv.CpuFlags, tt.flags) {
+				t.Errorf("ParseScript() = %q %v, want %q %v", inv.CgroupVersion, inv.CpuFlags, tt.cgroup, tt.flags)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := Inventory{
+		KernelVersion: "3.10.0",
+		CpuFlags:      []string{"avx", "sse2"},
+		Disks:         []Disk{{Name: "/dev/sda", Size: 100}, {Name: "/dev/sdb", Size: 100}},
+		Nics:          []Nic{{Name: "eth0", Mtu: 1500, Addresses: []string{"172.16.10.11"}}},
+	}
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Errorf("Diff() of same inventory = %v, want none", changes)
+	}
+	cur := Inventory{
+		KernelVersion: "5.4.0",
+		CpuFlags:      []string{"avx", "avx2"},
+		Disks:         []Disk{{Name: "/dev/sda", Size: 200}, {Name: "/dev/sdc", Size: 100}},
+		Nics:          []Nic{{Name: "eth0", Mtu: 1500, Addresses: []string{"172.16.10.11"}}},
+	}
+	want := []string{
+		"kernel: 3.10.0 -> 5.4.0",
+		"cpu flags added: avx2",
+		"cpu flags removed: sse2",
+		"disk changed: /dev/sda",
+		"disk added: /dev/sdc",
+		"disk removed: /dev/sdb",
+	}
+	if got := Diff(old, cur); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+}
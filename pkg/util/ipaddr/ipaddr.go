@@ -29,6 +29,36 @@ func GenerateIps(ip string, mask int, startIp string, endIp string) []string {
 	return ips
 }
 
+// CidrHosts 返回网段内可分配给主机的地址，地址数量超过 max 时报错
+func CidrHosts(cidr string, max int) ([]string, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("only ipv4 cidr is supported: %s", cidr)
+	}
+	ones, _ := ipNet.Mask.Size()
+	n := iplib.NewNet(ipNet.IP, ones)
+	if count := n.Count(); count > uint32(max) {
+		return nil, fmt.Errorf("cidr %s contains %d addresses, at most %d allowed", cidr, count, max)
+	}
+	var ips []string
+	last := n.LastAddress()
+	for i := n.FirstAddress(); ; {
+		ips = append(ips, i.String())
+		if i.Equal(last) {
+			break
+		}
+		next, err := n.NextIP(i)
+		if err != nil {
+			break
+		}
+		i = next
+	}
+	return ips, nil
+}
+
 func isBiggerThan(a string, b string) int {
 	aIp := net.ParseIP(a)
 	bIp := net.ParseIP(b)
@@ -2,6 +2,7 @@ package ipaddr
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -11,3 +12,30 @@ func TestGenerateIps(t *testing.T) {
 		fmt.Println(ip)
 	}
 }
+
+func TestCidrHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidr    string
+		max     int
+		want    []string
+		wantErr bool
+	}{
+		{name: "exclude network and broadcast", cidr: "172.16.10.4/30", max: 256, want: []string{"172.16.10.5", "172.16.10.6"}},
+		{name: "host address is masked", cidr: "172.16.10.6/30", max: 256, want: []string{"172.16.10.5", "172.16.10.6"}},
+		{name: "single address", cidr: "172.16.10.6/32", max: 256, want: []string{"172.16.10.6"}},
+		{name: "too many addresses", cidr: "172.16.0.0/16", max: 1024, wantErr: true},
+		{name: "invalid cidr", cidr: "172.16.10.6", max: 256, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CidrHosts(tt.cidr, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CidrHosts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CidrHosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
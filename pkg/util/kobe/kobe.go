@@ -3,6 +3,7 @@ package kobe
 import (
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/ClusterOperator/kobe/api"
 	kobeClient "github.com/ClusterOperator/kobe/pkg/client"
@@ -15,8 +16,14 @@ type Interface interface {
 	Watch(writer io.Writer, taskId string) error
 	GetResult(taskId string) (*api.Result, error)
 	SetVar(key string, value string)
+	SetGroupHosts(group string, hosts []string)
+	Detach()
+	Done() <-chan struct{}
 }
 
+// Project KubeOperator 在 kobe 中的项目名称
+const Project = "ko"
+
 type Config struct {
 	Inventory *api.Inventory
 }
@@ -25,6 +32,9 @@ type Kobe struct {
 	Project   string
 	Inventory *api.Inventory
 	client    *kobeClient.KobeClient
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 func NewAnsible(c *Config) *Kobe {
@@ -32,9 +42,10 @@ func NewAnsible(c *Config) *Kobe {
 	host := viper.GetString("kobe.host")
 	port := viper.GetInt("kobe.port")
 	return &Kobe{
-		Project:   "ko",
+		Project:   Project,
 		Inventory: c.Inventory,
 		client:    kobeClient.NewKobeClient(host, port),
+		stopCh:    make(chan struct{}),
 	}
 }
 
@@ -50,6 +61,22 @@ func (k *Kobe) SetVar(key string, value string) {
 	k.Inventory.Vars[key] = value
 }
 
+// SetGroupHosts 设置分组包含的主机，分组不存在时新建
+func (k *Kobe) SetGroupHosts(group string, hosts []string) {
+	for i := range k.Inventory.Groups {
+		if k.Inventory.Groups[i].Name == group {
+			k.Inventory.Groups[i].Hosts = hosts
+			return
+		}
+	}
+	k.Inventory.Groups = append(k.Inventory.Groups, &api.Group{
+		Name:     group,
+		Hosts:    hosts,
+		Children: []string{},
+		Vars:     map[string]string{},
+	})
+}
+
 func (k *Kobe) RunAdhoc(pattern, module, param string) (string, error) {
 	result, err := k.client.RunAdhoc(pattern, module, param, k.Inventory)
 	if err != nil {
@@ -72,3 +99,46 @@ func (k *Kobe) GetResult(taskId string) (*api.Result, error) {
 	}
 	return result, nil
 }
+
+// Detach 通知调用方停止等待当前 playbook 的执行结果
+// kobe 未提供终止接口，已经下发的 ansible 进程会在 kobe 侧继续执行至结束
+func (k *Kobe) Detach() {
+	k.stopOnce.Do(func() {
+		close(k.stopCh)
+	})
+}
+
+func (k *Kobe) Done() <-chan struct{} {
+	return k.stopCh
+}
+
+// ListPlaybooks 查询 kobe 项目中可以执行的 playbook
+func ListPlaybooks(project string) ([]string, error) {
+	client := kobeClient.NewKobeClient(viper.GetString("kobe.host"), viper.GetInt("kobe.port"))
+	projects, err := client.ListProject()
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("list kobe project failed: %v", err))
+	}
+	for _, p := range projects {
+		if p.Name == project {
+			return p.Playbooks, nil
+		}
+	}
+	return nil, fmt.Errorf("kobe project %s not found", project)
+}
+
+// MissingPlaybooks 返回 required 中 kobe 项目未提供的 playbook，保持 required 的顺序
+func MissingPlaybooks(available, required []string) []string {
+	exists := map[string]bool{}
+	for _, p := range available {
+		exists[p] = true
+	}
+	var missing []string
+	for _, p := range required {
+		if !exists[p] {
+			missing = append(missing, p)
+			exists[p] = true
+		}
+	}
+	return missing
+}
@@ -93,3 +93,29 @@ func TestKobe_RunAdhoc(t *testing.T) {
 //	result.GatherFailedInfo()
 //	f.Close()
 //}
+
+func TestMissingPlaybooks(t *testing.T) {
+	available := []string{"01-base.yml", "93-add-master-01-base.yml"}
+	tests := []struct {
+		name     string
+		required []string
+		want     []string
+	}{
+		{name: "all exist", required: []string{"01-base.yml", "93-add-master-01-base.yml"}},
+		{name: "missing", required: []string{"98-remove-master.yml", "01-base.yml", "98-reset-master.yml"}, want: []string{"98-remove-master.yml", "98-reset-master.yml"}},
+		{name: "duplicate", required: []string{"98-remove-master.yml", "98-remove-master.yml"}, want: []string{"98-remove-master.yml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MissingPlaybooks(available, tt.required)
+			if len(got) != len(tt.want) {
+				t.Fatalf("MissingPlaybooks() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("MissingPlaybooks() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 )
@@ -37,14 +38,40 @@ type Play struct {
 }
 type HostFailedInfo map[string]string
 
+// failureSnippetSize stdout、stderr 只保留末尾部分，错误信息通常在最后
+const failureSnippetSize = 4096
+
+type HostFailure struct {
+	Host        string `json:"host"`
+	Play        string `json:"play"`
+	Task        string `json:"task"`
+	Unreachable bool   `json:"unreachable"`
+	Rc          int    `json:"rc"`
+	Msg         string `json:"msg"`
+	Stdout      string `json:"stdout"`
+	Stderr      string `json:"stderr"`
+}
+
+// PlaybookError playbook 执行失败时携带各主机的失败记录，错误信息与 HostFailedInfo 的 json 一致
+type PlaybookError struct {
+	Failures []HostFailure
+	Message  string
+}
+
+func (e *PlaybookError) Error() string {
+	return e.Message
+}
+
 type Result struct {
 	Stats          map[string]Stat `json:"stats"`
 	Plays          []Play          `json:"plays"`
 	HostFailedInfo HostFailedInfo  `json:"-"`
+	HostFailures   []HostFailure   `json:"-"`
 }
 
 func (r *Result) GatherFailedInfo() {
 	hostFailed := make(map[string]string)
+	var failures []HostFailure
 	for _, play := range r.Plays {
 		for _, task := range play.Tasks {
 			for name := range task.Hosts {
@@ -60,11 +87,55 @@ func (r *Result) GatherFailedInfo() {
 						hostFailed[name] = err.Error()
 					}
 					hostFailed[name] = string(b)
+					failures = append(failures, newHostFailure(name, play.Name, task.Name, hostResult))
 				}
 			}
 		}
 	}
 	r.HostFailedInfo = hostFailed
+	r.HostFailures = failures
+}
+
+func newHostFailure(host, play, task string, hostResult map[string]interface{}) HostFailure {
+	failure := HostFailure{
+		Host:   host,
+		Play:   play,
+		Task:   task,
+		Msg:    resultString(hostResult["msg"]),
+		Stdout: tail(resultString(hostResult["stdout"])),
+		Stderr: tail(resultString(hostResult["stderr"])),
+	}
+	if v, ok := hostResult["unreachable"].(bool); ok {
+		failure.Unreachable = v
+	}
+	if v, ok := hostResult["rc"].(float64); ok {
+		failure.Rc = int(v)
+	}
+	return failure
+}
+
+func resultString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	default:
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+}
+
+// tail 截取末尾部分，起点落在多字节字符中间时后移到下一个字符
+func tail(s string) string {
+	if len(s) <= failureSnippetSize {
+		return s
+	}
+	i := len(s) - failureSnippetSize
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return "..." + s[i:]
 }
 
 func ParseResult(content string) (result Result, err error) {
@@ -0,0 +1,60 @@
+package kobe
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestResult_GatherFailedInfo(t *testing.T) {
+	content := `{"stats": {}, "plays": [{"name": "kube-master", "tasks": [{"name": "Init cluster", "hosts": {
+		"master1": {"changed": false},
+		"master2": {"failed": true, "rc": 1, "msg": "non-zero return code", "stdout": "", "stderr": "` + strings.Repeat("x", failureSnippetSize+10) + `"},
+		"worker1": {"unreachable": true, "msg": "ssh timeout"}
+	}}]}]}`
+	result, err := ParseResult(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.GatherFailedInfo()
+	if len(result.HostFailures) != 2 {
+		t.Fatalf("got %d failures, want 2", len(result.HostFailures))
+	}
+	failures := map[string]HostFailure{}
+	for _, f := range result.HostFailures {
+		failures[f.Host] = f
+	}
+	master := failures["master2"]
+	if master.Play != "kube-master" || master.Task != "Init cluster" || master.Rc != 1 || master.Msg != "non-zero return code" {
+		t.Errorf("unexpected failure of master2: %+v", master)
+	}
+	if len(master.Stderr) != failureSnippetSize+3 || !strings.HasPrefix(master.Stderr, "...") {
+		t.Errorf("stderr of master2 should be truncated, got length %d", len(master.Stderr))
+	}
+	if worker := failures["worker1"]; !worker.Unreachable || worker.Msg != "ssh timeout" {
+		t.Errorf("unexpected failure of worker1: %+v", worker)
+	}
+}
+
+func TestTail(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "short", in: "failed", want: "failed"},
+		{name: "ascii", in: "a" + strings.Repeat("x", failureSnippetSize), want: "..." + strings.Repeat("x", failureSnippetSize)},
+		{name: "rune boundary", in: "失败" + strings.Repeat("x", failureSnippetSize-1), want: "..." + strings.Repeat("x", failureSnippetSize-1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tail(tt.in)
+			if got != tt.want {
+				t.Errorf("tail() = %q..., want %q...", got[:8], tt.want[:8])
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("tail() returned invalid utf8")
+			}
+		})
+	}
+}
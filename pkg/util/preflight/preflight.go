@@ -0,0 +1,284 @@
+package preflight
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClusterOperator/ClusterOperator/pkg/util/ssh"
+)
+
+const (
+	LevelPass = "pass"
+	LevelWarn = "warn"
+	LevelFail = "fail"
+)
+
+const (
+	CheckConnection  = "connection"
+	CheckSwap        = "swap"
+	CheckPorts       = "ports"
+	CheckModules     = "kernelModules"
+	CheckClock       = "clock"
+	CheckDisk        = "disk"
+	CheckHostname    = "hostname"
+	CheckProductUUID = "productUUID"
+)
+
+const (
+	clockSkewWarn = 5 * time.Second
+	clockSkewFail = 5 * time.Minute
+	// DefaultMinDiskGB 容器存储目录所在磁盘的最小可用空间
+	DefaultMinDiskGB = 20
+)
+
+var (
+	MasterPorts = []int{6443, 2379, 2380, 10250, 10257, 10259}
+	WorkerPorts = []int{10250}
+	// BaseModules 所有集群都需要的内核模块，IpvsModules 仅 ipvs 模式的 kube-proxy 需要
+	BaseModules = []string{"overlay", "br_netfilter", "nf_conntrack"}
+	IpvsModules = []string{"ip_vs", "ip_vs_rr", "ip_vs_wrr", "ip_vs_sh"}
+)
+
+// Options 单个主机的检查项
+type Options struct {
+	Ports      []int
+	Modules    []string
+	StorageDir string
+	MinDiskGB  int
+}
+
+// Check 单个检查项的结果
+type Check struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+// Facts 从主机上采集的信息
+type Facts struct {
+	Hostname       string
+	ProductUUID    string
+	ClockSkew      time.Duration
+	SwapDevices    []string
+	ListenPorts    map[int]bool
+	MissingModules []string
+	DiskAvailKB    int64
+	DiskPath       string
+}
+
+// Run 在主机上执行一次采集脚本并检查，连接失败时只返回连接检查的结果
+func Run(client ssh.Interface, opts Options) (Facts, []Check) {
+	before := time.Now()
+	stdout, stderr, exit, err := client.Exec(Script(opts))
+	after := time.Now()
+	if err == nil && exit != 0 {
+		err = fmt.Errorf("exit error %d:%s", exit, stderr)
+	}
+	if err != nil {
+		return Facts{}, []Check{{Name: CheckConnection, Level: LevelFail, Msg: err.Error()}}
+	}
+	facts := ParseFacts(ParseSections(stdout), before.Add(after.Sub(before)/2))
+	return facts, Evaluate(facts, opts)
+}
+
+// Script 生成采集脚本，每段输出以 ==name 开头
+func Script(opts Options) string {
+	dir := opts.StorageDir
+	if dir == "" {
+		dir = "/"
+	}
+	lines := []string{
+		"echo ==hostname; hostname",
+		"echo ==product_uuid; sudo cat /sys/class/dmi/id/product_uuid 2>/dev/null || cat /sys/class/dmi/id/product_uuid 2>/dev/null",
+		"echo ==date; date +%s",
+		"echo ==swap; tail -n +2 /proc/swaps",
+		"echo ==ports; (ss -ltn 2>/dev/null || netstat -ltn 2>/dev/null) | grep -i listen",
+		fmt.Sprintf("echo ==modules; for m in %s; do lsmod | grep -qw \"^$m\" || modinfo $m >/dev/null 2>&1 || "+
+			"grep -q \"/$m.ko\" /lib/modules/$(uname -r)/modules.builtin 2>/dev/null || echo $m; done", strings.Join(opts.Modules, " ")),
+		fmt.Sprintf("echo ==disk; d=%s; while [ ! -d \"$d\" ]; do d=$(dirname \"$d\"); done; df -Pk \"$d\" | tail -n 1", ssh.ShellQuote(dir)),
+	}
+	return strings.Join(lines, "; ")
+}
+
+// ParseSections 按 ==name 分段解析脚本输出
+func ParseSections(out string) map[string]string {
+	sections := map[string]string{}
+	var (
+		name  string
+		lines []string
+	)
+	flush := func() {
+		if name != "" {
+			sections[name] = strings.TrimSpace(strings.Join(lines, "\n"))
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "==") {
+			flush()
+			name = strings.TrimSpace(strings.TrimPrefix(line, "=="))
+			lines = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return sections
+}
+
+// ParseFacts 解析采集结果，now 为远端执行 date 时的本地时间
+func ParseFacts(sections map[string]string, now time.Time) Facts {
+	facts := Facts{
+		Hostname:    sections["hostname"],
+		ProductUUID: strings.ToLower(sections["product_uuid"]),
+		ListenPorts: map[int]bool{},
+	}
+	if remote, err := strconv.ParseInt(sections["date"], 10, 64); err == nil {
+		facts.ClockSkew = time.Unix(remote, 0).Sub(now.Truncate(time.Second))
+	}
+	for _, line := range strings.Split(sections["swap"], "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			facts.SwapDevices = append(facts.SwapDevices, fields[0])
+		}
+	}
+	for _, line := range strings.Split(sections["ports"], "\n") {
+		for _, field := range strings.Fields(line) {
+			// ss 和 netstat 的本地地址列均为 addr:port 格式
+			i := strings.LastIndex(field, ":")
+			if i < 0 {
+				continue
+			}
+			if port, err := strconv.Atoi(field[i+1:]); err == nil {
+				facts.ListenPorts[port] = true
+				break
+			}
+		}
+	}
+	facts.MissingModules = strings.Fields(sections["modules"])
+	if fields := strings.Fields(sections["disk"]); len(fields) >= 6 {
+		facts.DiskAvailKB, _ = strconv.ParseInt(fields[3], 10, 64)
+		facts.DiskPath = fields[5]
+	}
+	return facts
+}
+
+// Evaluate 根据采集结果检查单个主机
+func Evaluate(facts Facts, opts Options) []Check {
+	var checks []Check
+
+	if len(facts.SwapDevices) > 0 {
+		checks = append(checks, Check{Name: CheckSwap, Level: LevelWarn,
+			Msg: fmt.Sprintf("swap is enabled on %s, it will be turned off during installation", strings.Join(facts.SwapDevices, ","))})
+	} else {
+		checks = append(checks, Check{Name: CheckSwap, Level: LevelPass})
+	}
+
+	var used []string
+	for _, port := range opts.Ports {
+		if facts.ListenPorts[port] {
+			used = append(used, strconv.Itoa(port))
+		}
+	}
+	if len(used) > 0 {
+		checks = append(checks, Check{Name: CheckPorts, Level: LevelFail, Msg: fmt.Sprintf("ports %s are already in use", strings.Join(used, ","))})
+	} else {
+		checks = append(checks, Check{Name: CheckPorts, Level: LevelPass})
+	}
+
+	if len(facts.MissingModules) > 0 {
+		checks = append(checks, Check{Name: CheckModules, Level: LevelFail, Msg: fmt.Sprintf("kernel modules %s are not available", strings.Join(facts.MissingModules, ","))})
+	} else {
+		checks = append(checks, Check{Name: CheckModules, Level: LevelPass})
+	}
+
+	skew := facts.ClockSkew
+	if skew < 0 {
+		skew = -skew
+	}
+	switch {
+	case skew > clockSkewFail:
+		checks = append(checks, Check{Name: CheckClock, Level: LevelFail, Msg: fmt.Sprintf("clock skew is %s", skew)})
+	case skew > clockSkewWarn:
+		checks = append(checks, Check{Name: CheckClock, Level: LevelWarn, Msg: fmt.Sprintf("clock skew is %s", skew)})
+	default:
+		checks = append(checks, Check{Name: CheckClock, Level: LevelPass})
+	}
+
+	minDisk := opts.MinDiskGB
+	if minDisk == 0 {
+		minDisk = DefaultMinDiskGB
+	}
+	if availGB := facts.DiskAvailKB / 1024 / 1024; availGB < int64(minDisk) {
+		checks = append(checks, Check{Name: CheckDisk, Level: LevelFail,
+			Msg: fmt.Sprintf("only %dG available under %s, at least %dG required", availGB, facts.DiskPath, minDisk)})
+	} else {
+		checks = append(checks, Check{Name: CheckDisk, Level: LevelPass})
+	}
+	return checks
+}
+
+// CheckDuplicates 检查主机名和 product_uuid 是否与其他主机重复，facts 和 peers 的 key 为主机名称，peers 仅用于比较
+func CheckDuplicates(facts map[string]Facts, peers map[string]Facts) map[string][]Check {
+	hostnames := map[string][]string{}
+	uuids := map[string][]string{}
+	collect := func(all map[string]Facts) {
+		for name, f := range all {
+			if f.Hostname != "" {
+				hostnames[f.Hostname] = append(hostnames[f.Hostname], name)
+			}
+			if f.ProductUUID != "" {
+				uuids[f.ProductUUID] = append(uuids[f.ProductUUID], name)
+			}
+		}
+	}
+	collect(facts)
+	collect(peers)
+
+	others := func(names []string, self string) []string {
+		var result []string
+		for _, n := range names {
+			if n != self {
+				result = append(result, n)
+			}
+		}
+		sort.Strings(result)
+		return result
+	}
+	result := map[string][]Check{}
+	for name, f := range facts {
+		if f.Hostname == "" {
+			continue
+		}
+		if dup := others(hostnames[f.Hostname], name); len(dup) > 0 {
+			result[name] = append(result[name], Check{Name: CheckHostname, Level: LevelFail,
+				Msg: fmt.Sprintf("hostname %s is the same as %s", f.Hostname, strings.Join(dup, ","))})
+		} else {
+			result[name] = append(result[name], Check{Name: CheckHostname, Level: LevelPass})
+		}
+		if f.ProductUUID == "" {
+			result[name] = append(result[name], Check{Name: CheckProductUUID, Level: LevelWarn, Msg: "can not read product_uuid"})
+		} else if dup := others(uuids[f.ProductUUID], name); len(dup) > 0 {
+			result[name] = append(result[name], Check{Name: CheckProductUUID, Level: LevelFail,
+				Msg: fmt.Sprintf("product_uuid %s is the same as %s", f.ProductUUID, strings.Join(dup, ","))})
+		} else {
+			result[name] = append(result[name], Check{Name: CheckProductUUID, Level: LevelPass})
+		}
+	}
+	return result
+}
+
+// Level 汇总检查结果，返回最严重的级别
+func Level(checks []Check) string {
+	level := LevelPass
+	for _, c := range checks {
+		switch c.Level {
+		case LevelFail:
+			return LevelFail
+		case LevelWarn:
+			level = LevelWarn
+		}
+	}
+	return level
+}
@@ -0,0 +1,122 @@
+package preflight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleOutput = `==hostname
+node1
+==product_uuid
+4C4C4544-0042-3510-8050-B4C04F564E32
+==date
+1700000010
+==swap
+/dev/dm-1                               partition	2097148	0	-2
+==ports
+LISTEN     0      128          *:22                       *:*
+LISTEN     0      128    [::]:6443                  [::]:*
+==modules
+br_netfilter
+==disk
+/dev/mapper/centos-root  52403200 4194304 48208896       9% /
+`
+
+func TestParseFacts(t *testing.T) {
+	now := time.Unix(1700000000, 500)
+	facts := ParseFacts(ParseSections(sampleOutput), now)
+	want := Facts{
+		Hostname:       "node1",
+		ProductUUID:    "4c4c4544-0042-3510-8050-b4c04f564e32",
+		ClockSkew:      10 * time.Second,
+		SwapDevices:    []string{"/dev/dm-1"},
+		ListenPorts:    map[int]bool{22: true, 6443: true},
+		MissingModules: []string{"br_netfilter"},
+		DiskAvailKB:    48208896,
+		DiskPath:       "/",
+	}
+	if !reflect.DeepEqual(facts, want) {
+		t.Errorf("ParseFacts() = %+v, want %+v", facts, want)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name  string
+		facts Facts
+		opts  Options
+		want  map[string]string
+	}{
+		{
+			name:  "healthy host",
+			facts: Facts{ListenPorts: map[int]bool{22: true}, ClockSkew: time.Second, DiskAvailKB: 30 * 1024 * 1024},
+			opts:  Options{Ports: MasterPorts},
+			want:  map[string]string{CheckSwap: LevelPass, CheckPorts: LevelPass, CheckModules: LevelPass, CheckClock: LevelPass, CheckDisk: LevelPass},
+		},
+		{
+			name: "problems found",
+			facts: Facts{
+				SwapDevices:    []string{"/dev/dm-1"},
+				ListenPorts:    map[int]bool{2379: true},
+				MissingModules: []string{"overlay"},
+				ClockSkew:      -time.Minute,
+				DiskAvailKB:    10 * 1024 * 1024,
+			},
+			opts: Options{Ports: MasterPorts},
+			want: map[string]string{CheckSwap: LevelWarn, CheckPorts: LevelFail, CheckModules: LevelFail, CheckClock: LevelWarn, CheckDisk: LevelFail},
+		},
+		{
+			name:  "worker does not check master ports",
+			facts: Facts{ListenPorts: map[int]bool{6443: true}, ClockSkew: time.Hour, DiskAvailKB: 30 * 1024 * 1024},
+			opts:  Options{Ports: WorkerPorts},
+			want:  map[string]string{CheckSwap: LevelPass, CheckPorts: LevelPass, CheckModules: LevelPass, CheckClock: LevelFail, CheckDisk: LevelPass},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			for _, c := range Evaluate(tt.facts, tt.opts) {
+				got[c.Name] = c.Level
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	facts := map[string]Facts{
+		"host1": {Hostname: "node", ProductUUID: "a"},
+		"host2": {Hostname: "node", ProductUUID: "b"},
+		"host3": {Hostname: "node3"},
+	}
+	peers := map[string]Facts{
+		"master1": {Hostname: "master", ProductUUID: "b"},
+	}
+	want := map[string]map[string]string{
+		"host1": {CheckHostname: LevelFail, CheckProductUUID: LevelPass},
+		"host2": {CheckHostname: LevelFail, CheckProductUUID: LevelFail},
+		"host3": {CheckHostname: LevelPass, CheckProductUUID: LevelWarn},
+	}
+	got := map[string]map[string]string{}
+	for name, checks := range CheckDuplicates(facts, peers) {
+		got[name] = map[string]string{}
+		for _, c := range checks {
+			got[name][c.Name] = c.Level
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckDuplicates() = %v, want %v", got, want)
+	}
+}
+
+func TestScriptQuotesStorageDir(t *testing.T) {
+	script := Script(Options{StorageDir: "/var/lib/docker; rm -rf /tmp/x '"})
+	want := `d='/var/lib/docker; rm -rf /tmp/x '\'''; while`
+	if !strings.Contains(script, want) {
+		t.Errorf("Script() does not quote storage dir: %s", script)
+	}
+}
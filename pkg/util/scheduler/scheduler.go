@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Limits 并发限制，Global 为全部任务的并发上限，Types 为各任务类型的并发上限，小于等于 0 表示不限制
+type Limits struct {
+	Global int
+	Types  map[string]int
+}
+
+// Job 提交给调度器的任务，Priority 越大越先执行，优先级相同时按提交顺序执行
+type Job struct {
+	ID       string
+	Type     string
+	Priority int
+	Run      func()
+}
+
+// Item 调度器中任务的快照，Position 为等待队列中的位置，从 1 开始，执行中的任务为 0
+type Item struct {
+	ID         string
+	Type       string
+	Priority   int
+	Position   int
+	SubmitTime time.Time
+	StartTime  time.Time
+}
+
+type entry struct {
+	job        Job
+	submitTime time.Time
+	startTime  time.Time
+}
+
+type Scheduler struct {
+	lock    sync.Mutex
+	limits  Limits
+	waiting []*entry
+	running map[string]*entry
+	counts  map[string]int
+}
+
+func New(limits Limits) *Scheduler {
+	return &Scheduler{
+		limits:  limits,
+		running: map[string]*entry{},
+		counts:  map[string]int{},
+	}
+}
+
+// SetLimits 修改并发限制，已在执行的任务不受影响
+func (s *Scheduler) SetLimits(limits Limits) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.limits = limits
+	s.dispatch()
+}
+
+func (s *Scheduler) Limits() Limits {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.limits
+}
+
+// Submit 将任务加入等待队列，有空闲的执行槽位时在新的 goroutine 中执行 Run
+func (s *Scheduler) Submit(job Job) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e := &entry{job: job, submitTime: time.Now()}
+	i := 0
+	for i < len(s.waiting) && s.waiting[i].job.Priority >= job.Priority {
+		i++
+	}
+	s.waiting = append(s.waiting, nil)
+	copy(s.waiting[i+1:], s.waiting[i:])
+	s.waiting[i] = e
+	s.dispatch()
+}
+
+// Snapshot 返回执行中和等待中的任务，执行中的任务按开始时间排列，等待中的任务按执行顺序排列
+func (s *Scheduler) Snapshot() (running []Item, waiting []Item) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, e := range s.running {
+		running = append(running, e.item(0))
+	}
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].StartTime.Before(running[j].StartTime)
+	})
+	for i, e := range s.waiting {
+		waiting = append(waiting, e.item(i+1))
+	}
+	return running, waiting
+}
+
+// dispatch 按优先级启动等待中的任务，类型已达上限的任务不阻塞其他类型的任务，调用方需持有锁
+func (s *Scheduler) dispatch() {
+	for i := 0; i < len(s.waiting); {
+		if s.limits.Global > 0 && len(s.running) >= s.limits.Global {
+			return
+		}
+		e := s.waiting[i]
+		if limit := s.limits.Types[e.job.Type]; limit > 0 && s.counts[e.job.Type] >= limit {
+			i++
+			continue
+		}
+		s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+		e.startTime = time.Now()
+		s.running[e.job.ID] = e
+		s.counts[e.job.Type]++
+		go s.run(e)
+	}
+}
+
+func (s *Scheduler) run(e *entry) {
+	defer func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.running, e.job.ID)
+		s.counts[e.job.Type]--
+		s.dispatch()
+	}()
+	e.job.Run()
+}
+
+func (e *entry) item(position int) Item {
+	return Item{
+		ID:         e.job.ID,
+		Type:       e.job.Type,
+		Priority:   e.job.Priority,
+		Position:   position,
+		SubmitTime: e.submitTime,
+		StartTime:  e.startTime,
+	}
+}
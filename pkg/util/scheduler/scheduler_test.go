@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		jobs   []Job
+		want   []string
+	}{
+		{
+			name:   "priority before submit order",
+			limits: Limits{Global: 1},
+			jobs: []Job{
+				{ID: "backup1", Type: "CLUSTER_BACKUP", Priority: 0},
+				{ID: "backup2", Type: "CLUSTER_BACKUP", Priority: 0},
+				{ID: "upgrade", Type: "CLUSTER_UPGRADE", Priority: 10},
+			},
+			want: []string{"upgrade", "backup1", "backup2"},
+		},
+		{
+			name:   "type limit does not block other types",
+			limits: Limits{Global: 2, Types: map[string]int{"CLUSTER_BACKUP": 1}},
+			jobs: []Job{
+				{ID: "backup1", Type: "CLUSTER_BACKUP"},
+				{ID: "backup2", Type: "CLUSTER_BACKUP"},
+				{ID: "create", Type: "CLUSTER_CREATE"},
+			},
+			want: []string{"create", "backup1", "backup2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				lock    sync.Mutex
+				started []string
+				wg      sync.WaitGroup
+			)
+			gate := make(chan struct{})
+			s := New(tt.limits)
+			// 占用全部槽位，保证其余任务先进入等待队列
+			wg.Add(1)
+			s.Submit(Job{ID: "blocker", Type: "CLUSTER_BACKUP", Run: func() {
+				defer wg.Done()
+				<-gate
+			}})
+			for _, job := range tt.jobs {
+				id := job.ID
+				job.Run = func() {
+					defer wg.Done()
+					lock.Lock()
+					started = append(started, id)
+					lock.Unlock()
+					time.Sleep(10 * time.Millisecond)
+				}
+				wg.Add(1)
+				s.Submit(job)
+			}
+			close(gate)
+			wg.Wait()
+			if !reflect.DeepEqual(started, tt.want) {
+				t.Errorf("start order = %v, want %v", started, tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package ssh
 import (
 	"bytes"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -42,6 +44,8 @@ type Config struct {
 	PassPhrase  []byte
 	DialTimeOut time.Duration
 	Retry       int
+	// Proxy 跳板机，不为空时经跳板机连接目标主机
+	Proxy *Config
 }
 
 type Interface interface {
@@ -96,13 +100,22 @@ func New(c *Config) (*SSH, error) {
 		c.DialTimeOut = 5 * time.Second
 	}
 
+	var dialer sshDialer = &realSSHDialer{}
+	if c.Proxy != nil {
+		proxy, err := New(c.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bastion config: %v", err)
+		}
+		dialer = &jumpDialer{proxy: proxy}
+	}
+
 	return &SSH{
 		User:        c.User,
 		Host:        c.Host,
 		Port:        c.Port,
 		addr:        addr,
 		authMethods: authMethods,
-		dialer:      &timeoutDialer{&realSSHDialer{}, c.DialTimeOut},
+		dialer:      &timeoutDialer{dialer, c.DialTimeOut},
 		Retry:       c.Retry,
 	}, nil
 }
@@ -341,6 +354,25 @@ func (s *SSH) ReadFile(filename string) ([]byte, error) {
 	return data.Bytes(), err
 }
 
+// Dial 经该主机转发建立到 addr 的连接，连接关闭时一并关闭到该主机的 ssh 连接
+func (s *SSH) Dial(network, addr string) (net.Conn, error) {
+	config := &ssh.ClientConfig{
+		User:            s.User,
+		Auth:            s.authMethods,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+	client, err := s.dialer.Dial("tcp", s.addr, config)
+	if err != nil {
+		return nil, fmt.Errorf("error getting SSH client to %s@%s: '%v'", s.User, s.addr, err)
+	}
+	conn, err := client.Dial(network, addr)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("dial %s via %s failed: '%v'", addr, s.addr, err)
+	}
+	return &forwardConn{Conn: conn, client: client}, nil
+}
+
 func (s *SSH) LookPath(file string) (string, error) {
 	data, err := s.CombinedOutput(fmt.Sprintf("which %s", file))
 	return string(data), err
@@ -368,6 +400,104 @@ func (d *realSSHDialer) Dial(network, addr string, config *ssh.ClientConfig) (*s
 	return ssh.NewClient(c, chans, reqs), nil
 }
 
+// jumpDialer 经跳板机建立到目标主机的 ssh 连接
+type jumpDialer struct {
+	proxy *SSH
+}
+
+func (d *jumpDialer) Dial(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := d.proxy.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+type forwardConn struct {
+	net.Conn
+	client *ssh.Client
+}
+
+func (c *forwardConn) Close() error {
+	err := c.Conn.Close()
+	_ = c.client.Close()
+	return err
+}
+
+// ProxyCommand 生成经跳板机连接目标主机的 ssh ProxyCommand，secretFile 为保存跳板机密码或私钥的文件，
+// 密码经 sshpass -f 读取，命令行和 ansible 日志中不包含密码和私钥
+func ProxyCommand(c *Config, secretFile string) string {
+	opts := fmt.Sprintf("-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -W %%h:%%p -p %d %s@%s", c.Port, c.User, c.Host)
+	if len(c.PrivateKey) != 0 {
+		return fmt.Sprintf("ssh -i %s %s", ShellQuote(secretFile), opts)
+	}
+	return fmt.Sprintf("sshpass -f %s ssh %s", ShellQuote(secretFile), opts)
+}
+
+// ProxyVars 生成经跳板机 proxy 连接的 ansible 主机变量，跳板机的密码或私钥写入 dir 下权限为 0600 的文件，proxy 为空时返回空。
+// ansible 在 kobe 中执行，dir 需要同时挂载到 kobe 的相同路径
+func ProxyVars(proxy *Config, dir string) (map[string]string, error) {
+	vars := map[string]string{}
+	if proxy == nil {
+		return vars, nil
+	}
+	secret := proxy.PrivateKey
+	if len(secret) == 0 {
+		secret = []byte(proxy.Password)
+	}
+	secretFile, err := writeProxySecret(dir, secret)
+	if err != nil {
+		return nil, err
+	}
+	cmd := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(ProxyCommand(proxy, secretFile))
+	vars["ansible_ssh_common_args"] = fmt.Sprintf(`-o ProxyCommand="%s"`, cmd)
+	return vars, nil
+}
+
+// writeProxySecret 按内容摘要命名保存跳板机密码或私钥，内容相同时复用已有文件
+func writeProxySecret(dir string, secret []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(secret)
+	name := path.Join(dir, "bastion-"+hex.EncodeToString(sum[:])[:16])
+	if content, err := ioutil.ReadFile(name); err == nil && bytes.Equal(content, secret) {
+		return name, nil
+	}
+	f, err := ioutil.TempFile(dir, ".bastion-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	if err := f.Chmod(0600); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if _, err := f.Write(secret); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ShellQuote 用单引号转义 shell 参数
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 type timeoutDialer struct {
 	dialer  sshDialer
 	timeout time.Duration
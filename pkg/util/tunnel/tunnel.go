@@ -0,0 +1,225 @@
+package tunnel
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/yamux"
+)
+
+const (
+	HeaderCluster   = "X-Ko-Cluster"
+	HeaderToken     = "X-Ko-Agent-Token"
+	HeaderKubeToken = "X-Ko-Kube-Token"
+
+	ConnectPath = "/api/v1/agent/connect"
+
+	dialTimeout = 30 * time.Second
+)
+
+var ErrNotConnected = errors.New("CLUSTER_AGENT_DISCONNECTED")
+
+// sessions 只保存在 agent 连接的副本上，其他副本无法经隧道访问该集群，
+// 使用 agent 接入集群时 KubeOperator 只支持单副本部署
+var (
+	mu       sync.RWMutex
+	sessions = map[string]*yamux.Session{}
+)
+
+// Register 登记 agent 建立的 websocket 连接，同一集群的旧连接会被关闭，session 关闭后自动注销
+func Register(name string, ws *websocket.Conn) (*yamux.Session, error) {
+	session, err := yamux.Client(newConn(ws), nil)
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	if old, ok := sessions[name]; ok {
+		_ = old.Close()
+	}
+	sessions[name] = session
+	mu.Unlock()
+
+	go func() {
+		<-session.CloseChan()
+		mu.Lock()
+		if sessions[name] == session {
+			delete(sessions, name)
+		}
+		mu.Unlock()
+	}()
+	return session, nil
+}
+
+func Connected(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := sessions[name]
+	return ok
+}
+
+// Dialer 返回经隧道拨号的函数，目标地址由 agent 在集群网络内连接
+func Dialer(name string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		mu.RLock()
+		session := sessions[name]
+		mu.RUnlock()
+		if session == nil {
+			return nil, ErrNotConnected
+		}
+		stream, err := session.Open()
+		if err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(dialTimeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		_ = stream.SetDeadline(deadline)
+		if _, err := fmt.Fprintf(stream, "%s %s\n", network, addr); err != nil {
+			_ = stream.Close()
+			return nil, err
+		}
+		reply, err := readLine(stream)
+		if err != nil {
+			_ = stream.Close()
+			return nil, err
+		}
+		if reply != "OK" {
+			_ = stream.Close()
+			return nil, fmt.Errorf("agent dial %s failed: %s", addr, reply)
+		}
+		_ = stream.SetDeadline(time.Time{})
+		return stream, nil
+	}
+}
+
+// Connect agent 端连接服务端并处理隧道中的拨号请求，连接断开后返回
+func Connect(url string, header http.Header, insecure bool, dial func(network, addr string) (net.Conn, error)) error {
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: dialTimeout,
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: insecure},
+	}
+	ws, resp, err := dialer.Dial(url, header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("connect %s failed: %s", url, resp.Status)
+		}
+		return err
+	}
+	session, err := yamux.Server(newConn(ws), nil)
+	if err != nil {
+		_ = ws.Close()
+		return err
+	}
+	defer session.Close()
+	for {
+		stream, err := session.Accept()
+		if err != nil {
+			return err
+		}
+		go handle(stream, dial)
+	}
+}
+
+func handle(stream net.Conn, dial func(network, addr string) (net.Conn, error)) {
+	defer stream.Close()
+	line, err := readLine(stream)
+	if err != nil {
+		return
+	}
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) != 2 {
+		_, _ = fmt.Fprintf(stream, "bad request\n")
+		return
+	}
+	target, err := dial(parts[0], parts[1])
+	if err != nil {
+		_, _ = fmt.Fprintf(stream, "%s\n", strings.ReplaceAll(err.Error(), "\n", " "))
+		return
+	}
+	defer target.Close()
+	if _, err := fmt.Fprintf(stream, "OK\n"); err != nil {
+		return
+	}
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(target, stream)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(stream, target)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// readLine 逐字节读取一行，避免缓冲读走之后的数据
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for len(line) < 1024 {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+		if b[0] == '\n' {
+			return string(line), nil
+		}
+		line = append(line, b[0])
+	}
+	return "", errors.New("line too long")
+}
+
+// conn 将 websocket 的二进制消息适配为字节流
+type conn struct {
+	ws     *websocket.Conn
+	reader io.Reader
+	wmu    sync.Mutex
+}
+
+func newConn(ws *websocket.Conn) *conn {
+	return &conn{ws: ws}
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			_, r, err := c.ws.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			c.reader = r
+		}
+		n, err := c.reader.Read(p)
+		if err == io.EOF {
+			c.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := c.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *conn) Close() error {
+	return c.ws.Close()
+}
@@ -0,0 +1,80 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDialer(t *testing.T) {
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			c, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		session, err := Register(r.Header.Get(HeaderCluster), ws)
+		if err != nil {
+			return
+		}
+		<-session.CloseChan()
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set(HeaderCluster, "test")
+	go func() {
+		_ = Connect("ws"+strings.TrimPrefix(server.URL, "http"), header, false, net.Dial)
+	}()
+	for i := 0; i < 50 && !Connected("test"); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !Connected("test") {
+		t.Fatal("agent not connected")
+	}
+
+	c, err := Dialer("test")(context.TODO(), "tcp", echo.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(c, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("read %q, %v, want ping", buf, err)
+	}
+
+	if _, err := Dialer("test")(context.TODO(), "tcp", "127.0.0.1:1"); err == nil {
+		t.Error("dial closed port through tunnel should fail")
+	}
+	if _, err := Dialer("other")(context.TODO(), "tcp", echo.Addr().String()); err != ErrNotConnected {
+		t.Errorf("dial unknown cluster error = %v, want %v", err, ErrNotConnected)
+	}
+}
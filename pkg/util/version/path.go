@@ -0,0 +1,108 @@
+package version
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// release 版本清单名称，如 v1.20.6-ko1
+type release struct {
+	name  string
+	major int
+	minor int
+	patch int
+	build int
+}
+
+func parseRelease(name string) (release, error) {
+	r := release{name: name}
+	v := strings.TrimPrefix(name, "v")
+	if i := strings.Index(v, "-"); i != -1 {
+		r.build, _ = strconv.Atoi(strings.TrimPrefix(v[i+1:], "ko"))
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return r, fmt.Errorf("invalid version %s", name)
+	}
+	var err error
+	if r.major, err = strconv.Atoi(parts[0]); err != nil {
+		return r, fmt.Errorf("invalid version %s", name)
+	}
+	if r.minor, err = strconv.Atoi(parts[1]); err != nil {
+		return r, fmt.Errorf("invalid version %s", name)
+	}
+	if r.patch, err = strconv.Atoi(parts[2]); err != nil {
+		return r, fmt.Errorf("invalid version %s", name)
+	}
+	return r, nil
+}
+
+func (r release) less(o release) bool {
+	if r.major != o.major {
+		return r.major < o.major
+	}
+	if r.minor != o.minor {
+		return r.minor < o.minor
+	}
+	if r.patch != o.patch {
+		return r.patch < o.patch
+	}
+	return r.build < o.build
+}
+
+// UpgradePath 计算从 current 升级到 target 需要依次经过的版本，每次最多升级一个小版本，
+// 中间版本取 available 中该小版本的最新版本，返回结果不包含 current
+func UpgradePath(current, target string, available []string) ([]string, error) {
+	cur, err := parseRelease(current)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := parseRelease(target)
+	if err != nil {
+		return nil, err
+	}
+	if !cur.less(dst) {
+		return nil, fmt.Errorf("target version %s is not newer than %s", target, current)
+	}
+	if cur.major != dst.major {
+		return nil, fmt.Errorf("can not upgrade across major version from %s to %s", current, target)
+	}
+
+	latest := map[int]release{}
+	found := false
+	for _, name := range available {
+		r, err := parseRelease(name)
+		if err != nil || r.major != cur.major {
+			continue
+		}
+		if name == target {
+			found = true
+		}
+		if l, ok := latest[r.minor]; !ok || l.less(r) {
+			latest[r.minor] = r
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("target version %s is not available", target)
+	}
+
+	var hops []release
+	for minor := cur.minor + 1; minor < dst.minor; minor++ {
+		r, ok := latest[minor]
+		if !ok {
+			return nil, fmt.Errorf("no available version of v%d.%d between %s and %s", cur.major, minor, current, target)
+		}
+		hops = append(hops, r)
+	}
+	hops = append(hops, dst)
+	sort.SliceStable(hops, func(i, j int) bool { return hops[i].less(hops[j]) })
+
+	var path []string
+	for _, h := range hops {
+		path = append(path, h.name)
+	}
+	return path, nil
+}
@@ -0,0 +1,32 @@
+package version
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpgradePath(t *testing.T) {
+	available := []string{"v1.18.20-ko1", "v1.19.8-ko1", "v1.19.10-ko1", "v1.19.10-ko2", "v1.20.6-ko1", "v1.22.6-ko1"}
+	cases := []struct {
+		current string
+		target  string
+		path    []string
+		err     bool
+	}{
+		{current: "v1.18.6-ko1", target: "v1.18.20-ko1", path: []string{"v1.18.20-ko1"}},
+		{current: "v1.18.6-ko1", target: "v1.20.6-ko1", path: []string{"v1.19.10-ko2", "v1.20.6-ko1"}},
+		{current: "v1.18.6-ko1", target: "v1.22.6-ko1", err: true},
+		{current: "v1.20.6-ko1", target: "v1.19.10-ko1", err: true},
+		{current: "v1.18.6-ko1", target: "v1.19.9-ko1", err: true},
+	}
+	for _, c := range cases {
+		path, err := UpgradePath(c.current, c.target, available)
+		if (err != nil) != c.err {
+			t.Errorf("%s -> %s: unexpected error %v", c.current, c.target, err)
+			continue
+		}
+		if !c.err && !reflect.DeepEqual(path, c.path) {
+			t.Errorf("%s -> %s: expect %v, got %v", c.current, c.target, c.path, path)
+		}
+	}
+}